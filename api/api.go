@@ -25,6 +25,7 @@ import (
 	"forgolang_forum/database"
 	model2 "forgolang_forum/database/model"
 	"forgolang_forum/model"
+	"forgolang_forum/tasks"
 	"forgolang_forum/utils"
 	"github.com/go-redis/redis"
 	"github.com/olivere/elastic/v7"
@@ -40,6 +41,7 @@ type API struct {
 	Router        *Router
 	JWTAuth       *JWTAuth
 	Authorization *Authorization
+	RateLimit     *RateLimit
 	Languages     []model2.Language
 }
 
@@ -48,6 +50,7 @@ func NewAPI(app *cmn.App) *API {
 	api := &API{App: app}
 	api.JWTAuth = NewJWTAuth(api)
 	api.Authorization = NewAuthorization(api)
+	api.RateLimit = NewRateLimit(api)
 	api.Router = NewRouter(api)
 
 	var language model2.Language
@@ -130,6 +133,80 @@ func (a *API) GetAuthContext(ctx *fasthttp.RequestCtx) *model.AuthContext {
 	return ctx.UserValue("AuthContext").(*model.AuthContext)
 }
 
+// GetOptionalAuthContext get auth context request if it is authenticated
+func (a *API) GetOptionalAuthContext(ctx *fasthttp.RequestCtx) *model.AuthContext {
+	if authContext, ok := ctx.UserValue("AuthContext").(*model.AuthContext); ok {
+		return authContext
+	}
+	return nil
+}
+
+// IsModerator request is authenticated by a moderator or superadmin
+func (a *API) IsModerator(ctx *fasthttp.RequestCtx) bool {
+	authContext := a.GetOptionalAuthContext(ctx)
+	return authContext != nil && (authContext.Role == "moderator" || authContext.Role == "superadmin")
+}
+
+// HasReputation the current user reached the reputation threshold of an
+// action, moderators are not limited by reputation
+func (a *API) HasReputation(ctx *fasthttp.RequestCtx, action string) bool {
+	if a.IsModerator(ctx) {
+		return true
+	}
+
+	authContext := a.GetOptionalAuthContext(ctx)
+	return authContext != nil && tasks.GetReputation(a.App, authContext.ID) >= tasks.ReputationThresholds[action]
+}
+
+// GetTrustLevel trust level of the current user, moderators have the
+// leader level and anonymous requests the new user level
+func (a *API) GetTrustLevel(ctx *fasthttp.RequestCtx) database.TrustLevel {
+	if a.IsModerator(ctx) {
+		return database.Leader
+	}
+
+	authContext := a.GetOptionalAuthContext(ctx)
+	if authContext == nil {
+		return database.NewUser
+	}
+
+	return tasks.GetTrustLevel(a.App, authContext.ID)
+}
+
+// GetTrustLimit limits of the trust level of the current user
+func (a *API) GetTrustLimit(ctx *fasthttp.RequestCtx) tasks.TrustLimit {
+	return tasks.TrustLimits[a.GetTrustLevel(ctx)]
+}
+
+// HasTooManyLinks text has more links than the trust level of the current
+// user allows
+func (a *API) HasTooManyLinks(ctx *fasthttp.RequestCtx, text string) bool {
+	limit := a.GetTrustLimit(ctx)
+	return limit.MaxLinks > 0 && utils.CountLinks(text) > limit.MaxLinks
+}
+
+// IsPostVisible post is published and not removed, or the request is
+// authenticated by its author or a moderator
+func (a *API) IsPostVisible(ctx *fasthttp.RequestCtx, postID int64) bool {
+	var userID int64
+	if authContext := a.GetOptionalAuthContext(ctx); authContext != nil {
+		userID = authContext.ID
+	}
+
+	var post model2.Post
+	var visible bool
+	a.App.Database.DB.Get(&visible, fmt.Sprintf(`
+		SELECT (p.status = $2 AND p.deleted_at IS NULL) OR p.author_id = $3 OR $4
+		FROM %s AS p WHERE p.id = $1
+	`, post.TableName()),
+		postID,
+		database.Published,
+		userID,
+		a.IsModerator(ctx))
+
+	return visible
+}
+
 // GetLanguageContext get default language context
 func (a *API) GetLanguageContext(ctx *fasthttp.RequestCtx) *model2.Language {
 	return ctx.UserValue("Language").(*model2.Language)
@@ -0,0 +1,202 @@
+// Copyright 2019 StreetByters Community
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with
+// this work for additional information regarding copyright ownership.
+// The ASF licenses this file to You under the Apache License, Version 2.0
+// (the "License"); you may not use this file except in compliance with
+// the License.  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"fmt"
+	"forgolang_forum/database"
+	"forgolang_forum/database/model"
+	model2 "forgolang_forum/model"
+	"github.com/fate-lovely/phi"
+	"github.com/valyala/fasthttp"
+	"gopkg.in/guregu/null.v3/zero"
+	"strings"
+)
+
+// BookmarkController private bookmarks of the current user api controller
+type BookmarkController struct {
+	Controller
+	*API
+	Model model.Bookmark
+}
+
+// likeEscaper escape wildcards of like patterns
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// Index list bookmarks of the current user, the query param searches notes,
+// post titles and comments of bookmarks
+func (c BookmarkController) Index(ctx *fasthttp.RequestCtx) {
+	paginate, _, _ := c.Paginate(ctx, "id", "updated_at", "inserted_at", "remind_at")
+
+	pattern := "%"
+	if query := strings.TrimSpace(c.ParseQuery(ctx)["query"]); query != "" {
+		pattern = "%" + likeEscaper.Replace(query) + "%"
+	}
+
+	var bookmarks []model.Bookmark
+	c.GetDB().QueryWithModel(fmt.Sprintf(`
+		SELECT q.* FROM (%s) AS q
+		WHERE $2 = '%%' OR q.note ILIKE $2 OR q.post_title ILIKE $2 OR q.comment ILIKE $2
+		ORDER BY q.%s %s NULLS LAST, q.id DESC
+		LIMIT $3 OFFSET $4
+	`, c.Model.Query(), paginate.OrderField, paginate.OrderBy),
+		&bookmarks,
+		c.GetAuthContext(ctx).ID,
+		pattern,
+		paginate.Limit,
+		paginate.Offset)
+
+	var count int64
+	c.GetDB().DB.Get(&count, fmt.Sprintf(`
+		SELECT count(q.id) FROM (%s) AS q
+		WHERE $2 = '%%' OR q.note ILIKE $2 OR q.post_title ILIKE $2 OR q.comment ILIKE $2
+	`, c.Model.Query()),
+		c.GetAuthContext(ctx).ID,
+		pattern)
+
+	c.JSONResponse(ctx, model2.ResponseSuccess{
+		Data:       bookmarks,
+		TotalCount: count,
+	}, fasthttp.StatusOK)
+}
+
+// Create bookmark a post or a comment, the note and the reminder of an
+// existing bookmark are replaced
+func (c BookmarkController) Create(ctx *fasthttp.RequestCtx) {
+	bookmark := new(model.Bookmark)
+	c.JSONBody(ctx, &bookmark)
+	bookmark.UserID = c.GetAuthContext(ctx).ID
+
+	if errs, err := database.ValidateStruct(bookmark); err != nil {
+		c.JSONResponse(ctx, model2.ResponseError{
+			Errors: errs,
+			Detail: fasthttp.StatusMessage(fasthttp.StatusUnprocessableEntity),
+		}, fasthttp.StatusUnprocessableEntity)
+		return
+	}
+
+	// comments are bookmarked with their own post
+	var comment model.PostComment
+	if bookmark.CommentID.Valid {
+		c.GetDB().DB.Get(&bookmark.PostID, fmt.Sprintf(`
+			SELECT c.post_id FROM %s AS c WHERE c.id = $1
+		`, comment.TableName()),
+			bookmark.CommentID)
+	}
+	if !c.IsPostVisible(ctx, bookmark.PostID) {
+		c.JSONResponse(ctx, model2.ResponseError{
+			Errors: map[string]string{
+				"post_id": string(database.NotExistsError),
+			},
+			Detail: fasthttp.StatusMessage(fasthttp.StatusUnprocessableEntity),
+		}, fasthttp.StatusUnprocessableEntity)
+		return
+	}
+	bookmark.Note = zero.StringFrom(c.App.TextPolicy.Sanitize(bookmark.Note.String))
+
+	err := c.GetDB().DB.Get(bookmark, fmt.Sprintf(`
+		INSERT INTO %s (user_id, post_id, comment_id, note, remind_at) VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (user_id, post_id, COALESCE(comment_id, 0)) DO UPDATE SET
+			note = EXCLUDED.note,
+			remind_at = EXCLUDED.remind_at,
+			reminded_at = NULL,
+			updated_at = (CURRENT_TIMESTAMP at time zone 'utc')
+		RETURNING *
+	`, c.Model.TableName()),
+		bookmark.UserID,
+		bookmark.PostID,
+		bookmark.CommentID,
+		bookmark.Note,
+		bookmark.RemindAt)
+	if errs, err := database.ValidateConstraint(err, bookmark); err != nil {
+		c.JSONResponse(ctx, model2.ResponseError{
+			Errors: errs,
+			Detail: fasthttp.StatusMessage(fasthttp.StatusUnprocessableEntity),
+		}, fasthttp.StatusUnprocessableEntity)
+		return
+	}
+
+	c.JSONResponse(ctx, model2.ResponseSuccessOne{
+		Data: c.getBookmark(ctx, bookmark.ID),
+	}, fasthttp.StatusCreated)
+}
+
+// Update change the note and the reminder of a bookmark of the current
+// user, a changed reminder fires again
+func (c BookmarkController) Update(ctx *fasthttp.RequestCtx) {
+	var request model2.BookmarkRequest
+	c.JSONBody(ctx, &request)
+
+	if errs, err := database.ValidateStruct(request); err != nil {
+		c.JSONResponse(ctx, model2.ResponseError{
+			Errors: errs,
+			Detail: fasthttp.StatusMessage(fasthttp.StatusUnprocessableEntity),
+		}, fasthttp.StatusUnprocessableEntity)
+		return
+	}
+
+	result, err := c.GetDB().DB.Exec(fmt.Sprintf(`
+		UPDATE %s SET
+			note = $3,
+			reminded_at = CASE WHEN remind_at IS NOT DISTINCT FROM $4 THEN reminded_at END,
+			remind_at = $4,
+			updated_at = (CURRENT_TIMESTAMP at time zone 'utc')
+		WHERE id::text = $1::text AND user_id = $2
+	`, c.Model.TableName()),
+		phi.URLParam(ctx, "bookmarkID"),
+		c.GetAuthContext(ctx).ID,
+		zero.StringFrom(c.App.TextPolicy.Sanitize(request.Note)),
+		request.RemindAt)
+	if err != nil {
+		c.JSONResponse(ctx, model2.ResponseError{
+			Detail: fasthttp.StatusMessage(fasthttp.StatusInternalServerError),
+		}, fasthttp.StatusInternalServerError)
+		return
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		c.JSONResponse(ctx, model2.ResponseError{
+			Detail: fasthttp.StatusMessage(fasthttp.StatusNotFound),
+		}, fasthttp.StatusNotFound)
+		return
+	}
+
+	c.JSONResponse(ctx, model2.ResponseSuccessOne{
+		Data: c.getBookmark(ctx, phi.URLParam(ctx, "bookmarkID")),
+	}, fasthttp.StatusOK)
+}
+
+// Delete remove a bookmark of the current user
+func (c BookmarkController) Delete(ctx *fasthttp.RequestCtx) {
+	c.GetDB().Delete(c.Model.TableName(), "id::text = $1::text AND user_id = $2",
+		phi.URLParam(ctx, "bookmarkID"),
+		c.GetAuthContext(ctx).ID).Force()
+
+	c.JSONResponse(ctx, nil, fasthttp.StatusNoContent)
+}
+
+// getBookmark bookmark of the current user with its post title and comment
+func (c BookmarkController) getBookmark(ctx *fasthttp.RequestCtx, id interface{}) model.Bookmark {
+	var bookmark model.Bookmark
+	c.GetDB().QueryRowWithModel(fmt.Sprintf(`
+		%s AND b.id::text = $2::text
+	`, c.Model.Query()),
+		&bookmark,
+		c.GetAuthContext(ctx).ID,
+		fmt.Sprint(id)).Force()
+
+	return bookmark
+}
@@ -0,0 +1,117 @@
+package api
+
+import (
+	"fmt"
+	"forgolang_forum/database"
+	"forgolang_forum/database/model"
+	model2 "forgolang_forum/model"
+	"forgolang_forum/tasks"
+	"github.com/valyala/fasthttp"
+	"gopkg.in/guregu/null.v3/zero"
+	"testing"
+	"time"
+)
+
+type BookmarkControllerTest struct {
+	*Suite
+}
+
+func (s BookmarkControllerTest) SetupSuite() {
+	SetupSuite(s.Suite)
+	UserAuth(s.Suite)
+}
+
+func (s BookmarkControllerTest) Test_CreateAndSearchBookmarks() {
+	post := model.NewPost(s.Auth.User.ID)
+	err := s.API.GetDB().Insert(new(model.Post), post, "id")
+	s.Nil(err)
+
+	bookmark := model.NewBookmark(s.Auth.User.ID, post.ID)
+	bookmark.Note = zero.StringFrom("Context cancellation pattern")
+
+	response := s.JSON(Post, "/api/v1/bookmark", bookmark)
+	s.Equal(response.Status, fasthttp.StatusCreated)
+	data, _ := response.Success.Data.(map[string]interface{})
+	s.Equal(data["note"], "Context cancellation pattern")
+	id := data["id"]
+
+	// bookmarking again replaces the note
+	bookmark.Note = zero.StringFrom("Context cancellation with deadlines")
+	response = s.JSON(Post, "/api/v1/bookmark", bookmark)
+	s.Equal(response.Status, fasthttp.StatusCreated)
+	data, _ = response.Success.Data.(map[string]interface{})
+	s.Equal(data["id"], id)
+
+	response = s.JSON(Get, "/api/v1/bookmark?query=deadlines", nil)
+	s.Equal(response.Status, fasthttp.StatusOK)
+	s.Equal(response.Success.TotalCount, int64(1))
+
+	response = s.JSON(Get, "/api/v1/bookmark?query=goroutine", nil)
+	s.Equal(response.Status, fasthttp.StatusOK)
+	s.Equal(response.Success.TotalCount, int64(0))
+
+	response = s.JSON(Put, fmt.Sprintf("/api/v1/bookmark/%.0f", id), model2.BookmarkRequest{
+		Note: "Goroutine leaks",
+	})
+	s.Equal(response.Status, fasthttp.StatusOK)
+	data, _ = response.Success.Data.(map[string]interface{})
+	s.Equal(data["note"], "Goroutine leaks")
+
+	response = s.JSON(Delete, fmt.Sprintf("/api/v1/bookmark/%.0f", id), nil)
+	s.Equal(response.Status, fasthttp.StatusNoContent)
+
+	response = s.JSON(Delete, fmt.Sprintf("/api/v1/bookmark/%.0f", id), nil)
+	s.Equal(response.Status, fasthttp.StatusNotFound)
+
+	defaultLogger.LogInfo("Create and search bookmarks")
+}
+
+func (s BookmarkControllerTest) Test_Should_422Err_CreateBookmarkIfPostNotExists() {
+	bookmark := model.NewBookmark(s.Auth.User.ID, 999999999)
+
+	response := s.JSON(Post, "/api/v1/bookmark", bookmark)
+	s.Equal(response.Status, fasthttp.StatusUnprocessableEntity)
+
+	defaultLogger.LogInfo("Should be 422 error create bookmark if post does not exists")
+}
+
+func (s BookmarkControllerTest) Test_SendDueBookmarkReminders() {
+	post := model.NewPost(s.Auth.User.ID)
+	err := s.API.GetDB().Insert(new(model.Post), post, "id")
+	s.Nil(err)
+
+	bookmark := model.NewBookmark(s.Auth.User.ID, post.ID)
+	bookmark.RemindAt = zero.TimeFrom(time.Now().UTC().Add(-time.Minute))
+	err = s.API.GetDB().Insert(new(model.Bookmark), bookmark, "id")
+	s.Nil(err)
+
+	s.Nil(tasks.SendBookmarkReminders(s.API.App, nil))
+
+	var notification model.Notification
+	err = s.API.GetDB().DB.Get(&notification, fmt.Sprintf(`
+		%s AND n.post_id = $2 AND n.type = $3
+	`, notification.Query()),
+		s.Auth.User.ID,
+		post.ID,
+		database.ReminderNotification)
+	s.Nil(err)
+
+	var remindedAt zero.Time
+	err = s.API.GetDB().DB.Get(&remindedAt, fmt.Sprintf(`
+		SELECT b.reminded_at FROM %s AS b WHERE b.id = $1
+	`, bookmark.TableName()),
+		bookmark.ID)
+	s.Nil(err)
+	s.True(remindedAt.Valid)
+
+	defaultLogger.LogInfo("Send due bookmark reminders")
+}
+
+func (s BookmarkControllerTest) TearDownSuite() {
+	TearDownSuite(s.Suite)
+}
+
+func Test_BookmarkController(t *testing.T) {
+	s := BookmarkControllerTest{NewSuite()}
+	Run(t, s)
+}
@@ -19,12 +19,26 @@ package api
 import (
 	"fmt"
 	"forgolang_forum/cmn"
+	"forgolang_forum/database"
 	"forgolang_forum/database/model"
 	model2 "forgolang_forum/model"
+	"forgolang_forum/tasks"
+	"forgolang_forum/utils"
 	"github.com/fate-lovely/phi"
+	"github.com/go-redis/redis"
+	"github.com/lib/pq"
 	"github.com/valyala/fasthttp"
+	"sort"
+	"strconv"
 )
 
+// rankingFallbacks order fields of rankings until they are precomputed
+var rankingFallbacks = map[string]string{
+	"hot":    "inserted_at",
+	"top":    "score",
+	"active": "inserted_at",
+}
+
 // CategoryPostController discussions api controller
 type CategoryPostController struct {
 	Controller
@@ -34,7 +48,68 @@ type CategoryPostController struct {
 
 // Index list all discussions with filter params
 func (c CategoryPostController) Index(ctx *fasthttp.RequestCtx) {
-	paginate, _, _ := c.Paginate(ctx, "id", "inserted_at", "updated_at")
+	paginate, _, _ := c.Paginate(ctx, "id", "inserted_at", "updated_at", "score", "hot", "top", "active")
+
+	orderClause := fmt.Sprintf("%s %s", paginate.OrderField, paginate.OrderBy)
+	rankingField := paginate.OrderField
+	filterClause := ""
+	offset := paginate.Offset
+	var rankingIDs []int64
+	var rankingCount int64
+	if exists, _ := utils.InArray(paginate.OrderField, []string{"hot", "top", "active"}); exists {
+		key, ok := c.rankingKey(ctx, paginate.OrderField)
+		if !ok {
+			return
+		}
+
+		queryParams := c.ParseQuery(ctx)
+		_, tagFilter := queryParams["tag"]
+		_, solvedFilter := queryParams["solved"]
+		if rankingCount, _ = c.GetCache().ZCard(key).Result(); rankingCount > 0 && !tagFilter && !solvedFilter {
+			rankingIDs, rankingCount = c.rankedPage(ctx, key, paginate)
+
+			filterClause = "AND p.id = ANY($4)"
+			orderClause = "array_position($4, p.id)"
+			offset = 0
+		} else {
+			// rankings are not precomputed yet or cover all posts of the
+			// category while the listing is filtered by tag or solved status
+			rankingCount = 0
+			rankingField = rankingFallbacks[paginate.OrderField]
+			orderClause = fmt.Sprintf("%s %s", rankingField, paginate.OrderBy)
+		}
+	}
+
+	solvedClause, ok := c.solvedClause(ctx)
+	if !ok {
+		return
+	}
+
+	// scores are stored on the posts by votes, the page is sorted in sql
+	if rankingField == "score" {
+		orderClause = fmt.Sprintf("p.score %s, p.id DESC", paginate.OrderBy)
+	}
+
+	// pinned threads come first, rankings keep their precomputed order
+	if filterClause == "" {
+		orderClause = fmt.Sprintf("(COALESCE(pst.pinned_globally, false) OR "+
+			"COALESCE(pst.pinned_in_category, false)) DESC, pst.pin_order ASC NULLS LAST, %s", orderClause)
+	}
+
+	params := []interface{}{
+		phi.URLParam(ctx, "categoryID"),
+		paginate.Limit,
+		offset,
+	}
+	if filterClause != "" {
+		params = append(params, pq.Array(rankingIDs))
+	}
+
+	tagClause := ""
+	if tag, ok := c.ParseQuery(ctx)["tag"]; ok {
+		params = append(params, NormalizeTagName(tag))
+		tagClause = c.tagClause(len(params))
+	}
 
 	var posts []model.PostDEP
 	var postSlug model.PostSlug
@@ -42,11 +117,23 @@ func (c CategoryPostController) Index(ctx *fasthttp.RequestCtx) {
 	var postCategoryAssignment model.PostCategoryAssignment
 	var category model.Category
 	var user model.User
+	var postTag model.PostTag
+	var tag model.Tag
+	var postAnswer model.PostAnswer
+	var postState model.PostState
 	c.GetDB().QueryWithModel(fmt.Sprintf(`
 		SELECT 
 			p.id as id, p.author_id as author_id, u.username as author_username, 
 			p.inserted_at as inserted_at, ps.slug as slug, pd.title as title, 
-			pd.description as description, pd.content as content
+			pd.description as description, pd.content as content,
+			ARRAY(
+				SELECT t.name FROM %s AS pt INNER JOIN %s AS t ON pt.tag_id = t.id
+				WHERE pt.post_id = p.id ORDER BY t.name
+			) as tags,
+			EXISTS (SELECT 1 FROM %s AS pa WHERE pa.post_id = p.id) as solved,
+			COALESCE(pst.state, 'open') as state,
+			COALESCE(pst.pinned_globally, false) as pinned_globally,
+			COALESCE(pst.pinned_in_category, false) as pinned_in_category
 		FROM %s AS p
 		LEFT OUTER JOIN %s AS ps ON p.id = ps.post_id
 		LEFT OUTER JOIN %s AS ps2 ON ps.post_id = ps2.post_id AND ps.id < ps2.id
@@ -55,27 +142,44 @@ func (c CategoryPostController) Index(ctx *fasthttp.RequestCtx) {
 		INNER JOIN %s AS u ON p.author_id = u.id
 		INNER JOIN %s AS pca ON p.id = pca.post_id
 		INNER JOIN %s AS c ON pca.category_id = c.id
-		WHERE ps2.id IS NULL AND pd2.id IS NULL AND (c.id::text = $1::text OR c.slug = $1)
-		ORDER BY %s %s
+		LEFT JOIN LATERAL (
+			SELECT s.* FROM %s AS s WHERE s.post_id = p.id ORDER BY s.id DESC LIMIT 1
+		) AS pst ON true
+		WHERE ps2.id IS NULL AND pd2.id IS NULL AND (c.id::text = $1::text OR c.slug = $1) AND
+			p.status = '%s' AND p.deleted_at IS NULL %s %s %s
+		ORDER BY %s
 		LIMIT $2 OFFSET $3
-	`, c.Model.TableName(), postSlug.TableName(), postSlug.TableName(), postDetail.TableName(),
+	`, postTag.TableName(), tag.TableName(), postAnswer.TableName(), c.Model.TableName(), postSlug.TableName(), postSlug.TableName(), postDetail.TableName(),
 		postDetail.TableName(), user.TableName(), postCategoryAssignment.TableName(), category.TableName(),
-		paginate.OrderField,
-		paginate.OrderBy),
+		postState.TableName(), database.Published,
+		filterClause,
+		tagClause,
+		solvedClause,
+		orderClause),
 		&posts,
-		phi.URLParam(ctx, "categoryID"),
-		paginate.Limit,
-		paginate.Offset)
+		params...)
+
+	c.renderScores(ctx, posts)
 
-	var count int64
-	count, _ = c.App.Cache.Get(cmn.GetRedisKey("post", "count")).Int64()
-	if count == 0 {
+	count := rankingCount
+	if count == 0 && (tagClause != "" || solvedClause != "") {
+		countParams := []interface{}{phi.URLParam(ctx, "categoryID")}
+		countTagClause := ""
+		if tagClause != "" {
+			countParams = append(countParams, params[len(params)-1])
+			countTagClause = c.tagClause(2)
+		}
 		c.GetDB().DB.Get(&count, fmt.Sprintf(`
-			SELECT count(p.id) FROM %s AS p
-			INNER JOIN %s AS pd ON p.id = pd.post_id
-			LEFT OUTER JOIN %s AS pd2 ON pd.post_id = pd2.post_id AND pd.id < pd2.id
-			WHERE pd2.id IS NULL
-		`, c.Model.TableName(), postDetail.TableName(), postDetail.TableName()))
+			SELECT count(DISTINCT p.id) FROM %s AS p
+			INNER JOIN %s AS pca ON p.id = pca.post_id
+			INNER JOIN %s AS c ON pca.category_id = c.id
+			WHERE (c.id::text = $1::text OR c.slug = $1) AND p.status = '%s' AND p.deleted_at IS NULL %s %s
+		`, c.Model.TableName(), postCategoryAssignment.TableName(), category.TableName(), database.Published,
+			countTagClause, solvedClause),
+			countParams...)
+	}
+	if count == 0 && tagClause == "" && solvedClause == "" {
+		count = c.postCount(c.categoryID(ctx))
 	}
 
 	c.JSONResponse(ctx, model2.ResponseSuccess{
@@ -84,19 +188,51 @@ func (c CategoryPostController) Index(ctx *fasthttp.RequestCtx) {
 	}, fasthttp.StatusOK)
 }
 
-// Show discussion with given identifier or slug
+// Show discussion with given identifier or slug, drafts and scheduled posts
+// are shown only to their author. Readers of duplicate posts are sent to the
+// original post with redirect_to.
 func (c CategoryPostController) Show(ctx *fasthttp.RequestCtx) {
+	var userID int64
+	if authContext := c.GetOptionalAuthContext(ctx); authContext != nil {
+		userID = authContext.ID
+	}
+
 	var post model.PostDEP
 	var postSlug model.PostSlug
 	var postDetail model.PostDetail
 	var postCategoryAssignment model.PostCategoryAssignment
 	var category model.Category
 	var user model.User
+	var postTag model.PostTag
+	var tag model.Tag
+	var postAnswer model.PostAnswer
+	var postState model.PostState
+	var mention model.Mention
 	c.GetDB().QueryRowWithModel(fmt.Sprintf(`
 		SELECT 
 			p.id as id, p.author_id as author_id, u.username as author_username, 
+			p.status as status, p.publish_at as publish_at, p.deleted_at as deleted_at, p.delete_reason as delete_reason,
+			CASE WHEN p.deleted_at IS NULL THEN NULL
+				WHEN p.deleted_by_id = p.author_id THEN 'author' ELSE 'moderator' END as removed_by,
 			p.inserted_at as inserted_at, ps.slug as slug, pd.title as title, 
-			pd.description as description, pd.content as content
+			pd.description as description, pd.content as content,
+			ARRAY(
+				SELECT t.name FROM %s AS pt INNER JOIN %s AS t ON pt.tag_id = t.id
+				WHERE pt.post_id = p.id ORDER BY t.name
+			) as tags,
+			EXISTS (SELECT 1 FROM %s AS pa WHERE pa.post_id = p.id) as solved,
+			ARRAY(
+				SELECT mu.username FROM %s AS m INNER JOIN %s AS mu ON m.user_id = mu.id
+				WHERE m.post_id = p.id AND m.comment_id IS NULL AND m.removed_at IS NULL
+				ORDER BY mu.username
+			) as mentions,
+			COALESCE(pst.state, 'open') as state,
+			COALESCE(pst.pinned_globally, false) as pinned_globally,
+			COALESCE(pst.pinned_in_category, false) as pinned_in_category,
+			pst.duplicate_of_id as duplicate_of_id,
+			(
+				SELECT ds.slug FROM %s AS ds WHERE ds.post_id = pst.duplicate_of_id ORDER BY ds.id DESC LIMIT 1
+			) as duplicate_of_slug
 		FROM %s AS p
 		LEFT OUTER JOIN %s AS ps ON p.id = ps.post_id
 		LEFT OUTER JOIN %s AS ps2 ON ps.post_id = ps2.post_id AND ps.id < ps2.id
@@ -105,15 +241,247 @@ func (c CategoryPostController) Show(ctx *fasthttp.RequestCtx) {
 		INNER JOIN %s AS u ON p.author_id = u.id
 		INNER JOIN %s AS pca ON p.id = pca.post_id
 		INNER JOIN %s AS c ON pca.category_id = c.id
+		LEFT JOIN LATERAL (
+			SELECT s.* FROM %s AS s WHERE s.post_id = p.id ORDER BY s.id DESC LIMIT 1
+		) AS pst ON true
 		WHERE ps2.id IS NULL AND pd2.id IS NULL AND (c.id::text = $1::text OR c.slug = $1) AND 
-			(p.id::text = $2::text OR ps.slug = $2)
-	`, c.Model.TableName(), postSlug.TableName(), postSlug.TableName(), postDetail.TableName(),
-		postDetail.TableName(), user.TableName(), postCategoryAssignment.TableName(), category.TableName()),
+			(p.id::text = $2::text OR ps.slug = $2) AND (p.status = '%s' OR p.author_id = $3)
+	`, postTag.TableName(), tag.TableName(), postAnswer.TableName(), mention.TableName(), user.TableName(),
+		postSlug.TableName(), c.Model.TableName(), postSlug.TableName(), postSlug.TableName(), postDetail.TableName(),
+		postDetail.TableName(), user.TableName(), postCategoryAssignment.TableName(), category.TableName(),
+		postState.TableName(), database.Published),
 		&post,
 		phi.URLParam(ctx, "categoryID"),
-		phi.URLParam(ctx, "postID")).Force()
+		phi.URLParam(ctx, "postID"),
+		userID).Force()
+
+	pvC := PostVoteController{API: c.API}
+	post.Score = pvC.GetScore(post.ID)
+	if userID > 0 {
+		post.Vote = pvC.GetVote(post.ID, userID)
+		tasks.RecordRead(c.App, userID, post.ID)
+	}
+	if post.Solved {
+		post.AcceptedAnswer = PostAnswerController{API: c.API}.GetAnswer(post.ID)
+	}
+	if post.AcceptedAnswer != nil {
+		PostCommentController{API: c.API}.render(ctx, post.AcceptedAnswer)
+	}
+	if !c.IsModerator(ctx) {
+		post.Tombstone()
+	}
+	post.LinkMentions(c.App.Config.UIHost)
+	if post.State == database.Duplicate && post.DuplicateOfID.Valid && post.AuthorID != userID &&
+		!c.IsModerator(ctx) {
+		post.RedirectTo.SetValid(fmt.Sprintf("%s/post/%d", c.App.Config.UIHost, post.DuplicateOfID.Int64))
+	}
 
 	c.JSONResponse(ctx, model2.ResponseSuccessOne{
 		Data: post,
 	}, fasthttp.StatusOK)
-}
\ No newline at end of file
+}
+
+// renderScores set cached scores of the listed posts and the votes of the
+// current user on them
+func (c CategoryPostController) renderScores(ctx *fasthttp.RequestCtx, posts []model.PostDEP) {
+	if len(posts) == 0 {
+		return
+	}
+
+	ids := make([]int64, len(posts))
+	for i, post := range posts {
+		ids[i] = post.ID
+	}
+
+	pvC := PostVoteController{API: c.API}
+	scores := pvC.GetScores(ids)
+	votes := make(map[int64]int64)
+	if authContext := c.GetOptionalAuthContext(ctx); authContext != nil {
+		votes = pvC.GetVotes(ids, authContext.ID)
+	}
+	for i := range posts {
+		posts[i].Score = scores[posts[i].ID]
+		posts[i].Vote = votes[posts[i].ID]
+	}
+}
+
+// rankedPage identifiers of the posts of a page of a precomputed ranking and
+// the number of ranked posts. Pinned threads of the category come first and
+// are skipped where the ranking lists them.
+func (c CategoryPostController) rankedPage(ctx *fasthttp.RequestCtx, key string,
+	paginate model2.Pagination) ([]int64, int64) {
+	var postCategoryAssignment model.PostCategoryAssignment
+	var postState model.PostState
+
+	var pinned []int64
+	c.GetDB().DB.Select(&pinned, fmt.Sprintf(`
+		SELECT p.id FROM %s AS p
+		INNER JOIN %s AS pca ON p.id = pca.post_id
+		INNER JOIN LATERAL (
+			SELECT s.* FROM %s AS s WHERE s.post_id = p.id ORDER BY s.id DESC LIMIT 1
+		) AS pst ON true
+		WHERE pca.category_id = $1 AND p.status = '%s' AND p.deleted_at IS NULL AND
+			(pst.pinned_globally OR pst.pinned_in_category)
+		ORDER BY pst.pin_order ASC NULLS LAST, p.id DESC
+	`, c.Model.TableName(), postCategoryAssignment.TableName(), postState.TableName(), database.Published),
+		c.categoryID(ctx))
+
+	// ranks of the pinned threads in the ranking, in ascending order
+	pipe := c.GetCache().Pipeline()
+	cmds := make([]*redis.IntCmd, len(pinned))
+	for i, id := range pinned {
+		if paginate.OrderBy == "asc" {
+			cmds[i] = pipe.ZRank(key, strconv.FormatInt(id, 10))
+		} else {
+			cmds[i] = pipe.ZRevRank(key, strconv.FormatInt(id, 10))
+		}
+	}
+	pipe.Exec()
+
+	count, _ := c.GetCache().ZCard(key).Result()
+	var ranks []int64
+	for _, cmd := range cmds {
+		if rank, err := cmd.Result(); err == nil {
+			ranks = append(ranks, rank)
+		} else {
+			count++
+		}
+	}
+	sort.Slice(ranks, func(i, j int) bool { return ranks[i] < ranks[j] })
+
+	ids := []int64{}
+	if paginate.Offset < int64(len(pinned)) {
+		stop := paginate.Offset + int64(paginate.Limit)
+		if stop > int64(len(pinned)) {
+			stop = int64(len(pinned))
+		}
+		ids = append(ids, pinned[paginate.Offset:stop]...)
+	}
+	limit := int64(paginate.Limit) - int64(len(ids))
+	if limit <= 0 {
+		return ids, count
+	}
+
+	// position in the ranking of the first unpinned post of the page
+	start := paginate.Offset + int64(len(ids)) - int64(len(pinned))
+	for _, rank := range ranks {
+		if rank <= start {
+			start++
+		}
+	}
+
+	stop := start + limit + int64(len(ranks)) - 1
+	var members []string
+	if paginate.OrderBy == "asc" {
+		members, _ = c.GetCache().ZRange(key, start, stop).Result()
+	} else {
+		members, _ = c.GetCache().ZRevRange(key, start, stop).Result()
+	}
+	for _, member := range members {
+		id, _ := strconv.ParseInt(member, 10, 64)
+		if exists, _ := utils.InArray(id, pinned); exists || int64(len(ids)) >= int64(paginate.Limit) {
+			continue
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, count
+}
+
+// tagClause filter posts having the tag given as the param with index n
+func (c CategoryPostController) tagClause(n int) string {
+	var postTag model.PostTag
+	var tag model.Tag
+	return fmt.Sprintf(`AND EXISTS (
+		SELECT 1 FROM %s AS fpt INNER JOIN %s AS ft ON fpt.tag_id = ft.id
+		WHERE fpt.post_id = p.id AND (ft.id::text = $%d OR lower(ft.name) = $%d)
+	)`, postTag.TableName(), tag.TableName(), n, n)
+}
+
+// solvedClause filter posts by the solved query param, posts are solved
+// when they have an accepted answer
+func (c CategoryPostController) solvedClause(ctx *fasthttp.RequestCtx) (string, bool) {
+	val, ok := c.ParseQuery(ctx)["solved"]
+	if !ok {
+		return "", true
+	}
+
+	solved, err := strconv.ParseBool(val)
+	if err != nil {
+		c.JSONResponse(ctx, model2.ResponseError{
+			Errors: map[string]string{
+				"solved": "is not valid",
+			},
+			Detail: fasthttp.StatusMessage(fasthttp.StatusBadRequest),
+		}, fasthttp.StatusBadRequest)
+		return "", false
+	}
+
+	var postAnswer model.PostAnswer
+	clause := fmt.Sprintf("EXISTS (SELECT 1 FROM %s AS fpa WHERE fpa.post_id = p.id)", postAnswer.TableName())
+	if !solved {
+		clause = "NOT " + clause
+	}
+
+	return "AND " + clause, true
+}
+
+// rankingKey precomputed ranking cache key of the category. Top rankings
+// use the window query param, week by default.
+func (c CategoryPostController) rankingKey(ctx *fasthttp.RequestCtx, ranking string) (string, bool) {
+	categoryID := c.categoryID(ctx)
+
+	if ranking != "top" {
+		return fmt.Sprintf("%s:%d", cmn.GetRedisKey("post", ranking), categoryID), true
+	}
+
+	window := "week"
+	if val, ok := c.ParseQuery(ctx)["window"]; ok {
+		window = val
+	}
+	if _, ok := utils.RankingWindows[window]; !ok {
+		c.JSONResponse(ctx, model2.ResponseError{
+			Errors: map[string]string{
+				"window": "is not valid",
+			},
+			Detail: fasthttp.StatusMessage(fasthttp.StatusBadRequest),
+		}, fasthttp.StatusBadRequest)
+		return "", false
+	}
+
+	return fmt.Sprintf("%s:%s:%d", cmn.GetRedisKey("post", "top"), window, categoryID), true
+}
+
+// categoryID identifier of the category given as identifier or slug
+func (c CategoryPostController) categoryID(ctx *fasthttp.RequestCtx) int64 {
+	var category model.Category
+	var categoryID int64
+	c.GetDB().DB.Get(&categoryID, fmt.Sprintf(`
+		SELECT c.id FROM %s AS c WHERE c.id::text = $1::text OR c.slug = $1
+	`, category.TableName()),
+		phi.URLParam(ctx, "categoryID"))
+
+	return categoryID
+}
+
+// postCount cached count of published posts in the category, the counter
+// is loaded from the database when it is not cached yet
+func (c CategoryPostController) postCount(categoryID int64) int64 {
+	key := fmt.Sprintf("%s:%d", cmn.GetRedisKey("category", "posts"), categoryID)
+	count, err := c.GetCache().Get(key).Int64()
+	if err == nil {
+		return count
+	}
+
+	var postCategoryAssignment model.PostCategoryAssignment
+	c.GetDB().DB.Get(&count, fmt.Sprintf(`
+		SELECT count(DISTINCT p.id) FROM %s AS p
+		INNER JOIN %s AS pca ON p.id = pca.post_id
+		WHERE pca.category_id = $1 AND p.status = $2 AND p.deleted_at IS NULL
+	`, c.Model.TableName(), postCategoryAssignment.TableName()),
+		categoryID,
+		database.Published)
+	c.GetCache().Set(key, count, 0)
+
+	return count
+}
@@ -4,7 +4,9 @@ import (
 	"fmt"
 	"forgolang_forum/cmn"
 	"forgolang_forum/database/model"
+	"forgolang_forum/tasks"
 	"github.com/gosimple/slug"
+	"github.com/lib/pq"
 	"github.com/valyala/fasthttp"
 	"testing"
 )
@@ -103,6 +105,157 @@ func (s CategoryPostControllerTest) Test_ListAllPostsWithPaginationParams() {
 	defaultLogger.LogInfo("List all posts with pagination params")
 }
 
+func (s CategoryPostControllerTest) Test_ListPostsWithRankings() {
+	category := model.NewCategory()
+	category.Title = "Category 5"
+	category.Slug = slug.Make(category.Title)
+	err := s.API.GetDB().Insert(new(model.Category), category, "id")
+	s.Nil(err)
+
+	var posts []*model.Post
+	for i := 0; i < 2; i++ {
+		post := model.NewPost(s.Auth.User.ID)
+		err := s.API.GetDB().Insert(new(model.Post), post, "id")
+		s.Nil(err)
+		postDetail := model.NewPostDetail(post.ID, s.Auth.User.ID)
+		postDetail.Title = "Post"
+		postDetail.Content = "Post Context"
+		err = s.API.GetDB().Insert(new(model.PostDetail), postDetail, "id")
+		s.Nil(err)
+		postCategoryAssignment := model.NewPostCategoryAssignment(post.ID, category.ID, s.Auth.User.ID)
+		err = s.API.GetDB().Insert(new(model.PostCategoryAssignment), postCategoryAssignment, "id")
+		s.Nil(err)
+		posts = append(posts, post)
+	}
+
+	votesUp := model.NewPostVotesUp(posts[0].ID, s.Auth.User.ID)
+	err = s.API.GetDB().Insert(new(model.PostVotesUp), votesUp, "id")
+	s.Nil(err)
+	postComment := model.NewPostComment(posts[1].ID, s.Auth.User.ID)
+	err = s.API.GetDB().Insert(new(model.PostComment), postComment, "id")
+	s.Nil(err)
+
+	err = tasks.RefreshPostRankings(s.API.App, nil)
+	s.Nil(err)
+
+	response := s.JSON(Get, fmt.Sprintf("/api/v1/category/%d/post?order_field=top&window=day", category.ID), nil)
+
+	s.Equal(response.Status, fasthttp.StatusOK)
+	s.Equal(response.Success.TotalCount, int64(2))
+	data, _ := response.Success.Data.([]interface{})
+	s.Equal(len(data), 2)
+	s.Equal(data[0].(map[string]interface{})["id"], float64(posts[0].ID))
+
+	response = s.JSON(Get, fmt.Sprintf("/api/v1/category/%d/post?order_field=active", category.ID), nil)
+
+	s.Equal(response.Status, fasthttp.StatusOK)
+	data, _ = response.Success.Data.([]interface{})
+	s.Equal(data[0].(map[string]interface{})["id"], float64(posts[1].ID))
+
+	response = s.JSON(Get, fmt.Sprintf("/api/v1/category/%d/post?order_field=top&window=year", category.ID), nil)
+
+	s.Equal(response.Status, fasthttp.StatusBadRequest)
+
+	defaultLogger.LogInfo("List posts with hot, top and active rankings")
+}
+
+func (s CategoryPostControllerTest) Test_TopRankingsCountVotesWithinWindow() {
+	category := model.NewCategory()
+	category.Title = "Category Top Window"
+	category.Slug = slug.Make(category.Title)
+	err := s.API.GetDB().Insert(new(model.Category), category, "id")
+	s.Nil(err)
+
+	var posts []*model.Post
+	for i := 0; i < 2; i++ {
+		post := model.NewPost(s.Auth.User.ID)
+		err := s.API.GetDB().Insert(new(model.Post), post, "id")
+		s.Nil(err)
+		postDetail := model.NewPostDetail(post.ID, s.Auth.User.ID)
+		postDetail.Title = "Post"
+		postDetail.Content = "Post Context"
+		err = s.API.GetDB().Insert(new(model.PostDetail), postDetail, "id")
+		s.Nil(err)
+		postCategoryAssignment := model.NewPostCategoryAssignment(post.ID, category.ID, s.Auth.User.ID)
+		err = s.API.GetDB().Insert(new(model.PostCategoryAssignment), postCategoryAssignment, "id")
+		s.Nil(err)
+		votesUp := model.NewPostVotesUp(post.ID, s.Auth.User.ID)
+		err = s.API.GetDB().Insert(new(model.PostVotesUp), votesUp, "id")
+		s.Nil(err)
+		posts = append(posts, post)
+	}
+
+	// both posts are old, only the second one is voted today
+	_, err = s.API.GetDB().DB.Exec(`UPDATE posts SET
+		published_at = (CURRENT_TIMESTAMP at time zone 'utc') - interval '40 days',
+		inserted_at = (CURRENT_TIMESTAMP at time zone 'utc') - interval '40 days'
+		WHERE id = ANY($1)`, pq.Array([]int64{posts[0].ID, posts[1].ID}))
+	s.Nil(err)
+	_, err = s.API.GetDB().DB.Exec(`UPDATE post_votes_up SET inserted_at = inserted_at - interval '40 days'
+		WHERE post_id = $1`, posts[0].ID)
+	s.Nil(err)
+
+	err = tasks.RefreshPostRankings(s.API.App, nil)
+	s.Nil(err)
+
+	response := s.JSON(Get, fmt.Sprintf("/api/v1/category/%d/post?order_field=top&window=day", category.ID), nil)
+	s.Equal(response.Status, fasthttp.StatusOK)
+	s.Equal(response.Success.TotalCount, int64(1))
+	data, _ := response.Success.Data.([]interface{})
+	s.Equal(data[0].(map[string]interface{})["id"], float64(posts[1].ID))
+
+	response = s.JSON(Get, fmt.Sprintf("/api/v1/category/%d/post?order_field=top&window=all", category.ID), nil)
+	s.Equal(response.Status, fasthttp.StatusOK)
+	s.Equal(response.Success.TotalCount, int64(2))
+
+	defaultLogger.LogInfo("Top rankings count votes within window")
+}
+
+func (s CategoryPostControllerTest) Test_ListPostsFilteredByTag() {
+	category := model.NewCategory()
+	category.Title = "Category 6"
+	category.Slug = slug.Make(category.Title)
+	err := s.API.GetDB().Insert(new(model.Category), category, "id")
+	s.Nil(err)
+
+	tag := model.NewTag()
+	tag.Name = "filter-tag"
+	err = s.API.GetDB().Insert(new(model.Tag), tag, "id", "inserted_at", "updated_at")
+	s.Nil(err)
+
+	var posts []*model.Post
+	for i := 0; i < 2; i++ {
+		post := model.NewPost(s.Auth.User.ID)
+		err := s.API.GetDB().Insert(new(model.Post), post, "id")
+		s.Nil(err)
+		postDetail := model.NewPostDetail(post.ID, s.Auth.User.ID)
+		postDetail.Title = "Post"
+		postDetail.Content = "Post Context"
+		err = s.API.GetDB().Insert(new(model.PostDetail), postDetail, "id")
+		s.Nil(err)
+		postCategoryAssignment := model.NewPostCategoryAssignment(post.ID, category.ID, s.Auth.User.ID)
+		err = s.API.GetDB().Insert(new(model.PostCategoryAssignment), postCategoryAssignment, "id")
+		s.Nil(err)
+		posts = append(posts, post)
+	}
+
+	postTag := model.NewPostTag(posts[0].ID)
+	postTag.TagID = tag.ID
+	err = s.API.GetDB().Insert(new(model.PostTag), postTag, "id")
+	s.Nil(err)
+
+	response := s.JSON(Get, fmt.Sprintf("/api/v1/category/%d/post?tag=%s", category.ID, tag.Name), nil)
+
+	s.Equal(response.Status, fasthttp.StatusOK)
+	s.Equal(response.Success.TotalCount, int64(1))
+	data, _ := response.Success.Data.([]interface{})
+	s.Equal(len(data), 1)
+	s.Equal(data[0].(map[string]interface{})["id"], float64(posts[0].ID))
+	s.Equal(data[0].(map[string]interface{})["tags"], []interface{}{"filter-tag"})
+
+	defaultLogger.LogInfo("List posts filtered by tag")
+}
+
 func (s CategoryPostControllerTest) Test_ShowPostWithGivenSlug() {
 	category := model.NewCategory()
 	category.Title = "Category 3"
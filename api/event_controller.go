@@ -0,0 +1,199 @@
+// Copyright 2019 StreetByters Community
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with
+// this work for additional information regarding copyright ownership.
+// The ASF licenses this file to You under the Apache License, Version 2.0
+// (the "License"); you may not use this file except in compliance with
+// the License.  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"forgolang_forum/database/model"
+	model2 "forgolang_forum/model"
+	"forgolang_forum/tasks"
+	"github.com/valyala/fasthttp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EventHeartbeat interval of comments sent to idle event streams, so
+// proxies keep the connection open and closed clients are noticed
+const EventHeartbeat = 15 * time.Second
+
+// EventController realtime server-sent events api controller
+type EventController struct {
+	Controller
+	*API
+}
+
+// Index stream events of the subscribed topics. Topics are given as a
+// comma separated list of post:{id}, category:{id} and notifications.
+// Events after the Last-Event-ID header or the last_event_id query param
+// are replayed first.
+func (c EventController) Index(ctx *fasthttp.RequestCtx) {
+	topics, ok := c.topics(ctx)
+	if !ok {
+		c.JSONResponse(ctx, model2.ResponseError{
+			Errors: map[string]string{
+				"topics": "is not valid",
+			},
+			Detail: fasthttp.StatusMessage(fasthttp.StatusUnprocessableEntity),
+		}, fasthttp.StatusUnprocessableEntity)
+		return
+	}
+
+	lastEventID := string(ctx.Request.Header.Peek("Last-Event-ID"))
+	if val, ok := c.ParseQuery(ctx)["last_event_id"]; ok && lastEventID == "" {
+		lastEventID = val
+	}
+
+	channels := make([]string, len(topics))
+	for i, topic := range topics {
+		channels[i] = tasks.EventChannel(topic)
+	}
+
+	// subscribed before the replay, events published in the meantime are
+	// received twice and skipped by their identifiers
+	pubSub := c.GetCache().Subscribe(channels...)
+	if _, err := pubSub.Receive(); err != nil {
+		pubSub.Close()
+		c.JSONResponse(ctx, model2.ResponseError{
+			Detail: fasthttp.StatusMessage(fasthttp.StatusServiceUnavailable),
+		}, fasthttp.StatusServiceUnavailable)
+		return
+	}
+
+	var replay []model2.Event
+	if lastEventID != "" {
+		var err error
+		if replay, err = tasks.ReplayEvents(c.App, topics, lastEventID); err != nil {
+			pubSub.Close()
+			c.JSONResponse(ctx, model2.ResponseError{
+				Errors: map[string]string{
+					"last_event_id": "is not valid",
+				},
+				Detail: fasthttp.StatusMessage(fasthttp.StatusUnprocessableEntity),
+			}, fasthttp.StatusUnprocessableEntity)
+			return
+		}
+	}
+
+	ctx.SetContentType("text/event-stream")
+	ctx.Response.Header.Set("Cache-Control", "no-cache")
+	ctx.Response.Header.Set("X-Accel-Buffering", "no")
+	ctx.SetStatusCode(fasthttp.StatusOK)
+	ctx.SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer pubSub.Close()
+
+		for _, event := range replay {
+			lastEventID = event.ID
+			if writeEvent(w, event) != nil {
+				return
+			}
+		}
+
+		heartbeat := time.NewTicker(EventHeartbeat)
+		defer heartbeat.Stop()
+
+		messages := pubSub.Channel()
+		for {
+			select {
+			case message, ok := <-messages:
+				if !ok {
+					return
+				}
+
+				event, err := tasks.ParseEventMessage(message.Payload)
+				if err != nil {
+					continue
+				}
+				if lastEventID != "" && !event.After(lastEventID) {
+					continue
+				}
+				lastEventID = event.ID
+				if writeEvent(w, event) != nil {
+					return
+				}
+			case <-heartbeat.C:
+				fmt.Fprint(w, ": heartbeat\n\n")
+				if w.Flush() != nil {
+					return
+				}
+			}
+		}
+	})
+}
+
+// topics parse and authorize subscribed topics of the current user. Threads
+// of posts which are not published yet or removed are subscribed only by
+// their authors and moderators.
+func (c EventController) topics(ctx *fasthttp.RequestCtx) ([]string, bool) {
+	val := c.ParseQuery(ctx)["topics"]
+	if val == "" {
+		return nil, false
+	}
+
+	var topics []string
+	for _, topic := range strings.Split(val, ",") {
+		if topic == "notifications" {
+			topics = append(topics, model2.UserTopic(c.GetAuthContext(ctx).ID))
+			continue
+		}
+
+		parts := strings.SplitN(topic, ":", 2)
+		if len(parts) != 2 {
+			return nil, false
+		}
+		id, err := strconv.ParseInt(parts[1], 10, 64)
+		if err != nil {
+			return nil, false
+		}
+
+		switch parts[0] {
+		case "post":
+			if !c.IsPostVisible(ctx, id) {
+				return nil, false
+			}
+			topics = append(topics, model2.PostTopic(id))
+		case "category":
+			var category model.Category
+			var exists bool
+			c.GetDB().DB.Get(&exists, fmt.Sprintf(`
+				SELECT EXISTS (SELECT 1 FROM %s AS c WHERE c.id = $1)
+			`, category.TableName()),
+				id)
+			if !exists {
+				return nil, false
+			}
+			topics = append(topics, model2.CategoryTopic(id))
+		default:
+			return nil, false
+		}
+	}
+
+	return topics, true
+}
+
+// writeEvent write an event in the server-sent events format
+func writeEvent(w *bufio.Writer, event model2.Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", event.ID, event.Type, body)
+	return w.Flush()
+}
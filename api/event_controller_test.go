@@ -0,0 +1,93 @@
+package api
+
+import (
+	"forgolang_forum/database/model"
+	model2 "forgolang_forum/model"
+	"forgolang_forum/tasks"
+	"github.com/valyala/fasthttp"
+	"sync"
+	"testing"
+)
+
+type EventControllerTest struct {
+	*Suite
+}
+
+func (s EventControllerTest) SetupSuite() {
+	SetupSuite(s.Suite)
+	UserAuth(s.Suite)
+}
+
+func (s EventControllerTest) Test_ReplayEventsSinceLastEventID() {
+	post := model.NewPost(s.Auth.User.ID)
+	err := s.API.GetDB().Insert(new(model.Post), post, "id")
+	s.Nil(err)
+
+	topics := []string{model2.PostTopic(post.ID), model2.UserTopic(s.Auth.User.ID)}
+	s.Nil(tasks.PublishEvent(s.API.App, topics[0], "comment", map[string]interface{}{"id": 1}))
+
+	events, err := tasks.ReplayEvents(s.API.App, topics, "0")
+	s.Nil(err)
+	s.Equal(len(events), 1)
+	lastEventID := events[0].ID
+
+	s.Nil(tasks.PublishEvent(s.API.App, topics[1], "notification", map[string]interface{}{"id": 2}))
+	s.Nil(tasks.PublishEvent(s.API.App, topics[0], "comment", map[string]interface{}{"id": 3}))
+
+	events, err = tasks.ReplayEvents(s.API.App, topics, lastEventID)
+	s.Nil(err)
+	s.Equal(len(events), 2)
+	s.Equal(events[0].Type, "notification")
+	s.Equal(events[0].Topic, topics[1])
+	s.Equal(events[1].Type, "comment")
+	s.True(events[1].After(events[0].ID))
+
+	defaultLogger.LogInfo("Replay events since last event id")
+}
+
+func (s EventControllerTest) Test_KeepConcurrentlyPublishedEvents() {
+	post := model.NewPost(s.Auth.User.ID)
+	err := s.API.GetDB().Insert(new(model.Post), post, "id")
+	s.Nil(err)
+
+	topic := model2.PostTopic(post.ID)
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs <- tasks.PublishEvent(s.API.App, topic, "comment", map[string]interface{}{"id": i})
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		s.Nil(err)
+	}
+
+	events, err := tasks.ReplayEvents(s.API.App, []string{topic}, "0")
+	s.Nil(err)
+	s.Equal(len(events), 20)
+
+	defaultLogger.LogInfo("Keep concurrently published events")
+}
+
+func (s EventControllerTest) Test_Should_422Err_SubscribeInvalidTopics() {
+	response := s.JSON(Get, "/api/v1/events?topics=thread:1", nil)
+	s.Equal(response.Status, fasthttp.StatusUnprocessableEntity)
+
+	response = s.JSON(Get, "/api/v1/events?topics=post:999999999", nil)
+	s.Equal(response.Status, fasthttp.StatusUnprocessableEntity)
+
+	defaultLogger.LogInfo("Should be 422 error subscribe invalid topics")
+}
+
+func (s EventControllerTest) TearDownSuite() {
+	TearDownSuite(s.Suite)
+}
+
+func Test_EventController(t *testing.T) {
+	s := EventControllerTest{NewSuite()}
+	Run(t, s)
+}
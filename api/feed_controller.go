@@ -0,0 +1,160 @@
+// Copyright 2019 StreetByters Community
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with
+// this work for additional information regarding copyright ownership.
+// The ASF licenses this file to You under the Apache License, Version 2.0
+// (the "License"); you may not use this file except in compliance with
+// the License.  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"forgolang_forum/database"
+	"forgolang_forum/database/model"
+	model2 "forgolang_forum/model"
+	"forgolang_forum/tasks"
+	"github.com/lib/pq"
+	"github.com/valyala/fasthttp"
+	"strconv"
+	"strings"
+)
+
+// FeedController personalized home feed api controller
+type FeedController struct {
+	Controller
+	*API
+	Model model.Post
+}
+
+// Index list new posts of followed users, tags and categories ranked by
+// recency with a boost for votes. The feed is paginated with the cursor of
+// the previous page instead of an offset so new posts do not shift pages.
+func (c FeedController) Index(ctx *fasthttp.RequestCtx) {
+	paginate, _, _ := c.Paginate(ctx, "id")
+	userID := c.GetAuthContext(ctx).ID
+
+	score, id := tasks.FeedStart()
+	if val, ok := c.ParseQuery(ctx)["cursor"]; ok {
+		var err error
+		if score, id, err = decodeFeedCursor(val); err != nil {
+			c.JSONResponse(ctx, model2.ResponseError{
+				Errors: map[string]string{
+					"cursor": "is not valid",
+				},
+				Detail: fasthttp.StatusMessage(fasthttp.StatusBadRequest),
+			}, fasthttp.StatusBadRequest)
+			return
+		}
+	}
+
+	entries, err := tasks.ReadFeed(c.App, userID, score, id, paginate.Limit)
+	if err != nil {
+		c.JSONResponse(ctx, model2.ResponseError{
+			Detail: fasthttp.StatusMessage(fasthttp.StatusInternalServerError),
+		}, fasthttp.StatusInternalServerError)
+		return
+	}
+
+	var ids []int64
+	for _, e := range entries {
+		ids = append(ids, e.ID)
+	}
+
+	// precomputed feeds may still hold posts removed since they were fanned
+	// out, they are left out here
+	posts := make([]model.PostDEP, 0)
+	var postSlug model.PostSlug
+	var postDetail model.PostDetail
+	var user model.User
+	var votesUp model.PostVotesUp
+	var votesDown model.PostVotesDown
+	var postTag model.PostTag
+	var tag model.Tag
+	var postAnswer model.PostAnswer
+	if len(ids) > 0 {
+		c.GetDB().QueryWithModel(fmt.Sprintf(`
+			SELECT
+				p.id as id, p.author_id as author_id, u.username as author_username,
+				p.inserted_at as inserted_at, ps.slug as slug, pd.title as title,
+				pd.description as description, pd.content as content,
+				(SELECT count(pvu.id) FROM %s AS pvu WHERE pvu.post_id = p.id) -
+					(SELECT count(pvd.id) FROM %s AS pvd WHERE pvd.post_id = p.id) as score,
+				COALESCE(
+					(SELECT 1 FROM %s AS pvu WHERE pvu.post_id = p.id AND pvu.user_id = $2),
+					(SELECT -1 FROM %s AS pvd WHERE pvd.post_id = p.id AND pvd.user_id = $2),
+					0) as vote,
+				ARRAY(
+					SELECT t.name FROM %s AS pt INNER JOIN %s AS t ON pt.tag_id = t.id
+					WHERE pt.post_id = p.id ORDER BY t.name
+				) as tags,
+				EXISTS (SELECT 1 FROM %s AS pa WHERE pa.post_id = p.id) as solved
+			FROM %s AS p
+			LEFT OUTER JOIN %s AS ps ON p.id = ps.post_id
+			LEFT OUTER JOIN %s AS ps2 ON ps.post_id = ps2.post_id AND ps.id < ps2.id
+			INNER JOIN %s AS pd ON p.id = pd.post_id
+			LEFT OUTER JOIN %s AS pd2 ON pd.post_id = pd2.post_id AND pd.id < pd2.id
+			INNER JOIN %s AS u ON p.author_id = u.id
+			WHERE ps2.id IS NULL AND pd2.id IS NULL AND p.id = ANY($1) AND
+				p.status = $3 AND p.deleted_at IS NULL
+			ORDER BY array_position($1, p.id)
+		`, votesUp.TableName(), votesDown.TableName(), votesUp.TableName(), votesDown.TableName(),
+			postTag.TableName(), tag.TableName(), postAnswer.TableName(), c.Model.TableName(),
+			postSlug.TableName(), postSlug.TableName(), postDetail.TableName(), postDetail.TableName(),
+			user.TableName()),
+			&posts,
+			pq.Array(ids),
+			userID,
+			database.Published)
+	}
+
+	var nextCursor string
+	if len(entries) == paginate.Limit {
+		last := entries[len(entries)-1]
+		nextCursor = encodeFeedCursor(last.Score, last.ID)
+	}
+
+	c.JSONResponse(ctx, model2.ResponseSuccessCursor{
+		Data:       posts,
+		NextCursor: nextCursor,
+	}, fasthttp.StatusOK)
+}
+
+// encodeFeedCursor next page cursor after a post of the feed
+func encodeFeedCursor(score, id int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf("%d:%d", score, id)))
+}
+
+// decodeFeedCursor feed score and post of a next page cursor
+func decodeFeedCursor(cursor string) (int64, int64, error) {
+	body, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	parts := strings.Split(string(body), ":")
+	if len(parts) != 2 {
+		return 0, 0, errors.New("cursor is not valid")
+	}
+
+	score, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return 0, 0, errors.New("cursor is not valid")
+	}
+	id, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil || id < 1 {
+		return 0, 0, errors.New("cursor is not valid")
+	}
+
+	return score, id, nil
+}
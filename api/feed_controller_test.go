@@ -0,0 +1,91 @@
+package api
+
+import (
+	"fmt"
+	"forgolang_forum/database/model"
+	"forgolang_forum/tasks"
+	"github.com/valyala/fasthttp"
+	"gopkg.in/guregu/null.v3/zero"
+	"testing"
+	"time"
+)
+
+type FeedControllerTest struct {
+	*Suite
+}
+
+func (s FeedControllerTest) SetupSuite() {
+	SetupSuite(s.Suite)
+	UserAuth(s.Suite)
+}
+
+func (s FeedControllerTest) Test_ListFeedOfFollowedUsers() {
+	pwd := "12345"
+	user := model.NewUser(&pwd)
+	user.Username = "test-feed-author"
+	user.Email = "test-feed-author@mail.com"
+	err := s.API.GetDB().Insert(new(model.User), user, "id")
+	s.Nil(err)
+
+	follow := model.NewFollow(s.Auth.User.ID)
+	follow.FollowedUserID.SetValid(user.ID)
+	err = s.API.GetDB().Insert(new(model.Follow), follow, "id")
+	s.Nil(err)
+	tasks.RemoveFeed(s.API.App, s.Auth.User.ID)
+
+	now := time.Now().UTC()
+	for i := 0; i < 3; i++ {
+		post := model.NewPost(user.ID)
+		post.PublishedAt = zero.TimeFrom(now.Add(time.Duration(i) * time.Minute))
+		err := s.API.GetDB().Insert(new(model.Post), post, "id")
+		s.Nil(err)
+
+		postDetail := model.NewPostDetail(post.ID, user.ID)
+		postDetail.Title = fmt.Sprintf("Feed Post %d", i)
+		postDetail.Description.SetValid("Feed Post Detail")
+		postDetail.Content = "Feed Post Content"
+		err = s.API.GetDB().Insert(new(model.PostDetail), postDetail, "id")
+		s.Nil(err)
+	}
+
+	response := s.JSON(Get, "/api/v1/feed?limit=2", nil)
+	s.Equal(response.Status, fasthttp.StatusOK)
+	data, _ := response.Success.Data.([]interface{})
+	s.Len(data, 2)
+	first, _ := data[0].(map[string]interface{})
+	s.Equal(first["title"], "Feed Post 2")
+
+	// the next page continues after the last post of the previous page
+	score, id := tasks.FeedStart()
+	entries, err := tasks.ComputeFeed(s.API.App, s.Auth.User.ID, score, id, 2)
+	s.Nil(err)
+	s.Len(entries, 2)
+	cursor := encodeFeedCursor(entries[1].Score, entries[1].ID)
+
+	response = s.JSON(Get, fmt.Sprintf("/api/v1/feed?limit=2&cursor=%s", cursor), nil)
+	s.Equal(response.Status, fasthttp.StatusOK)
+	data, _ = response.Success.Data.([]interface{})
+	s.Len(data, 1)
+	first, _ = data[0].(map[string]interface{})
+	s.Equal(first["title"], "Feed Post 0")
+
+	defaultLogger.LogInfo("List feed of followed users")
+}
+
+func (s FeedControllerTest) Test_Should_400Err_ListFeedWithInvalidCursor() {
+	response := s.JSON(Get, "/api/v1/feed?cursor=invalid", nil)
+	s.Equal(response.Status, fasthttp.StatusBadRequest)
+	data, _ := response.Error.Errors.(map[string]interface{})
+	s.Equal(data["cursor"], "is not valid")
+
+	defaultLogger.LogInfo("Should be 400 error list feed with invalid cursor")
+}
+
+func (s FeedControllerTest) TearDownSuite() {
+	TearDownSuite(s.Suite)
+}
+
+func Test_FeedController(t *testing.T) {
+	s := FeedControllerTest{NewSuite()}
+	Run(t, s)
+}
@@ -0,0 +1,231 @@
+// Copyright 2019 StreetByters Community
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with
+// this work for additional information regarding copyright ownership.
+// The ASF licenses this file to You under the Apache License, Version 2.0
+// (the "License"); you may not use this file except in compliance with
+// the License.  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"database/sql"
+	"fmt"
+	"forgolang_forum/database"
+	"forgolang_forum/database/model"
+	model2 "forgolang_forum/model"
+	"forgolang_forum/tasks"
+	"github.com/fate-lovely/phi"
+	"github.com/valyala/fasthttp"
+	"gopkg.in/guregu/null.v3/zero"
+)
+
+// FlagController flags and moderation queue api controller
+type FlagController struct {
+	Controller
+	*API
+	Model model.Flag
+}
+
+// Index list the moderation queue, pending flags are aggregated by their
+// target. The type query param filters posts, comments or users.
+func (c FlagController) Index(ctx *fasthttp.RequestCtx) {
+	paginate, _, _ := c.Paginate(ctx, "id", "count", "trusted_count", "last_flagged_at")
+
+	var clause string
+	switch c.ParseQuery(ctx)["type"] {
+	case "post":
+		clause = "AND f.post_id IS NOT NULL AND f.comment_id IS NULL"
+	case "comment":
+		clause = "AND f.comment_id IS NOT NULL"
+	case "user":
+		clause = "AND f.post_id IS NULL"
+	}
+
+	var user model.User
+	var post model.Post
+	var postComment model.PostComment
+	var items []model.FlagQueueItem
+	c.GetDB().QueryWithModel(fmt.Sprintf(`
+		SELECT
+			min(f.id) as id, f.target_user_id as target_user_id, u.username as target_username,
+			f.post_id as post_id, f.comment_id as comment_id, count(f.id) as count,
+			count(f.id) FILTER (WHERE f.trusted) as trusted_count,
+			array_agg(DISTINCT f.reason::text) as reasons,
+			COALESCE(bool_or(CASE WHEN f.comment_id IS NULL THEN p.deleted_at IS NOT NULL
+				ELSE pc.deleted_at IS NOT NULL END), false) as hidden,
+			min(f.inserted_at) as first_flagged_at, max(f.inserted_at) as last_flagged_at
+		FROM %s AS f
+		INNER JOIN %s AS u ON f.target_user_id = u.id
+		LEFT OUTER JOIN %s AS p ON f.post_id = p.id
+		LEFT OUTER JOIN %s AS pc ON f.comment_id = pc.id
+		WHERE f.status = $1 %s
+		GROUP BY f.target_user_id, u.username, f.post_id, f.comment_id
+		ORDER BY %s %s, id ASC
+		LIMIT $2 OFFSET $3
+	`, c.Model.TableName(), user.TableName(), post.TableName(), postComment.TableName(), clause,
+		paginate.OrderField, paginate.OrderBy),
+		&items,
+		database.Pending,
+		paginate.Limit,
+		paginate.Offset)
+
+	var count int64
+	c.GetDB().DB.Get(&count, fmt.Sprintf(`
+		SELECT count(*) FROM (
+			SELECT 1 FROM %s AS f WHERE f.status = $1 %s
+			GROUP BY f.target_user_id, f.post_id, f.comment_id
+		) AS q
+	`, c.Model.TableName(), clause),
+		database.Pending)
+
+	c.JSONResponse(ctx, model2.ResponseSuccess{
+		Data:       items,
+		TotalCount: count,
+	}, fasthttp.StatusOK)
+}
+
+// Show list the flags of the target of a flag with the accuracies of their
+// flaggers, flags of the spam classifier have no flagger
+func (c FlagController) Show(ctx *fasthttp.RequestCtx) {
+	flag, ok := c.getFlag(ctx)
+	if !ok {
+		return
+	}
+
+	var user model.User
+	var flags []model.Flag
+	c.GetDB().QueryWithModel(fmt.Sprintf(`
+		SELECT f.*, u.username as username FROM %s AS f
+		LEFT OUTER JOIN %s AS u ON f.user_id = u.id
+		WHERE f.target_user_id = $1 AND f.post_id IS NOT DISTINCT FROM $2 AND
+			f.comment_id IS NOT DISTINCT FROM $3
+		ORDER BY f.id DESC
+	`, c.Model.TableName(), user.TableName()),
+		&flags,
+		flag.TargetUserID,
+		flag.PostID,
+		flag.CommentID)
+	for i := range flags {
+		if flags[i].UserID.Valid {
+			flags[i].Accuracy = zero.FloatFrom(tasks.GetFlagAccuracy(c.App, flags[i].UserID.Int64))
+		}
+	}
+
+	c.JSONResponse(ctx, model2.ResponseSuccess{
+		Data:       flags,
+		TotalCount: int64(len(flags)),
+	}, fasthttp.StatusOK)
+}
+
+// Create flag a post, a comment or a user of the route. Flags of moderators
+// are always trusted.
+func (c FlagController) Create(ctx *fasthttp.RequestCtx) {
+	flag := FlagPolicy{API: c.API}.GetTarget(ctx)
+	if flag.TargetUserID == 0 {
+		c.JSONResponse(ctx, model2.ResponseError{
+			Detail: fasthttp.StatusMessage(fasthttp.StatusNotFound),
+		}, fasthttp.StatusNotFound)
+		return
+	}
+
+	var request model.Flag
+	c.JSONBody(ctx, &request)
+	flag.UserID.SetValid(c.GetAuthContext(ctx).ID)
+	flag.Reason = request.Reason
+	flag.Note = request.Note
+	if flag.Note.Valid {
+		flag.Note.SetValid(c.App.TextPolicy.Sanitize(flag.Note.String))
+	}
+	flag.Trusted = c.IsModerator(ctx)
+
+	if errs, err := database.ValidateStruct(flag); err != nil {
+		c.JSONResponse(ctx, model2.ResponseError{
+			Errors: errs,
+			Detail: fasthttp.StatusMessage(fasthttp.StatusUnprocessableEntity),
+		}, fasthttp.StatusUnprocessableEntity)
+		return
+	}
+
+	err := tasks.AddFlag(c.App, flag)
+	if errs, err := database.ValidateConstraint(err, flag); err != nil {
+		c.JSONResponse(ctx, model2.ResponseError{
+			Errors: errs,
+			Detail: fasthttp.StatusMessage(fasthttp.StatusUnprocessableEntity),
+		}, fasthttp.StatusUnprocessableEntity)
+		return
+	}
+
+	c.JSONResponse(ctx, model2.ResponseSuccessOne{
+		Data: flag,
+	}, fasthttp.StatusCreated)
+}
+
+// Resolve resolve the pending flags of the target of a flag as agreed,
+// disagreed or deferred
+func (c FlagController) Resolve(ctx *fasthttp.RequestCtx) {
+	var request model2.FlagResolveRequest
+	c.JSONBody(ctx, &request)
+	if errs, err := database.ValidateStruct(request); err != nil {
+		c.JSONResponse(ctx, model2.ResponseError{
+			Errors: errs,
+			Detail: fasthttp.StatusMessage(fasthttp.StatusUnprocessableEntity),
+		}, fasthttp.StatusUnprocessableEntity)
+		return
+	}
+
+	flag, ok := c.getFlag(ctx)
+	if !ok {
+		return
+	}
+
+	n, err := tasks.ResolveFlags(c.App, flag, database.FlagStatus(request.Status), c.GetAuthContext(ctx).ID)
+	if err != nil {
+		c.JSONResponse(ctx, model2.ResponseError{
+			Detail: fasthttp.StatusMessage(fasthttp.StatusInternalServerError),
+		}, fasthttp.StatusInternalServerError)
+		return
+	}
+	if n == 0 {
+		c.JSONResponse(ctx, model2.ResponseError{
+			Errors: map[string]string{
+				"status": "has been already resolved",
+			},
+			Detail: fasthttp.StatusMessage(fasthttp.StatusUnprocessableEntity),
+		}, fasthttp.StatusUnprocessableEntity)
+		return
+	}
+
+	c.JSONResponse(ctx, model2.ResponseSuccessOne{
+		Data: map[string]interface{}{
+			"status":   request.Status,
+			"resolved": n,
+		},
+	}, fasthttp.StatusOK)
+}
+
+// getFlag flag of the route, a not found response is written when it does
+// not exist
+func (c FlagController) getFlag(ctx *fasthttp.RequestCtx) (*model.Flag, bool) {
+	flag := new(model.Flag)
+	err := c.GetDB().DB.Get(flag, fmt.Sprintf(`
+		SELECT f.* FROM %s AS f WHERE f.id::text = $1::text
+	`, c.Model.TableName()),
+		phi.URLParam(ctx, "flagID"))
+	if err == sql.ErrNoRows {
+		c.JSONResponse(ctx, model2.ResponseError{
+			Detail: fasthttp.StatusMessage(fasthttp.StatusNotFound),
+		}, fasthttp.StatusNotFound)
+		return nil, false
+	}
+
+	return flag, true
+}
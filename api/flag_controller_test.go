@@ -0,0 +1,266 @@
+package api
+
+import (
+	"fmt"
+	"forgolang_forum/database"
+	"forgolang_forum/database/model"
+	model2 "forgolang_forum/model"
+	"forgolang_forum/tasks"
+	"github.com/valyala/fasthttp"
+	"testing"
+)
+
+type FlagControllerTest struct {
+	*Suite
+}
+
+func (s FlagControllerTest) SetupSuite() {
+	SetupSuite(s.Suite)
+	UserAuth(s.Suite)
+}
+
+// trustedUser authenticate as a new user with the basic trust level
+func (s FlagControllerTest) trustedUser() {
+	UserAuth(s.Suite, "user")
+	err := tasks.SetTrustLevel(s.API.App, model.NewUserTrustLevel(s.Auth.User.ID, database.Basic))
+	s.Nil(err)
+}
+
+func (s FlagControllerTest) isDeleted(postID int64) bool {
+	var deleted bool
+	err := s.API.GetDB().DB.Get(&deleted, `SELECT p.deleted_at IS NOT NULL FROM posts AS p WHERE p.id = $1`, postID)
+	s.Nil(err)
+
+	return deleted
+}
+
+func (s FlagControllerTest) postStatus(postID int64) string {
+	var status string
+	s.API.GetDB().DB.Get(&status, `SELECT p.status FROM posts AS p WHERE p.id = $1`, postID)
+	return status
+}
+
+func (s FlagControllerTest) Test_HideByTrustedFlagsAndDisagree() {
+	post := model.NewPost(s.Auth.User.ID)
+	err := s.API.GetDB().Insert(new(model.Post), post, "id")
+	s.Nil(err)
+
+	flag := new(model.Flag)
+	flag.Reason = database.Spam
+
+	// flags of new users do not count towards hiding
+	UserAuth(s.Suite, "user")
+	response := s.JSON(Post, fmt.Sprintf("/api/v1/post/%d/flag", post.ID), flag)
+	s.Equal(response.Status, fasthttp.StatusCreated)
+	data, _ := response.Success.Data.(map[string]interface{})
+	s.Equal(data["trusted"], false)
+
+	response = s.JSON(Post, fmt.Sprintf("/api/v1/post/%d/flag", post.ID), flag)
+	s.Equal(response.Status, fasthttp.StatusUnprocessableEntity)
+
+	s.trustedUser()
+	response = s.JSON(Post, fmt.Sprintf("/api/v1/post/%d/flag", post.ID), flag)
+	s.Equal(response.Status, fasthttp.StatusCreated)
+	s.False(s.isDeleted(post.ID))
+
+	s.trustedUser()
+	flag.Reason = database.OffTopic
+	response = s.JSON(Post, fmt.Sprintf("/api/v1/post/%d/flag", post.ID), flag)
+	s.Equal(response.Status, fasthttp.StatusCreated)
+	s.True(s.isDeleted(post.ID))
+	flaggerID := s.Auth.User.ID
+
+	UserAuth(s.Suite, "moderator")
+
+	response = s.JSON(Get, "/api/v1/flag?type=post&order_field=count", nil)
+	s.Equal(response.Status, fasthttp.StatusOK)
+	var item map[string]interface{}
+	items, _ := response.Success.Data.([]interface{})
+	for _, i := range items {
+		if i.(map[string]interface{})["post_id"] == float64(post.ID) {
+			item = i.(map[string]interface{})
+		}
+	}
+	s.NotNil(item)
+	s.Equal(item["count"], float64(3))
+	s.Equal(item["trusted_count"], float64(2))
+	s.Equal(item["hidden"], true)
+
+	response = s.JSON(Get, fmt.Sprintf("/api/v1/flag/%.0f", item["id"]), nil)
+	s.Equal(response.Status, fasthttp.StatusOK)
+	s.Equal(response.Success.TotalCount, int64(3))
+
+	response = s.JSON(Post, fmt.Sprintf("/api/v1/flag/%.0f/resolve", item["id"]), model2.FlagResolveRequest{
+		Status: "disagreed",
+	})
+	s.Equal(response.Status, fasthttp.StatusOK)
+	s.False(s.isDeleted(post.ID))
+	s.Equal(tasks.GetFlagAccuracy(s.API.App, flaggerID), 1.0/3.0)
+
+	response = s.JSON(Post, fmt.Sprintf("/api/v1/flag/%.0f/resolve", item["id"]), model2.FlagResolveRequest{
+		Status: "agreed",
+	})
+	s.Equal(response.Status, fasthttp.StatusUnprocessableEntity)
+
+	UserAuth(s.Suite)
+
+	defaultLogger.LogInfo("Hide by trusted flags and disagree")
+}
+
+func (s FlagControllerTest) Test_AgreeWithCommentFlag() {
+	post := model.NewPost(s.Auth.User.ID)
+	err := s.API.GetDB().Insert(new(model.Post), post, "id")
+	s.Nil(err)
+	comment := model.NewPostComment(post.ID, s.Auth.User.ID)
+	err = s.API.GetDB().Insert(new(model.PostComment), comment, "id")
+	s.Nil(err)
+
+	UserAuth(s.Suite, "user")
+	flaggerID := s.Auth.User.ID
+
+	flag := new(model.Flag)
+	flag.Reason = database.Abusive
+	response := s.JSON(Post, fmt.Sprintf("/api/v1/post/%d/comment/%d/flag", post.ID, comment.ID), flag)
+	s.Equal(response.Status, fasthttp.StatusCreated)
+	data, _ := response.Success.Data.(map[string]interface{})
+
+	UserAuth(s.Suite, "moderator")
+
+	response = s.JSON(Post, fmt.Sprintf("/api/v1/flag/%.0f/resolve", data["id"]), model2.FlagResolveRequest{
+		Status: "agreed",
+	})
+	s.Equal(response.Status, fasthttp.StatusOK)
+	s.Equal(tasks.GetFlagAccuracy(s.API.App, flaggerID), 2.0/3.0)
+
+	var deletedByID int64
+	err = s.API.GetDB().DB.Get(&deletedByID, `SELECT c.deleted_by_id FROM post_comments AS c WHERE c.id = $1`,
+		comment.ID)
+	s.Nil(err)
+	s.Equal(deletedByID, s.Auth.User.ID)
+
+	UserAuth(s.Suite)
+
+	defaultLogger.LogInfo("Agree with comment flag")
+}
+
+func (s FlagControllerTest) Test_Should_403Err_FlagOwnPostAndListQueueWithUserRole() {
+	UserAuth(s.Suite, "user")
+
+	post := model.NewPost(s.Auth.User.ID)
+	err := s.API.GetDB().Insert(new(model.Post), post, "id")
+	s.Nil(err)
+
+	flag := new(model.Flag)
+	flag.Reason = database.Spam
+	response := s.JSON(Post, fmt.Sprintf("/api/v1/post/%d/flag", post.ID), flag)
+	s.Equal(response.Status, fasthttp.StatusForbidden)
+
+	response = s.JSON(Post, fmt.Sprintf("/api/v1/user/%d/flag", s.Auth.User.ID), flag)
+	s.Equal(response.Status, fasthttp.StatusForbidden)
+
+	response = s.JSON(Get, "/api/v1/flag", nil)
+	s.Equal(response.Status, fasthttp.StatusForbidden)
+
+	UserAuth(s.Suite)
+
+	defaultLogger.LogInfo("Should be 403 error flag own post and list queue with user role")
+}
+
+func (s FlagControllerTest) Test_Should_422Err_FlagUserWithInvalidReason() {
+	userID := s.Auth.User.ID
+	UserAuth(s.Suite, "user")
+
+	flag := new(model.Flag)
+	flag.Reason = "boring"
+	response := s.JSON(Post, fmt.Sprintf("/api/v1/user/%d/flag", userID), flag)
+	s.Equal(response.Status, fasthttp.StatusUnprocessableEntity)
+
+	flag.Reason = database.Abusive
+	response = s.JSON(Post, fmt.Sprintf("/api/v1/user/%d/flag", userID), flag)
+	s.Equal(response.Status, fasthttp.StatusCreated)
+
+	UserAuth(s.Suite)
+
+	defaultLogger.LogInfo("Should be 422 error flag user with invalid reason")
+}
+
+// trainSpam label a new post with the given content as spam or ham
+func (s FlagControllerTest) trainSpam(content string, spam bool) {
+	post := model.NewPost(s.Auth.User.ID)
+	err := s.API.GetDB().Insert(new(model.Post), post, "id")
+	s.Nil(err)
+	postDetail := model.NewPostDetail(post.ID, s.Auth.User.ID)
+	postDetail.Title = "Training post"
+	postDetail.Content = content
+	err = s.API.GetDB().Insert(new(model.PostDetail), postDetail, "id")
+	s.Nil(err)
+
+	flag := new(model.Flag)
+	flag.PostID.SetValid(post.ID)
+	s.Nil(tasks.TrainSpam(s.API.App, flag, spam, s.Auth.User.ID))
+	// labeling the same content again is learned once
+	s.Nil(tasks.TrainSpam(s.API.App, flag, spam, s.Auth.User.ID))
+}
+
+func (s FlagControllerTest) Test_HoldSpamPostAndPublishOnDisagree() {
+	s.trainSpam("buy cheap replica watches now at https://cheap-watches.biz", true)
+	s.trainSpam("cheap pills, buy now with discount https://cheap-watches.biz", true)
+	s.trainSpam("how do I cancel a goroutine blocked on a channel receive", false)
+
+	UserAuth(s.Suite, "user")
+
+	postDep := new(model.PostDEP)
+	postDep.Title.SetValid("Cheap watches")
+	postDep.Content.SetValid("buy cheap watches now https://cheap-watches.biz")
+	response := s.JSON(Post, "/api/v1/post", postDep)
+	s.Equal(response.Status, fasthttp.StatusCreated)
+	data, _ := response.Success.Data.(map[string]interface{})
+	s.Equal(data["held_for_review"], true)
+	postID := int64(data["id"].(float64))
+	s.True(s.isDeleted(postID))
+	s.Equal(s.postStatus(postID), "scheduled")
+
+	postDep.Title.SetValid("Goroutine cancellation")
+	postDep.Content.SetValid("how do I cancel a goroutine blocked on a channel")
+	response = s.JSON(Post, "/api/v1/post", postDep)
+	s.Equal(response.Status, fasthttp.StatusCreated)
+	data, _ = response.Success.Data.(map[string]interface{})
+	s.Nil(data["held_for_review"])
+
+	UserAuth(s.Suite, "moderator")
+
+	var flagID int64
+	err := s.API.GetDB().DB.Get(&flagID, `SELECT f.id FROM flags AS f WHERE f.post_id = $1 AND f.user_id IS NULL`,
+		postID)
+	s.Nil(err)
+
+	response = s.JSON(Post, fmt.Sprintf("/api/v1/flag/%d/resolve", flagID), model2.FlagResolveRequest{
+		Status: "disagreed",
+	})
+	s.Equal(response.Status, fasthttp.StatusOK)
+	s.False(s.isDeleted(postID))
+	s.Equal(s.postStatus(postID), "published")
+
+	var slugs int64
+	err = s.API.GetDB().DB.Get(&slugs, `SELECT count(ps.id) FROM post_slugs AS ps WHERE ps.post_id = $1`, postID)
+	s.Nil(err)
+	s.Equal(slugs, int64(1))
+
+	var label string
+	err = s.API.GetDB().DB.Get(&label, `SELECT d.label FROM spam_documents AS d WHERE d.post_id = $1`, postID)
+	s.Nil(err)
+	s.Equal(label, "ham")
+
+	UserAuth(s.Suite)
+
+	defaultLogger.LogInfo("Hold spam post and publish on disagree")
+}
+
+func (s FlagControllerTest) TearDownSuite() {
+	TearDownSuite(s.Suite)
+}
+
+func Test_FlagController(t *testing.T) {
+	s := FlagControllerTest{NewSuite()}
+	Run(t, s)
+}
@@ -0,0 +1,98 @@
+// Copyright 2019 StreetByters Community
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with
+// this work for additional information regarding copyright ownership.
+// The ASF licenses this file to You under the Apache License, Version 2.0
+// (the "License"); you may not use this file except in compliance with
+// the License.  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"fmt"
+	"forgolang_forum/database"
+	"forgolang_forum/database/model"
+	"github.com/fate-lovely/phi"
+	"github.com/valyala/fasthttp"
+)
+
+// FlagPolicy flag authorization
+type FlagPolicy struct {
+	Policy
+	*API
+}
+
+// Index method for flag api authorization, the moderation queue is listed
+// by moderators
+func (p FlagPolicy) Index(next phi.HandlerFunc) phi.HandlerFunc {
+	return p.API.Authorization.Apply(next, "FlagController", "Index",
+		func(ctx *fasthttp.RequestCtx) bool {
+			return true
+		})
+}
+
+// Show method for flag api authorization
+func (p FlagPolicy) Show(next phi.HandlerFunc) phi.HandlerFunc {
+	return p.API.Authorization.Apply(next, "FlagController", "Show",
+		func(ctx *fasthttp.RequestCtx) bool {
+			return true
+		})
+}
+
+// Create method for flag api authorization, users do not flag themselves
+// or their own content
+func (p FlagPolicy) Create(next phi.HandlerFunc) phi.HandlerFunc {
+	return p.API.Authorization.Apply(next, "FlagController", "Create",
+		func(ctx *fasthttp.RequestCtx) bool {
+			return p.GetTarget(ctx).TargetUserID != p.GetAuthContext(ctx).ID
+		})
+}
+
+// Resolve method for flag api authorization
+func (p FlagPolicy) Resolve(next phi.HandlerFunc) phi.HandlerFunc {
+	return p.API.Authorization.Apply(next, "FlagController", "Resolve",
+		func(ctx *fasthttp.RequestCtx) bool {
+			return true
+		})
+}
+
+// GetTarget flag structure of the post, the comment or the user of the
+// route. The target user is the author of flagged content, it is zero when
+// the target does not exist or content is not published.
+func (p FlagPolicy) GetTarget(ctx *fasthttp.RequestCtx) *model.Flag {
+	flag := new(model.Flag)
+
+	if commentID := phi.URLParam(ctx, "commentID"); commentID != "" {
+		var postComment model.PostComment
+		p.GetDB().DB.Get(flag, fmt.Sprintf(`
+			SELECT c.user_id as target_user_id, c.post_id, c.id as comment_id FROM %s AS c
+			WHERE c.post_id::text = $1::text AND c.id::text = $2::text AND c.deleted_at IS NULL
+		`, postComment.TableName()),
+			phi.URLParam(ctx, "postID"),
+			commentID)
+	} else if postID := phi.URLParam(ctx, "postID"); postID != "" {
+		var post model.Post
+		p.GetDB().DB.Get(flag, fmt.Sprintf(`
+			SELECT p.author_id as target_user_id, p.id as post_id FROM %s AS p
+			WHERE p.id::text = $1::text AND p.status = $2 AND p.deleted_at IS NULL
+		`, post.TableName()),
+			postID,
+			database.Published)
+	} else {
+		var user model.User
+		p.GetDB().DB.Get(flag, fmt.Sprintf(`
+			SELECT u.id as target_user_id FROM %s AS u WHERE u.id::text = $1::text
+		`, user.TableName()),
+			phi.URLParam(ctx, "userID"))
+	}
+
+	return flag
+}
@@ -0,0 +1,158 @@
+// Copyright 2019 StreetByters Community
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with
+// this work for additional information regarding copyright ownership.
+// The ASF licenses this file to You under the Apache License, Version 2.0
+// (the "License"); you may not use this file except in compliance with
+// the License.  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"fmt"
+	"forgolang_forum/cmn"
+	"forgolang_forum/database"
+	"forgolang_forum/database/model"
+	model2 "forgolang_forum/model"
+	"forgolang_forum/tasks"
+	"github.com/fate-lovely/phi"
+	"github.com/valyala/fasthttp"
+	"gopkg.in/guregu/null.v3/zero"
+)
+
+// FollowController followed users, tags and categories of the current user
+// api controller
+type FollowController struct {
+	Controller
+	*API
+	Model model.Follow
+}
+
+// Index list follows of the current user
+func (c FollowController) Index(ctx *fasthttp.RequestCtx) {
+	paginate, _, _ := c.Paginate(ctx, "id", "inserted_at")
+
+	var follows []model.Follow
+	c.GetDB().QueryWithModel(fmt.Sprintf(`
+		SELECT f.* FROM %s AS f WHERE f.user_id = $1
+		ORDER BY f.%s %s
+		LIMIT $2 OFFSET $3
+	`, c.Model.TableName(), paginate.OrderField, paginate.OrderBy),
+		&follows,
+		c.GetAuthContext(ctx).ID,
+		paginate.Limit,
+		paginate.Offset)
+
+	var count int64
+	c.GetDB().DB.Get(&count, fmt.Sprintf(`
+		SELECT count(f.id) FROM %s AS f WHERE f.user_id = $1
+	`, c.Model.TableName()),
+		c.GetAuthContext(ctx).ID)
+
+	c.JSONResponse(ctx, model2.ResponseSuccess{
+		Data:       follows,
+		TotalCount: count,
+	}, fasthttp.StatusOK)
+}
+
+// Create follow a user, a tag or a category, following again returns the
+// existing follow
+func (c FollowController) Create(ctx *fasthttp.RequestCtx) {
+	follow := model.NewFollow(c.GetAuthContext(ctx).ID)
+	c.JSONBody(ctx, &follow)
+	follow.UserID = c.GetAuthContext(ctx).ID
+
+	errs, err := database.ValidateStruct(follow)
+	targets := 0
+	for _, target := range []zero.Int{follow.FollowedUserID, follow.TagID, follow.CategoryID} {
+		if target.Valid {
+			targets++
+		}
+	}
+	if errs == nil {
+		errs = make(map[string]string)
+	}
+	if targets != 1 {
+		errs["followed_user_id"] = "either user, tag or category is required"
+	} else if follow.FollowedUserID.Int64 == follow.UserID {
+		errs["followed_user_id"] = "can not be the current user"
+	}
+	if err != nil || len(errs) > 0 {
+		c.JSONResponse(ctx, model2.ResponseError{
+			Errors: errs,
+			Detail: fasthttp.StatusMessage(fasthttp.StatusUnprocessableEntity),
+		}, fasthttp.StatusUnprocessableEntity)
+		return
+	}
+
+	target := "(user_id, followed_user_id) WHERE followed_user_id IS NOT NULL"
+	if follow.TagID.Valid {
+		target = "(user_id, tag_id) WHERE tag_id IS NOT NULL"
+	} else if follow.CategoryID.Valid {
+		target = "(user_id, category_id) WHERE category_id IS NOT NULL"
+	}
+
+	err = c.GetDB().DB.Get(follow, fmt.Sprintf(`
+		INSERT INTO %s (user_id, followed_user_id, tag_id, category_id) VALUES ($1, $2, $3, $4)
+		ON CONFLICT %s DO UPDATE SET user_id = EXCLUDED.user_id
+		RETURNING *
+	`, c.Model.TableName(), target),
+		follow.UserID,
+		follow.FollowedUserID,
+		follow.TagID,
+		follow.CategoryID)
+	if errs, err := database.ValidateConstraint(err, follow); err != nil {
+		c.JSONResponse(ctx, model2.ResponseError{
+			Errors: errs,
+			Detail: fasthttp.StatusMessage(fasthttp.StatusUnprocessableEntity),
+		}, fasthttp.StatusUnprocessableEntity)
+		return
+	}
+
+	c.followsChanged(follow)
+
+	c.JSONResponse(ctx, model2.ResponseSuccessOne{
+		Data: follow,
+	}, fasthttp.StatusCreated)
+}
+
+// Delete unfollow a user, a tag or a category of the current user
+func (c FollowController) Delete(ctx *fasthttp.RequestCtx) {
+	var follow model.Follow
+	err := c.GetDB().DB.Get(&follow, fmt.Sprintf(`
+		DELETE FROM %s WHERE id::text = $1::text AND user_id = $2
+		RETURNING *
+	`, c.Model.TableName()),
+		phi.URLParam(ctx, "followID"),
+		c.GetAuthContext(ctx).ID)
+	if err != nil {
+		c.JSONResponse(ctx, model2.ResponseError{
+			Detail: fasthttp.StatusMessage(fasthttp.StatusNotFound),
+		}, fasthttp.StatusNotFound)
+		return
+	}
+
+	c.followsChanged(&follow)
+
+	c.JSONResponse(ctx, nil, fasthttp.StatusNoContent)
+}
+
+// followsChanged drop the precomputed feed of the user and cached users
+// whose follower counts changed
+func (c FollowController) followsChanged(follow *model.Follow) {
+	tasks.RemoveFeed(c.App, follow.UserID)
+
+	keys := []string{fmt.Sprintf("%s:%d", cmn.GetRedisKey("user", "one"), follow.UserID)}
+	if follow.FollowedUserID.Valid {
+		keys = append(keys, fmt.Sprintf("%s:%d", cmn.GetRedisKey("user", "one"), follow.FollowedUserID.Int64))
+	}
+	c.GetCache().Del(keys...)
+}
@@ -0,0 +1,95 @@
+package api
+
+import (
+	"fmt"
+	"forgolang_forum/database/model"
+	"github.com/valyala/fasthttp"
+	"testing"
+)
+
+type FollowControllerTest struct {
+	*Suite
+}
+
+func (s FollowControllerTest) SetupSuite() {
+	SetupSuite(s.Suite)
+	UserAuth(s.Suite)
+}
+
+func (s FollowControllerTest) Test_FollowAndUnfollowUser() {
+	pwd := "12345"
+	user := model.NewUser(&pwd)
+	user.Username = "test-followed-user"
+	user.Email = "test-followed-user@mail.com"
+	err := s.API.GetDB().Insert(new(model.User), user, "id")
+	s.Nil(err)
+
+	follow := model.NewFollow(s.Auth.User.ID)
+	follow.FollowedUserID.SetValid(user.ID)
+
+	response := s.JSON(Post, "/api/v1/follow", follow)
+	s.Equal(response.Status, fasthttp.StatusCreated)
+	data, _ := response.Success.Data.(map[string]interface{})
+	id := data["id"]
+
+	// following again returns the existing follow
+	response = s.JSON(Post, "/api/v1/follow", follow)
+	s.Equal(response.Status, fasthttp.StatusCreated)
+	data, _ = response.Success.Data.(map[string]interface{})
+	s.Equal(data["id"], id)
+
+	response = s.JSON(Get, fmt.Sprintf("/api/v1/user/%d", s.Auth.User.ID), nil)
+	s.Equal(response.Status, fasthttp.StatusOK)
+	data, _ = response.Success.Data.(map[string]interface{})
+	s.Equal(data["following_count"], float64(1))
+
+	response = s.JSON(Get, "/api/v1/follow", nil)
+	s.Equal(response.Status, fasthttp.StatusOK)
+	s.Equal(response.Success.TotalCount, int64(1))
+
+	response = s.JSON(Delete, fmt.Sprintf("/api/v1/follow/%v", id), nil)
+	s.Equal(response.Status, fasthttp.StatusNoContent)
+
+	response = s.JSON(Get, fmt.Sprintf("/api/v1/user/%d", s.Auth.User.ID), nil)
+	s.Equal(response.Status, fasthttp.StatusOK)
+	data, _ = response.Success.Data.(map[string]interface{})
+	s.Equal(data["following_count"], float64(0))
+
+	defaultLogger.LogInfo("Follow and unfollow user")
+}
+
+func (s FollowControllerTest) Test_Should_422Err_FollowWithoutTargetOrSelf() {
+	follow := model.NewFollow(s.Auth.User.ID)
+
+	response := s.JSON(Post, "/api/v1/follow", follow)
+	s.Equal(response.Status, fasthttp.StatusUnprocessableEntity)
+
+	follow.FollowedUserID.SetValid(s.Auth.User.ID)
+	response = s.JSON(Post, "/api/v1/follow", follow)
+	s.Equal(response.Status, fasthttp.StatusUnprocessableEntity)
+	data, _ := response.Error.Errors.(map[string]interface{})
+	s.Equal(data["followed_user_id"], "can not be the current user")
+
+	follow.FollowedUserID.SetValid(s.Auth.User.ID)
+	follow.TagID.SetValid(1)
+	response = s.JSON(Post, "/api/v1/follow", follow)
+	s.Equal(response.Status, fasthttp.StatusUnprocessableEntity)
+
+	defaultLogger.LogInfo("Should be 422 error follow without target or self")
+}
+
+func (s FollowControllerTest) Test_Should_404Err_DeleteFollowIfNotExists() {
+	response := s.JSON(Delete, "/api/v1/follow/999999999", nil)
+	s.Equal(response.Status, fasthttp.StatusNotFound)
+
+	defaultLogger.LogInfo("Should be 404 error delete follow if does not exists")
+}
+
+func (s FollowControllerTest) TearDownSuite() {
+	TearDownSuite(s.Suite)
+}
+
+func Test_FollowController(t *testing.T) {
+	s := FollowControllerTest{NewSuite()}
+	Run(t, s)
+}
@@ -0,0 +1,51 @@
+// Copyright 2019 StreetByters Community
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with
+// this work for additional information regarding copyright ownership.
+// The ASF licenses this file to You under the Apache License, Version 2.0
+// (the "License"); you may not use this file except in compliance with
+// the License.  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"forgolang_forum/database"
+	"forgolang_forum/model"
+	"forgolang_forum/utils"
+	"github.com/valyala/fasthttp"
+)
+
+// GofmtController go source format api controller
+type GofmtController struct {
+	Controller
+	*API
+}
+
+// Create parse and format given go source for editor previews
+func (c GofmtController) Create(ctx *fasthttp.RequestCtx) {
+	var gofmtRequest model.GofmtRequest
+	c.JSONBody(ctx, &gofmtRequest)
+
+	if errs, err := database.ValidateStruct(gofmtRequest); err != nil {
+		c.JSONResponse(ctx, model.ResponseError{
+			Errors: errs,
+			Detail: fasthttp.StatusMessage(fasthttp.StatusUnprocessableEntity),
+		}, fasthttp.StatusUnprocessableEntity)
+		return
+	}
+
+	gofmtResponse := new(model.GofmtResponse)
+	gofmtResponse.Source, gofmtResponse.Warnings = utils.FormatGoCode(gofmtRequest.Source)
+
+	c.JSONResponse(ctx, model.ResponseSuccessOne{
+		Data: gofmtResponse,
+	}, fasthttp.StatusOK)
+}
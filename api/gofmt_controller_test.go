@@ -0,0 +1,62 @@
+package api
+
+import (
+	"forgolang_forum/model"
+	"github.com/valyala/fasthttp"
+	"testing"
+)
+
+type GofmtControllerTest struct {
+	*Suite
+}
+
+func (s GofmtControllerTest) SetupSuite() {
+	SetupSuite(s.Suite)
+}
+
+func (s GofmtControllerTest) Test_FormatGoSourceWithValidParams() {
+	gofmtRequest := new(model.GofmtRequest)
+	gofmtRequest.Source = "x:=1\nif x>0 {\nx++\n}"
+
+	response := s.JSON(Post, "/api/v1/tools/gofmt", gofmtRequest)
+
+	s.Equal(response.Status, fasthttp.StatusOK)
+	data, _ := response.Success.Data.(map[string]interface{})
+	s.Equal(data["source"], "x := 1\nif x > 0 {\n\tx++\n}\n")
+	s.Nil(data["warnings"])
+
+	defaultLogger.LogInfo("Format go source with valid params")
+}
+
+func (s GofmtControllerTest) Test_FormatGoSourceWithSyntaxError() {
+	gofmtRequest := new(model.GofmtRequest)
+	gofmtRequest.Source = "x := 1\nif x > {\n}"
+
+	response := s.JSON(Post, "/api/v1/tools/gofmt", gofmtRequest)
+
+	s.Equal(response.Status, fasthttp.StatusOK)
+	data, _ := response.Success.Data.(map[string]interface{})
+	s.Equal(data["source"], gofmtRequest.Source)
+	warnings, _ := data["warnings"].([]interface{})
+	s.Greater(len(warnings), 0)
+	s.Equal(warnings[0].(map[string]interface{})["line"], float64(2))
+
+	defaultLogger.LogInfo("Format go source with syntax error")
+}
+
+func (s GofmtControllerTest) Test_Should_422Err_FormatGoSourceWithInvalidParams() {
+	response := s.JSON(Post, "/api/v1/tools/gofmt", new(model.GofmtRequest))
+
+	s.Equal(response.Status, fasthttp.StatusUnprocessableEntity)
+
+	defaultLogger.LogInfo("Should be 422 error format go source with invalid params")
+}
+
+func (s GofmtControllerTest) TearDownSuite() {
+	TearDownSuite(s.Suite)
+}
+
+func Test_GofmtController(t *testing.T) {
+	s := GofmtControllerTest{NewSuite()}
+	Run(t, s)
+}
@@ -19,6 +19,7 @@ package api
 import (
 	"fmt"
 	"forgolang_forum/model"
+	"forgolang_forum/tasks"
 	"github.com/dgrijalva/jwt-go"
 	"github.com/fate-lovely/phi"
 	"github.com/valyala/fasthttp"
@@ -121,8 +122,45 @@ func (a JWTAuth) Verify(next phi.HandlerFunc) phi.HandlerFunc {
 			authContext.Role = claims["role"].(string)
 
 			ctx.SetUserValue("AuthContext", authContext)
+			tasks.RecordVisit(a.API.App, authContext.ID)
 
 			next(ctx)
 		}
 	}
-}
\ No newline at end of file
+}
+
+// Identify set auth context if the request has a valid token without
+// rejecting anonymous requests
+func (a JWTAuth) Identify(next phi.HandlerFunc) phi.HandlerFunc {
+	return func(ctx *fasthttp.RequestCtx) {
+		h := ctx.Request.Header.Peek("authorization")
+
+		if len(string(h)) >= 7 && strings.ToUpper(string(h)[0:6]) == "BEARER" {
+			if claims, status := a.Parse(string(h)[7:]); status == 1 {
+				authContext := new(model.AuthContext)
+				authContext.ID = int64(claims["id"].(float64))
+				authContext.RoleID = int64(claims["role_id"].(float64))
+				authContext.Role = claims["role"].(string)
+
+				ctx.SetUserValue("AuthContext", authContext)
+				tasks.RecordVisit(a.API.App, authContext.ID)
+			}
+		}
+
+		next(ctx)
+	}
+}
+
+// FromQuery take the token from the access_token query param when the
+// request has no authorization header, browsers can not set headers on
+// event streams
+func (a JWTAuth) FromQuery(next phi.HandlerFunc) phi.HandlerFunc {
+	return func(ctx *fasthttp.RequestCtx) {
+		token := ctx.QueryArgs().Peek("access_token")
+		if len(ctx.Request.Header.Peek("authorization")) == 0 && len(token) > 0 {
+			ctx.Request.Header.Set("authorization", "Bearer "+string(token))
+		}
+
+		next(ctx)
+	}
+}
@@ -0,0 +1,168 @@
+// Copyright 2019 StreetByters Community
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with
+// this work for additional information regarding copyright ownership.
+// The ASF licenses this file to You under the Apache License, Version 2.0
+// (the "License"); you may not use this file except in compliance with
+// the License.  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"fmt"
+	"forgolang_forum/cmn"
+	"forgolang_forum/database/model"
+	model2 "forgolang_forum/model"
+	"github.com/fate-lovely/phi"
+	"github.com/valyala/fasthttp"
+	"strconv"
+)
+
+// NotificationController notification inbox of the current user api
+// controller
+type NotificationController struct {
+	Controller
+	*API
+	Model model.Notification
+}
+
+// Index list notifications of the current user, latest activity first.
+// Only unread notifications are listed with the unread query param.
+func (c NotificationController) Index(ctx *fasthttp.RequestCtx) {
+	paginate, _, _ := c.Paginate(ctx, "id", "updated_at", "inserted_at")
+	if _, ok := c.ParseQuery(ctx)["order_field"]; !ok {
+		paginate.OrderField = "updated_at"
+	}
+
+	unreadClause := ""
+	if val, ok := c.ParseQuery(ctx)["unread"]; ok {
+		unread, err := strconv.ParseBool(val)
+		if err != nil {
+			c.JSONResponse(ctx, model2.ResponseError{
+				Errors: map[string]string{
+					"unread": "is not valid",
+				},
+				Detail: fasthttp.StatusMessage(fasthttp.StatusBadRequest),
+			}, fasthttp.StatusBadRequest)
+			return
+		}
+		if unread {
+			unreadClause = "AND n.read_at IS NULL"
+		}
+	}
+
+	var notifications []model.Notification
+	c.GetDB().QueryWithModel(fmt.Sprintf(`
+		%s %s
+		ORDER BY n.%s %s, n.id DESC
+		LIMIT $2 OFFSET $3
+	`, c.Model.Query(), unreadClause, paginate.OrderField, paginate.OrderBy),
+		&notifications,
+		c.GetAuthContext(ctx).ID,
+		paginate.Limit,
+		paginate.Offset)
+
+	var count int64
+	c.GetDB().DB.Get(&count, fmt.Sprintf(`
+		SELECT count(n.id) FROM %s AS n WHERE n.user_id = $1 %s
+	`, c.Model.TableName(), unreadClause),
+		c.GetAuthContext(ctx).ID)
+
+	c.JSONResponse(ctx, model2.ResponseSuccess{
+		Data:       notifications,
+		TotalCount: count,
+	}, fasthttp.StatusOK)
+}
+
+// Unread number of unread notifications of the current user
+func (c NotificationController) Unread(ctx *fasthttp.RequestCtx) {
+	c.JSONResponse(ctx, model2.ResponseSuccessOne{
+		Data: model2.UnreadCountResponse{
+			Count: c.UnreadCount(c.GetAuthContext(ctx).ID),
+		},
+	}, fasthttp.StatusOK)
+}
+
+// Read mark a notification of the current user as read
+func (c NotificationController) Read(ctx *fasthttp.RequestCtx) {
+	result, err := c.GetDB().DB.Exec(fmt.Sprintf(`
+		UPDATE %s SET read_at = (CURRENT_TIMESTAMP at time zone 'utc')
+		WHERE id::text = $1::text AND user_id = $2 AND read_at IS NULL
+	`, c.Model.TableName()),
+		phi.URLParam(ctx, "notificationID"),
+		c.GetAuthContext(ctx).ID)
+	if err != nil {
+		c.JSONResponse(ctx, model2.ResponseError{
+			Detail: fasthttp.StatusMessage(fasthttp.StatusInternalServerError),
+		}, fasthttp.StatusInternalServerError)
+		return
+	}
+
+	// notifications which are already read are left as they are
+	if n, _ := result.RowsAffected(); n > 0 {
+		key := c.unreadKey(c.GetAuthContext(ctx).ID)
+		if exists, _ := c.GetCache().Exists(key).Result(); exists > 0 {
+			c.GetCache().Decr(key)
+		}
+	}
+
+	var notification model.Notification
+	c.GetDB().QueryRowWithModel(fmt.Sprintf(`
+		%s AND n.id::text = $2::text
+	`, c.Model.Query()),
+		&notification,
+		c.GetAuthContext(ctx).ID,
+		phi.URLParam(ctx, "notificationID")).Force()
+
+	c.JSONResponse(ctx, model2.ResponseSuccessOne{
+		Data: notification,
+	}, fasthttp.StatusOK)
+}
+
+// ReadAll mark all notifications of the current user as read
+func (c NotificationController) ReadAll(ctx *fasthttp.RequestCtx) {
+	_, err := c.GetDB().DB.Exec(fmt.Sprintf(`
+		UPDATE %s SET read_at = (CURRENT_TIMESTAMP at time zone 'utc')
+		WHERE user_id = $1 AND read_at IS NULL
+	`, c.Model.TableName()),
+		c.GetAuthContext(ctx).ID)
+	if err != nil {
+		c.JSONResponse(ctx, model2.ResponseError{
+			Detail: fasthttp.StatusMessage(fasthttp.StatusInternalServerError),
+		}, fasthttp.StatusInternalServerError)
+		return
+	}
+
+	c.GetCache().Set(c.unreadKey(c.GetAuthContext(ctx).ID), 0, 0)
+
+	c.JSONResponse(ctx, nil, fasthttp.StatusNoContent)
+}
+
+// UnreadCount cached number of unread notifications of a user, the counter
+// is loaded from the database when it is not cached
+func (c NotificationController) UnreadCount(userID int64) int64 {
+	count, err := c.GetCache().Get(c.unreadKey(userID)).Int64()
+	if err == nil && count >= 0 {
+		return count
+	}
+
+	c.GetDB().DB.Get(&count, fmt.Sprintf(`
+		SELECT count(n.id) FROM %s AS n WHERE n.user_id = $1 AND n.read_at IS NULL
+	`, c.Model.TableName()),
+		userID)
+	c.GetCache().Set(c.unreadKey(userID), count, 0)
+
+	return count
+}
+
+func (c NotificationController) unreadKey(userID int64) string {
+	return fmt.Sprintf("%s:%d", cmn.GetRedisKey("notification", "unread"), userID)
+}
@@ -0,0 +1,161 @@
+package api
+
+import (
+	"fmt"
+	"forgolang_forum/database"
+	"forgolang_forum/database/model"
+	"forgolang_forum/tasks"
+	"github.com/valyala/fasthttp"
+	"gopkg.in/guregu/null.v3/zero"
+	"testing"
+)
+
+type NotificationControllerTest struct {
+	*Suite
+}
+
+func (s NotificationControllerTest) SetupSuite() {
+	SetupSuite(s.Suite)
+	UserAuth(s.Suite)
+}
+
+func (s NotificationControllerTest) newUser(username string) *model.User {
+	pwd := "12345"
+	user := model.NewUser(&pwd)
+	user.Username = username
+	user.Email = fmt.Sprintf("%s@mail.com", username)
+	err := s.API.GetDB().Insert(new(model.User), user, "id")
+	s.Nil(err)
+
+	return user
+}
+
+func (s NotificationControllerTest) Test_GroupNotificationsOfTheSameTarget() {
+	post := model.NewPost(s.Auth.User.ID)
+	err := s.API.GetDB().Insert(new(model.Post), post, "id")
+	s.Nil(err)
+
+	for _, username := range []string{"test-notify-voter-1", "test-notify-voter-2"} {
+		user := s.newUser(username)
+		notification := model.NewNotification(s.Auth.User.ID, database.VoteNotification, post.ID, zero.Int{})
+		notification.ActorID.SetValid(user.ID)
+		s.Nil(tasks.Notify(s.API.App, notification))
+		// the same actor is counted once
+		s.Nil(tasks.Notify(s.API.App, notification))
+	}
+
+	response := s.JSON(Get, "/api/v1/notification?unread=true", nil)
+
+	s.Equal(response.Status, fasthttp.StatusOK)
+	var found map[string]interface{}
+	for _, n := range response.Success.Data.([]interface{}) {
+		if n.(map[string]interface{})["post_id"] == float64(post.ID) {
+			found = n.(map[string]interface{})
+		}
+	}
+	s.NotNil(found)
+	s.Equal(found["type"], "vote")
+	s.Equal(found["actor_count"], float64(2))
+	s.Equal(found["actor_username"], "test-notify-voter-2")
+
+	defaultLogger.LogInfo("Group notifications of the same target")
+}
+
+func (s NotificationControllerTest) Test_ReadNotificationsAndUnreadCount() {
+	post := model.NewPost(s.Auth.User.ID)
+	err := s.API.GetDB().Insert(new(model.Post), post, "id")
+	s.Nil(err)
+
+	response := s.JSON(Post, "/api/v1/notification/read", nil)
+	s.Equal(response.Status, fasthttp.StatusNoContent)
+
+	user := s.newUser("test-notify-replier")
+	postComment := model.NewPostComment(post.ID, user.ID)
+	err = s.API.GetDB().Insert(new(model.PostComment), postComment, "id")
+	s.Nil(err)
+
+	reply := model.NewNotification(s.Auth.User.ID, database.ReplyNotification, post.ID, zero.Int{})
+	reply.ActorID.SetValid(user.ID)
+	s.Nil(tasks.Notify(s.API.App, reply))
+	answer := model.NewNotification(s.Auth.User.ID, database.AnswerNotification, post.ID,
+		zero.IntFrom(postComment.ID))
+	answer.ActorID.SetValid(user.ID)
+	s.Nil(tasks.Notify(s.API.App, answer))
+
+	response = s.JSON(Get, "/api/v1/notification/unread", nil)
+	s.Equal(response.Status, fasthttp.StatusOK)
+	data, _ := response.Success.Data.(map[string]interface{})
+	s.Equal(data["count"], float64(2))
+
+	response = s.JSON(Post, fmt.Sprintf("/api/v1/notification/%d/read", reply.ID), nil)
+	s.Equal(response.Status, fasthttp.StatusOK)
+	data, _ = response.Success.Data.(map[string]interface{})
+	s.NotNil(data["read_at"])
+
+	response = s.JSON(Get, "/api/v1/notification/unread", nil)
+	data, _ = response.Success.Data.(map[string]interface{})
+	s.Equal(data["count"], float64(1))
+
+	// a new reply after the notification is read starts a new group
+	s.Nil(tasks.Notify(s.API.App, reply))
+	response = s.JSON(Get, "/api/v1/notification/unread", nil)
+	data, _ = response.Success.Data.(map[string]interface{})
+	s.Equal(data["count"], float64(2))
+
+	response = s.JSON(Post, "/api/v1/notification/read", nil)
+	s.Equal(response.Status, fasthttp.StatusNoContent)
+
+	response = s.JSON(Get, "/api/v1/notification/unread", nil)
+	data, _ = response.Success.Data.(map[string]interface{})
+	s.Equal(data["count"], float64(0))
+
+	defaultLogger.LogInfo("Read notifications and unread count")
+}
+
+func (s NotificationControllerTest) Test_NotifyPostAuthorOfComments() {
+	user := s.newUser("test-notify-author")
+	post := model.NewPost(user.ID)
+	err := s.API.GetDB().Insert(new(model.Post), post, "id")
+	s.Nil(err)
+
+	postComment := new(model.PostComment)
+	response := s.JSON(Post, fmt.Sprintf("/api/v1/post/%d/comment", post.ID), postComment)
+	s.Equal(response.Status, fasthttp.StatusCreated)
+
+	var notification model.Notification
+	err = s.API.GetDB().DB.Get(&notification, fmt.Sprintf(`
+		%s AND n.post_id = $2
+	`, notification.Query()),
+		user.ID,
+		post.ID)
+	s.Nil(err)
+	s.Equal(notification.Type, database.ReplyNotification)
+	s.Equal(notification.ActorID.Int64, s.Auth.User.ID)
+
+	defaultLogger.LogInfo("Notify post author of comments")
+}
+
+func (s NotificationControllerTest) Test_Should_404Err_ReadNotificationOfOtherUser() {
+	user := s.newUser("test-notify-other")
+	post := model.NewPost(user.ID)
+	err := s.API.GetDB().Insert(new(model.Post), post, "id")
+	s.Nil(err)
+
+	notification := model.NewNotification(user.ID, database.VoteNotification, post.ID, zero.Int{})
+	notification.ActorID.SetValid(s.Auth.User.ID)
+	s.Nil(tasks.Notify(s.API.App, notification))
+
+	response := s.JSON(Post, fmt.Sprintf("/api/v1/notification/%d/read", notification.ID), nil)
+	s.Equal(response.Status, fasthttp.StatusNotFound)
+
+	defaultLogger.LogInfo("Should be 404 error read notification of other user")
+}
+
+func (s NotificationControllerTest) TearDownSuite() {
+	TearDownSuite(s.Suite)
+}
+
+func Test_NotificationController(t *testing.T) {
+	s := NotificationControllerTest{NewSuite()}
+	Run(t, s)
+}
@@ -0,0 +1,62 @@
+// Copyright 2019 StreetByters Community
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with
+// this work for additional information regarding copyright ownership.
+// The ASF licenses this file to You under the Apache License, Version 2.0
+// (the "License"); you may not use this file except in compliance with
+// the License.  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"github.com/fate-lovely/phi"
+	"github.com/valyala/fasthttp"
+)
+
+// NotificationPolicy notification authorization
+type NotificationPolicy struct {
+	Policy
+	*API
+}
+
+// Index method for notifications api authorization, notifications are
+// listed only for their user
+func (p NotificationPolicy) Index(next phi.HandlerFunc) phi.HandlerFunc {
+	return p.API.Authorization.Apply(next, "NotificationController", "Index",
+		func(ctx *fasthttp.RequestCtx) bool {
+			return true
+		})
+}
+
+// Unread method for notifications api authorization
+func (p NotificationPolicy) Unread(next phi.HandlerFunc) phi.HandlerFunc {
+	return p.API.Authorization.Apply(next, "NotificationController", "Unread",
+		func(ctx *fasthttp.RequestCtx) bool {
+			return true
+		})
+}
+
+// Read method for notifications api authorization, the controller updates
+// only notifications of the current user
+func (p NotificationPolicy) Read(next phi.HandlerFunc) phi.HandlerFunc {
+	return p.API.Authorization.Apply(next, "NotificationController", "Read",
+		func(ctx *fasthttp.RequestCtx) bool {
+			return true
+		})
+}
+
+// ReadAll method for notifications api authorization
+func (p NotificationPolicy) ReadAll(next phi.HandlerFunc) phi.HandlerFunc {
+	return p.API.Authorization.Apply(next, "NotificationController", "ReadAll",
+		func(ctx *fasthttp.RequestCtx) bool {
+			return true
+		})
+}
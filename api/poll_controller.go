@@ -0,0 +1,445 @@
+// Copyright 2019 StreetByters Community
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with
+// this work for additional information regarding copyright ownership.
+// The ASF licenses this file to You under the Apache License, Version 2.0
+// (the "License"); you may not use this file except in compliance with
+// the License.  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"forgolang_forum/cmn"
+	"forgolang_forum/database"
+	"forgolang_forum/database/model"
+	model2 "forgolang_forum/model"
+	"github.com/valyala/fasthttp"
+	"strconv"
+	"time"
+)
+
+// PollController post polls api controller
+type PollController struct {
+	Controller
+	*API
+	Model model.Poll
+}
+
+// Show poll of a post with its options, tallies and the vote of the
+// current user
+func (c PollController) Show(ctx *fasthttp.RequestCtx) {
+	poll := PollPolicy{API: c.API}.GetPoll(ctx)
+	if poll == nil || !c.IsPostVisible(ctx, poll.PostID) {
+		c.JSONResponse(ctx, model2.ResponseError{
+			Detail: fasthttp.StatusMessage(fasthttp.StatusNotFound),
+		}, fasthttp.StatusNotFound)
+		return
+	}
+
+	c.render(ctx, poll)
+
+	c.JSONResponse(ctx, model2.ResponseSuccessOne{
+		Data: poll,
+	}, fasthttp.StatusOK)
+}
+
+// Create attach a poll to a post, posts have one poll
+func (c PollController) Create(ctx *fasthttp.RequestCtx) {
+	postID := PostPolicy{API: c.API}.GetPost(ctx).ID
+	poll := model.NewPoll(postID)
+	c.JSONBody(ctx, &poll)
+	poll.ID = 0
+	poll.PostID = postID
+
+	if !c.save(ctx, poll) {
+		return
+	}
+
+	c.JSONResponse(ctx, model2.ResponseSuccessOne{
+		Data: poll,
+	}, fasthttp.StatusCreated)
+}
+
+// Update replace the question, the options and the settings of a poll
+// without votes
+func (c PollController) Update(ctx *fasthttp.RequestCtx) {
+	current := PollPolicy{API: c.API}.GetPoll(ctx)
+	poll := model.NewPoll(current.PostID)
+	c.JSONBody(ctx, &poll)
+	poll.ID = current.ID
+	poll.PostID = current.PostID
+
+	if !c.save(ctx, poll) {
+		return
+	}
+
+	c.JSONResponse(ctx, model2.ResponseSuccessOne{
+		Data: poll,
+	}, fasthttp.StatusOK)
+}
+
+// Delete remove the poll of a post with its votes
+func (c PollController) Delete(ctx *fasthttp.RequestCtx) {
+	poll := PollPolicy{API: c.API}.GetPoll(ctx)
+	c.GetDB().Delete(c.Model.TableName(), "id = $1", poll.ID).Force()
+	c.GetCache().Del(c.tallyKey(poll.ID))
+
+	c.JSONResponse(ctx, nil, fasthttp.StatusNoContent)
+}
+
+// Vote choose options of a poll, the previous vote of the current user is
+// replaced
+func (c PollController) Vote(ctx *fasthttp.RequestCtx) {
+	poll := PollPolicy{API: c.API}.GetPoll(ctx)
+
+	var request model2.PollVoteRequest
+	c.JSONBody(ctx, &request)
+
+	if errs, err := database.ValidateStruct(request); err != nil {
+		c.JSONResponse(ctx, model2.ResponseError{
+			Errors: errs,
+			Detail: fasthttp.StatusMessage(fasthttp.StatusUnprocessableEntity),
+		}, fasthttp.StatusUnprocessableEntity)
+		return
+	}
+
+	pollVote := model.NewPollVote(poll.ID, c.GetAuthContext(ctx).ID)
+	seen := make(map[int64]bool)
+	for _, id := range request.OptionIDs {
+		if !seen[id] {
+			seen[id] = true
+			pollVote.OptionIDs = append(pollVote.OptionIDs, id)
+		}
+	}
+
+	// the poll is share locked so an edit replacing its options waits for
+	// the vote, or the vote sees the replaced options
+	var pollOption model.PollOption
+	var err error
+	valid := true
+	db := c.GetDB().Transaction(func(tx *database.Tx) error {
+		if err = tx.DB.Error; err != nil {
+			return err
+		}
+
+		if _, err = tx.DB.Tx.Exec(fmt.Sprintf(`SELECT pl.id FROM %s AS pl WHERE pl.id = $1 FOR SHARE`,
+			c.Model.TableName()), poll.ID); err != nil {
+			return err
+		}
+
+		var count int
+		err = tx.DB.Tx.Get(&count, fmt.Sprintf(`
+			SELECT count(o.id) FROM %s AS o WHERE o.poll_id = $1 AND o.id = ANY($2)
+		`, pollOption.TableName()),
+			poll.ID,
+			pollVote.OptionIDs)
+		if err != nil {
+			return err
+		}
+		if count != len(pollVote.OptionIDs) || (!poll.Multiple && count != 1) {
+			valid = false
+			return errors.New("poll options are not valid")
+		}
+
+		err = tx.DB.Tx.Get(pollVote, fmt.Sprintf(`
+			INSERT INTO %s (poll_id, user_id, option_ids) VALUES ($1, $2, $3)
+			ON CONFLICT (poll_id, user_id) DO UPDATE SET
+				option_ids = EXCLUDED.option_ids,
+				updated_at = (CURRENT_TIMESTAMP at time zone 'utc')
+			RETURNING *
+		`, pollVote.TableName()),
+			pollVote.PollID,
+			pollVote.UserID,
+			pollVote.OptionIDs)
+
+		return err
+	})
+	if err == nil {
+		err = db.Error
+	}
+	if !valid {
+		c.JSONResponse(ctx, model2.ResponseError{
+			Errors: map[string]string{"option_ids": "is not valid"},
+			Detail: fasthttp.StatusMessage(fasthttp.StatusUnprocessableEntity),
+		}, fasthttp.StatusUnprocessableEntity)
+		return
+	}
+	if errs, err := database.ValidateConstraint(err, pollVote); err != nil {
+		c.JSONResponse(ctx, model2.ResponseError{
+			Errors: errs,
+			Detail: fasthttp.StatusMessage(fasthttp.StatusUnprocessableEntity),
+		}, fasthttp.StatusUnprocessableEntity)
+		return
+	}
+
+	// concurrent votes of a user replace each other, so the tally is loaded
+	// again from the committed votes instead of adjusted
+	c.GetCache().Del(c.tallyKey(poll.ID))
+
+	c.JSONResponse(ctx, model2.ResponseSuccessOne{
+		Data: pollVote,
+	}, fasthttp.StatusCreated)
+}
+
+// Unvote retract the vote of the current user from an open poll
+func (c PollController) Unvote(ctx *fasthttp.RequestCtx) {
+	poll := PollPolicy{API: c.API}.GetPoll(ctx)
+
+	var pollVote model.PollVote
+	var id int64
+	err := c.GetDB().DB.Get(&id, fmt.Sprintf(`
+		DELETE FROM %s WHERE poll_id = $1 AND user_id = $2 RETURNING id
+	`, pollVote.TableName()),
+		poll.ID,
+		c.GetAuthContext(ctx).ID)
+	if err == sql.ErrNoRows {
+		c.JSONResponse(ctx, model2.ResponseError{
+			Detail: fasthttp.StatusMessage(fasthttp.StatusNotFound),
+		}, fasthttp.StatusNotFound)
+		return
+	}
+
+	c.GetCache().Del(c.tallyKey(poll.ID))
+
+	c.JSONResponse(ctx, nil, fasthttp.StatusNoContent)
+}
+
+// save validate and write a new or an edited poll with its options, it
+// responds with the errors when the poll is not valid
+func (c PollController) save(ctx *fasthttp.RequestCtx, poll *model.Poll) bool {
+	poll.Question = c.App.TextPolicy.Sanitize(poll.Question)
+	for i := range poll.Options {
+		poll.Options[i].Body = c.App.TextPolicy.Sanitize(poll.Options[i].Body)
+		poll.Options[i].Position = int64(i)
+	}
+
+	errs, err := database.ValidateStruct(poll)
+	if err == nil && poll.ClosesAt.Valid {
+		poll.ClosesAt.SetValid(poll.ClosesAt.Time.UTC())
+		if !poll.ClosesAt.Time.After(time.Now().UTC()) {
+			errs = map[string]string{"closes_at": "must be in the future"}
+			err = fmt.Errorf("poll closes in the past")
+		}
+	}
+	if err != nil {
+		c.JSONResponse(ctx, model2.ResponseError{
+			Errors: errs,
+			Detail: fasthttp.StatusMessage(fasthttp.StatusUnprocessableEntity),
+		}, fasthttp.StatusUnprocessableEntity)
+		return false
+	}
+
+	var pollOption model.PollOption
+	var voted bool
+	db := c.GetDB().Transaction(func(tx *database.Tx) error {
+		if err = tx.DB.Error; err != nil {
+			return err
+		}
+
+		// options of a poll are replaced only while nobody voted, the poll is
+		// locked so a vote can not commit in between
+		if poll.ID != 0 {
+			_, err = tx.DB.Tx.Exec(fmt.Sprintf(`SELECT pl.id FROM %s AS pl WHERE pl.id = $1 FOR UPDATE`,
+				c.Model.TableName()), poll.ID)
+			if err != nil {
+				return err
+			}
+
+			var pollVote model.PollVote
+			err = tx.DB.Tx.Get(&voted, fmt.Sprintf(`
+				SELECT EXISTS (SELECT 1 FROM %s AS v WHERE v.poll_id = $1)
+			`, pollVote.TableName()),
+				poll.ID)
+			if err == nil && voted {
+				err = errors.New("poll has votes")
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		if poll.ID == 0 {
+			err = tx.DB.Tx.Get(poll, fmt.Sprintf(`
+				INSERT INTO %s (post_id, question, multiple, public_voters, hide_results, closes_at)
+				VALUES ($1, $2, $3, $4, $5, $6)
+				RETURNING *, false as closed
+			`, c.Model.TableName()),
+				poll.PostID,
+				poll.Question,
+				poll.Multiple,
+				poll.PublicVoters,
+				poll.HideResults,
+				poll.ClosesAt)
+		} else {
+			err = tx.DB.Tx.Get(poll, fmt.Sprintf(`
+				UPDATE %s SET
+					question = $2, multiple = $3, public_voters = $4, hide_results = $5, closes_at = $6,
+					updated_at = (CURRENT_TIMESTAMP at time zone 'utc')
+				WHERE id = $1
+				RETURNING *, false as closed
+			`, c.Model.TableName()),
+				poll.ID,
+				poll.Question,
+				poll.Multiple,
+				poll.PublicVoters,
+				poll.HideResults,
+				poll.ClosesAt)
+		}
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.DB.Tx.Exec(fmt.Sprintf(`DELETE FROM %s WHERE poll_id = $1`, pollOption.TableName()), poll.ID)
+		if err != nil {
+			return err
+		}
+		for i := range poll.Options {
+			poll.Options[i].PollID = poll.ID
+			err = tx.DB.Tx.Get(&poll.Options[i].ID, fmt.Sprintf(`
+				INSERT INTO %s (poll_id, body, position) VALUES ($1, $2, $3) RETURNING id
+			`, pollOption.TableName()),
+				poll.ID,
+				poll.Options[i].Body,
+				poll.Options[i].Position)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err == nil {
+		err = db.Error
+	}
+	if voted {
+		c.JSONResponse(ctx, model2.ResponseError{
+			Errors: map[string]string{"options": "can not be edited once the poll has votes"},
+			Detail: fasthttp.StatusMessage(fasthttp.StatusUnprocessableEntity),
+		}, fasthttp.StatusUnprocessableEntity)
+		return false
+	}
+	if errs, err := database.ValidateConstraint(err, poll); err != nil {
+		c.JSONResponse(ctx, model2.ResponseError{
+			Errors: errs,
+			Detail: fasthttp.StatusMessage(fasthttp.StatusUnprocessableEntity),
+		}, fasthttp.StatusUnprocessableEntity)
+		return false
+	}
+
+	c.GetCache().Del(c.tallyKey(poll.ID))
+	c.render(ctx, poll)
+
+	return true
+}
+
+// render fill options, tallies and the vote of the current user of a poll,
+// results of polls hiding them are shown to moderators only until the poll
+// closes and voters are listed only for public polls
+func (c PollController) render(ctx *fasthttp.RequestCtx, poll *model.Poll) {
+	poll.ResultsHidden = poll.HideResults && !poll.Closed && !c.IsModerator(ctx)
+
+	var pollOption model.PollOption
+	var pollVote model.PollVote
+	var user model.User
+	poll.Options = nil
+	c.GetDB().QueryWithModel(fmt.Sprintf(`
+		SELECT
+			o.*,
+			CASE WHEN $2 THEN ARRAY(
+				SELECT u.username FROM %s AS v INNER JOIN %s AS u ON v.user_id = u.id
+				WHERE v.poll_id = o.poll_id AND o.id = ANY(v.option_ids)
+				ORDER BY v.id
+			) END as voter_names
+		FROM %s AS o WHERE o.poll_id = $1
+		ORDER BY o.position ASC, o.id ASC
+	`, pollVote.TableName(), user.TableName(), pollOption.TableName()),
+		&poll.Options,
+		poll.ID,
+		poll.PublicVoters && !poll.ResultsHidden)
+
+	tally, voters := c.GetTally(poll.ID)
+	poll.Voters = voters
+	if !poll.ResultsHidden {
+		for i := range poll.Options {
+			poll.Options[i].Votes.SetValid(tally[poll.Options[i].ID])
+		}
+	}
+
+	if authContext := c.GetOptionalAuthContext(ctx); authContext != nil {
+		c.GetDB().DB.Get(&poll.Vote, fmt.Sprintf(`
+			SELECT v.option_ids FROM %s AS v WHERE v.poll_id = $1 AND v.user_id = $2
+		`, pollVote.TableName()),
+			poll.ID,
+			authContext.ID)
+	}
+}
+
+func (c PollController) tallyKey(pollID int64) string {
+	return fmt.Sprintf("%s:%d", cmn.GetRedisKey("poll", "tally"), pollID)
+}
+
+// GetTally cached vote counts of the options of a poll and the number of
+// its voters, tallies are cached for a minute
+func (c PollController) GetTally(pollID int64) (map[int64]int64, int64) {
+	tally := make(map[int64]int64)
+	key := c.tallyKey(pollID)
+
+	values, _ := c.GetCache().HGetAll(key).Result()
+	if len(values) == 0 {
+		var pollOption model.PollOption
+		var pollVote model.PollVote
+		var counts []struct {
+			ID    int64 `db:"id"`
+			Votes int64 `db:"votes"`
+		}
+		c.GetDB().DB.Select(&counts, fmt.Sprintf(`
+			SELECT o.id, count(v.id) as votes FROM %s AS o
+			LEFT OUTER JOIN %s AS v ON v.poll_id = o.poll_id AND o.id = ANY(v.option_ids)
+			WHERE o.poll_id = $1
+			GROUP BY o.id
+		`, pollOption.TableName(), pollVote.TableName()),
+			pollID)
+
+		var voters int64
+		c.GetDB().DB.Get(&voters, fmt.Sprintf(`
+			SELECT count(v.id) FROM %s AS v WHERE v.poll_id = $1
+		`, pollVote.TableName()),
+			pollID)
+
+		values = map[string]string{"voters": strconv.FormatInt(voters, 10)}
+		fields := map[string]interface{}{"voters": voters}
+		for _, count := range counts {
+			values[strconv.FormatInt(count.ID, 10)] = strconv.FormatInt(count.Votes, 10)
+			fields[strconv.FormatInt(count.ID, 10)] = count.Votes
+		}
+		// a tally loaded before a vote committed can be written after the
+		// vote invalidated it, the expiry bounds how long it is served
+		pipe := c.GetCache().TxPipeline()
+		pipe.HMSet(key, fields)
+		pipe.Expire(key, time.Minute)
+		pipe.Exec()
+	}
+
+	var voters int64
+	for field, value := range values {
+		n, _ := strconv.ParseInt(value, 10, 64)
+		if field == "voters" {
+			voters = n
+		} else if id, err := strconv.ParseInt(field, 10, 64); err == nil {
+			tally[id] = n
+		}
+	}
+
+	return tally, voters
+}
@@ -0,0 +1,211 @@
+package api
+
+import (
+	"fmt"
+	"forgolang_forum/database/model"
+	model2 "forgolang_forum/model"
+	"github.com/valyala/fasthttp"
+	"testing"
+)
+
+type PollControllerTest struct {
+	*Suite
+}
+
+func (s PollControllerTest) SetupSuite() {
+	SetupSuite(s.Suite)
+	UserAuth(s.Suite)
+}
+
+// post new post of the current user
+func (s PollControllerTest) post() *model.Post {
+	post := model.NewPost(s.Auth.User.ID)
+	err := s.API.GetDB().Insert(new(model.Post), post, "id")
+	s.Nil(err)
+
+	return post
+}
+
+// poll new poll request asking for the go version
+func (s PollControllerTest) poll() *model.Poll {
+	poll := model.NewPoll(0)
+	poll.Question = "Which Go version are you on?"
+	poll.Options = []model.PollOption{{Body: "1.13"}, {Body: "1.14"}, {Body: "tip"}}
+
+	return poll
+}
+
+// optionIDs identifiers of the options of a poll response
+func (s PollControllerTest) optionIDs(response *TestResponse) []int64 {
+	var ids []int64
+	data, _ := response.Success.Data.(map[string]interface{})
+	options, _ := data["options"].([]interface{})
+	for _, o := range options {
+		ids = append(ids, int64(o.(map[string]interface{})["id"].(float64)))
+	}
+	return ids
+}
+
+func (s PollControllerTest) Test_CreatePollAndVote() {
+	UserAuth(s.Suite, "user")
+	post := s.post()
+
+	poll := s.poll()
+	poll.PublicVoters = true
+	response := s.JSON(Post, fmt.Sprintf("/api/v1/post/%d/poll", post.ID), poll)
+	s.Equal(response.Status, fasthttp.StatusCreated)
+	optionIDs := s.optionIDs(response)
+	s.Len(optionIDs, 3)
+
+	response = s.JSON(Post, fmt.Sprintf("/api/v1/post/%d/poll", post.ID), poll)
+	s.Equal(response.Status, fasthttp.StatusUnprocessableEntity)
+
+	UserAuth(s.Suite, "user")
+	voter := s.Auth.User.Username
+
+	response = s.JSON(Post, fmt.Sprintf("/api/v1/post/%d/poll/vote", post.ID), model2.PollVoteRequest{
+		OptionIDs: optionIDs[:2],
+	})
+	s.Equal(response.Status, fasthttp.StatusUnprocessableEntity)
+
+	response = s.JSON(Post, fmt.Sprintf("/api/v1/post/%d/poll/vote", post.ID), model2.PollVoteRequest{
+		OptionIDs: []int64{optionIDs[0]},
+	})
+	s.Equal(response.Status, fasthttp.StatusCreated)
+
+	// the vote is replaced
+	response = s.JSON(Post, fmt.Sprintf("/api/v1/post/%d/poll/vote", post.ID), model2.PollVoteRequest{
+		OptionIDs: []int64{optionIDs[1]},
+	})
+	s.Equal(response.Status, fasthttp.StatusCreated)
+
+	response = s.JSON(Get, fmt.Sprintf("/api/v1/post/%d/poll", post.ID), nil)
+	s.Equal(response.Status, fasthttp.StatusOK)
+	data, _ := response.Success.Data.(map[string]interface{})
+	s.Equal(data["voters"], float64(1))
+	s.Equal(data["vote"], []interface{}{float64(optionIDs[1])})
+	options, _ := data["options"].([]interface{})
+	s.Equal(options[0].(map[string]interface{})["votes"], float64(0))
+	s.Equal(options[1].(map[string]interface{})["votes"], float64(1))
+	s.Equal(options[1].(map[string]interface{})["voters"], []interface{}{voter})
+
+	response = s.JSON(Delete, fmt.Sprintf("/api/v1/post/%d/poll/vote", post.ID), nil)
+	s.Equal(response.Status, fasthttp.StatusNoContent)
+
+	response = s.JSON(Get, fmt.Sprintf("/api/v1/post/%d/poll", post.ID), nil)
+	data, _ = response.Success.Data.(map[string]interface{})
+	s.Equal(data["voters"], float64(0))
+
+	UserAuth(s.Suite)
+
+	defaultLogger.LogInfo("Create poll and vote")
+}
+
+func (s PollControllerTest) Test_HideResultsUntilPollCloses() {
+	UserAuth(s.Suite, "user")
+	post := s.post()
+
+	poll := s.poll()
+	poll.Multiple = true
+	poll.HideResults = true
+	response := s.JSON(Post, fmt.Sprintf("/api/v1/post/%d/poll", post.ID), poll)
+	s.Equal(response.Status, fasthttp.StatusCreated)
+	optionIDs := s.optionIDs(response)
+
+	UserAuth(s.Suite, "user")
+
+	response = s.JSON(Post, fmt.Sprintf("/api/v1/post/%d/poll/vote", post.ID), model2.PollVoteRequest{
+		OptionIDs: optionIDs[:2],
+	})
+	s.Equal(response.Status, fasthttp.StatusCreated)
+
+	response = s.JSON(Get, fmt.Sprintf("/api/v1/post/%d/poll", post.ID), nil)
+	data, _ := response.Success.Data.(map[string]interface{})
+	s.Equal(data["results_hidden"], true)
+	options, _ := data["options"].([]interface{})
+	s.Nil(options[0].(map[string]interface{})["votes"])
+
+	// closing the poll reveals the results
+	_, err := s.API.GetDB().DB.Exec(`UPDATE polls SET closes_at = (CURRENT_TIMESTAMP at time zone 'utc')
+		WHERE post_id = $1`, post.ID)
+	s.Nil(err)
+
+	response = s.JSON(Get, fmt.Sprintf("/api/v1/post/%d/poll", post.ID), nil)
+	data, _ = response.Success.Data.(map[string]interface{})
+	s.Equal(data["closed"], true)
+	s.Equal(data["results_hidden"], false)
+	options, _ = data["options"].([]interface{})
+	s.Equal(options[0].(map[string]interface{})["votes"], float64(1))
+	s.Equal(options[1].(map[string]interface{})["votes"], float64(1))
+
+	response = s.JSON(Delete, fmt.Sprintf("/api/v1/post/%d/poll/vote", post.ID), nil)
+	s.Equal(response.Status, fasthttp.StatusForbidden)
+
+	UserAuth(s.Suite)
+
+	defaultLogger.LogInfo("Hide results until poll closes")
+}
+
+func (s PollControllerTest) Test_Should_403Err_EditPollHavingVotes() {
+	UserAuth(s.Suite, "user")
+	authorToken := s.Auth.Token
+	post := s.post()
+
+	poll := s.poll()
+	response := s.JSON(Post, fmt.Sprintf("/api/v1/post/%d/poll", post.ID), poll)
+	s.Equal(response.Status, fasthttp.StatusCreated)
+
+	poll.Question = "Which Go release are you on?"
+	response = s.JSON(Put, fmt.Sprintf("/api/v1/post/%d/poll", post.ID), poll)
+	s.Equal(response.Status, fasthttp.StatusOK)
+	data, _ := response.Success.Data.(map[string]interface{})
+	s.Equal(data["question"], poll.Question)
+	optionIDs := s.optionIDs(response)
+
+	UserAuth(s.Suite, "user")
+
+	response = s.JSON(Put, fmt.Sprintf("/api/v1/post/%d/poll", post.ID), poll)
+	s.Equal(response.Status, fasthttp.StatusForbidden)
+
+	response = s.JSON(Post, fmt.Sprintf("/api/v1/post/%d/poll/vote", post.ID), model2.PollVoteRequest{
+		OptionIDs: []int64{optionIDs[2]},
+	})
+	s.Equal(response.Status, fasthttp.StatusCreated)
+
+	s.Auth.Token = authorToken
+	response = s.JSON(Put, fmt.Sprintf("/api/v1/post/%d/poll", post.ID), poll)
+	s.Equal(response.Status, fasthttp.StatusForbidden)
+
+	// superadmins are not stopped by the policy but the options are kept
+	UserAuth(s.Suite)
+	response = s.JSON(Put, fmt.Sprintf("/api/v1/post/%d/poll", post.ID), poll)
+	s.Equal(response.Status, fasthttp.StatusUnprocessableEntity)
+
+	response = s.JSON(Get, fmt.Sprintf("/api/v1/post/%d/poll", post.ID), nil)
+	s.Equal(s.optionIDs(response), optionIDs)
+
+	defaultLogger.LogInfo("Should be 403 error edit poll having votes")
+}
+
+func (s PollControllerTest) Test_Should_422Err_CreatePollWithOneOption() {
+	UserAuth(s.Suite, "user")
+	post := s.post()
+
+	poll := s.poll()
+	poll.Options = poll.Options[:1]
+	response := s.JSON(Post, fmt.Sprintf("/api/v1/post/%d/poll", post.ID), poll)
+	s.Equal(response.Status, fasthttp.StatusUnprocessableEntity)
+
+	UserAuth(s.Suite)
+
+	defaultLogger.LogInfo("Should be 422 error create poll with one option")
+}
+
+func (s PollControllerTest) TearDownSuite() {
+	TearDownSuite(s.Suite)
+}
+
+func Test_PollController(t *testing.T) {
+	s := PollControllerTest{NewSuite()}
+	Run(t, s)
+}
@@ -0,0 +1,136 @@
+// Copyright 2019 StreetByters Community
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with
+// this work for additional information regarding copyright ownership.
+// The ASF licenses this file to You under the Apache License, Version 2.0
+// (the "License"); you may not use this file except in compliance with
+// the License.  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"fmt"
+	"forgolang_forum/database"
+	"forgolang_forum/database/model"
+	"github.com/fate-lovely/phi"
+	"github.com/valyala/fasthttp"
+)
+
+// PollPolicy post poll authorization, authors of posts and moderators
+// manage polls
+type PollPolicy struct {
+	Policy
+	*API
+}
+
+// Create method for polls api authorization, archived and removed posts
+// are read-only
+func (p PollPolicy) Create(next phi.HandlerFunc) phi.HandlerFunc {
+	return p.API.Authorization.Apply(next, "PollController", "Create",
+		func(ctx *fasthttp.RequestCtx) bool {
+			return p.IsEditable(ctx)
+		})
+}
+
+// Update method for polls api authorization, polls having votes can not be
+// edited. Saving checks the votes again under a lock of the poll.
+func (p PollPolicy) Update(next phi.HandlerFunc) phi.HandlerFunc {
+	return p.API.Authorization.Apply(next, "PollController", "Update",
+		func(ctx *fasthttp.RequestCtx) bool {
+			poll := p.GetPoll(ctx)
+			return poll != nil && p.IsEditable(ctx) && !p.HasVotes(poll.ID)
+		})
+}
+
+// Delete method for polls api authorization
+func (p PollPolicy) Delete(next phi.HandlerFunc) phi.HandlerFunc {
+	return p.API.Authorization.Apply(next, "PollController", "Delete",
+		func(ctx *fasthttp.RequestCtx) bool {
+			return p.GetPoll(ctx) != nil && p.IsEditable(ctx)
+		})
+}
+
+// Vote method for polls api authorization, closed polls and polls of
+// closed and archived posts do not accept votes
+func (p PollPolicy) Vote(next phi.HandlerFunc) phi.HandlerFunc {
+	return p.API.Authorization.Apply(next, "PollController", "Vote",
+		func(ctx *fasthttp.RequestCtx) bool {
+			return p.IsOpen(ctx)
+		})
+}
+
+// Unvote method for polls api authorization, votes of closed polls are
+// final
+func (p PollPolicy) Unvote(next phi.HandlerFunc) phi.HandlerFunc {
+	return p.API.Authorization.Apply(next, "PollController", "Unvote",
+		func(ctx *fasthttp.RequestCtx) bool {
+			return p.IsOpen(ctx)
+		})
+}
+
+// IsEditable post of the poll is not archived or removed and the current
+// user is its author or a moderator
+func (p PollPolicy) IsEditable(ctx *fasthttp.RequestCtx) bool {
+	pP := PostPolicy{API: p.API}
+	post := pP.GetPost(ctx)
+	if post.ID == 0 || post.DeletedAt.Valid || pP.GetState(ctx) == database.Archived {
+		return false
+	}
+
+	return p.IsModerator(ctx) || post.AuthorID == p.GetAuthContext(ctx).ID
+}
+
+// IsOpen poll of the post accepts votes
+func (p PollPolicy) IsOpen(ctx *fasthttp.RequestCtx) bool {
+	pP := PostPolicy{API: p.API}
+	poll := p.GetPoll(ctx)
+	if poll == nil || poll.Closed || !p.IsPostVisible(ctx, poll.PostID) {
+		return false
+	}
+
+	state := pP.GetState(ctx)
+	return state != database.Closed && state != database.Archived
+}
+
+// HasVotes anyone voted in the poll
+func (p PollPolicy) HasVotes(pollID int64) bool {
+	var pollVote model.PollVote
+	var exists bool
+	p.App.Database.DB.Get(&exists, fmt.Sprintf(`
+		SELECT EXISTS (SELECT 1 FROM %s AS v WHERE v.poll_id = $1)
+	`, pollVote.TableName()),
+		pollID)
+
+	return exists
+}
+
+// GetPoll poll of the post given with its identifier or slug, polls past
+// their close time are closed
+func (p PollPolicy) GetPoll(ctx *fasthttp.RequestCtx) *model.Poll {
+	post := PostPolicy{API: p.API}.GetPost(ctx)
+	if post.ID == 0 {
+		return nil
+	}
+
+	var poll model.Poll
+	err := p.App.Database.DB.Get(&poll, fmt.Sprintf(`
+		SELECT
+			pl.*,
+			pl.closes_at IS NOT NULL AND pl.closes_at <= (CURRENT_TIMESTAMP at time zone 'utc') as closed
+		FROM %s AS pl WHERE pl.post_id = $1
+	`, poll.TableName()),
+		post.ID)
+	if err != nil {
+		return nil
+	}
+
+	return &poll
+}
@@ -0,0 +1,161 @@
+// Copyright 2019 StreetByters Community
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with
+// this work for additional information regarding copyright ownership.
+// The ASF licenses this file to You under the Apache License, Version 2.0
+// (the "License"); you may not use this file except in compliance with
+// the License.  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"context"
+	"fmt"
+	"forgolang_forum/database"
+	"forgolang_forum/database/model"
+	model2 "forgolang_forum/model"
+	"forgolang_forum/tasks"
+	"github.com/fate-lovely/phi"
+	"github.com/valyala/fasthttp"
+	"gopkg.in/guregu/null.v3/zero"
+	"strconv"
+)
+
+// PostAnswerController accepted answers of question posts api controller
+type PostAnswerController struct {
+	Controller
+	*API
+	Model model.PostAnswer
+}
+
+// Create accept a top level comment as the answer of a post in a Q&A
+// category, a previously accepted answer is replaced
+func (c PostAnswerController) Create(ctx *fasthttp.RequestCtx) {
+	var comment model.PostComment
+	c.GetDB().QueryRowWithModel(fmt.Sprintf(`
+		SELECT c.* FROM %s AS c WHERE c.post_id::text = $1::text AND c.id::text = $2::text
+	`, comment.TableName()),
+		&comment,
+		phi.URLParam(ctx, "postID"),
+		phi.URLParam(ctx, "commentID")).Force()
+
+	if !c.IsQuestion(comment.PostID) || comment.ParentID.Valid {
+		c.JSONResponse(ctx, model2.ResponseError{
+			Errors: map[string]string{
+				"comment_id": "can not be accepted as answer",
+			},
+			Detail: fasthttp.StatusMessage(fasthttp.StatusUnprocessableEntity),
+		}, fasthttp.StatusUnprocessableEntity)
+		return
+	}
+
+	// the author of a replaced answer loses the points of the answer
+	var previousID zero.Int
+	c.GetDB().DB.Get(&previousID, fmt.Sprintf(`
+		SELECT a.comment_id FROM %s AS a WHERE a.post_id = $1
+	`, c.Model.TableName()),
+		comment.PostID)
+
+	var postAnswer model.PostAnswer
+	err := c.GetDB().DB.Get(&postAnswer, fmt.Sprintf(`
+		INSERT INTO %s (post_id, comment_id, source_user_id) VALUES ($1, $2, $3)
+		ON CONFLICT (post_id) DO UPDATE SET
+			comment_id = EXCLUDED.comment_id,
+			source_user_id = EXCLUDED.source_user_id,
+			inserted_at = (CURRENT_TIMESTAMP at time zone 'utc')
+		RETURNING *
+	`, c.Model.TableName()),
+		comment.PostID,
+		comment.ID,
+		c.GetAuthContext(ctx).ID)
+	if err != nil {
+		c.JSONResponse(ctx, model2.ResponseError{
+			Detail: fasthttp.StatusMessage(fasthttp.StatusUnprocessableEntity),
+		}, fasthttp.StatusUnprocessableEntity)
+		return
+	}
+
+	c.indexSolved(comment.PostID, true)
+
+	if previousID.Valid && previousID.Int64 != comment.ID {
+		tasks.SyncAnswerReputation(c.App, previousID.Int64, false)
+	}
+	tasks.SyncAnswerReputation(c.App, comment.ID, true)
+
+	notification := model.NewNotification(comment.UserID, database.AnswerNotification, comment.PostID,
+		zero.IntFrom(comment.ID))
+	notification.ActorID.SetValid(c.GetAuthContext(ctx).ID)
+	tasks.Notify(c.App, notification)
+
+	c.JSONResponse(ctx, model2.ResponseSuccessOne{
+		Data: postAnswer,
+	}, fasthttp.StatusCreated)
+}
+
+// Delete unaccept the accepted answer of a post
+func (c PostAnswerController) Delete(ctx *fasthttp.RequestCtx) {
+	var postAnswer model.PostAnswer
+	c.GetDB().QueryRowWithModel(fmt.Sprintf(`
+		SELECT a.* FROM %s AS a WHERE a.post_id::text = $1::text AND a.comment_id::text = $2::text
+	`, c.Model.TableName()),
+		&postAnswer,
+		phi.URLParam(ctx, "postID"),
+		phi.URLParam(ctx, "commentID")).Force()
+
+	c.GetDB().Delete(c.Model.TableName(), "id = $1", postAnswer.ID).Force()
+
+	c.indexSolved(postAnswer.PostID, false)
+	tasks.SyncAnswerReputation(c.App, postAnswer.CommentID, false)
+
+	c.JSONResponse(ctx, nil, fasthttp.StatusNoContent)
+}
+
+// IsQuestion post is assigned to a Q&A category
+func (c PostAnswerController) IsQuestion(postID int64) bool {
+	var postCategoryAssignment model.PostCategoryAssignment
+	var category model.Category
+	var question bool
+	c.GetDB().DB.Get(&question, fmt.Sprintf(`
+		SELECT EXISTS (
+			SELECT 1 FROM %s AS pca
+			INNER JOIN %s AS c ON pca.category_id = c.id
+			WHERE pca.post_id = $1 AND c.qa
+		)
+	`, postCategoryAssignment.TableName(), category.TableName()),
+		postID)
+
+	return question
+}
+
+// GetAnswer accepted answer of a post
+func (c PostAnswerController) GetAnswer(postID int64) *model.PostComment {
+	var comment model.PostComment
+	err := c.GetDB().DB.Get(&comment, fmt.Sprintf(`
+		%s AND c.id = (SELECT a.comment_id FROM %s AS a WHERE a.post_id = $1)
+	`, comment.Query(), c.Model.TableName()),
+		postID)
+	if err != nil {
+		return nil
+	}
+
+	return &comment
+}
+
+// indexSolved update solved status of the post search document
+func (c PostAnswerController) indexSolved(postID int64, solved bool) {
+	c.App.ElasticClient.Update().
+		Index("posts").
+		Id(strconv.FormatInt(postID, 10)).
+		Doc(map[string]interface{}{
+			"solved": solved,
+		}).
+		Do(context.TODO())
+}
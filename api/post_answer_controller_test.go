@@ -0,0 +1,163 @@
+package api
+
+import (
+	"fmt"
+	"forgolang_forum/database/model"
+	"github.com/gosimple/slug"
+	"github.com/valyala/fasthttp"
+	"testing"
+)
+
+type PostAnswerControllerTest struct {
+	*Suite
+}
+
+func (s PostAnswerControllerTest) SetupSuite() {
+	SetupSuite(s.Suite)
+	UserAuth(s.Suite)
+}
+
+func (s PostAnswerControllerTest) question(title string, qa bool) (*model.Category, *model.Post, []*model.PostComment) {
+	category := model.NewCategory()
+	category.Title = title
+	category.Slug = slug.Make(category.Title)
+	category.QA = qa
+	err := s.API.GetDB().Insert(new(model.Category), category, "id")
+	s.Nil(err)
+
+	post := model.NewPost(s.Auth.User.ID)
+	err = s.API.GetDB().Insert(new(model.Post), post, "id")
+	s.Nil(err)
+	postDetail := model.NewPostDetail(post.ID, s.Auth.User.ID)
+	postDetail.Title = "Question"
+	postDetail.Content = "Question Context"
+	err = s.API.GetDB().Insert(new(model.PostDetail), postDetail, "id")
+	s.Nil(err)
+	postCategoryAssignment := model.NewPostCategoryAssignment(post.ID, category.ID, s.Auth.User.ID)
+	err = s.API.GetDB().Insert(new(model.PostCategoryAssignment), postCategoryAssignment, "id")
+	s.Nil(err)
+
+	var comments []*model.PostComment
+	for i := 0; i < 2; i++ {
+		comment := model.NewPostComment(post.ID, s.Auth.User.ID)
+		err = s.API.GetDB().Insert(new(model.PostComment), comment, "id")
+		s.Nil(err)
+		comments = append(comments, comment)
+	}
+
+	return category, post, comments
+}
+
+func (s PostAnswerControllerTest) Test_AcceptAnswerAndPinItInCommentTree() {
+	category, post, comments := s.question("Questions 1", true)
+
+	response := s.JSON(Post, fmt.Sprintf("/api/v1/post/%d/comment/%d/accept", post.ID, comments[1].ID), nil)
+
+	s.Equal(response.Status, fasthttp.StatusCreated)
+	data, _ := response.Success.Data.(map[string]interface{})
+	s.Equal(data["comment_id"], float64(comments[1].ID))
+	s.Equal(data["source_user_id"], float64(s.Auth.User.ID))
+
+	response = s.JSON(Get, fmt.Sprintf("/api/v1/post/%d/comment/tree?order_by=asc", post.ID), nil)
+
+	s.Equal(response.Status, fasthttp.StatusOK)
+	tree, _ := response.Success.Data.([]interface{})
+	s.Equal(tree[0].(map[string]interface{})["id"], float64(comments[1].ID))
+	s.Equal(tree[0].(map[string]interface{})["accepted"], true)
+
+	response = s.JSON(Get, fmt.Sprintf("/api/v1/category/%d/post/%d", category.ID, post.ID), nil)
+
+	s.Equal(response.Status, fasthttp.StatusOK)
+	data, _ = response.Success.Data.(map[string]interface{})
+	s.Equal(data["solved"], true)
+	s.Equal(data["accepted_answer"].(map[string]interface{})["id"], float64(comments[1].ID))
+
+	response = s.JSON(Post, fmt.Sprintf("/api/v1/post/%d/comment/%d/accept", post.ID, comments[0].ID), nil)
+
+	s.Equal(response.Status, fasthttp.StatusCreated)
+
+	response = s.JSON(Delete, fmt.Sprintf("/api/v1/post/%d/comment/%d/accept", post.ID, comments[1].ID), nil)
+
+	s.Equal(response.Status, fasthttp.StatusNotFound)
+
+	response = s.JSON(Delete, fmt.Sprintf("/api/v1/post/%d/comment/%d/accept", post.ID, comments[0].ID), nil)
+
+	s.Equal(response.Status, fasthttp.StatusNoContent)
+
+	defaultLogger.LogInfo("Accept answer and pin it in comment tree")
+}
+
+func (s PostAnswerControllerTest) Test_ListPostsFilteredBySolvedStatus() {
+	category, post, comments := s.question("Questions 2", true)
+
+	unsolved := model.NewPost(s.Auth.User.ID)
+	err := s.API.GetDB().Insert(new(model.Post), unsolved, "id")
+	s.Nil(err)
+	postDetail := model.NewPostDetail(unsolved.ID, s.Auth.User.ID)
+	postDetail.Title = "Question"
+	postDetail.Content = "Question Context"
+	err = s.API.GetDB().Insert(new(model.PostDetail), postDetail, "id")
+	s.Nil(err)
+	postCategoryAssignment := model.NewPostCategoryAssignment(unsolved.ID, category.ID, s.Auth.User.ID)
+	err = s.API.GetDB().Insert(new(model.PostCategoryAssignment), postCategoryAssignment, "id")
+	s.Nil(err)
+
+	postAnswer := model.NewPostAnswer(post.ID, comments[0].ID)
+	err = s.API.GetDB().Insert(new(model.PostAnswer), postAnswer, "id", "inserted_at")
+	s.Nil(err)
+
+	response := s.JSON(Get, fmt.Sprintf("/api/v1/category/%d/post?solved=true", category.ID), nil)
+
+	s.Equal(response.Status, fasthttp.StatusOK)
+	s.Equal(response.Success.TotalCount, int64(1))
+	data, _ := response.Success.Data.([]interface{})
+	s.Equal(data[0].(map[string]interface{})["id"], float64(post.ID))
+	s.Equal(data[0].(map[string]interface{})["solved"], true)
+
+	response = s.JSON(Get, fmt.Sprintf("/api/v1/category/%d/post?solved=false", category.ID), nil)
+
+	s.Equal(response.Status, fasthttp.StatusOK)
+	s.Equal(response.Success.TotalCount, int64(1))
+	data, _ = response.Success.Data.([]interface{})
+	s.Equal(data[0].(map[string]interface{})["id"], float64(unsolved.ID))
+
+	response = s.JSON(Get, fmt.Sprintf("/api/v1/category/%d/post?solved=maybe", category.ID), nil)
+
+	s.Equal(response.Status, fasthttp.StatusBadRequest)
+
+	defaultLogger.LogInfo("List posts filtered by solved status")
+}
+
+func (s PostAnswerControllerTest) Test_Should_422Err_AcceptAnswerOutsideQACategory() {
+	_, post, comments := s.question("Discussions 1", false)
+
+	response := s.JSON(Post, fmt.Sprintf("/api/v1/post/%d/comment/%d/accept", post.ID, comments[0].ID), nil)
+
+	s.Equal(response.Status, fasthttp.StatusUnprocessableEntity)
+
+	defaultLogger.LogInfo("Should be 422 error accept answer outside Q&A category")
+}
+
+func (s PostAnswerControllerTest) Test_Should_422Err_AcceptReplyAsAnswer() {
+	_, post, comments := s.question("Questions 3", true)
+
+	reply := model.NewPostComment(post.ID, s.Auth.User.ID)
+	reply.ParentID.SetValid(comments[0].ID)
+	err := s.API.GetDB().Insert(new(model.PostComment), reply, "id")
+	s.Nil(err)
+
+	response := s.JSON(Post, fmt.Sprintf("/api/v1/post/%d/comment/%d/accept", post.ID, reply.ID), nil)
+
+	s.Equal(response.Status, fasthttp.StatusUnprocessableEntity)
+
+	defaultLogger.LogInfo("Should be 422 error accept reply as answer")
+}
+
+func (s PostAnswerControllerTest) TearDownSuite() {
+	TearDownSuite(s.Suite)
+}
+
+func Test_PostAnswerController(t *testing.T) {
+	s := PostAnswerControllerTest{NewSuite()}
+	Run(t, s)
+}
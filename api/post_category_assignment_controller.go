@@ -18,9 +18,12 @@ package api
 
 import (
 	"context"
+	"fmt"
+	"forgolang_forum/cmn"
 	"forgolang_forum/database"
 	model2 "forgolang_forum/database/model"
 	"forgolang_forum/model"
+	"forgolang_forum/tasks"
 	"github.com/fate-lovely/phi"
 	"github.com/olivere/elastic/v7"
 	"github.com/valyala/fasthttp"
@@ -80,6 +83,28 @@ func (c PostCategoryAssignmentController) Create(ctx *fasthttp.RequestCtx) {
 			})).
 		Do(context.TODO())
 
+	// posts are counted in categories once they are published and while
+	// they are not removed
+	var post model2.Post
+	var published bool
+	c.GetDB().DB.Get(&published, fmt.Sprintf(`
+		SELECT p.status = $2 AND p.deleted_at IS NULL FROM %s AS p WHERE p.id = $1
+	`, post.TableName()),
+		postID,
+		database.Published)
+	key := fmt.Sprintf("%s:%d", cmn.GetRedisKey("category", "posts"), postCategoryAssignment.CategoryID)
+	if n, _ := c.GetCache().Exists(key).Result(); published && n > 0 {
+		c.GetCache().Incr(key)
+	}
+	if published {
+		tasks.PublishEvent(c.App, model.CategoryTopic(postCategoryAssignment.CategoryID), "post",
+			map[string]interface{}{
+				"post_id":     postID,
+				"category_id": postCategoryAssignment.CategoryID,
+			})
+		go tasks.FanOutPost(c.App, postID)
+	}
+
 	c.JSONResponse(ctx, model.ResponseSuccessOne{
 		Data: postCategoryAssignment,
 	}, fasthttp.StatusCreated)
@@ -22,8 +22,11 @@ import (
 	"forgolang_forum/database"
 	"forgolang_forum/database/model"
 	model2 "forgolang_forum/model"
+	"forgolang_forum/tasks"
+	"forgolang_forum/utils"
 	"github.com/fate-lovely/phi"
 	"github.com/valyala/fasthttp"
+	"gopkg.in/guregu/null.v3/zero"
 	"strconv"
 )
 
@@ -34,44 +37,131 @@ type PostCommentController struct {
 	Model model.PostComment
 }
 
-// Index list all post comments
+// Index list all post comments, removed comments are listed as tombstones
+// to users other than moderators
 func (c PostCommentController) Index(ctx *fasthttp.RequestCtx) {
-	paginate, _, _ := c.Paginate(ctx, "id", "inserted_at")
+	postID, notExists := utils.ParseInt(phi.URLParam(ctx, "postID"), 10, 64)
+	if notExists || !c.IsPostVisible(ctx, postID) {
+		c.JSONResponse(ctx, model2.ResponseError{
+			Detail: fasthttp.StatusMessage(fasthttp.StatusNotFound),
+		}, fasthttp.StatusNotFound)
+		return
+	}
+
+	paginate, _, _ := c.Paginate(ctx, "id", "inserted_at", "score")
+	orderField := commentOrderField(paginate.OrderField)
 
 	var comments []model.PostComment
 	c.GetDB().QueryWithModel(fmt.Sprintf(`
-		SELECT * FROM %s AS c
-		WHERE c.post_id = $1
-		ORDER BY %s %s
+		%s
+		ORDER BY %s %s, c.id ASC
 		LIMIT $2 OFFSET $3
-	`, c.Model.TableName(), paginate.OrderField, paginate.OrderBy),
+	`, c.Model.Query(), orderField, paginate.OrderBy),
 		&comments,
-		phi.URLParam(ctx, "postID"),
+		postID,
 		paginate.Limit,
 		paginate.Offset)
 
 	var count int64
-	if count, _ = c.GetCache().Get(fmt.Sprintf("%s:%s",
+	if count, _ = c.GetCache().Get(fmt.Sprintf("%s:%d",
 		cmn.GetRedisKey("comment", "count"),
-		phi.URLParam(ctx, "postID"))).Int64(); count <= 0 {
+		postID)).Int64(); count <= 0 {
 		c.GetDB().DB.Get(&count, fmt.Sprintf(`
 			SELECT count(c.id) FROM %s AS c
-			WHERE c.post_id = $1
+			WHERE c.post_id = $1 AND c.deleted_at IS NULL
 		`, c.Model.TableName()),
-			phi.URLParam(ctx, "postID"))
+			postID)
 
-		c.GetCache().Set(fmt.Sprintf("%s:%s",
+		c.GetCache().Set(fmt.Sprintf("%s:%d",
 			cmn.GetRedisKey("comment", "count"),
-			phi.URLParam(ctx, "postID")),
+			postID),
 			count, 0)
 	}
 
+	for i := range comments {
+		c.render(ctx, &comments[i])
+	}
+
 	c.JSONResponse(ctx, model2.ResponseSuccess{
 		Data:       comments,
 		TotalCount: count,
 	}, fasthttp.StatusOK)
 }
 
+// Show post comment with its ancestors and position of its thread in the
+// comment tree, so clients can jump straight to a comment
+func (c PostCommentController) Show(ctx *fasthttp.RequestCtx) {
+	paginate, _, _ := c.Paginate(ctx, "id", "inserted_at", "score")
+
+	var comment model.PostComment
+	c.GetDB().QueryRowWithModel(fmt.Sprintf(`
+		%s AND c.id::text = $2::text
+	`, c.Model.Query()),
+		&comment,
+		phi.URLParam(ctx, "postID"),
+		phi.URLParam(ctx, "commentID")).Force()
+
+	if !c.IsPostVisible(ctx, comment.PostID) {
+		c.JSONResponse(ctx, model2.ResponseError{
+			Detail: fasthttp.StatusMessage(fasthttp.StatusNotFound),
+		}, fasthttp.StatusNotFound)
+		return
+	}
+
+	var ancestors []model.PostComment
+	c.GetDB().QueryWithModel(fmt.Sprintf(`
+		WITH RECURSIVE ancestors AS (
+			SELECT a.id, a.parent_id, 0 AS depth FROM %s AS a WHERE a.id = $2
+			UNION ALL
+			SELECT a.id, a.parent_id, an.depth + 1 FROM %s AS a
+			INNER JOIN ancestors AS an ON a.id = an.parent_id
+		)
+		SELECT q.* FROM (%s) AS q
+		INNER JOIN ancestors AS an ON q.id = an.id
+		WHERE an.depth > 0
+		ORDER BY an.depth DESC
+	`, c.Model.TableName(), c.Model.TableName(), c.Model.Query()),
+		&ancestors,
+		comment.PostID,
+		comment.ID)
+	if ancestors == nil {
+		ancestors = []model.PostComment{}
+	}
+
+	rootID := comment.ID
+	if len(ancestors) > 0 {
+		rootID = ancestors[0].ID
+	}
+
+	var position int64
+	c.GetDB().DB.Get(&position, fmt.Sprintf(`
+		SELECT t.position FROM (
+			SELECT q.id, row_number() OVER (ORDER BY q.accepted DESC, q.%s %s, q.id ASC) - 1 as position
+			FROM (%s AND c.parent_id IS NULL) AS q
+		) AS t
+		WHERE t.id = $2
+	`, commentOrderField(paginate.OrderField), paginate.OrderBy, c.Model.Query()),
+		comment.PostID,
+		rootID)
+
+	page := position / int64(paginate.Limit)
+
+	c.render(ctx, &comment)
+	for i := range ancestors {
+		c.render(ctx, &ancestors[i])
+	}
+
+	c.JSONResponse(ctx, model2.ResponseSuccessOne{
+		Data: model2.CommentPermalink{
+			Comment:   comment,
+			Ancestors: ancestors,
+			Position:  position,
+			Page:      page,
+			Offset:    page * int64(paginate.Limit),
+		},
+	}, fasthttp.StatusOK)
+}
+
 // Create post comment
 func (c PostCommentController) Create(ctx *fasthttp.RequestCtx) {
 	postID, err := strconv.ParseInt(phi.URLParam(ctx, "postID"), 10, 64)
@@ -105,15 +195,136 @@ func (c PostCommentController) Create(ctx *fasthttp.RequestCtx) {
 	}, fasthttp.StatusCreated)
 }
 
-// Delete post comment
+// Delete remove post comment, the comment is left as a tombstone so its
+// replies keep their place in the thread
 func (c PostCommentController) Delete(ctx *fasthttp.RequestCtx) {
-	c.GetDB().Delete(c.Model.TableName(), "post_id = $1 AND id = $2",
+	var request model2.DeleteRequest
+	c.JSONBody(ctx, &request)
+	if errs, err := database.ValidateStruct(request); err != nil {
+		c.JSONResponse(ctx, model2.ResponseError{
+			Errors: errs,
+			Detail: fasthttp.StatusMessage(fasthttp.StatusUnprocessableEntity),
+		}, fasthttp.StatusUnprocessableEntity)
+		return
+	}
+
+	result, err := c.GetDB().DB.Exec(fmt.Sprintf(`
+		UPDATE %s SET deleted_at = (CURRENT_TIMESTAMP at time zone 'utc'), deleted_by_id = $3, delete_reason = $4
+		WHERE post_id::text = $1::text AND id::text = $2::text AND deleted_at IS NULL
+	`, c.Model.TableName()),
 		phi.URLParam(ctx, "postID"),
-		phi.URLParam(ctx, "commentID")).Force()
+		phi.URLParam(ctx, "commentID"),
+		c.GetAuthContext(ctx).ID,
+		zero.StringFrom(request.Reason))
+	if err != nil {
+		c.JSONResponse(ctx, model2.ResponseError{
+			Detail: fasthttp.StatusMessage(fasthttp.StatusInternalServerError),
+		}, fasthttp.StatusInternalServerError)
+		return
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		c.JSONResponse(ctx, model2.ResponseError{
+			Detail: fasthttp.StatusMessage(fasthttp.StatusNotFound),
+		}, fasthttp.StatusNotFound)
+		return
+	}
 
 	c.GetCache().Decr(fmt.Sprintf("%s:%s",
 		cmn.GetRedisKey("comment", "count"),
 		phi.URLParam(ctx, "postID")))
 
+	var comment model.PostComment
+	c.GetDB().DB.Get(&comment, fmt.Sprintf(`
+		SELECT c.id, c.post_id, c.user_id FROM %s AS c WHERE c.id::text = $1::text
+	`, c.Model.TableName()),
+		phi.URLParam(ctx, "commentID"))
+	tasks.PublishEvent(c.App, model2.PostTopic(comment.PostID), "comment_removed", map[string]interface{}{
+		"post_id":    comment.PostID,
+		"comment_id": comment.ID,
+	})
+	if comment.UserID != c.GetAuthContext(ctx).ID {
+		notification := model.NewModerationNotification(comment.UserID, "removed", comment.PostID,
+			zero.IntFrom(comment.ID))
+		notification.ActorID.SetValid(c.GetAuthContext(ctx).ID)
+		tasks.Notify(c.App, notification)
+	}
+
+	// removed comments can not stay as the accepted answer
+	var postAnswer model.PostAnswer
+	result, err = c.GetDB().DB.Exec(fmt.Sprintf(`
+		DELETE FROM %s WHERE post_id::text = $1::text AND comment_id::text = $2::text
+	`, postAnswer.TableName()),
+		phi.URLParam(ctx, "postID"),
+		phi.URLParam(ctx, "commentID"))
+	if err != nil {
+		c.JSONResponse(ctx, model2.ResponseError{
+			Detail: fasthttp.StatusMessage(fasthttp.StatusInternalServerError),
+		}, fasthttp.StatusInternalServerError)
+		return
+	}
+	if n, _ := result.RowsAffected(); n > 0 {
+		postID, _ := strconv.ParseInt(phi.URLParam(ctx, "postID"), 10, 64)
+		PostAnswerController{API: c.API}.indexSolved(postID, false)
+	}
+
 	c.JSONResponse(ctx, nil, fasthttp.StatusNoContent)
 }
+
+// Restore bring back a removed post comment
+func (c PostCommentController) Restore(ctx *fasthttp.RequestCtx) {
+	result, err := c.GetDB().DB.Exec(fmt.Sprintf(`
+		UPDATE %s SET deleted_at = NULL, deleted_by_id = NULL, delete_reason = NULL
+		WHERE post_id::text = $1::text AND id::text = $2::text AND deleted_at IS NOT NULL
+	`, c.Model.TableName()),
+		phi.URLParam(ctx, "postID"),
+		phi.URLParam(ctx, "commentID"))
+	if err != nil {
+		c.JSONResponse(ctx, model2.ResponseError{
+			Detail: fasthttp.StatusMessage(fasthttp.StatusInternalServerError),
+		}, fasthttp.StatusInternalServerError)
+		return
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		c.JSONResponse(ctx, model2.ResponseError{
+			Detail: fasthttp.StatusMessage(fasthttp.StatusNotFound),
+		}, fasthttp.StatusNotFound)
+		return
+	}
+
+	c.GetCache().Incr(fmt.Sprintf("%s:%s",
+		cmn.GetRedisKey("comment", "count"),
+		phi.URLParam(ctx, "postID")))
+
+	var comment model.PostComment
+	c.GetDB().QueryRowWithModel(fmt.Sprintf(`
+		%s AND c.id::text = $2::text
+	`, c.Model.Query()),
+		&comment,
+		phi.URLParam(ctx, "postID"),
+		phi.URLParam(ctx, "commentID"))
+	c.render(ctx, &comment)
+
+	c.JSONResponse(ctx, model2.ResponseSuccessOne{
+		Data: comment,
+	}, fasthttp.StatusOK)
+}
+
+// render prepare a comment thread for the response, mentions are linked and
+// removed comments are left as tombstones to users other than moderators
+func (c PostCommentController) render(ctx *fasthttp.RequestCtx, comment *model.PostComment) {
+	if !c.IsModerator(ctx) {
+		comment.Tombstone()
+	}
+	comment.LinkMentions(c.App.Config.UIHost)
+}
+
+// commentOrderField order field of comment listings, best comments come
+// first by score since the Wilson rank keeps a comment with a few votes
+// from outranking a well established one
+func commentOrderField(orderField string) string {
+	if orderField == "score" {
+		return "rank"
+	}
+
+	return orderField
+}
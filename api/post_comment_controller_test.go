@@ -19,6 +19,7 @@ package api
 import (
 	"fmt"
 	"forgolang_forum/cmn"
+	"forgolang_forum/database"
 	"forgolang_forum/database/model"
 	"github.com/valyala/fasthttp"
 	"testing"
@@ -107,15 +108,99 @@ func (s PostCommentControllerTest) Test_CreatePostCommentWithValidParams() {
 	defaultLogger.LogInfo("Create post comment with valid params")
 }
 
-func (s PostCommentControllerTest) Test_CreatePostCommentWithValidParamsIfRelationalError() {
+func (s PostCommentControllerTest) Test_Should_403Err_CreatePostCommentIfPostNotExists() {
 	postComment := new(model.PostComment)
 
 	response := s.JSON(Post, "/api/v1/post/999999999/comment", postComment)
 
-	s.Equal(response.Status, fasthttp.StatusUnprocessableEntity)
+	s.Equal(response.Status, fasthttp.StatusForbidden)
 
-	defaultLogger.LogInfo("Should be 422 error create post comment with " +
-		"valid params if relational error")
+	defaultLogger.LogInfo("Should be 403 error create post comment if post not exists")
+}
+
+func (s PostCommentControllerTest) Test_Should_404Err_ListPostCommentsOfDraftAndRemovedPosts() {
+	draft := model.NewPost(s.Auth.User.ID)
+	draft.Status = database.Draft
+	err := s.API.GetDB().Insert(new(model.Post), draft, "id")
+	s.Nil(err)
+
+	removed := model.NewPost(s.Auth.User.ID)
+	err = s.API.GetDB().Insert(new(model.Post), removed, "id")
+	s.Nil(err)
+	_, err = s.API.GetDB().DB.Exec(`UPDATE posts SET deleted_at = now() WHERE id = $1`, removed.ID)
+	s.Nil(err)
+
+	for _, postID := range []int64{draft.ID, removed.ID} {
+		postComment := model.NewPostComment(postID, s.Auth.User.ID)
+		err = s.API.GetDB().Insert(new(model.PostComment), postComment, "id")
+		s.Nil(err)
+	}
+
+	response := s.JSON(Get, fmt.Sprintf("/api/v1/post/%d/comment", draft.ID), nil)
+	s.Equal(response.Status, fasthttp.StatusOK)
+
+	UserAuth(s.Suite, "user")
+
+	for _, postID := range []int64{draft.ID, removed.ID} {
+		response = s.JSON(Get, fmt.Sprintf("/api/v1/post/%d/comment", postID), nil)
+		s.Equal(response.Status, fasthttp.StatusNotFound)
+
+		response = s.JSON(Get, fmt.Sprintf("/api/v1/post/%d/comment/tree", postID), nil)
+		s.Equal(response.Status, fasthttp.StatusNotFound)
+	}
+
+	response = s.JSON(Post, fmt.Sprintf("/api/v1/post/%d/comment", draft.ID), new(model.PostComment))
+	s.Equal(response.Status, fasthttp.StatusForbidden)
+
+	UserAuth(s.Suite)
+
+	defaultLogger.LogInfo("Should be 404 error list post comments of draft and removed posts")
+}
+
+func (s PostCommentControllerTest) Test_ShowPostCommentWithAncestors() {
+	post := model.NewPost(s.Auth.User.ID)
+	err := s.API.GetDB().Insert(new(model.Post), post, "id")
+	s.Nil(err)
+
+	var roots []int64
+	for i := 0; i < 3; i++ {
+		postComment := model.NewPostComment(post.ID, s.Auth.User.ID)
+		err := s.API.GetDB().Insert(new(model.PostComment), postComment, "id")
+		s.Nil(err)
+		roots = append(roots, postComment.ID)
+	}
+
+	reply := model.NewPostComment(post.ID, s.Auth.User.ID)
+	reply.ParentID.SetValid(roots[0])
+	err = s.API.GetDB().Insert(new(model.PostComment), reply, "id")
+	s.Nil(err)
+
+	response := s.JSON(Get, fmt.Sprintf("/api/v1/post/%d/comment/%d?limit=2",
+		post.ID, reply.ID), nil)
+
+	s.Equal(response.Status, fasthttp.StatusOK)
+	data, _ := response.Success.Data.(map[string]interface{})
+	s.Equal(data["comment"].(map[string]interface{})["id"], float64(reply.ID))
+	ancestors := data["ancestors"].([]interface{})
+	s.Equal(len(ancestors), 1)
+	s.Equal(ancestors[0].(map[string]interface{})["id"], float64(roots[0]))
+	s.Equal(data["position"], float64(2))
+	s.Equal(data["page"], float64(1))
+	s.Equal(data["offset"], float64(2))
+
+	defaultLogger.LogInfo("Show post comment with ancestors")
+}
+
+func (s PostCommentControllerTest) Test_Should_404Err_ShowPostCommentIfNotExists() {
+	post := model.NewPost(s.Auth.User.ID)
+	err := s.API.GetDB().Insert(new(model.Post), post, "id")
+	s.Nil(err)
+
+	response := s.JSON(Get, fmt.Sprintf("/api/v1/post/%d/comment/999999999", post.ID), nil)
+
+	s.Equal(response.Status, fasthttp.StatusNotFound)
+
+	defaultLogger.LogInfo("Should be 404 error show post comment if does not exists")
 }
 
 func (s PostCommentControllerTest) Test_DeletePostCommentWithGivenIdentifier() {
@@ -140,10 +225,11 @@ func (s PostCommentControllerTest) Test_DeletePostCommentWithGivenIdentifier() {
 	var count int64
 	s.API.GetDB().DB.Get(&count, fmt.Sprintf(`
 		SELECT count(c.id) FROM %s AS c
-		WHERE c.id = $1
+		WHERE c.id = $1 AND c.deleted_at IS NOT NULL AND c.deleted_by_id = $2
 	`, postComment.TableName()),
-		postComment.ID)
-	s.Equal(count, int64(0))
+		postComment.ID,
+		s.Auth.User.ID)
+	s.Equal(count, int64(1))
 
 	count, _ = s.API.GetCache().Get(fmt.Sprintf("%s:%d",
 		cmn.GetRedisKey("comment", "count"),
@@ -153,6 +239,73 @@ func (s PostCommentControllerTest) Test_DeletePostCommentWithGivenIdentifier() {
 	defaultLogger.LogInfo("Delete post comment with given identifier")
 }
 
+func (s PostCommentControllerTest) Test_ListRemovedPostCommentsAsTombstonesAndRestore() {
+	post := model.NewPost(s.Auth.User.ID)
+	err := s.API.GetDB().Insert(new(model.Post), post, "id")
+	s.Nil(err)
+
+	postComment := model.NewPostComment(post.ID, s.Auth.User.ID)
+	err = s.API.GetDB().Insert(new(model.PostComment), postComment, "id")
+	s.Nil(err)
+	postCommentDetail := model.NewPostCommentDetail(post.ID, postComment.ID)
+	postCommentDetail.Comment = "Removed comment"
+	err = s.API.GetDB().Insert(new(model.PostCommentDetail), postCommentDetail, "id")
+	s.Nil(err)
+
+	reply := model.NewPostComment(post.ID, s.Auth.User.ID)
+	reply.ParentID.SetValid(postComment.ID)
+	err = s.API.GetDB().Insert(new(model.PostComment), reply, "id")
+	s.Nil(err)
+
+	response := s.JSON(Delete, fmt.Sprintf("/api/v1/post/%d/comment/%d",
+		post.ID, postComment.ID), map[string]string{
+		"reason": "Spam",
+	})
+
+	s.Equal(response.Status, fasthttp.StatusNoContent)
+
+	response = s.JSON(Get, fmt.Sprintf("/api/v1/post/%d/comment/tree", post.ID), nil)
+
+	s.Equal(response.Status, fasthttp.StatusOK)
+	tree, _ := response.Success.Data.([]interface{})
+	s.Equal(tree[0].(map[string]interface{})["comment"], "Removed comment")
+	s.Equal(tree[0].(map[string]interface{})["delete_reason"], "Spam")
+
+	UserAuth(s.Suite, "user")
+
+	response = s.JSON(Get, fmt.Sprintf("/api/v1/post/%d/comment/tree", post.ID), nil)
+
+	s.Equal(response.Status, fasthttp.StatusOK)
+	tree, _ = response.Success.Data.([]interface{})
+	s.Equal(tree[0].(map[string]interface{})["id"], float64(postComment.ID))
+	s.Equal(tree[0].(map[string]interface{})["comment"], "")
+	s.Equal(tree[0].(map[string]interface{})["removed_by"], "author")
+	replies := tree[0].(map[string]interface{})["replies"].([]interface{})
+	s.Equal(replies[0].(map[string]interface{})["id"], float64(reply.ID))
+
+	response = s.JSON(Post, fmt.Sprintf("/api/v1/post/%d/comment/%d/restore",
+		post.ID, postComment.ID), nil)
+
+	s.Equal(response.Status, fasthttp.StatusForbidden)
+
+	UserAuth(s.Suite)
+
+	response = s.JSON(Post, fmt.Sprintf("/api/v1/post/%d/comment/%d/restore",
+		post.ID, postComment.ID), nil)
+
+	s.Equal(response.Status, fasthttp.StatusOK)
+	data, _ := response.Success.Data.(map[string]interface{})
+	s.Equal(data["comment"], "Removed comment")
+	s.Equal(data["removed_by"], "")
+
+	response = s.JSON(Post, fmt.Sprintf("/api/v1/post/%d/comment/%d/restore",
+		post.ID, postComment.ID), nil)
+
+	s.Equal(response.Status, fasthttp.StatusNotFound)
+
+	defaultLogger.LogInfo("List removed post comments as tombstones and restore")
+}
+
 func (s PostCommentControllerTest) TearDownSuite() {
 	TearDownSuite(s.Suite)
 }
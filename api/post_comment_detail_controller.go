@@ -17,12 +17,16 @@
 package api
 
 import (
+	"fmt"
+	"forgolang_forum/cmn"
 	"forgolang_forum/database"
 	"forgolang_forum/database/model"
 	model2 "forgolang_forum/model"
+	"forgolang_forum/tasks"
 	"forgolang_forum/utils"
 	"github.com/fate-lovely/phi"
 	"github.com/valyala/fasthttp"
+	"gopkg.in/guregu/null.v3/zero"
 )
 
 // PostCommentDetailController post comment detail api controller
@@ -31,6 +35,76 @@ type PostCommentDetailController struct {
 	*API
 }
 
+// Index edit history of a post comment, each version carries the diff from
+// the previous one. The history is hidden with the post, and removed
+// comments are shown only as their tombstone to users other than moderators.
+func (c PostCommentDetailController) Index(ctx *fasthttp.RequestCtx) {
+	var e []bool
+	postID, notExists := utils.ParseInt(phi.URLParam(ctx, "postID"), 10, 64)
+	e = append(e, notExists)
+	commentID, notExists := utils.ParseInt(phi.URLParam(ctx, "commentID"), 10, 64)
+	e = append(e, notExists)
+
+	if exists, _ := utils.InArray(true, e); exists {
+		c.JSONResponse(ctx, model2.ResponseError{
+			Detail: fasthttp.StatusMessage(fasthttp.StatusBadRequest),
+		}, fasthttp.StatusBadRequest)
+		return
+	}
+
+	if !c.IsPostVisible(ctx, postID) {
+		c.JSONResponse(ctx, model2.ResponseError{
+			Detail: fasthttp.StatusMessage(fasthttp.StatusNotFound),
+		}, fasthttp.StatusNotFound)
+		return
+	}
+
+	var comment model.PostComment
+	c.GetDB().QueryRowWithModel(fmt.Sprintf(`
+		%s AND c.id = $2
+	`, comment.Query()),
+		&comment,
+		postID,
+		commentID).Force()
+
+	if comment.DeletedAt.Valid && !c.IsModerator(ctx) {
+		comment.Tombstone()
+		c.JSONResponse(ctx, model2.ResponseSuccessOne{
+			Data: comment,
+		}, fasthttp.StatusOK)
+		return
+	}
+
+	var commentDetail model.PostCommentDetail
+	var user model.User
+	var details []model.PostCommentDetail
+	c.GetDB().QueryWithModel(fmt.Sprintf(`
+		SELECT d.*, u.username as source_username FROM %s AS d
+		LEFT OUTER JOIN %s AS u ON d.source_user_id = u.id
+		WHERE d.post_id = $1 AND d.comment_id = $2
+		ORDER BY d.id ASC
+	`, commentDetail.TableName(), user.TableName()),
+		&details,
+		postID,
+		commentID)
+
+	if len(details) == 0 {
+		c.JSONResponse(ctx, model2.ResponseError{
+			Detail: fasthttp.StatusMessage(fasthttp.StatusNotFound),
+		}, fasthttp.StatusNotFound)
+		return
+	}
+
+	for i := 1; i < len(details); i++ {
+		details[i].Diff = utils.Diff(details[i-1].Comment, details[i].Comment)
+	}
+
+	c.JSONResponse(ctx, model2.ResponseSuccess{
+		Data:       details,
+		TotalCount: int64(len(details)),
+	}, fasthttp.StatusOK)
+}
+
 // Create post comment detail
 func (c PostCommentDetailController) Create(ctx *fasthttp.RequestCtx) {
 	var e []bool
@@ -50,6 +124,13 @@ func (c PostCommentDetailController) Create(ctx *fasthttp.RequestCtx) {
 	c.JSONBody(ctx, &commentDetail)
 	commentDetail.PostID = postID
 	commentDetail.CommentID = commentID
+	commentDetail.SourceUserID.SetValid(c.GetAuthContext(ctx).ID)
+
+	// edits on comments of other users are made by moderators
+	comment := PostCommentPolicy{API: c.API}.GetComment(ctx)
+	if comment.UserID != 0 {
+		commentDetail.Moderated = comment.UserID != c.GetAuthContext(ctx).ID
+	}
 
 	if errs, err := database.ValidateStruct(commentDetail); err != nil {
 		c.JSONResponse(ctx, model2.ResponseError{
@@ -59,10 +140,59 @@ func (c PostCommentDetailController) Create(ctx *fasthttp.RequestCtx) {
 		return
 	}
 
+	if c.HasTooManyLinks(ctx, commentDetail.Comment) {
+		c.JSONResponse(ctx, model2.ResponseError{
+			Errors: map[string]string{
+				"comment": "has too many links for your trust level",
+			},
+			Detail: fasthttp.StatusMessage(fasthttp.StatusUnprocessableEntity),
+		}, fasthttp.StatusUnprocessableEntity)
+		return
+	}
+
+	commentDetail.Comment, commentDetail.CodeWarnings = utils.CheckGoCode(commentDetail.Comment,
+		commentDetail.Gofmt)
 	commentDetail.Comment = c.App.TextPolicy.Sanitize(commentDetail.Comment)
 
-	err := c.GetDB().Insert(new(model.PostCommentDetail), commentDetail, "id", "inserted_at")
-	if errs, err := database.ValidateConstraint(err, commentDetail); err != nil {
+	// replies are announced with their first text, not when the bare
+	// comment is created
+	var first bool
+	c.GetDB().DB.Get(&first, fmt.Sprintf(`
+		SELECT NOT EXISTS (SELECT 1 FROM %s AS d WHERE d.comment_id = $1)
+	`, commentDetail.TableName()),
+		commentID)
+
+	// text scored as spam hides its comment in the same transaction and
+	// waits in the moderation queue
+	var score float64
+	if !c.IsModerator(ctx) {
+		score = tasks.ScoreSpam(c.App, commentDetail.Comment)
+	}
+	commentDetail.HeldForReview = score >= tasks.SpamThreshold
+
+	var err error
+	var hidden bool
+	errs := make(map[string]string)
+	db := c.GetDB().Transaction(func(tx *database.Tx) error {
+		if err = tx.DB.Error; err != nil {
+			return err
+		}
+
+		err = tx.DB.Insert(new(model.PostCommentDetail), commentDetail, "id", "inserted_at")
+		if errs, err = database.ValidateConstraint(err, commentDetail); err != nil {
+			return err
+		}
+
+		if commentDetail.HeldForReview {
+			hidden, err = tasks.HoldSpam(tx, comment.UserID, postID, zero.IntFrom(commentID), score)
+		}
+
+		return err
+	})
+	if err == nil {
+		err = db.Error
+	}
+	if err != nil {
 		c.JSONResponse(ctx, model2.ResponseError{
 			Errors: errs,
 			Detail: fasthttp.StatusMessage(fasthttp.StatusUnprocessableEntity),
@@ -70,6 +200,29 @@ func (c PostCommentDetailController) Create(ctx *fasthttp.RequestCtx) {
 		return
 	}
 
+	if commentDetail.HeldForReview {
+		if hidden {
+			c.GetCache().Decr(fmt.Sprintf("%s:%d",
+				cmn.GetRedisKey("comment", "count"),
+				postID))
+		}
+		tasks.Notify(c.App, model.NewModerationNotification(comment.UserID, "hidden", postID,
+			zero.IntFrom(commentID)))
+	} else {
+		tasks.SyncMentions(c.App, postID, zero.IntFrom(commentID), comment.UserID, c.GetAuthContext(ctx).ID,
+			commentDetail.Comment)
+		if first {
+			tasks.PublishReply(c.App, postID, commentID)
+		} else {
+			tasks.PublishEvent(c.App, model2.PostTopic(postID), "comment_edited", commentDetail)
+		}
+	}
+	if commentDetail.Moderated {
+		notification := model.NewModerationNotification(comment.UserID, "edited", postID, zero.IntFrom(commentID))
+		notification.ActorID.SetValid(c.GetAuthContext(ctx).ID)
+		tasks.Notify(c.App, notification)
+	}
+
 	c.JSONResponse(ctx, model2.ResponseSuccessOne{
 		Data: commentDetail,
 	}, fasthttp.StatusCreated)
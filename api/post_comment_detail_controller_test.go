@@ -2,6 +2,7 @@ package api
 
 import (
 	"fmt"
+	"forgolang_forum/database"
 	"forgolang_forum/database/model"
 	"github.com/valyala/fasthttp"
 	"testing"
@@ -104,6 +105,215 @@ func (s PostCommentDetailControllerTest) Test_Should_422Err_CreatePostCommentDet
 		"with valid params if relational error")
 }
 
+func (s PostCommentDetailControllerTest) Test_ListPostCommentHistoryWithDiffs() {
+	post := model.NewPost(s.Auth.User.ID)
+	err := s.API.GetDB().Insert(new(model.Post), post, "id")
+	s.Nil(err)
+
+	postComment := model.NewPostComment(post.ID, s.Auth.User.ID)
+	err = s.API.GetDB().Insert(new(model.PostComment), postComment, "id")
+	s.Nil(err)
+
+	commentDetail := model.NewPostCommentDetail(post.ID, postComment.ID)
+	commentDetail.SourceUserID.SetValid(s.Auth.User.ID)
+	commentDetail.Comment = "First line\nSecond line"
+	err = s.API.GetDB().Insert(new(model.PostCommentDetail), commentDetail, "id")
+	s.Nil(err)
+
+	commentDetail = new(model.PostCommentDetail)
+	commentDetail.Comment = "First line\nChanged line"
+	commentDetail.Reason.SetValid("Typo")
+
+	response := s.JSON(Post, fmt.Sprintf("/api/v1/post/%d/comment/%d/detail",
+		post.ID, postComment.ID), commentDetail)
+
+	s.Equal(response.Status, fasthttp.StatusCreated)
+	data, _ := response.Success.Data.(map[string]interface{})
+	s.Equal(data["source_user_id"], float64(s.Auth.User.ID))
+	s.Equal(data["reason"], "Typo")
+	s.Equal(data["moderated"], false)
+
+	response = s.JSON(Get, fmt.Sprintf("/api/v1/post/%d/comment/%d/history",
+		post.ID, postComment.ID), nil)
+
+	s.Equal(response.Status, fasthttp.StatusOK)
+	s.Equal(response.Success.TotalCount, int64(2))
+	versions, _ := response.Success.Data.([]interface{})
+	s.Nil(versions[0].(map[string]interface{})["diff"])
+	diff := versions[1].(map[string]interface{})["diff"].([]interface{})
+	s.Equal(len(diff), 3)
+	s.Equal(diff[1].(map[string]interface{})["op"], "delete")
+	s.Equal(diff[2].(map[string]interface{})["op"], "insert")
+	s.Equal(versions[1].(map[string]interface{})["source_username"], s.Auth.User.Username)
+
+	response = s.JSON(Get, fmt.Sprintf("/api/v1/post/%d/comment/%d",
+		post.ID, postComment.ID), nil)
+
+	s.Equal(response.Status, fasthttp.StatusOK)
+	data, _ = response.Success.Data.(map[string]interface{})
+	comment := data["comment"].(map[string]interface{})
+	s.Equal(comment["edited"], true)
+	s.NotNil(comment["edited_at"])
+	s.Equal(comment["edited_by_moderator"], false)
+
+	defaultLogger.LogInfo("List post comment history with diffs")
+}
+
+func (s PostCommentDetailControllerTest) Test_CreatePostCommentDetailOnOtherUserCommentAsModerated() {
+	post := model.NewPost(s.Auth.User.ID)
+	err := s.API.GetDB().Insert(new(model.Post), post, "id")
+	s.Nil(err)
+
+	pwd := "12345"
+	user := model.NewUser(&pwd)
+	user.Username = "test-comment-user-4"
+	user.Email = "test-comment-user-4@mail.com"
+	err = s.API.GetDB().Insert(new(model.User), user, "id")
+	s.Nil(err)
+
+	postComment := model.NewPostComment(post.ID, user.ID)
+	err = s.API.GetDB().Insert(new(model.PostComment), postComment, "id")
+	s.Nil(err)
+
+	commentDetail := new(model.PostCommentDetail)
+	commentDetail.Comment = "Moderated comment"
+	commentDetail.Reason.SetValid("Removed spam link")
+
+	response := s.JSON(Post, fmt.Sprintf("/api/v1/post/%d/comment/%d/detail",
+		post.ID, postComment.ID), commentDetail)
+
+	s.Equal(response.Status, fasthttp.StatusCreated)
+	data, _ := response.Success.Data.(map[string]interface{})
+	s.Equal(data["moderated"], true)
+
+	defaultLogger.LogInfo("Create post comment detail on other user comment as moderated")
+}
+
+func (s PostCommentDetailControllerTest) Test_Should_404Err_ListPostCommentHistoryIfNotExists() {
+	response := s.JSON(Get, "/api/v1/post/999999999/comment/999999999/history", nil)
+
+	s.Equal(response.Status, fasthttp.StatusNotFound)
+
+	defaultLogger.LogInfo("Should be 404 error list post comment history if does not exists")
+}
+
+func (s PostCommentDetailControllerTest) Test_ListRemovedPostCommentHistoryAsTombstone() {
+	post := model.NewPost(s.Auth.User.ID)
+	err := s.API.GetDB().Insert(new(model.Post), post, "id")
+	s.Nil(err)
+
+	postComment := model.NewPostComment(post.ID, s.Auth.User.ID)
+	err = s.API.GetDB().Insert(new(model.PostComment), postComment, "id")
+	s.Nil(err)
+	commentDetail := model.NewPostCommentDetail(post.ID, postComment.ID)
+	commentDetail.Comment = "Removed comment text"
+	err = s.API.GetDB().Insert(new(model.PostCommentDetail), commentDetail, "id", "inserted_at")
+	s.Nil(err)
+
+	_, err = s.API.GetDB().DB.Exec(`UPDATE post_comments SET deleted_at = (CURRENT_TIMESTAMP at time zone 'utc'),
+		deleted_by_id = user_id WHERE id = $1`, postComment.ID)
+	s.Nil(err)
+
+	UserAuth(s.Suite, "user")
+
+	response := s.JSON(Get, fmt.Sprintf("/api/v1/post/%d/comment/%d/history",
+		post.ID, postComment.ID), nil)
+	s.Equal(response.Status, fasthttp.StatusOK)
+	data, _ := response.Success.Data.(map[string]interface{})
+	s.Equal(data["id"], float64(postComment.ID))
+	s.Equal(data["removed_by"], "author")
+	s.Equal(data["comment"], "")
+
+	UserAuth(s.Suite)
+
+	response = s.JSON(Get, fmt.Sprintf("/api/v1/post/%d/comment/%d/history",
+		post.ID, postComment.ID), nil)
+	s.Equal(response.Status, fasthttp.StatusOK)
+	s.Equal(response.Success.TotalCount, int64(1))
+
+	defaultLogger.LogInfo("List removed post comment history as tombstone")
+}
+
+func (s PostCommentDetailControllerTest) Test_CreatePostCommentDetailWithMentions() {
+	post := model.NewPost(s.Auth.User.ID)
+	err := s.API.GetDB().Insert(new(model.Post), post, "id")
+	s.Nil(err)
+
+	pwd := "12345"
+	var users []*model.User
+	for i := 1; i <= 2; i++ {
+		user := model.NewUser(&pwd)
+		user.Username = fmt.Sprintf("mention-user-%d", i)
+		user.Email = fmt.Sprintf("mention-user-%d@mail.com", i)
+		err = s.API.GetDB().Insert(new(model.User), user, "id")
+		s.Nil(err)
+		users = append(users, user)
+	}
+
+	userState := model.NewUserState(users[1].ID)
+	userState.State = database.Banned
+	err = s.API.GetDB().Insert(new(model.UserState), userState, "id", "inserted_at")
+	s.Nil(err)
+
+	postComment := model.NewPostComment(post.ID, s.Auth.User.ID)
+	err = s.API.GetDB().Insert(new(model.PostComment), postComment, "id")
+	s.Nil(err)
+
+	mentions := func() (active, all int64) {
+		var mention model.Mention
+		s.API.GetDB().DB.Get(&all, fmt.Sprintf(`
+			SELECT count(m.id) FROM %s AS m WHERE m.comment_id = $1
+		`, mention.TableName()),
+			postComment.ID)
+		s.API.GetDB().DB.Get(&active, fmt.Sprintf(`
+			SELECT count(m.id) FROM %s AS m WHERE m.comment_id = $1 AND m.removed_at IS NULL
+		`, mention.TableName()),
+			postComment.ID)
+		return active, all
+	}
+
+	commentDetail := new(model.PostCommentDetail)
+	commentDetail.Comment = "Thanks @Mention-User-1, @mention-user-2 and @nobody"
+
+	response := s.JSON(Post, fmt.Sprintf("/api/v1/post/%d/comment/%d/detail",
+		post.ID, postComment.ID), commentDetail)
+
+	s.Equal(response.Status, fasthttp.StatusCreated)
+	active, all := mentions()
+	s.Equal(active, int64(1))
+	s.Equal(all, int64(1))
+
+	response = s.JSON(Get, fmt.Sprintf("/api/v1/post/%d/comment/%d", post.ID, postComment.ID), nil)
+
+	s.Equal(response.Status, fasthttp.StatusOK)
+	data, _ := response.Success.Data.(map[string]interface{})
+	comment := data["comment"].(map[string]interface{})
+	s.Equal(comment["mentions"], []interface{}{"mention-user-1"})
+	s.Contains(comment["comment"], fmt.Sprintf(`<a href="%s/user/Mention-User-1" class="mention">@Mention-User-1</a>`,
+		s.API.App.Config.UIHost))
+	s.Contains(comment["comment"], "@mention-user-2 and @nobody")
+
+	commentDetail.Comment = "Edited without mentions"
+	response = s.JSON(Post, fmt.Sprintf("/api/v1/post/%d/comment/%d/detail",
+		post.ID, postComment.ID), commentDetail)
+
+	s.Equal(response.Status, fasthttp.StatusCreated)
+	active, all = mentions()
+	s.Equal(active, int64(0))
+	s.Equal(all, int64(1))
+
+	commentDetail.Comment = "Edited back, thanks @mention-user-1"
+	response = s.JSON(Post, fmt.Sprintf("/api/v1/post/%d/comment/%d/detail",
+		post.ID, postComment.ID), commentDetail)
+
+	s.Equal(response.Status, fasthttp.StatusCreated)
+	active, all = mentions()
+	s.Equal(active, int64(1))
+	s.Equal(all, int64(1))
+
+	defaultLogger.LogInfo("Create post comment detail with mentions")
+}
+
 func (s PostCommentDetailControllerTest) TearDownSuite() {
 	TearDownSuite(s.Suite)
 }
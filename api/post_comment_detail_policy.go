@@ -17,6 +17,7 @@
 package api
 
 import (
+	"forgolang_forum/database"
 	"github.com/fate-lovely/phi"
 	"github.com/valyala/fasthttp"
 )
@@ -27,11 +28,19 @@ type PostCommentDetailPolicy struct {
 	*API
 }
 
-// Delete post comment authorization
+// Create post comment detail authorization, moderators can edit comments
+// of other users
 func (p PostCommentDetailPolicy) Create(next phi.HandlerFunc) phi.HandlerFunc {
+	pP := PostPolicy{API: p.API}
 	pcP := PostCommentPolicy{API: p.API}
 	return p.API.Authorization.Apply(next, "PostCommentDetailController", "Create",
 		func(ctx *fasthttp.RequestCtx) bool {
+			if pP.GetState(ctx) == database.Archived {
+				return false
+			}
+			if p.IsModerator(ctx) {
+				return true
+			}
 			if comment := pcP.GetComment(ctx); comment != nil && comment.UserID == p.GetAuthContext(ctx).ID {
 				return true
 			}
@@ -18,6 +18,7 @@ package api
 
 import (
 	"fmt"
+	"forgolang_forum/database"
 	"forgolang_forum/database/model"
 	"github.com/fate-lovely/phi"
 	"github.com/valyala/fasthttp"
@@ -29,18 +30,40 @@ type PostCommentPolicy struct {
 	*API
 }
 
-// Create post comment authorization
+// Create post comment authorization, only published posts accept comments so
+// drafts, scheduled and held posts stay private. Locked, closed and duplicate
+// posts accept comments only from moderators, archived and removed posts are
+// read-only
 func (p PostCommentPolicy) Create(next phi.HandlerFunc) phi.HandlerFunc {
+	pP := PostPolicy{API: p.API}
 	return p.API.Authorization.Apply(next, "PostCommentController", "Create",
 		func(ctx *fasthttp.RequestCtx) bool {
+			post := pP.GetPost(ctx)
+			if post.Status != database.Published || post.DeletedAt.Valid {
+				return false
+			}
+			switch pP.GetState(ctx) {
+			case database.Archived:
+				return false
+			case database.Locked, database.Closed, database.Duplicate:
+				return p.IsModerator(ctx)
+			}
 			return true
 		})
 }
 
-// Delete post comment authorization
+// Delete post comment authorization, moderators remove comments of other
+// users
 func (p PostCommentPolicy) Delete(next phi.HandlerFunc) phi.HandlerFunc {
+	pP := PostPolicy{API: p.API}
 	return p.API.Authorization.Apply(next, "PostCommentController", "Delete",
 		func(ctx *fasthttp.RequestCtx) bool {
+			if pP.GetState(ctx) == database.Archived {
+				return false
+			}
+			if p.IsModerator(ctx) {
+				return true
+			}
 			if comment := p.GetComment(ctx); comment != nil && comment.UserID == p.GetAuthContext(ctx).ID {
 				return true
 			}
@@ -48,6 +71,15 @@ func (p PostCommentPolicy) Delete(next phi.HandlerFunc) phi.HandlerFunc {
 		})
 }
 
+// Restore post comment authorization
+func (p PostCommentPolicy) Restore(next phi.HandlerFunc) phi.HandlerFunc {
+	pP := PostPolicy{API: p.API}
+	return p.API.Authorization.Apply(next, "PostCommentController", "Restore",
+		func(ctx *fasthttp.RequestCtx) bool {
+			return pP.GetState(ctx) != database.Archived
+		})
+}
+
 // GetComment get comment
 func (p PostCommentPolicy) GetComment(ctx *fasthttp.RequestCtx) *model.PostComment {
 	postComment := new(model.PostComment)
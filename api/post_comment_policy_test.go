@@ -2,6 +2,7 @@ package api
 
 import (
 	"fmt"
+	"forgolang_forum/database"
 	"forgolang_forum/database/model"
 	"github.com/valyala/fasthttp"
 	"testing"
@@ -117,6 +118,55 @@ func (s PostCommentPolicyTest) Test_Should_403Err_DeletePostCommentWithUserRoleI
 		"given identifier and user role if comment other user")
 }
 
+func (s PostCommentPolicyTest) Test_Should_403Err_CreatePostCommentWithUserRoleIfPostLocked() {
+	UserAuth(s.Suite, "user")
+
+	post := model.NewPost(s.Auth.User.ID)
+	err := s.API.GetDB().Insert(new(model.Post), post, "id")
+	s.Nil(err)
+
+	postState := model.NewPostState(post.ID)
+	postState.State = database.Locked
+	err = s.API.GetDB().Insert(new(model.PostState), postState, "id", "inserted_at")
+	s.Nil(err)
+
+	response := s.JSON(Post, fmt.Sprintf("/api/v1/post/%d/comment", post.ID),
+		new(model.PostComment))
+
+	s.Equal(response.Status, fasthttp.StatusForbidden)
+
+	UserAuth(s.Suite, "moderator")
+
+	response = s.JSON(Post, fmt.Sprintf("/api/v1/post/%d/comment", post.ID),
+		new(model.PostComment))
+
+	s.Equal(response.Status, fasthttp.StatusCreated)
+
+	defaultLogger.LogInfo("Should be 403 error create post comment with " +
+		"user role if post locked")
+}
+
+func (s PostCommentPolicyTest) Test_Should_403Err_CreatePostCommentWithModeratorRoleIfPostArchived() {
+	UserAuth(s.Suite, "moderator")
+
+	post := model.NewPost(s.Auth.User.ID)
+	err := s.API.GetDB().Insert(new(model.Post), post, "id")
+	s.Nil(err)
+
+	postState := model.NewPostState(post.ID)
+	postState.State = database.Archived
+	err = s.API.GetDB().Insert(new(model.PostState), postState, "id", "inserted_at")
+	s.Nil(err)
+
+	response := s.JSON(Post, fmt.Sprintf("/api/v1/post/%d/comment", post.ID),
+		new(model.PostComment))
+
+	s.Equal(response.Status, fasthttp.StatusForbidden)
+
+	defaultLogger.LogInfo("Should be 403 error create post comment with " +
+		"moderator role if post archived")
+}
+
 func (s PostCommentPolicyTest) TearDownSuite() {
 	TearDownSuite(s.Suite)
 }
@@ -0,0 +1,234 @@
+// Copyright 2019 StreetByters Community
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with
+// this work for additional information regarding copyright ownership.
+// The ASF licenses this file to You under the Apache License, Version 2.0
+// (the "License"); you may not use this file except in compliance with
+// the License.  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"forgolang_forum/database/model"
+	model2 "forgolang_forum/model"
+	"forgolang_forum/utils"
+	"github.com/fate-lovely/phi"
+	"github.com/lib/pq"
+	"github.com/valyala/fasthttp"
+	"strconv"
+	"strings"
+)
+
+const (
+	// commentTreeDepth default levels of a comment tree
+	commentTreeDepth = 3
+	// commentTreeMaxDepth maximum levels of a comment tree
+	commentTreeMaxDepth = 10
+	// commentTreeReplies default replies of a comment in a tree
+	commentTreeReplies = 5
+	// commentTreeMaxReplies maximum replies of a comment in a tree
+	commentTreeMaxReplies = 40
+	// commentTreeMaxNodes maximum comments of a tree in a single response
+	commentTreeMaxNodes = 500
+)
+
+// PostCommentTreeController threaded post comments api controller
+type PostCommentTreeController struct {
+	Controller
+	*API
+	Model model.PostComment
+}
+
+// Index list post comments as a tree with nested replies. Replies deeper
+// than the depth param or beyond the replies param of a comment are left
+// out with a cursor, the cursor param lists them as a new tree. Removed
+// comments are left in the tree as tombstones.
+func (c PostCommentTreeController) Index(ctx *fasthttp.RequestCtx) {
+	postID, notExists := utils.ParseInt(phi.URLParam(ctx, "postID"), 10, 64)
+	if notExists || !c.IsPostVisible(ctx, postID) {
+		c.JSONResponse(ctx, model2.ResponseError{
+			Detail: fasthttp.StatusMessage(fasthttp.StatusNotFound),
+		}, fasthttp.StatusNotFound)
+		return
+	}
+
+	paginate, _, _ := c.Paginate(ctx, "id", "inserted_at", "score")
+	orderField := commentOrderField(paginate.OrderField)
+	queryParams := c.ParseQuery(ctx)
+
+	errs := make(map[string]string)
+	depth, err := commentTreeParam(queryParams, "depth", commentTreeDepth, commentTreeMaxDepth)
+	if err != nil {
+		errs["depth"] = "is not valid"
+	}
+	replies, err := commentTreeParam(queryParams, "replies", commentTreeReplies, commentTreeMaxReplies)
+	if err != nil {
+		errs["replies"] = "is not valid"
+	}
+
+	var parentID int64
+	offset := paginate.Offset
+	if val, ok := queryParams["cursor"]; ok {
+		if parentID, offset, err = decodeCommentCursor(val); err != nil {
+			errs["cursor"] = "is not valid"
+		}
+	}
+
+	if len(errs) > 0 {
+		c.JSONResponse(ctx, model2.ResponseError{
+			Errors: errs,
+			Detail: fasthttp.StatusMessage(fasthttp.StatusBadRequest),
+		}, fasthttp.StatusBadRequest)
+		return
+	}
+
+	// accepted answers are pinned to the top of the tree
+	parentClause := "AND c.parent_id IS NULL"
+	pinClause := "accepted DESC,"
+	params := []interface{}{postID, paginate.Limit, offset}
+	if parentID > 0 {
+		parentClause = "AND c.parent_id = $4"
+		pinClause = ""
+		params = append(params, parentID)
+	}
+
+	var roots []model.PostComment
+	c.GetDB().QueryWithModel(fmt.Sprintf(`
+		%s %s
+		ORDER BY %s %s %s, c.id ASC
+		LIMIT $2 OFFSET $3
+	`, c.Model.Query(), parentClause, pinClause, orderField, paginate.OrderBy),
+		&roots,
+		params...)
+
+	var count int64
+	countParams := []interface{}{postID}
+	countClause := "AND c.parent_id IS NULL"
+	if parentID > 0 {
+		countClause = "AND c.parent_id = $2"
+		countParams = append(countParams, parentID)
+	}
+	c.GetDB().DB.Get(&count, fmt.Sprintf(`
+		SELECT count(c.id) FROM %s AS c
+		WHERE c.post_id = $1 %s
+	`, c.Model.TableName(), countClause),
+		countParams...)
+
+	tree := make([]*model.PostComment, len(roots))
+	nodes := make(map[int64]*model.PostComment)
+	var rootIDs []int64
+	for i := range roots {
+		tree[i] = &roots[i]
+		nodes[roots[i].ID] = &roots[i]
+		rootIDs = append(rootIDs, roots[i].ID)
+	}
+
+	// replies are loaded level by level, each parent gets at most the
+	// replies param of replies and the tree at most commentTreeMaxNodes
+	// comments, the rest is reached with the more replies cursors
+	parentIDs := rootIDs
+	loaded := len(roots)
+	for level := 1; level < depth && len(parentIDs) > 0 && loaded < commentTreeMaxNodes; level++ {
+		var children []model.PostComment
+		c.GetDB().QueryWithModel(fmt.Sprintf(`
+			%s AND c.id IN (
+				SELECT r.id FROM (
+					SELECT r.id, row_number() OVER (
+						PARTITION BY r.parent_id ORDER BY r.%s %s, r.id ASC
+					) AS n
+					FROM %s AS r WHERE r.parent_id = ANY($2)
+				) AS r WHERE r.n <= $3
+			)
+			ORDER BY %s %s, c.id ASC
+			LIMIT $4
+		`, c.Model.Query(), orderField, paginate.OrderBy, c.Model.TableName(), orderField, paginate.OrderBy),
+			&children,
+			postID,
+			pq.Array(parentIDs),
+			replies,
+			commentTreeMaxNodes-loaded)
+
+		parentIDs = nil
+		for i := range children {
+			parent, ok := nodes[children[i].ParentID.Int64]
+			if !ok {
+				continue
+			}
+			nodes[children[i].ID] = &children[i]
+			parent.Replies = append(parent.Replies, &children[i])
+			parentIDs = append(parentIDs, children[i].ID)
+		}
+		loaded += len(children)
+	}
+
+	pcC := PostCommentController{API: c.API}
+	for _, node := range tree {
+		pcC.render(ctx, node)
+	}
+
+	for _, node := range nodes {
+		if node.ReplyCount > int64(len(node.Replies)) {
+			node.RepliesCursor = encodeCommentCursor(node.ID, int64(len(node.Replies)))
+		}
+	}
+
+	c.JSONResponse(ctx, model2.ResponseSuccess{
+		Data:       tree,
+		TotalCount: count,
+	}, fasthttp.StatusOK)
+}
+
+// commentTreeParam parse a positive query param with a default and upper limit
+func commentTreeParam(queryParams map[string]string, key string, def, max int) (int, error) {
+	val, ok := queryParams[key]
+	if !ok {
+		return def, nil
+	}
+
+	i, err := strconv.Atoi(val)
+	if err != nil || i < 1 || i > max {
+		return 0, errors.New(key + " is not valid")
+	}
+
+	return i, nil
+}
+
+// encodeCommentCursor load more replies cursor of a comment
+func encodeCommentCursor(parentID, offset int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf("%d:%d", parentID, offset)))
+}
+
+// decodeCommentCursor parent comment and offset of a load more replies cursor
+func decodeCommentCursor(cursor string) (int64, int64, error) {
+	body, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	parts := strings.Split(string(body), ":")
+	if len(parts) != 2 {
+		return 0, 0, errors.New("cursor is not valid")
+	}
+
+	parentID, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil || parentID < 1 {
+		return 0, 0, errors.New("cursor is not valid")
+	}
+	offset, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil || offset < 0 {
+		return 0, 0, errors.New("cursor is not valid")
+	}
+
+	return parentID, offset, nil
+}
@@ -0,0 +1,91 @@
+package api
+
+import (
+	"fmt"
+	"forgolang_forum/database/model"
+	"github.com/valyala/fasthttp"
+	"testing"
+)
+
+type PostCommentTreeControllerTest struct {
+	*Suite
+}
+
+func (s PostCommentTreeControllerTest) SetupSuite() {
+	SetupSuite(s.Suite)
+	UserAuth(s.Suite)
+}
+
+func (s PostCommentTreeControllerTest) Test_ListPostCommentTree() {
+	post := model.NewPost(s.Auth.User.ID)
+	err := s.API.GetDB().Insert(new(model.Post), post, "id")
+	s.Nil(err)
+
+	root := model.NewPostComment(post.ID, s.Auth.User.ID)
+	err = s.API.GetDB().Insert(new(model.PostComment), root, "id")
+	s.Nil(err)
+
+	commentDetail := model.NewPostCommentDetail(post.ID, root.ID)
+	commentDetail.Comment = "Root comment"
+	err = s.API.GetDB().Insert(new(model.PostCommentDetail), commentDetail, "id")
+	s.Nil(err)
+
+	var replyIDs []int64
+	parentID := root.ID
+	for i := 0; i < 3; i++ {
+		reply := model.NewPostComment(post.ID, s.Auth.User.ID)
+		reply.ParentID.SetValid(parentID)
+		err = s.API.GetDB().Insert(new(model.PostComment), reply, "id")
+		s.Nil(err)
+		parentID = reply.ID
+		replyIDs = append(replyIDs, reply.ID)
+	}
+
+	response := s.JSON(Get, fmt.Sprintf("/api/v1/post/%d/comment/tree?depth=2", post.ID), nil)
+
+	s.Equal(response.Status, fasthttp.StatusOK)
+	s.Equal(response.Success.TotalCount, int64(1))
+	data, _ := response.Success.Data.([]interface{})
+	s.Equal(len(data), 1)
+	rootData := data[0].(map[string]interface{})
+	s.Equal(rootData["id"], float64(root.ID))
+	s.Equal(rootData["comment"], "Root comment")
+	s.Equal(rootData["author_username"], s.Auth.User.Username)
+	s.Equal(rootData["score"], float64(0))
+
+	replies := rootData["replies"].([]interface{})
+	s.Equal(len(replies), 1)
+	reply := replies[0].(map[string]interface{})
+	s.Nil(reply["replies"])
+	s.NotEmpty(reply["replies_cursor"])
+
+	response = s.JSON(Get, fmt.Sprintf("/api/v1/post/%d/comment/tree?cursor=%s",
+		post.ID, reply["replies_cursor"]), nil)
+
+	s.Equal(response.Status, fasthttp.StatusOK)
+	s.Equal(response.Success.TotalCount, int64(1))
+	data, _ = response.Success.Data.([]interface{})
+	s.Equal(data[0].(map[string]interface{})["id"], float64(replyIDs[1]))
+
+	defaultLogger.LogInfo("List post comment tree")
+}
+
+func (s PostCommentTreeControllerTest) Test_Should_400Err_ListPostCommentTreeWithInvalidParams() {
+	response := s.JSON(Get, "/api/v1/post/1/comment/tree?depth=0&cursor=invalid", nil)
+
+	s.Equal(response.Status, fasthttp.StatusBadRequest)
+	data, _ := response.Error.Errors.(map[string]interface{})
+	s.Equal(data["depth"], "is not valid")
+	s.Equal(data["cursor"], "is not valid")
+
+	defaultLogger.LogInfo("Should be 400 error list post comment tree with invalid params")
+}
+
+func (s PostCommentTreeControllerTest) TearDownSuite() {
+	TearDownSuite(s.Suite)
+}
+
+func Test_PostCommentTreeController(t *testing.T) {
+	s := PostCommentTreeControllerTest{NewSuite()}
+	Run(t, s)
+}
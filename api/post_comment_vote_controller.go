@@ -0,0 +1,134 @@
+// Copyright 2019 StreetByters Community
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with
+// this work for additional information regarding copyright ownership.
+// The ASF licenses this file to You under the Apache License, Version 2.0
+// (the "License"); you may not use this file except in compliance with
+// the License.  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"fmt"
+	"forgolang_forum/database"
+	"forgolang_forum/database/model"
+	model2 "forgolang_forum/model"
+	"forgolang_forum/tasks"
+	"github.com/fate-lovely/phi"
+	"github.com/valyala/fasthttp"
+	"gopkg.in/guregu/null.v3/zero"
+)
+
+// PostCommentVoteController post comment votes api controller
+type PostCommentVoteController struct {
+	Controller
+	*API
+}
+
+// Create upvote or downvote a post comment. A vote in the other direction
+// is removed in the same statement.
+func (c PostCommentVoteController) Create(ctx *fasthttp.RequestCtx) {
+	comment := c.findComment(ctx)
+
+	var vote, other database.DBInterface
+	voteResponse := new(model2.VoteResponse)
+	voteResponse.PostID = comment.PostID
+	voteResponse.CommentID = comment.ID
+	switch database.Vote(phi.URLParam(ctx, "direction")) {
+	case database.VoteUp:
+		vote = model.NewPostCommentVotesUp(comment.PostID, comment.ID, c.GetAuthContext(ctx).ID)
+		other = model.NewPostCommentVotesDown(comment.PostID, comment.ID, c.GetAuthContext(ctx).ID)
+		voteResponse.Vote = 1
+	case database.VoteDown:
+		vote = model.NewPostCommentVotesDown(comment.PostID, comment.ID, c.GetAuthContext(ctx).ID)
+		other = model.NewPostCommentVotesUp(comment.PostID, comment.ID, c.GetAuthContext(ctx).ID)
+		voteResponse.Vote = -1
+	default:
+		c.JSONResponse(ctx, model2.ResponseError{
+			Errors: map[string]string{
+				"direction": "is not valid",
+			},
+			Detail: fasthttp.StatusMessage(fasthttp.StatusBadRequest),
+		}, fasthttp.StatusBadRequest)
+		return
+	}
+
+	result, err := c.GetDB().DB.Exec(fmt.Sprintf(`
+		WITH other AS (
+			DELETE FROM %s WHERE post_id = $1 AND comment_id = $2 AND user_id = $3
+		)
+		INSERT INTO %s (post_id, comment_id, user_id) VALUES ($1, $2, $3)
+		ON CONFLICT (post_id, comment_id, user_id) DO NOTHING
+	`, other.TableName(), vote.TableName()),
+		comment.PostID,
+		comment.ID,
+		c.GetAuthContext(ctx).ID)
+	if errs, err := database.ValidateConstraint(err, vote); err != nil {
+		c.JSONResponse(ctx, model2.ResponseError{
+			Errors: errs,
+			Detail: fasthttp.StatusMessage(fasthttp.StatusUnprocessableEntity),
+		}, fasthttp.StatusUnprocessableEntity)
+		return
+	}
+
+	voteResponse.Score, voteResponse.Rank, _ = tasks.RefreshCommentRank(c.App, comment.ID)
+	tasks.SyncVoteReputation(c.App, comment.UserID, c.GetAuthContext(ctx).ID, comment.PostID,
+		zero.IntFrom(comment.ID), voteResponse.Vote)
+
+	// only new upvotes are notified, voting again does not notify
+	if n, _ := result.RowsAffected(); n > 0 && voteResponse.Vote > 0 {
+		notification := model.NewNotification(comment.UserID, database.VoteNotification, comment.PostID,
+			zero.IntFrom(comment.ID))
+		notification.ActorID.SetValid(c.GetAuthContext(ctx).ID)
+		tasks.Notify(c.App, notification)
+	}
+
+	c.JSONResponse(ctx, model2.ResponseSuccessOne{
+		Data: voteResponse,
+	}, fasthttp.StatusCreated)
+}
+
+// Delete retract the vote of the user on a post comment
+func (c PostCommentVoteController) Delete(ctx *fasthttp.RequestCtx) {
+	comment := c.findComment(ctx)
+
+	var votesUp model.PostCommentVotesUp
+	var votesDown model.PostCommentVotesDown
+	c.GetDB().DB.Exec(fmt.Sprintf(`
+		WITH up AS (
+			DELETE FROM %s WHERE comment_id = $1 AND user_id = $2
+		)
+		DELETE FROM %s WHERE comment_id = $1 AND user_id = $2
+	`, votesUp.TableName(), votesDown.TableName()),
+		comment.ID,
+		c.GetAuthContext(ctx).ID)
+
+	tasks.RefreshCommentRank(c.App, comment.ID)
+	tasks.SyncVoteReputation(c.App, comment.UserID, c.GetAuthContext(ctx).ID, comment.PostID,
+		zero.IntFrom(comment.ID), 0)
+
+	c.JSONResponse(ctx, nil, fasthttp.StatusNoContent)
+}
+
+// findComment comment of the post given in the path, removed comments are
+// not found so their tombstones can not be voted
+func (c PostCommentVoteController) findComment(ctx *fasthttp.RequestCtx) model.PostComment {
+	var comment model.PostComment
+	c.GetDB().QueryRowWithModel(fmt.Sprintf(`
+		SELECT c.* FROM %s AS c
+		WHERE c.post_id::text = $1::text AND c.id::text = $2::text AND c.deleted_at IS NULL
+	`, comment.TableName()),
+		&comment,
+		phi.URLParam(ctx, "postID"),
+		phi.URLParam(ctx, "commentID")).Force()
+
+	return comment
+}
@@ -0,0 +1,157 @@
+package api
+
+import (
+	"fmt"
+	"forgolang_forum/database/model"
+	"github.com/valyala/fasthttp"
+	"testing"
+)
+
+type PostCommentVoteControllerTest struct {
+	*Suite
+}
+
+func (s PostCommentVoteControllerTest) SetupSuite() {
+	SetupSuite(s.Suite)
+	UserAuth(s.Suite)
+}
+
+func (s PostCommentVoteControllerTest) Test_UpvotePostComment() {
+	post := model.NewPost(s.Auth.User.ID)
+	err := s.API.GetDB().Insert(new(model.Post), post, "id")
+	s.Nil(err)
+
+	postComment := model.NewPostComment(post.ID, s.Auth.User.ID)
+	err = s.API.GetDB().Insert(new(model.PostComment), postComment, "id")
+	s.Nil(err)
+
+	response := s.JSON(Post, fmt.Sprintf("/api/v1/post/%d/comment/%d/vote/up",
+		post.ID, postComment.ID), nil)
+
+	s.Equal(response.Status, fasthttp.StatusCreated)
+	data, _ := response.Success.Data.(map[string]interface{})
+	s.Equal(data["post_id"], float64(post.ID))
+	s.Equal(data["comment_id"], float64(postComment.ID))
+	s.Equal(data["score"], float64(1))
+	s.Equal(data["vote"], float64(1))
+	s.Greater(data["rank"], float64(0))
+
+	defaultLogger.LogInfo("Upvote post comment")
+}
+
+func (s PostCommentVoteControllerTest) Test_ChangeVoteOnPostComment() {
+	post := model.NewPost(s.Auth.User.ID)
+	err := s.API.GetDB().Insert(new(model.Post), post, "id")
+	s.Nil(err)
+
+	postComment := model.NewPostComment(post.ID, s.Auth.User.ID)
+	err = s.API.GetDB().Insert(new(model.PostComment), postComment, "id")
+	s.Nil(err)
+
+	votesUp := model.NewPostCommentVotesUp(post.ID, postComment.ID, s.Auth.User.ID)
+	err = s.API.GetDB().Insert(new(model.PostCommentVotesUp), votesUp, "id")
+	s.Nil(err)
+
+	response := s.JSON(Post, fmt.Sprintf("/api/v1/post/%d/comment/%d/vote/down",
+		post.ID, postComment.ID), nil)
+
+	s.Equal(response.Status, fasthttp.StatusCreated)
+	data, _ := response.Success.Data.(map[string]interface{})
+	s.Equal(data["score"], float64(-1))
+	s.Equal(data["vote"], float64(-1))
+
+	defaultLogger.LogInfo("Change vote on post comment")
+}
+
+func (s PostCommentVoteControllerTest) Test_RetractVoteOnPostComment() {
+	post := model.NewPost(s.Auth.User.ID)
+	err := s.API.GetDB().Insert(new(model.Post), post, "id")
+	s.Nil(err)
+
+	postComment := model.NewPostComment(post.ID, s.Auth.User.ID)
+	err = s.API.GetDB().Insert(new(model.PostComment), postComment, "id")
+	s.Nil(err)
+
+	votesDown := model.NewPostCommentVotesDown(post.ID, postComment.ID, s.Auth.User.ID)
+	err = s.API.GetDB().Insert(new(model.PostCommentVotesDown), votesDown, "id")
+	s.Nil(err)
+
+	response := s.JSON(Delete, fmt.Sprintf("/api/v1/post/%d/comment/%d/vote",
+		post.ID, postComment.ID), nil)
+
+	s.Equal(response.Status, fasthttp.StatusNoContent)
+
+	defaultLogger.LogInfo("Retract vote on post comment")
+}
+
+func (s PostCommentVoteControllerTest) Test_ListPostCommentsOrderedByScore() {
+	post := model.NewPost(s.Auth.User.ID)
+	err := s.API.GetDB().Insert(new(model.Post), post, "id")
+	s.Nil(err)
+
+	newComment := model.NewPostComment(post.ID, s.Auth.User.ID)
+	err = s.API.GetDB().Insert(new(model.PostComment), newComment, "id")
+	s.Nil(err)
+
+	bestComment := model.NewPostComment(post.ID, s.Auth.User.ID)
+	err = s.API.GetDB().Insert(new(model.PostComment), bestComment, "id")
+	s.Nil(err)
+
+	votesUp := model.NewPostCommentVotesUp(post.ID, bestComment.ID, s.Auth.User.ID)
+	err = s.API.GetDB().Insert(new(model.PostCommentVotesUp), votesUp, "id")
+	s.Nil(err)
+
+	response := s.JSON(Get, fmt.Sprintf("/api/v1/post/%d/comment?order_field=score&order_by=desc",
+		post.ID), nil)
+
+	s.Equal(response.Status, fasthttp.StatusOK)
+	data, _ := response.Success.Data.([]interface{})
+	s.Equal(len(data), 2)
+	s.Equal(data[0].(map[string]interface{})["id"], float64(bestComment.ID))
+	s.Equal(data[0].(map[string]interface{})["score"], float64(1))
+
+	defaultLogger.LogInfo("List post comments ordered by score")
+}
+
+func (s PostCommentVoteControllerTest) Test_Should_404Err_VotePostCommentIfNotExists() {
+	post := model.NewPost(s.Auth.User.ID)
+	err := s.API.GetDB().Insert(new(model.Post), post, "id")
+	s.Nil(err)
+
+	response := s.JSON(Post, fmt.Sprintf("/api/v1/post/%d/comment/999999999/vote/up",
+		post.ID), nil)
+
+	s.Equal(response.Status, fasthttp.StatusNotFound)
+
+	defaultLogger.LogInfo("Should be 404 error vote post comment if does not exists")
+}
+
+func (s PostCommentVoteControllerTest) Test_Should_404Err_VotePostCommentIfRemoved() {
+	post := model.NewPost(s.Auth.User.ID)
+	err := s.API.GetDB().Insert(new(model.Post), post, "id")
+	s.Nil(err)
+
+	postComment := model.NewPostComment(post.ID, s.Auth.User.ID)
+	err = s.API.GetDB().Insert(new(model.PostComment), postComment, "id")
+	s.Nil(err)
+	_, err = s.API.GetDB().DB.Exec(fmt.Sprintf(`UPDATE %s SET deleted_at = now() WHERE id = $1`,
+		postComment.TableName()),
+		postComment.ID)
+	s.Nil(err)
+
+	response := s.JSON(Post, fmt.Sprintf("/api/v1/post/%d/comment/%d/vote/up",
+		post.ID, postComment.ID), nil)
+
+	s.Equal(response.Status, fasthttp.StatusNotFound)
+
+	defaultLogger.LogInfo("Should be 404 error vote post comment if removed")
+}
+
+func (s PostCommentVoteControllerTest) TearDownSuite() {
+	TearDownSuite(s.Suite)
+}
+
+func Test_PostCommentVoteController(t *testing.T) {
+	s := PostCommentVoteControllerTest{NewSuite()}
+	Run(t, s)
+}
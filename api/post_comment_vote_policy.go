@@ -0,0 +1,56 @@
+// Copyright 2019 StreetByters Community
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with
+// this work for additional information regarding copyright ownership.
+// The ASF licenses this file to You under the Apache License, Version 2.0
+// (the "License"); you may not use this file except in compliance with
+// the License.  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"forgolang_forum/database"
+	"github.com/fate-lovely/phi"
+	"github.com/valyala/fasthttp"
+)
+
+// PostCommentVotePolicy post comment vote authorization
+type PostCommentVotePolicy struct {
+	Policy
+	*API
+}
+
+// Create post comment vote authorization, votes of closed and archived posts
+// are frozen and downvotes need reputation
+func (p PostCommentVotePolicy) Create(next phi.HandlerFunc) phi.HandlerFunc {
+	pP := PostPolicy{API: p.API}
+	return p.API.Authorization.Apply(next, "PostCommentVoteController", "Create",
+		func(ctx *fasthttp.RequestCtx) bool {
+			if database.Vote(phi.URLParam(ctx, "direction")) == database.VoteDown &&
+				!p.HasReputation(ctx, "downvote") {
+				return false
+			}
+
+			state := pP.GetState(ctx)
+			return state != database.Closed && state != database.Archived
+		})
+}
+
+// Delete post comment vote authorization, votes of closed and archived posts
+// are frozen
+func (p PostCommentVotePolicy) Delete(next phi.HandlerFunc) phi.HandlerFunc {
+	pP := PostPolicy{API: p.API}
+	return p.API.Authorization.Apply(next, "PostCommentVoteController", "Delete",
+		func(ctx *fasthttp.RequestCtx) bool {
+			state := pP.GetState(ctx)
+			return state != database.Closed && state != database.Archived
+		})
+}
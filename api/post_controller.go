@@ -23,10 +23,16 @@ import (
 	"forgolang_forum/database"
 	"forgolang_forum/database/model"
 	model2 "forgolang_forum/model"
+	"forgolang_forum/tasks"
+	"forgolang_forum/utils"
 	"github.com/fate-lovely/phi"
 	"github.com/gosimple/slug"
+	"github.com/lib/pq"
 	"github.com/valyala/fasthttp"
+	"gopkg.in/guregu/null.v3/zero"
 	"strconv"
+	"strings"
+	"time"
 )
 
 type PostController struct {
@@ -48,19 +54,123 @@ func (c PostController) Create(ctx *fasthttp.RequestCtx) {
 		return
 	}
 
+	tC := TagController{API: c.API}
+	tags, missingTags := tC.FindByNames(postReq.Tags)
+	if len(missingTags) > 0 {
+		c.JSONResponse(ctx, model2.ResponseError{
+			Errors: map[string]string{
+				"tags": fmt.Sprintf("does not exists: %s", strings.Join(missingTags, ", ")),
+			},
+			Detail: fasthttp.StatusMessage(fasthttp.StatusUnprocessableEntity),
+		}, fasthttp.StatusUnprocessableEntity)
+		return
+	}
+
+	if postReq.Status == "" {
+		postReq.Status = database.Published
+	}
+	if postReq.Status == database.Scheduled && !postReq.PublishAt.Time.After(time.Now().UTC()) {
+		c.JSONResponse(ctx, model2.ResponseError{
+			Errors: map[string]string{
+				"publish_at": "is not valid",
+			},
+			Detail: fasthttp.StatusMessage(fasthttp.StatusUnprocessableEntity),
+		}, fasthttp.StatusUnprocessableEntity)
+		return
+	}
+	if postReq.Status != database.Scheduled {
+		postReq.PublishAt = zero.Time{}
+	}
+
+	if c.HasTooManyLinks(ctx, postReq.Content.String) {
+		c.JSONResponse(ctx, model2.ResponseError{
+			Errors: map[string]string{
+				"content": "has too many links for your trust level",
+			},
+			Detail: fasthttp.StatusMessage(fasthttp.StatusUnprocessableEntity),
+		}, fasthttp.StatusUnprocessableEntity)
+		return
+	}
+
+	content, codeWarnings := utils.CheckGoCode(postReq.Content.String, postReq.Gofmt)
+	postReq.Content.SetValid(content)
+
 	post := new(model.Post)
 	postSlug := model.NewPostSlug(0, c.GetAuthContext(ctx).ID)
 	postDetail := model.NewPostDetail(0, c.GetAuthContext(ctx).ID)
 
+	var tagIDs []int64
+	for _, t := range tags {
+		tagIDs = append(tagIDs, t.ID)
+	}
+
+	// content scored as spam is saved hidden and waits in the moderation
+	// queue, held posts wait as due scheduled posts and are published when
+	// moderators disagree with the classifier
+	var score float64
+	if !c.IsModerator(ctx) {
+		score = tasks.ScoreSpam(c.App, strings.Join([]string{postReq.Title.String, postReq.Description.String,
+			postReq.Content.String}, "\n"))
+	}
+	postReq.HeldForReview = score >= tasks.SpamThreshold
+	if postReq.HeldForReview && postReq.Status == database.Published {
+		postReq.Status = database.Scheduled
+		postReq.PublishAt.SetValid(time.Now().UTC())
+	}
+
 	var err error
 	errs := make(map[string]string)
-	c.GetDB().Transaction(func(tx *database.Tx) error {
+	db := c.GetDB().Transaction(func(tx *database.Tx) error {
+		if err = tx.DB.Error; err != nil {
+			return err
+		}
+
 		post.AuthorID = c.GetAuthContext(ctx).ID
+		post.Status = postReq.Status
+		post.PublishAt = postReq.PublishAt
+		if post.Status == database.Published {
+			post.PublishedAt.SetValid(time.Now().UTC())
+		}
 		err = tx.DB.Insert(new(model.Post), post, "id")
 		if errs, err = database.ValidateConstraint(err, post); err != nil {
 			return err
 		}
 
+		postDetail.Title = c.App.TextPolicy.Sanitize(postReq.Title.String)
+		postDetail.Description.SetValid(c.App.TextPolicy.Sanitize(postReq.Description.String))
+		postDetail.Content = c.App.TextPolicy.Sanitize(postReq.Content.String)
+		postDetail.PostID = post.ID
+		err = tx.DB.Insert(new(model.PostDetail), postDetail, "id")
+		if errs, err = database.ValidateConstraint(err, postDetail); err != nil {
+			return err
+		}
+
+		if len(tagIDs) > 0 {
+			var postTag model.PostTag
+			_, err = tx.DB.Tx.Exec(fmt.Sprintf(`
+				INSERT INTO %s (post_id, tag_id, source_user_id)
+				SELECT $1, t.id, $3 FROM unnest($2::bigint[]) AS t(id)
+				ON CONFLICT (post_id, tag_id) DO NOTHING
+			`, postTag.TableName()),
+				post.ID,
+				pq.Array(tagIDs),
+				c.GetAuthContext(ctx).ID)
+			if errs, err = database.ValidateConstraint(err, new(model.PostTag)); err != nil {
+				return err
+			}
+		}
+
+		if postReq.HeldForReview {
+			if _, err = tasks.HoldSpam(tx, post.AuthorID, post.ID, zero.Int{}, score); err != nil {
+				return err
+			}
+		}
+
+		// drafts and scheduled posts get their slug when they are published
+		if post.Status != database.Published {
+			return nil
+		}
+
 		result := c.GetDB().QueryRow(fmt.Sprintf(`
 			SELECT * FROM %s AS ps
 			LEFT OUTER JOIN %s AS ps2 ON ps.post_id = ps2.post_id AND ps.id < ps2.id
@@ -83,17 +193,11 @@ func (c PostController) Create(ctx *fasthttp.RequestCtx) {
 		}
 		postReq.Slug.SetValid(postSlug.Slug)
 
-		postDetail.Title = c.App.TextPolicy.Sanitize(postReq.Title.String)
-		postDetail.Description.SetValid(c.App.TextPolicy.Sanitize(postReq.Description.String))
-		postDetail.Content = c.App.TextPolicy.Sanitize(postReq.Content.String)
-		postDetail.PostID = post.ID
-		err = tx.DB.Insert(new(model.PostDetail), postDetail, "id")
-		if errs, err = database.ValidateConstraint(err, postDetail); err != nil {
-			return err
-		}
-
 		return nil
 	})
+	if err == nil {
+		err = db.Error
+	}
 
 	if err != nil {
 		c.JSONResponse(ctx, model2.ResponseError{
@@ -106,22 +210,187 @@ func (c PostController) Create(ctx *fasthttp.RequestCtx) {
 	postReq.ID = post.ID
 	postReq.InsertedAt = post.InsertedAt
 
-	c.App.ElasticClient.Index().
-		Index("posts").
-		Id(strconv.FormatInt(post.ID, 10)).
-		BodyJson(postReq).
-		Do(context.TODO())
+	if len(tags) > 0 {
+		postReq.Tags = nil
+		for _, t := range tags {
+			postReq.Tags = append(postReq.Tags, t.Name)
+		}
+		tC.IncrCount(1, tagIDs...)
+	}
+
+	if postReq.HeldForReview {
+		tasks.Notify(c.App, model.NewModerationNotification(post.AuthorID, "hidden", post.ID, zero.Int{}))
+	}
+
+	if post.Status == database.Published {
+		c.App.ElasticClient.Index().
+			Index("posts").
+			Id(strconv.FormatInt(post.ID, 10)).
+			BodyJson(postReq).
+			Do(context.TODO())
+		tasks.CountPublishedPost(c.App, post.ID)
+		tasks.SyncMentions(c.App, post.ID, zero.Int{}, post.AuthorID, post.AuthorID, postDetail.Content)
+		go tasks.FanOutPost(c.App, post.ID)
+	}
+
+	postReq.CodeWarnings = codeWarnings
 
 	c.JSONResponse(ctx, model2.ResponseSuccessOne{
 		Data: postReq,
 	}, fasthttp.StatusCreated)
 }
 
-// Delete post with given identifier
+// Publish publish a draft or scheduled post now, or schedule it when the
+// publish time is in the future
+func (c PostController) Publish(ctx *fasthttp.RequestCtx) {
+	var post model.Post
+	c.GetDB().QueryRowWithModel(fmt.Sprintf(`
+		SELECT p.* FROM %s AS p WHERE p.id::text = $1::text
+	`, post.TableName()),
+		&post,
+		phi.URLParam(ctx, "postID")).Force()
+
+	if post.Status == database.Published {
+		c.JSONResponse(ctx, model2.ResponseError{
+			Errors: map[string]string{
+				"status": "has been already published",
+			},
+			Detail: fasthttp.StatusMessage(fasthttp.StatusUnprocessableEntity),
+		}, fasthttp.StatusUnprocessableEntity)
+		return
+	}
+
+	var request model2.PostPublishRequest
+	c.JSONBody(ctx, &request)
+
+	var err error
+	if request.PublishAt.Time.After(time.Now().UTC()) {
+		_, err = c.GetDB().DB.Exec(fmt.Sprintf(`
+			UPDATE %s SET status = $2, publish_at = $3 WHERE id = $1
+		`, post.TableName()),
+			post.ID,
+			database.Scheduled,
+			request.PublishAt.Time.UTC())
+	} else {
+		_, err = tasks.PublishPost(c.App, post.ID)
+	}
+	if err != nil {
+		c.JSONResponse(ctx, model2.ResponseError{
+			Detail: fasthttp.StatusMessage(fasthttp.StatusInternalServerError),
+		}, fasthttp.StatusInternalServerError)
+		return
+	}
+
+	c.GetDB().QueryRowWithModel(fmt.Sprintf(`
+		SELECT p.* FROM %s AS p WHERE p.id = $1
+	`, post.TableName()),
+		&post,
+		post.ID)
+
+	c.JSONResponse(ctx, model2.ResponseSuccessOne{
+		Data: post,
+	}, fasthttp.StatusOK)
+}
+
+// Delete remove post with given identifier, the post is left as a tombstone
+// until it is restored by a moderator or purged
 func (c PostController) Delete(ctx *fasthttp.RequestCtx) {
+	var request model2.DeleteRequest
+	c.JSONBody(ctx, &request)
+	if errs, err := database.ValidateStruct(request); err != nil {
+		c.JSONResponse(ctx, model2.ResponseError{
+			Errors: errs,
+			Detail: fasthttp.StatusMessage(fasthttp.StatusUnprocessableEntity),
+		}, fasthttp.StatusUnprocessableEntity)
+		return
+	}
+
 	var post model.Post
-	c.GetDB().Delete(post.TableName(), "id = $1",
+	c.GetDB().QueryRowWithModel(fmt.Sprintf(`
+		SELECT p.* FROM %s AS p WHERE p.id::text = $1::text AND p.deleted_at IS NULL
+	`, post.TableName()),
+		&post,
 		phi.URLParam(ctx, "postID")).Force()
 
+	reason := zero.StringFrom(request.Reason)
+	result, err := c.GetDB().DB.Exec(fmt.Sprintf(`
+		UPDATE %s SET deleted_at = (CURRENT_TIMESTAMP at time zone 'utc'), deleted_by_id = $2, delete_reason = $3
+		WHERE id = $1 AND deleted_at IS NULL
+	`, post.TableName()),
+		post.ID,
+		c.GetAuthContext(ctx).ID,
+		reason)
+	if err != nil {
+		c.JSONResponse(ctx, model2.ResponseError{
+			Detail: fasthttp.StatusMessage(fasthttp.StatusInternalServerError),
+		}, fasthttp.StatusInternalServerError)
+		return
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		c.JSONResponse(ctx, model2.ResponseError{
+			Detail: fasthttp.StatusMessage(fasthttp.StatusNotFound),
+		}, fasthttp.StatusNotFound)
+		return
+	}
+
+	if post.Status == database.Published {
+		c.App.ElasticClient.Delete().
+			Index("posts").
+			Id(strconv.FormatInt(post.ID, 10)).
+			Do(context.TODO())
+		tasks.UncountPublishedPost(c.App, post.ID)
+		tasks.RemovePostRankings(c.App, post.ID)
+	}
+
+	if post.AuthorID != c.GetAuthContext(ctx).ID {
+		notification := model.NewModerationNotification(post.AuthorID, "removed", post.ID, zero.Int{})
+		notification.ActorID.SetValid(c.GetAuthContext(ctx).ID)
+		tasks.Notify(c.App, notification)
+	}
+
 	c.JSONResponse(ctx, nil, fasthttp.StatusNoContent)
 }
+
+// Restore bring back a removed post with its search document and counters
+func (c PostController) Restore(ctx *fasthttp.RequestCtx) {
+	var post model.Post
+	c.GetDB().QueryRowWithModel(fmt.Sprintf(`
+		SELECT p.* FROM %s AS p WHERE p.id::text = $1::text AND p.deleted_at IS NOT NULL
+	`, post.TableName()),
+		&post,
+		phi.URLParam(ctx, "postID")).Force()
+
+	result, err := c.GetDB().DB.Exec(fmt.Sprintf(`
+		UPDATE %s SET deleted_at = NULL, deleted_by_id = NULL, delete_reason = NULL
+		WHERE id = $1 AND deleted_at IS NOT NULL
+	`, post.TableName()),
+		post.ID)
+	if err != nil {
+		c.JSONResponse(ctx, model2.ResponseError{
+			Detail: fasthttp.StatusMessage(fasthttp.StatusInternalServerError),
+		}, fasthttp.StatusInternalServerError)
+		return
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		c.JSONResponse(ctx, model2.ResponseError{
+			Detail: fasthttp.StatusMessage(fasthttp.StatusNotFound),
+		}, fasthttp.StatusNotFound)
+		return
+	}
+
+	// rankings pick the post up again on their next refresh
+	if post.Status == database.Published {
+		tasks.IndexPost(c.App, post.ID)
+		tasks.CountPublishedPost(c.App, post.ID)
+	}
+
+	c.GetDB().QueryRowWithModel(fmt.Sprintf(`
+		SELECT p.* FROM %s AS p WHERE p.id = $1
+	`, post.TableName()),
+		&post,
+		post.ID)
+
+	c.JSONResponse(ctx, model2.ResponseSuccessOne{
+		Data: post,
+	}, fasthttp.StatusOK)
+}
@@ -37,6 +37,51 @@ func (s PostControllerTest) Test_CreatePostWithValidParams() {
 	defaultLogger.LogInfo("Create post with valid params")
 }
 
+func (s PostControllerTest) Test_CreatePostWithValidParamsAndGofmt() {
+	postDep := new(model.PostDEP)
+	postDep.Title.SetValid("Post title gofmt")
+	postDep.Content.SetValid("Post content\n```go\nx:=1\n```\n```go\nfunc (\n```")
+	postDep.Gofmt = true
+
+	response := s.JSON(Post, "/api/v1/post", postDep)
+
+	s.Equal(response.Status, fasthttp.StatusCreated)
+	data, _ := response.Success.Data.(map[string]interface{})
+	s.Equal(data["content"], "Post content\n```go\nx := 1\n```\n```go\nfunc (\n```")
+	warnings, _ := data["code_warnings"].([]interface{})
+	s.Equal(len(warnings), 1)
+	s.Equal(warnings[0].(map[string]interface{})["block"], float64(2))
+
+	defaultLogger.LogInfo("Create post with valid params and gofmt")
+}
+
+func (s PostControllerTest) Test_CreatePostWithValidParamsAndTags() {
+	tag := model.NewTag()
+	tag.Name = "post-create-tag"
+	err := s.API.GetDB().Insert(new(model.Tag), tag, "id", "inserted_at", "updated_at")
+	s.Nil(err)
+
+	postDep := new(model.PostDEP)
+	postDep.Title.SetValid("Post title tags")
+	postDep.Content.SetValid("Post content with tags")
+	postDep.Tags = []string{"Post Create Tag"}
+
+	response := s.JSON(Post, "/api/v1/post", postDep)
+
+	s.Equal(response.Status, fasthttp.StatusCreated)
+	data, _ := response.Success.Data.(map[string]interface{})
+	s.Equal(data["tags"], []interface{}{"post-create-tag"})
+
+	postDep.Title.SetValid("Post title unknown tags")
+	postDep.Tags = []string{"unknown-tag"}
+
+	response = s.JSON(Post, "/api/v1/post", postDep)
+
+	s.Equal(response.Status, fasthttp.StatusUnprocessableEntity)
+
+	defaultLogger.LogInfo("Create post with valid params and tags")
+}
+
 func (s PostControllerTest) Test_Should_422Err_CreatePostWithInvalidParams() {
 	postDep := new(model.PostDEP)
 	postDep.Title.SetValid("Po")
@@ -95,6 +140,62 @@ func (s PostControllerTest) Test_Shoul_404Err_DeletePostWithGivenIdentifierIfNot
 		"if does not exists")
 }
 
+func (s PostControllerTest) Test_RemovePostAsTombstoneAndRestore() {
+	category := model.NewCategory()
+	category.Title = "Removed Posts"
+	category.Slug = slug.Make(category.Title)
+	err := s.API.GetDB().Insert(new(model.Category), category, "id")
+	s.Nil(err)
+
+	post := model.NewPost(s.Auth.User.ID)
+	err = s.API.GetDB().Insert(new(model.Post), post, "id")
+	s.Nil(err)
+	postDetail := model.NewPostDetail(post.ID, s.Auth.User.ID)
+	postDetail.Title = "Removed Post"
+	postDetail.Content = "Removed Post Context"
+	err = s.API.GetDB().Insert(new(model.PostDetail), postDetail, "id")
+	s.Nil(err)
+	postCategoryAssignment := model.NewPostCategoryAssignment(post.ID, category.ID, s.Auth.User.ID)
+	err = s.API.GetDB().Insert(new(model.PostCategoryAssignment), postCategoryAssignment, "id")
+	s.Nil(err)
+
+	response := s.JSON(Delete, fmt.Sprintf("/api/v1/post/%d", post.ID), map[string]string{
+		"reason": "Duplicate",
+	})
+
+	s.Equal(response.Status, fasthttp.StatusNoContent)
+
+	response = s.JSON(Get, fmt.Sprintf("/api/v1/category/%d/post", category.ID), nil)
+
+	s.Equal(response.Status, fasthttp.StatusOK)
+	s.Equal(response.Success.TotalCount, int64(0))
+
+	response = s.JSON(Get, fmt.Sprintf("/api/v1/category/%d/post/%d", category.ID, post.ID), nil)
+
+	s.Equal(response.Status, fasthttp.StatusOK)
+	data, _ := response.Success.Data.(map[string]interface{})
+	s.Equal(data["title"], "Removed Post")
+	s.Equal(data["delete_reason"], "Duplicate")
+	s.Equal(data["removed_by"], "author")
+
+	response = s.JSON(Delete, fmt.Sprintf("/api/v1/post/%d", post.ID), nil)
+
+	s.Equal(response.Status, fasthttp.StatusNotFound)
+
+	response = s.JSON(Post, fmt.Sprintf("/api/v1/post/%d/restore", post.ID), nil)
+
+	s.Equal(response.Status, fasthttp.StatusOK)
+	data, _ = response.Success.Data.(map[string]interface{})
+	s.Equal(data["deleted_by_id"], float64(0))
+
+	response = s.JSON(Get, fmt.Sprintf("/api/v1/category/%d/post", category.ID), nil)
+
+	s.Equal(response.Status, fasthttp.StatusOK)
+	s.Equal(response.Success.TotalCount, int64(1))
+
+	defaultLogger.LogInfo("Remove post as tombstone and restore")
+}
+
 func (s PostControllerTest) TearDownSuite() {
 	TearDownSuite(s.Suite)
 }
@@ -18,14 +18,18 @@ package api
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"fmt"
 	"forgolang_forum/database"
 	model2 "forgolang_forum/database/model"
 	"forgolang_forum/model"
+	"forgolang_forum/tasks"
+	"forgolang_forum/utils"
 	"github.com/fate-lovely/phi"
 	"github.com/gosimple/slug"
 	"github.com/valyala/fasthttp"
+	"gopkg.in/guregu/null.v3/zero"
 	"strconv"
 )
 
@@ -58,13 +62,52 @@ func (c PostDetailController) Create(ctx *fasthttp.RequestCtx) {
 		return
 	}
 
+	if c.HasTooManyLinks(ctx, postDetail.Content) {
+		c.JSONResponse(ctx, model.ResponseError{
+			Errors: map[string]string{
+				"content": "has too many links for your trust level",
+			},
+			Detail: fasthttp.StatusMessage(fasthttp.StatusUnprocessableEntity),
+		}, fasthttp.StatusUnprocessableEntity)
+		return
+	}
+
+	postDetail.Content, postDetail.CodeWarnings = utils.CheckGoCode(postDetail.Content, postDetail.Gofmt)
+
 	postDetail.Title = c.App.TextPolicy.Sanitize(postDetail.Title)
 	postDetail.Description.SetValid(c.App.TextPolicy.Sanitize(postDetail.Description.String))
 	postDetail.Content = c.App.TextPolicy.Sanitize(postDetail.Content)
 
+	// drafts and scheduled posts are autosaved as detail revisions, their
+	// titles do not take slugs until they are published
+	var post model2.Post
+	var published bool
+	err = c.GetDB().DB.Get(&published, fmt.Sprintf(`
+		SELECT p.status = $2 FROM %s AS p WHERE p.id = $1
+	`, post.TableName()),
+		postID,
+		database.Published)
+	if err == sql.ErrNoRows {
+		c.JSONResponse(ctx, model.ResponseError{
+			Detail: fasthttp.StatusMessage(fasthttp.StatusNotFound),
+		}, fasthttp.StatusNotFound)
+		return
+	} else if err != nil {
+		c.JSONResponse(ctx, model.ResponseError{
+			Detail: fasthttp.StatusMessage(fasthttp.StatusInternalServerError),
+		}, fasthttp.StatusInternalServerError)
+		return
+	}
+
 	postSlug := model2.NewPostSlug(postID, c.GetAuthContext(ctx).ID)
 	errs := make(map[string]string)
 	c.GetDB().Transaction(func(tx *database.Tx) error {
+		if !published {
+			err = c.GetDB().Insert(new(model2.PostDetail), postDetail, "id", "inserted_at")
+			errs, err = database.ValidateConstraint(err, postDetail)
+			return err
+		}
+
 		result := c.GetDB().QueryRow(fmt.Sprintf(`
 			SELECT * FROM %s AS ps
 			LEFT OUTER JOIN %s AS ps2 ON ps.post_id = ps2.post_id AND ps.id < ps2.id
@@ -96,16 +139,22 @@ func (c PostDetailController) Create(ctx *fasthttp.RequestCtx) {
 		return
 	}
 
-	c.App.ElasticClient.Update().
-		Index("posts").
-		Id(strconv.FormatInt(postID, 10)).
-		Doc(map[string]interface{}{
-			"title":       postDetail.Title,
-			"content":     postDetail.Content,
-			"description": postDetail.Description,
-			"slug":        postSlug.Slug,
-		}).
-		Do(context.TODO())
+	if published {
+		c.App.ElasticClient.Update().
+			Index("posts").
+			Id(strconv.FormatInt(postID, 10)).
+			Doc(map[string]interface{}{
+				"title":       postDetail.Title,
+				"content":     postDetail.Content,
+				"description": postDetail.Description,
+				"slug":        postSlug.Slug,
+			}).
+			Do(context.TODO())
+
+		post := PostPolicy{API: c.API}.GetPost(ctx)
+		tasks.SyncMentions(c.App, postID, zero.Int{}, post.AuthorID, c.GetAuthContext(ctx).ID,
+			postDetail.Content)
+	}
 
 	c.JSONResponse(ctx, model.ResponseSuccessOne{
 		Data: postDetail,
@@ -17,6 +17,7 @@
 package api
 
 import (
+	"forgolang_forum/database"
 	"github.com/fate-lovely/phi"
 	"github.com/valyala/fasthttp"
 )
@@ -30,6 +31,9 @@ func (p PostDetailPolicy) Create(next phi.HandlerFunc) phi.HandlerFunc {
 	postPolicy := &PostPolicy{API: p.API}
 	return p.API.Authorization.Apply(next, "PostDetailController", "Create",
 		func(ctx *fasthttp.RequestCtx) bool {
+			if postPolicy.GetState(ctx) == database.Archived {
+				return false
+			}
 			if post := postPolicy.GetPost(ctx); post != nil && post.AuthorID == p.GetAuthContext(ctx).ID {
 				return true
 			}
@@ -0,0 +1,72 @@
+// Copyright 2019 StreetByters Community
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with
+// this work for additional information regarding copyright ownership.
+// The ASF licenses this file to You under the Apache License, Version 2.0
+// (the "License"); you may not use this file except in compliance with
+// the License.  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"fmt"
+	"forgolang_forum/database"
+	"forgolang_forum/database/model"
+	model2 "forgolang_forum/model"
+	"github.com/valyala/fasthttp"
+)
+
+// PostDraftController draft and scheduled posts of the current user api
+// controller
+type PostDraftController struct {
+	Controller
+	*API
+	Model model.Post
+}
+
+// Index list draft and scheduled posts of the current user with their
+// latest autosaved details
+func (c PostDraftController) Index(ctx *fasthttp.RequestCtx) {
+	paginate, _, _ := c.Paginate(ctx, "id", "inserted_at", "publish_at")
+
+	var posts []model.PostDEP
+	var postDetail model.PostDetail
+	c.GetDB().QueryWithModel(fmt.Sprintf(`
+		SELECT
+			p.id as id, p.author_id as author_id, p.status as status, p.publish_at as publish_at,
+			p.inserted_at as inserted_at, pd.title as title, pd.description as description,
+			pd.content as content
+		FROM %s AS p
+		INNER JOIN %s AS pd ON p.id = pd.post_id
+		LEFT OUTER JOIN %s AS pd2 ON pd.post_id = pd2.post_id AND pd.id < pd2.id
+		WHERE pd2.id IS NULL AND p.author_id = $1 AND p.status != $2 AND p.deleted_at IS NULL
+		ORDER BY p.%s %s
+		LIMIT $3 OFFSET $4
+	`, c.Model.TableName(), postDetail.TableName(), postDetail.TableName(),
+		paginate.OrderField, paginate.OrderBy),
+		&posts,
+		c.GetAuthContext(ctx).ID,
+		database.Published,
+		paginate.Limit,
+		paginate.Offset)
+
+	var count int64
+	c.GetDB().DB.Get(&count, fmt.Sprintf(`
+		SELECT count(p.id) FROM %s AS p WHERE p.author_id = $1 AND p.status != $2 AND p.deleted_at IS NULL
+	`, c.Model.TableName()),
+		c.GetAuthContext(ctx).ID,
+		database.Published)
+
+	c.JSONResponse(ctx, model2.ResponseSuccess{
+		Data:       posts,
+		TotalCount: count,
+	}, fasthttp.StatusOK)
+}
@@ -0,0 +1,155 @@
+package api
+
+import (
+	"fmt"
+	"forgolang_forum/database"
+	"forgolang_forum/database/model"
+	"forgolang_forum/tasks"
+	"github.com/gosimple/slug"
+	"github.com/valyala/fasthttp"
+	"testing"
+	"time"
+)
+
+type PostDraftControllerTest struct {
+	*Suite
+}
+
+func (s PostDraftControllerTest) SetupSuite() {
+	SetupSuite(s.Suite)
+	UserAuth(s.Suite)
+}
+
+func (s PostDraftControllerTest) Test_CreateDraftsWithSameTitleAndPublish() {
+	postDep := new(model.PostDEP)
+	postDep.Title.SetValid("Weekly Go news roundup")
+	postDep.Content.SetValid("Draft content of the weekly roundup")
+	postDep.Status = database.Draft
+
+	var ids []int64
+	for i := 0; i < 2; i++ {
+		response := s.JSON(Post, "/api/v1/post", postDep)
+
+		s.Equal(response.Status, fasthttp.StatusCreated)
+		data, _ := response.Success.Data.(map[string]interface{})
+		s.Equal(data["status"], string(database.Draft))
+		s.Equal(data["slug"], "")
+		ids = append(ids, int64(data["id"].(float64)))
+	}
+
+	response := s.JSON(Get, "/api/v1/post/draft", nil)
+
+	s.Equal(response.Status, fasthttp.StatusOK)
+	s.Greater(response.Success.TotalCount, int64(1))
+
+	postDetail := model.NewPostDetail(ids[0], s.Auth.User.ID)
+	postDetail.Title = "Weekly Go news roundup"
+	postDetail.Content = "Autosaved content of the weekly roundup"
+
+	response = s.JSON(Post, fmt.Sprintf("/api/v1/post/%d/detail", ids[0]), postDetail)
+
+	s.Equal(response.Status, fasthttp.StatusCreated)
+
+	response = s.JSON(Post, fmt.Sprintf("/api/v1/post/%d/publish", ids[0]), nil)
+
+	s.Equal(response.Status, fasthttp.StatusOK)
+	data, _ := response.Success.Data.(map[string]interface{})
+	s.Equal(data["status"], string(database.Published))
+
+	response = s.JSON(Post, fmt.Sprintf("/api/v1/post/%d/publish", ids[1]), nil)
+
+	s.Equal(response.Status, fasthttp.StatusOK)
+
+	var slugs []string
+	s.API.GetDB().DB.Select(&slugs, fmt.Sprintf(`
+		SELECT ps.slug FROM %s AS ps WHERE ps.post_id = ANY(ARRAY[$1, $2]::bigint[]) ORDER BY ps.post_id
+	`, model.PostSlug{}.TableName()),
+		ids[0],
+		ids[1])
+	s.Equal(len(slugs), 2)
+	s.Equal(slugs[0], slug.Make("Weekly Go news roundup"))
+	s.Equal(slugs[1], fmt.Sprintf("%s-%d", slug.Make("Weekly Go news roundup"), ids[1]))
+
+	response = s.JSON(Post, fmt.Sprintf("/api/v1/post/%d/publish", ids[0]), nil)
+
+	s.Equal(response.Status, fasthttp.StatusUnprocessableEntity)
+
+	defaultLogger.LogInfo("Create drafts with same title and publish")
+}
+
+func (s PostDraftControllerTest) Test_PublishScheduledPosts() {
+	category := model.NewCategory()
+	category.Title = "Go News"
+	category.Slug = slug.Make(category.Title)
+	err := s.API.GetDB().Insert(new(model.Category), category, "id")
+	s.Nil(err)
+
+	post := model.NewPost(s.Auth.User.ID)
+	post.Status = database.Draft
+	err = s.API.GetDB().Insert(new(model.Post), post, "id")
+	s.Nil(err)
+	postDetail := model.NewPostDetail(post.ID, s.Auth.User.ID)
+	postDetail.Title = "Scheduled roundup"
+	postDetail.Content = "Scheduled roundup content"
+	err = s.API.GetDB().Insert(new(model.PostDetail), postDetail, "id")
+	s.Nil(err)
+	postCategoryAssignment := model.NewPostCategoryAssignment(post.ID, category.ID, s.Auth.User.ID)
+	err = s.API.GetDB().Insert(new(model.PostCategoryAssignment), postCategoryAssignment, "id")
+	s.Nil(err)
+
+	response := s.JSON(Get, fmt.Sprintf("/api/v1/category/%d/post", category.ID), nil)
+
+	s.Equal(response.Status, fasthttp.StatusOK)
+	s.Equal(response.Success.TotalCount, int64(0))
+
+	response = s.JSON(Post, fmt.Sprintf("/api/v1/post/%d/publish", post.ID), map[string]interface{}{
+		"publish_at": time.Now().UTC().Add(time.Hour),
+	})
+
+	s.Equal(response.Status, fasthttp.StatusOK)
+	data, _ := response.Success.Data.(map[string]interface{})
+	s.Equal(data["status"], string(database.Scheduled))
+
+	s.API.GetDB().DB.Exec(fmt.Sprintf(`
+		UPDATE %s SET publish_at = $2 WHERE id = $1
+	`, post.TableName()),
+		post.ID,
+		time.Now().UTC().Add(-time.Minute))
+
+	err = tasks.PublishScheduledPosts(s.API.App, nil)
+	s.Nil(err)
+
+	response = s.JSON(Get, fmt.Sprintf("/api/v1/category/%d/post", category.ID), nil)
+
+	s.Equal(response.Status, fasthttp.StatusOK)
+	s.Equal(response.Success.TotalCount, int64(1))
+	posts, _ := response.Success.Data.([]interface{})
+	s.Equal(posts[0].(map[string]interface{})["id"], float64(post.ID))
+
+	defaultLogger.LogInfo("Publish scheduled posts")
+}
+
+func (s PostDraftControllerTest) Test_Should_422Err_SchedulePostInThePast() {
+	postDep := new(model.PostDEP)
+	postDep.Title.SetValid("Past roundup")
+	postDep.Content.SetValid("Past roundup content of the week")
+	postDep.Status = database.Scheduled
+	postDep.PublishAt.SetValid(time.Now().UTC().Add(-time.Hour))
+
+	response := s.JSON(Post, "/api/v1/post", postDep)
+
+	s.Equal(response.Status, fasthttp.StatusUnprocessableEntity)
+	data, _ := response.Error.Errors.(map[string]interface{})
+	s.Equal(data["publish_at"], "is not valid")
+
+	defaultLogger.LogInfo("Should be 422 error schedule post in the past")
+}
+
+func (s PostDraftControllerTest) TearDownSuite() {
+	TearDownSuite(s.Suite)
+}
+
+func Test_PostDraftController(t *testing.T) {
+	s := PostDraftControllerTest{NewSuite()}
+	Run(t, s)
+}
@@ -0,0 +1,68 @@
+// Copyright 2019 StreetByters Community
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with
+// this work for additional information regarding copyright ownership.
+// The ASF licenses this file to You under the Apache License, Version 2.0
+// (the "License"); you may not use this file except in compliance with
+// the License.  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"fmt"
+	"forgolang_forum/database"
+	"forgolang_forum/database/model"
+	model2 "forgolang_forum/model"
+	"github.com/valyala/fasthttp"
+)
+
+// PostPinController globally pinned posts api controller
+type PostPinController struct {
+	Controller
+	*API
+	Model model.Post
+}
+
+// Index list globally pinned posts like announcements in pin order
+func (c PostPinController) Index(ctx *fasthttp.RequestCtx) {
+	var posts []model.PostDEP
+	var postState model.PostState
+	var postSlug model.PostSlug
+	var postDetail model.PostDetail
+	var user model.User
+	c.GetDB().QueryWithModel(fmt.Sprintf(`
+		SELECT
+			p.id as id, p.author_id as author_id, u.username as author_username,
+			p.inserted_at as inserted_at, ps.slug as slug, pd.title as title,
+			pd.description as description, pst.state as state,
+			pst.pinned_globally as pinned_globally, pst.pinned_in_category as pinned_in_category
+		FROM %s AS p
+		INNER JOIN LATERAL (
+			SELECT s.* FROM %s AS s WHERE s.post_id = p.id ORDER BY s.id DESC LIMIT 1
+		) AS pst ON true
+		LEFT OUTER JOIN %s AS ps ON p.id = ps.post_id
+		LEFT OUTER JOIN %s AS ps2 ON ps.post_id = ps2.post_id AND ps.id < ps2.id
+		INNER JOIN %s AS pd ON p.id = pd.post_id
+		LEFT OUTER JOIN %s AS pd2 ON pd.post_id = pd2.post_id AND pd.id < pd2.id
+		INNER JOIN %s AS u ON p.author_id = u.id
+		WHERE ps2.id IS NULL AND pd2.id IS NULL AND pst.pinned_globally AND p.status = $1 AND
+			p.deleted_at IS NULL
+		ORDER BY pst.pin_order ASC, p.id DESC
+	`, c.Model.TableName(), postState.TableName(), postSlug.TableName(), postSlug.TableName(),
+		postDetail.TableName(), postDetail.TableName(), user.TableName()),
+		&posts,
+		database.Published)
+
+	c.JSONResponse(ctx, model2.ResponseSuccess{
+		Data:       posts,
+		TotalCount: int64(len(posts)),
+	}, fasthttp.StatusOK)
+}
@@ -18,6 +18,7 @@ package api
 
 import (
 	"fmt"
+	"forgolang_forum/database"
 	"forgolang_forum/database/model"
 	"github.com/fate-lovely/phi"
 	"github.com/valyala/fasthttp"
@@ -33,13 +34,37 @@ type PostPolicy struct {
 func (p PostPolicy) Create(next phi.HandlerFunc) phi.HandlerFunc {
 	return p.API.Authorization.Apply(next, "PostController", "Create",
 		func(ctx *fasthttp.RequestCtx) bool {
-			return true
+			limit := p.GetTrustLimit(ctx)
+			return limit.PostsPerDay == 0 || p.CountRecentPosts(ctx) < limit.PostsPerDay
 		})
 }
 
-// Delete method for posts api authorization
+// Delete method for posts api authorization, moderators remove posts of
+// other users
 func (p PostPolicy) Delete(next phi.HandlerFunc) phi.HandlerFunc {
 	return p.API.Authorization.Apply(next, "PostController", "Delete",
+		func(ctx *fasthttp.RequestCtx) bool {
+			if p.IsModerator(ctx) {
+				return true
+			}
+			if post := p.GetPost(ctx); post != nil && post.AuthorID == p.GetAuthContext(ctx).ID {
+				return true
+			}
+			return false
+		})
+}
+
+// Restore method for posts api authorization
+func (p PostPolicy) Restore(next phi.HandlerFunc) phi.HandlerFunc {
+	return p.API.Authorization.Apply(next, "PostController", "Restore",
+		func(ctx *fasthttp.RequestCtx) bool {
+			return true
+		})
+}
+
+// Publish method for posts api authorization, authors publish their drafts
+func (p PostPolicy) Publish(next phi.HandlerFunc) phi.HandlerFunc {
+	return p.API.Authorization.Apply(next, "PostController", "Publish",
 		func(ctx *fasthttp.RequestCtx) bool {
 			if post := p.GetPost(ctx); post != nil && post.AuthorID == p.GetAuthContext(ctx).ID {
 				return true
@@ -48,13 +73,47 @@ func (p PostPolicy) Delete(next phi.HandlerFunc) phi.HandlerFunc {
 		})
 }
 
+// CountRecentPosts number of posts the current user created in the last
+// day, drafts are counted too
+func (p PostPolicy) CountRecentPosts(ctx *fasthttp.RequestCtx) int64 {
+	var post model.Post
+	var count int64
+	p.App.Database.DB.Get(&count, fmt.Sprintf(`
+		SELECT count(p.id) FROM %s AS p
+		WHERE p.author_id = $1 AND p.inserted_at > (CURRENT_TIMESTAMP at time zone 'utc') - interval '1 day'
+	`, post.TableName()),
+		p.GetAuthContext(ctx).ID)
+
+	return count
+}
+
+// GetState current moderation state of the post, posts without a state
+// history are open
+func (p PostPolicy) GetState(ctx *fasthttp.RequestCtx) database.PostState {
+	var postState model.PostState
+	var postSlug model.PostSlug
+
+	state := database.Open
+	p.App.Database.DB.Get(&state, fmt.Sprintf(`
+		SELECT pst.state FROM %s AS pst
+		WHERE pst.post_id::text = $1::text OR pst.post_id IN (
+			SELECT ps.post_id FROM %s AS ps WHERE ps.slug = $1
+		)
+		ORDER BY pst.id DESC
+		LIMIT 1
+	`, postState.TableName(), postSlug.TableName()),
+		phi.URLParam(ctx, "postID"))
+
+	return state
+}
+
 func (p PostPolicy) GetPost(ctx *fasthttp.RequestCtx) *model.Post {
 	var post model.Post
 	var postSlug model.PostSlug
 
 	p.App.Database.QueryRowWithModel(fmt.Sprintf(`
 		SELECT 
-			p.id, p.author_id, p.inserted_at
+			p.id, p.author_id, p.status, p.deleted_at, p.inserted_at
 		FROM %s AS p
 		LEFT OUTER JOIN %s AS ps ON p.id = ps.post_id
 		LEFT OUTER JOIN %s AS ps2 ON ps.post_id = ps2.post_id AND ps.id < ps2.id
@@ -114,7 +114,7 @@ func (s PostPolicyTest) Test_DeletePostWithGivenIdentifierAndModeratorRole() {
 	defaultLogger.LogInfo("Delete post with given identifier and user role")
 }
 
-func (s PostPolicyTest) Test_Should_403Err_DeletePostWithGivenIdentifierAndModeratorRoleIfPostAuthorOtherUser() {
+func (s PostPolicyTest) Test_DeletePostWithGivenIdentifierAndModeratorRoleIfPostAuthorOtherUser() {
 	UserAuth(s.Suite, "moderator")
 
 	pwd := "123456"
@@ -133,9 +133,9 @@ func (s PostPolicyTest) Test_Should_403Err_DeletePostWithGivenIdentifierAndModer
 
 	response := s.JSON(Delete, fmt.Sprintf("/api/v1/post/%d", post.ID), nil)
 
-	s.Equal(response.Status, fasthttp.StatusForbidden)
+	s.Equal(response.Status, fasthttp.StatusNoContent)
 
-	defaultLogger.LogInfo("Should 403 error delete post with given identifier and " +
+	defaultLogger.LogInfo("Delete post with given identifier and " +
 		"moderator role if post author other user")
 }
 
@@ -0,0 +1,148 @@
+// Copyright 2019 StreetByters Community
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with
+// this work for additional information regarding copyright ownership.
+// The ASF licenses this file to You under the Apache License, Version 2.0
+// (the "License"); you may not use this file except in compliance with
+// the License.  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"fmt"
+	"forgolang_forum/database"
+	"forgolang_forum/database/model"
+	model2 "forgolang_forum/model"
+	"forgolang_forum/tasks"
+	"github.com/fate-lovely/phi"
+	"github.com/valyala/fasthttp"
+	"gopkg.in/guregu/null.v3/zero"
+	"strconv"
+)
+
+// PostStateController post moderation states api controller
+type PostStateController struct {
+	Controller
+	*API
+	Model model.PostState
+}
+
+// Index list state history of a post, latest state first
+func (c PostStateController) Index(ctx *fasthttp.RequestCtx) {
+	paginate, _, _ := c.Paginate(ctx, "id", "inserted_at")
+
+	var postStates []model.PostState
+	c.GetDB().QueryWithModel(fmt.Sprintf(`
+		SELECT ps.* FROM %s AS ps
+		WHERE ps.post_id::text = $1::text
+		ORDER BY ps.%s %s
+		LIMIT $2 OFFSET $3
+	`, c.Model.TableName(), paginate.OrderField, paginate.OrderBy),
+		&postStates,
+		phi.URLParam(ctx, "postID"),
+		paginate.Limit,
+		paginate.Offset)
+
+	var count int64
+	c.GetDB().DB.Get(&count, fmt.Sprintf(`
+		SELECT count(ps.id) FROM %s AS ps WHERE ps.post_id::text = $1::text
+	`, c.Model.TableName()),
+		phi.URLParam(ctx, "postID"))
+
+	c.JSONResponse(ctx, model2.ResponseSuccess{
+		Data:       postStates,
+		TotalCount: count,
+	}, fasthttp.StatusOK)
+}
+
+// Create change state and pins of a post, previous states are kept as
+// history. Pins which are not given are kept from the previous state. Posts
+// are closed as duplicates of published posts which are not duplicates
+// themselves.
+func (c PostStateController) Create(ctx *fasthttp.RequestCtx) {
+	postID, err := strconv.ParseInt(phi.URLParam(ctx, "postID"), 10, 64)
+	if err != nil {
+		c.JSONResponse(ctx, model2.ResponseError{
+			Detail: fasthttp.StatusMessage(fasthttp.StatusBadRequest),
+		}, fasthttp.StatusBadRequest)
+		return
+	}
+
+	postState := model.NewPostState(postID)
+	c.GetDB().DB.Get(postState, fmt.Sprintf(`
+		SELECT ps.pinned_globally, ps.pinned_in_category, ps.pin_order FROM %s AS ps
+		WHERE ps.post_id = $1
+		ORDER BY ps.id DESC
+		LIMIT 1
+	`, c.Model.TableName()),
+		postID)
+	c.JSONBody(ctx, &postState)
+	postState.PostID = postID
+	postState.SourceUserID.SetValid(c.GetAuthContext(ctx).ID)
+	if postState.State == "" {
+		postState.State = database.Open
+	}
+
+	if errs, err := database.ValidateStruct(postState); err != nil {
+		c.JSONResponse(ctx, model2.ResponseError{
+			Errors: errs,
+			Detail: fasthttp.StatusMessage(fasthttp.StatusUnprocessableEntity),
+		}, fasthttp.StatusUnprocessableEntity)
+		return
+	}
+
+	if postState.State != database.Duplicate {
+		postState.DuplicateOfID = zero.Int{}
+	} else if !postState.DuplicateOfID.Valid || postState.DuplicateOfID.Int64 == postID ||
+		!c.IsDuplicateTarget(postState.DuplicateOfID.Int64) {
+		c.JSONResponse(ctx, model2.ResponseError{
+			Errors: map[string]string{"duplicate_of_id": "is not valid"},
+			Detail: fasthttp.StatusMessage(fasthttp.StatusUnprocessableEntity),
+		}, fasthttp.StatusUnprocessableEntity)
+		return
+	}
+
+	err = c.GetDB().Insert(new(model.PostState), postState, "id", "inserted_at")
+	if errs, err := database.ValidateConstraint(err, postState); err != nil {
+		c.JSONResponse(ctx, model2.ResponseError{
+			Errors: errs,
+			Detail: fasthttp.StatusMessage(fasthttp.StatusUnprocessableEntity),
+		}, fasthttp.StatusUnprocessableEntity)
+		return
+	}
+
+	post := PostPolicy{API: c.API}.GetPost(ctx)
+	notification := model.NewModerationNotification(post.AuthorID, string(postState.State), postID, zero.Int{})
+	notification.ActorID.SetValid(c.GetAuthContext(ctx).ID)
+	tasks.Notify(c.App, notification)
+
+	c.JSONResponse(ctx, model2.ResponseSuccessOne{
+		Data: postState,
+	}, fasthttp.StatusCreated)
+}
+
+// IsDuplicateTarget post is published, not removed and not closed as a
+// duplicate, so readers of its duplicates can be sent to it
+func (c PostStateController) IsDuplicateTarget(postID int64) bool {
+	var post model.Post
+	var target bool
+	c.GetDB().DB.Get(&target, fmt.Sprintf(`
+		SELECT p.status = $2 AND p.deleted_at IS NULL AND COALESCE((
+			SELECT pst.state FROM %s AS pst WHERE pst.post_id = p.id ORDER BY pst.id DESC LIMIT 1
+		), 'open') != $3
+		FROM %s AS p WHERE p.id = $1
+	`, c.Model.TableName(), post.TableName()),
+		postID,
+		database.Published,
+		database.Duplicate)
+
+	return target
+}
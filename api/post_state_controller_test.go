@@ -0,0 +1,275 @@
+package api
+
+import (
+	"fmt"
+	"forgolang_forum/database"
+	"forgolang_forum/database/model"
+	"forgolang_forum/tasks"
+	"github.com/gosimple/slug"
+	"github.com/valyala/fasthttp"
+	"testing"
+)
+
+type PostStateControllerTest struct {
+	*Suite
+}
+
+func (s PostStateControllerTest) SetupSuite() {
+	SetupSuite(s.Suite)
+	UserAuth(s.Suite)
+}
+
+func (s PostStateControllerTest) post(categoryID int64) *model.Post {
+	post := model.NewPost(s.Auth.User.ID)
+	err := s.API.GetDB().Insert(new(model.Post), post, "id")
+	s.Nil(err)
+	postDetail := model.NewPostDetail(post.ID, s.Auth.User.ID)
+	postDetail.Title = "Thread"
+	postDetail.Content = "Thread Context"
+	err = s.API.GetDB().Insert(new(model.PostDetail), postDetail, "id")
+	s.Nil(err)
+	postCategoryAssignment := model.NewPostCategoryAssignment(post.ID, categoryID, s.Auth.User.ID)
+	err = s.API.GetDB().Insert(new(model.PostCategoryAssignment), postCategoryAssignment, "id")
+	s.Nil(err)
+
+	return post
+}
+
+func (s PostStateControllerTest) Test_ChangePostStateAndListHistory() {
+	category := model.NewCategory()
+	category.Title = "Threads 1"
+	category.Slug = slug.Make(category.Title)
+	err := s.API.GetDB().Insert(new(model.Category), category, "id")
+	s.Nil(err)
+	post := s.post(category.ID)
+
+	postState := model.NewPostState(post.ID)
+	postState.State = database.Locked
+	postState.Reason.SetValid("Off topic")
+
+	response := s.JSON(Post, fmt.Sprintf("/api/v1/post/%d/state", post.ID), postState)
+
+	s.Equal(response.Status, fasthttp.StatusCreated)
+	data, _ := response.Success.Data.(map[string]interface{})
+	s.Equal(data["state"], "locked")
+	s.Equal(data["source_user_id"], float64(s.Auth.User.ID))
+
+	postState.State = database.Archived
+	response = s.JSON(Post, fmt.Sprintf("/api/v1/post/%d/state", post.ID), postState)
+
+	s.Equal(response.Status, fasthttp.StatusCreated)
+
+	response = s.JSON(Get, fmt.Sprintf("/api/v1/post/%d/state", post.ID), nil)
+
+	s.Equal(response.Status, fasthttp.StatusOK)
+	s.Equal(response.Success.TotalCount, int64(2))
+	states, _ := response.Success.Data.([]interface{})
+	s.Equal(states[0].(map[string]interface{})["state"], "archived")
+	s.Equal(states[1].(map[string]interface{})["state"], "locked")
+
+	response = s.JSON(Get, fmt.Sprintf("/api/v1/category/%d/post/%d", category.ID, post.ID), nil)
+
+	s.Equal(response.Status, fasthttp.StatusOK)
+	data, _ = response.Success.Data.(map[string]interface{})
+	s.Equal(data["state"], "archived")
+
+	defaultLogger.LogInfo("Change post state and list history")
+}
+
+func (s PostStateControllerTest) Test_Should_422Err_ChangePostStateWithInvalidState() {
+	category := model.NewCategory()
+	category.Title = "Threads 2"
+	category.Slug = slug.Make(category.Title)
+	err := s.API.GetDB().Insert(new(model.Category), category, "id")
+	s.Nil(err)
+	post := s.post(category.ID)
+
+	response := s.JSON(Post, fmt.Sprintf("/api/v1/post/%d/state", post.ID), map[string]string{
+		"state": "frozen",
+	})
+
+	s.Equal(response.Status, fasthttp.StatusUnprocessableEntity)
+
+	defaultLogger.LogInfo("Should be 422 error change post state with invalid state")
+}
+
+func (s PostStateControllerTest) Test_MarkPostAsDuplicateAndRedirectReaders() {
+	category := model.NewCategory()
+	category.Title = "Threads duplicate"
+	category.Slug = slug.Make(category.Title)
+	err := s.API.GetDB().Insert(new(model.Category), category, "id")
+	s.Nil(err)
+	original := s.post(category.ID)
+	post := s.post(category.ID)
+
+	postState := model.NewPostState(post.ID)
+	postState.State = database.Duplicate
+	response := s.JSON(Post, fmt.Sprintf("/api/v1/post/%d/state", post.ID), postState)
+	s.Equal(response.Status, fasthttp.StatusUnprocessableEntity)
+
+	postState.DuplicateOfID.SetValid(post.ID)
+	response = s.JSON(Post, fmt.Sprintf("/api/v1/post/%d/state", post.ID), postState)
+	s.Equal(response.Status, fasthttp.StatusUnprocessableEntity)
+
+	postState.DuplicateOfID.SetValid(original.ID)
+	response = s.JSON(Post, fmt.Sprintf("/api/v1/post/%d/state", post.ID), postState)
+	s.Equal(response.Status, fasthttp.StatusCreated)
+	data, _ := response.Success.Data.(map[string]interface{})
+	s.Equal(data["duplicate_of_id"], float64(original.ID))
+
+	// duplicates are not targets of other duplicates
+	postState = model.NewPostState(original.ID)
+	postState.State = database.Duplicate
+	postState.DuplicateOfID.SetValid(post.ID)
+	response = s.JSON(Post, fmt.Sprintf("/api/v1/post/%d/state", original.ID), postState)
+	s.Equal(response.Status, fasthttp.StatusUnprocessableEntity)
+
+	UserAuth(s.Suite, "user")
+
+	response = s.JSON(Get, fmt.Sprintf("/api/v1/category/%d/post/%d", category.ID, post.ID), nil)
+	s.Equal(response.Status, fasthttp.StatusOK)
+	data, _ = response.Success.Data.(map[string]interface{})
+	s.Equal(data["state"], "duplicate")
+	s.Equal(data["duplicate_of_id"], float64(original.ID))
+	s.Equal(data["redirect_to"], fmt.Sprintf("%s/post/%d", s.API.App.Config.UIHost, original.ID))
+
+	response = s.JSON(Post, fmt.Sprintf("/api/v1/post/%d/comment", post.ID), nil)
+	s.Equal(response.Status, fasthttp.StatusForbidden)
+
+	UserAuth(s.Suite)
+
+	defaultLogger.LogInfo("Mark post as duplicate and redirect readers")
+}
+
+func (s PostStateControllerTest) Test_ListPinnedPostsFirst() {
+	category := model.NewCategory()
+	category.Title = "Threads 3"
+	category.Slug = slug.Make(category.Title)
+	err := s.API.GetDB().Insert(new(model.Category), category, "id")
+	s.Nil(err)
+
+	var posts []*model.Post
+	for i := 0; i < 3; i++ {
+		posts = append(posts, s.post(category.ID))
+	}
+
+	postState := model.NewPostState(posts[0].ID)
+	postState.PinnedInCategory = true
+	response := s.JSON(Post, fmt.Sprintf("/api/v1/post/%d/state", posts[0].ID), postState)
+
+	s.Equal(response.Status, fasthttp.StatusCreated)
+
+	postState = model.NewPostState(posts[1].ID)
+	postState.PinnedGlobally = true
+	response = s.JSON(Post, fmt.Sprintf("/api/v1/post/%d/state", posts[1].ID), postState)
+
+	s.Equal(response.Status, fasthttp.StatusCreated)
+
+	response = s.JSON(Get, fmt.Sprintf("/api/v1/category/%d/post?order_field=inserted_at&order_by=desc", category.ID), nil)
+
+	s.Equal(response.Status, fasthttp.StatusOK)
+	data, _ := response.Success.Data.([]interface{})
+	s.Equal(data[0].(map[string]interface{})["id"], float64(posts[1].ID))
+	s.Equal(data[0].(map[string]interface{})["pinned_globally"], true)
+	s.Equal(data[1].(map[string]interface{})["id"], float64(posts[0].ID))
+	s.Equal(data[1].(map[string]interface{})["pinned_in_category"], true)
+	s.Equal(data[2].(map[string]interface{})["id"], float64(posts[2].ID))
+	s.Equal(data[2].(map[string]interface{})["state"], "open")
+
+	response = s.JSON(Get, "/api/v1/post/pinned", nil)
+
+	s.Equal(response.Status, fasthttp.StatusOK)
+	found := false
+	for _, p := range response.Success.Data.([]interface{}) {
+		if p.(map[string]interface{})["id"] == float64(posts[1].ID) {
+			found = true
+		}
+		s.NotEqual(p.(map[string]interface{})["id"], float64(posts[0].ID))
+	}
+	s.True(found)
+
+	defaultLogger.LogInfo("List pinned posts first")
+}
+
+func (s PostStateControllerTest) Test_KeepPinsWhenStateChanges() {
+	category := model.NewCategory()
+	category.Title = "Threads 5"
+	category.Slug = slug.Make(category.Title)
+	err := s.API.GetDB().Insert(new(model.Category), category, "id")
+	s.Nil(err)
+
+	post := s.post(category.ID)
+
+	postState := model.NewPostState(post.ID)
+	postState.PinnedInCategory = true
+	postState.PinOrder = 2
+	response := s.JSON(Post, fmt.Sprintf("/api/v1/post/%d/state", post.ID), postState)
+
+	s.Equal(response.Status, fasthttp.StatusCreated)
+
+	response = s.JSON(Post, fmt.Sprintf("/api/v1/post/%d/state", post.ID), map[string]interface{}{
+		"state": database.Closed,
+	})
+
+	s.Equal(response.Status, fasthttp.StatusCreated)
+	data, _ := response.Success.Data.(map[string]interface{})
+	s.Equal(data["state"], "closed")
+	s.Equal(data["pinned_in_category"], true)
+	s.Equal(data["pin_order"], float64(2))
+
+	response = s.JSON(Post, fmt.Sprintf("/api/v1/post/%d/state", post.ID), map[string]interface{}{
+		"state":              database.Open,
+		"pinned_in_category": false,
+	})
+
+	s.Equal(response.Status, fasthttp.StatusCreated)
+	data, _ = response.Success.Data.(map[string]interface{})
+	s.Equal(data["pinned_in_category"], false)
+
+	defaultLogger.LogInfo("Keep pins when the state of a post changes")
+}
+
+func (s PostStateControllerTest) Test_ListPinnedPostsFirstInRankings() {
+	category := model.NewCategory()
+	category.Title = "Threads 4"
+	category.Slug = slug.Make(category.Title)
+	err := s.API.GetDB().Insert(new(model.Category), category, "id")
+	s.Nil(err)
+
+	var posts []*model.Post
+	for i := 0; i < 3; i++ {
+		posts = append(posts, s.post(category.ID))
+	}
+
+	postState := model.NewPostState(posts[0].ID)
+	postState.PinnedInCategory = true
+	response := s.JSON(Post, fmt.Sprintf("/api/v1/post/%d/state", posts[0].ID), postState)
+
+	s.Equal(response.Status, fasthttp.StatusCreated)
+
+	err = tasks.RefreshPostRankings(s.API.App, nil)
+	s.Nil(err)
+
+	response = s.JSON(Get, fmt.Sprintf("/api/v1/category/%d/post?order_field=active&limit=2", category.ID), nil)
+
+	s.Equal(response.Status, fasthttp.StatusOK)
+	s.Equal(response.Success.TotalCount, int64(3))
+	data, _ := response.Success.Data.([]interface{})
+	s.Equal(len(data), 2)
+	s.Equal(data[0].(map[string]interface{})["id"], float64(posts[0].ID))
+	s.Equal(data[1].(map[string]interface{})["id"], float64(posts[2].ID))
+
+	response = s.JSON(Get, fmt.Sprintf("/api/v1/category/%d/post?order_field=active&limit=2&offset=2", category.ID), nil)
+
+	s.Equal(response.Status, fasthttp.StatusOK)
+	data, _ = response.Success.Data.([]interface{})
+	s.Equal(len(data), 1)
+	s.Equal(data[0].(map[string]interface{})["id"], float64(posts[1].ID))
+
+	defaultLogger.LogInfo("List pinned posts first in rankings")
+}
+
+func Test_PostStateController(t *testing.T) {
+	s := PostStateControllerTest{NewSuite()}
+	Run(t, s)
+}
@@ -0,0 +1,121 @@
+// Copyright 2019 StreetByters Community
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with
+// this work for additional information regarding copyright ownership.
+// The ASF licenses this file to You under the Apache License, Version 2.0
+// (the "License"); you may not use this file except in compliance with
+// the License.  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"fmt"
+	"forgolang_forum/database"
+	"forgolang_forum/database/model"
+	model2 "forgolang_forum/model"
+	"forgolang_forum/tasks"
+	"github.com/fate-lovely/phi"
+	"github.com/valyala/fasthttp"
+	"strconv"
+)
+
+// PostTagController post tags api controller
+type PostTagController struct {
+	Controller
+	*API
+	Model model.PostTag
+}
+
+// Create attach a tag to a post
+func (c PostTagController) Create(ctx *fasthttp.RequestCtx) {
+	postID, err := strconv.ParseInt(phi.URLParam(ctx, "postID"), 10, 64)
+	if err != nil {
+		c.JSONResponse(ctx, model2.ResponseError{
+			Detail: fasthttp.StatusMessage(fasthttp.StatusBadRequest),
+		}, fasthttp.StatusBadRequest)
+		return
+	}
+
+	postTag := model.NewPostTag(postID)
+	c.JSONBody(ctx, &postTag)
+	postTag.PostID = postID
+	postTag.SourceUserID.SetValid(c.GetAuthContext(ctx).ID)
+
+	// synonyms are attached as their canonical tags
+	var tag model.Tag
+	c.GetDB().DB.Get(&postTag.TagID, fmt.Sprintf(`
+		SELECT COALESCE(t.synonym_of_id, t.id) FROM %s AS t WHERE t.id = $1
+	`, tag.TableName()),
+		postTag.TagID)
+
+	if errs, err := database.ValidateStruct(postTag); err != nil {
+		c.JSONResponse(ctx, model2.ResponseError{
+			Errors: errs,
+			Detail: fasthttp.StatusMessage(fasthttp.StatusUnprocessableEntity),
+		}, fasthttp.StatusUnprocessableEntity)
+		return
+	}
+
+	err = c.GetDB().Insert(new(model.PostTag), postTag, "id", "inserted_at")
+	if errs, err := database.ValidateConstraint(err, postTag); err != nil {
+		c.JSONResponse(ctx, model2.ResponseError{
+			Errors: errs,
+			Detail: fasthttp.StatusMessage(fasthttp.StatusUnprocessableEntity),
+		}, fasthttp.StatusUnprocessableEntity)
+		return
+	}
+
+	TagController{API: c.API}.IncrCount(1, postTag.TagID)
+	c.reindex(postID)
+	go tasks.FanOutPost(c.App, postID)
+
+	c.JSONResponse(ctx, model2.ResponseSuccessOne{
+		Data: postTag,
+	}, fasthttp.StatusCreated)
+}
+
+// Delete detach a tag from a post
+func (c PostTagController) Delete(ctx *fasthttp.RequestCtx) {
+	tagID, err := strconv.ParseInt(phi.URLParam(ctx, "tagID"), 10, 64)
+	if err != nil {
+		c.JSONResponse(ctx, model2.ResponseError{
+			Detail: fasthttp.StatusMessage(fasthttp.StatusBadRequest),
+		}, fasthttp.StatusBadRequest)
+		return
+	}
+
+	c.GetDB().Delete(c.Model.TableName(), "post_id = $1 AND tag_id = $2",
+		phi.URLParam(ctx, "postID"),
+		tagID).Force()
+
+	TagController{API: c.API}.IncrCount(-1, tagID)
+	if postID, err := strconv.ParseInt(phi.URLParam(ctx, "postID"), 10, 64); err == nil {
+		c.reindex(postID)
+	}
+
+	c.JSONResponse(ctx, nil, fasthttp.StatusNoContent)
+}
+
+// reindex index the tags of a listed post again, drafts and removed posts
+// are not searchable
+func (c PostTagController) reindex(postID int64) {
+	var post model.Post
+	var listed bool
+	c.GetDB().DB.Get(&listed, fmt.Sprintf(`
+		SELECT EXISTS (
+			SELECT 1 FROM %s AS p WHERE p.id = $1 AND p.status = '%s' AND p.deleted_at IS NULL
+		)
+	`, post.TableName(), database.Published),
+		postID)
+	if listed {
+		tasks.IndexPost(c.App, postID)
+	}
+}
@@ -0,0 +1,117 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"forgolang_forum/database/model"
+	"github.com/valyala/fasthttp"
+	"strconv"
+	"testing"
+)
+
+type PostTagControllerTest struct {
+	*Suite
+}
+
+func (s PostTagControllerTest) SetupSuite() {
+	SetupSuite(s.Suite)
+	UserAuth(s.Suite)
+}
+
+func (s PostTagControllerTest) Test_AttachAndDetachPostTag() {
+	post := model.NewPost(s.Auth.User.ID)
+	err := s.API.GetDB().Insert(new(model.Post), post, "id")
+	s.Nil(err)
+
+	tag := model.NewTag()
+	tag.Name = "attach-tag"
+	err = s.API.GetDB().Insert(new(model.Tag), tag, "id", "inserted_at", "updated_at")
+	s.Nil(err)
+
+	postTag := model.NewPostTag(post.ID)
+	postTag.TagID = tag.ID
+
+	response := s.JSON(Post, fmt.Sprintf("/api/v1/post/%d/tag", post.ID), postTag)
+
+	s.Equal(response.Status, fasthttp.StatusCreated)
+	data, _ := response.Success.Data.(map[string]interface{})
+	s.Equal(data["post_id"], float64(post.ID))
+	s.Equal(data["tag_id"], float64(tag.ID))
+	s.Equal(data["source_user_id"], float64(s.Auth.User.ID))
+
+	response = s.JSON(Post, fmt.Sprintf("/api/v1/post/%d/tag", post.ID), postTag)
+
+	s.Equal(response.Status, fasthttp.StatusUnprocessableEntity)
+
+	response = s.JSON(Delete, fmt.Sprintf("/api/v1/post/%d/tag/%d", post.ID, tag.ID), nil)
+
+	s.Equal(response.Status, fasthttp.StatusNoContent)
+
+	defaultLogger.LogInfo("Attach and detach post tag")
+}
+
+func (s PostTagControllerTest) Test_ReindexPostTagsOnAttachAndDetach() {
+	post := model.NewPost(s.Auth.User.ID)
+	err := s.API.GetDB().Insert(new(model.Post), post, "id")
+	s.Nil(err)
+	postDetail := model.NewPostDetail(post.ID, s.Auth.User.ID)
+	postDetail.Title = "Reindexed tags"
+	err = s.API.GetDB().Insert(new(model.PostDetail), postDetail, "id")
+	s.Nil(err)
+
+	tag := model.NewTag()
+	tag.Name = "reindex-tag"
+	err = s.API.GetDB().Insert(new(model.Tag), tag, "id", "inserted_at", "updated_at")
+	s.Nil(err)
+
+	postTag := model.NewPostTag(post.ID)
+	postTag.TagID = tag.ID
+
+	response := s.JSON(Post, fmt.Sprintf("/api/v1/post/%d/tag", post.ID), postTag)
+
+	s.Equal(response.Status, fasthttp.StatusCreated)
+	s.Equal(s.indexedTags(post.ID), []string{"reindex-tag"})
+
+	response = s.JSON(Delete, fmt.Sprintf("/api/v1/post/%d/tag/%d", post.ID, tag.ID), nil)
+
+	s.Equal(response.Status, fasthttp.StatusNoContent)
+	s.Empty(s.indexedTags(post.ID))
+
+	defaultLogger.LogInfo("Reindex post tags on attach and detach")
+}
+
+// indexedTags tags of the post in the search index
+func (s PostTagControllerTest) indexedTags(postID int64) []string {
+	result, err := s.API.App.ElasticClient.Get().
+		Index("posts").
+		Id(strconv.FormatInt(postID, 10)).
+		Do(context.TODO())
+	s.Nil(err)
+
+	var post model.PostDEP
+	s.Nil(json.Unmarshal(result.Source, &post))
+
+	return []string(post.Tags)
+}
+
+func (s PostTagControllerTest) Test_Should_404Err_DetachPostTagIfNotExists() {
+	post := model.NewPost(s.Auth.User.ID)
+	err := s.API.GetDB().Insert(new(model.Post), post, "id")
+	s.Nil(err)
+
+	response := s.JSON(Delete, fmt.Sprintf("/api/v1/post/%d/tag/999999999", post.ID), nil)
+
+	s.Equal(response.Status, fasthttp.StatusNotFound)
+
+	defaultLogger.LogInfo("Should be 404 error detach post tag if does not exists")
+}
+
+func (s PostTagControllerTest) TearDownSuite() {
+	TearDownSuite(s.Suite)
+}
+
+func Test_PostTagController(t *testing.T) {
+	s := PostTagControllerTest{NewSuite()}
+	Run(t, s)
+}
@@ -0,0 +1,241 @@
+// Copyright 2019 StreetByters Community
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with
+// this work for additional information regarding copyright ownership.
+// The ASF licenses this file to You under the Apache License, Version 2.0
+// (the "License"); you may not use this file except in compliance with
+// the License.  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"fmt"
+	"forgolang_forum/cmn"
+	"forgolang_forum/database"
+	"forgolang_forum/database/model"
+	model2 "forgolang_forum/model"
+	"forgolang_forum/tasks"
+	"github.com/fate-lovely/phi"
+	"github.com/go-redis/redis"
+	"github.com/lib/pq"
+	"github.com/valyala/fasthttp"
+	"gopkg.in/guregu/null.v3/zero"
+	"strconv"
+)
+
+// PostVoteController post votes api controller
+type PostVoteController struct {
+	Controller
+	*API
+}
+
+// Create upvote or downvote a post. A vote in the other direction is
+// removed in the same statement.
+func (c PostVoteController) Create(ctx *fasthttp.RequestCtx) {
+	postID, err := strconv.ParseInt(phi.URLParam(ctx, "postID"), 10, 64)
+	if err != nil {
+		c.JSONResponse(ctx, model2.ResponseError{
+			Detail: fasthttp.StatusMessage(fasthttp.StatusBadRequest),
+		}, fasthttp.StatusBadRequest)
+		return
+	}
+
+	var vote, other database.DBInterface
+	voteResponse := new(model2.VoteResponse)
+	voteResponse.PostID = postID
+	switch database.Vote(phi.URLParam(ctx, "direction")) {
+	case database.VoteUp:
+		vote = model.NewPostVotesUp(postID, c.GetAuthContext(ctx).ID)
+		other = model.NewPostVotesDown(postID, c.GetAuthContext(ctx).ID)
+		voteResponse.Vote = 1
+	case database.VoteDown:
+		vote = model.NewPostVotesDown(postID, c.GetAuthContext(ctx).ID)
+		other = model.NewPostVotesUp(postID, c.GetAuthContext(ctx).ID)
+		voteResponse.Vote = -1
+	default:
+		c.JSONResponse(ctx, model2.ResponseError{
+			Errors: map[string]string{
+				"direction": "is not valid",
+			},
+			Detail: fasthttp.StatusMessage(fasthttp.StatusBadRequest),
+		}, fasthttp.StatusBadRequest)
+		return
+	}
+
+	result, err := c.GetDB().DB.Exec(fmt.Sprintf(`
+		WITH other AS (
+			DELETE FROM %s WHERE post_id = $1 AND user_id = $2
+		)
+		INSERT INTO %s (post_id, user_id) VALUES ($1, $2)
+		ON CONFLICT (post_id, user_id) DO NOTHING
+	`, other.TableName(), vote.TableName()),
+		postID,
+		c.GetAuthContext(ctx).ID)
+	if errs, err := database.ValidateConstraint(err, vote); err != nil {
+		c.JSONResponse(ctx, model2.ResponseError{
+			Errors: errs,
+			Detail: fasthttp.StatusMessage(fasthttp.StatusUnprocessableEntity),
+		}, fasthttp.StatusUnprocessableEntity)
+		return
+	}
+
+	voteResponse.Score = c.RefreshScore(postID)
+	go tasks.FanOutPost(c.App, postID)
+
+	post := PostPolicy{API: c.API}.GetPost(ctx)
+	tasks.SyncVoteReputation(c.App, post.AuthorID, c.GetAuthContext(ctx).ID, postID, zero.Int{},
+		voteResponse.Vote)
+
+	// only new upvotes are notified, voting again does not notify
+	if n, _ := result.RowsAffected(); n > 0 && voteResponse.Vote > 0 {
+		notification := model.NewNotification(post.AuthorID, database.VoteNotification, postID, zero.Int{})
+		notification.ActorID.SetValid(c.GetAuthContext(ctx).ID)
+		tasks.Notify(c.App, notification)
+	}
+
+	c.JSONResponse(ctx, model2.ResponseSuccessOne{
+		Data: voteResponse,
+	}, fasthttp.StatusCreated)
+}
+
+// Delete retract the vote of the user on a post
+func (c PostVoteController) Delete(ctx *fasthttp.RequestCtx) {
+	postID, err := strconv.ParseInt(phi.URLParam(ctx, "postID"), 10, 64)
+	if err != nil {
+		c.JSONResponse(ctx, model2.ResponseError{
+			Detail: fasthttp.StatusMessage(fasthttp.StatusBadRequest),
+		}, fasthttp.StatusBadRequest)
+		return
+	}
+
+	var votesUp model.PostVotesUp
+	var votesDown model.PostVotesDown
+	c.GetDB().DB.Exec(fmt.Sprintf(`
+		WITH up AS (
+			DELETE FROM %s WHERE post_id = $1 AND user_id = $2
+		)
+		DELETE FROM %s WHERE post_id = $1 AND user_id = $2
+	`, votesUp.TableName(), votesDown.TableName()),
+		postID,
+		c.GetAuthContext(ctx).ID)
+
+	c.RefreshScore(postID)
+	go tasks.FanOutPost(c.App, postID)
+
+	post := PostPolicy{API: c.API}.GetPost(ctx)
+	tasks.SyncVoteReputation(c.App, post.AuthorID, c.GetAuthContext(ctx).ID, postID, zero.Int{}, 0)
+
+	c.JSONResponse(ctx, nil, fasthttp.StatusNoContent)
+}
+
+// GetScore cached post score
+func (c PostVoteController) GetScore(postID int64) int64 {
+	score, err := c.GetCache().ZScore(cmn.GetRedisKey("post", "scores"),
+		strconv.FormatInt(postID, 10)).Result()
+	if err == redis.Nil {
+		return c.RefreshScore(postID)
+	}
+
+	return int64(score)
+}
+
+// GetScores cached scores of posts in a single round trip, scores which
+// are not cached yet are counted and cached
+func (c PostVoteController) GetScores(postIDs []int64) map[int64]int64 {
+	pipe := c.GetCache().Pipeline()
+	cmds := make([]*redis.FloatCmd, len(postIDs))
+	for i, postID := range postIDs {
+		cmds[i] = pipe.ZScore(cmn.GetRedisKey("post", "scores"), strconv.FormatInt(postID, 10))
+	}
+	pipe.Exec()
+
+	scores := make(map[int64]int64)
+	for i, postID := range postIDs {
+		score, err := cmds[i].Result()
+		if err != nil {
+			scores[postID] = c.RefreshScore(postID)
+			continue
+		}
+		scores[postID] = int64(score)
+	}
+
+	return scores
+}
+
+// RefreshScore count post votes, store the score on the post for sorted
+// listings and cache it
+func (c PostVoteController) RefreshScore(postID int64) int64 {
+	var votesUp model.PostVotesUp
+	var votesDown model.PostVotesDown
+	var post model.Post
+
+	var score int64
+	c.GetDB().DB.Get(&score, fmt.Sprintf(`
+		UPDATE %s SET score =
+			(SELECT count(v.id) FROM %s AS v WHERE v.post_id = $1) -
+			(SELECT count(v.id) FROM %s AS v WHERE v.post_id = $1)
+		WHERE id = $1
+		RETURNING score
+	`, post.TableName(), votesUp.TableName(), votesDown.TableName()),
+		postID)
+
+	c.GetCache().ZAdd(cmn.GetRedisKey("post", "scores"), redis.Z{
+		Score:  float64(score),
+		Member: strconv.FormatInt(postID, 10),
+	})
+
+	return score
+}
+
+// GetVote the vote of the user on a post, 1 for up, -1 for down and
+// 0 if the user has not voted
+func (c PostVoteController) GetVote(postID int64, userID int64) int64 {
+	var votesUp model.PostVotesUp
+	var votesDown model.PostVotesDown
+
+	var vote int64
+	c.GetDB().DB.Get(&vote, fmt.Sprintf(`
+		SELECT COALESCE(
+			(SELECT 1 FROM %s AS v WHERE v.post_id = $1 AND v.user_id = $2),
+			(SELECT -1 FROM %s AS v WHERE v.post_id = $1 AND v.user_id = $2),
+			0)
+	`, votesUp.TableName(), votesDown.TableName()),
+		postID,
+		userID)
+
+	return vote
+}
+
+// GetVotes votes of the user on posts, posts the user has not voted on are
+// left out
+func (c PostVoteController) GetVotes(postIDs []int64, userID int64) map[int64]int64 {
+	var votesUp model.PostVotesUp
+	var votesDown model.PostVotesDown
+
+	var rows []struct {
+		PostID int64 `db:"post_id"`
+		Vote   int64 `db:"vote"`
+	}
+	c.GetDB().DB.Select(&rows, fmt.Sprintf(`
+		SELECT v.post_id, 1 as vote FROM %s AS v WHERE v.post_id = ANY($1) AND v.user_id = $2
+		UNION ALL
+		SELECT v.post_id, -1 as vote FROM %s AS v WHERE v.post_id = ANY($1) AND v.user_id = $2
+	`, votesUp.TableName(), votesDown.TableName()),
+		pq.Array(postIDs),
+		userID)
+
+	votes := make(map[int64]int64)
+	for _, r := range rows {
+		votes[r.PostID] = r.Vote
+	}
+
+	return votes
+}
@@ -0,0 +1,114 @@
+package api
+
+import (
+	"fmt"
+	"forgolang_forum/cmn"
+	"forgolang_forum/database/model"
+	"github.com/valyala/fasthttp"
+	"strconv"
+	"testing"
+)
+
+type PostVoteControllerTest struct {
+	*Suite
+}
+
+func (s PostVoteControllerTest) SetupSuite() {
+	SetupSuite(s.Suite)
+	UserAuth(s.Suite)
+}
+
+func (s PostVoteControllerTest) Test_UpvotePost() {
+	post := model.NewPost(s.Auth.User.ID)
+	err := s.API.GetDB().Insert(new(model.Post), post, "id")
+	s.Nil(err)
+
+	response := s.JSON(Post, fmt.Sprintf("/api/v1/post/%d/vote/up", post.ID), nil)
+
+	s.Equal(response.Status, fasthttp.StatusCreated)
+	data, _ := response.Success.Data.(map[string]interface{})
+	s.Equal(data["post_id"], float64(post.ID))
+	s.Equal(data["score"], float64(1))
+	s.Equal(data["vote"], float64(1))
+
+	score, _ := s.API.GetCache().ZScore(cmn.GetRedisKey("post", "scores"),
+		strconv.FormatInt(post.ID, 10)).Result()
+	s.Equal(score, float64(1))
+
+	defaultLogger.LogInfo("Upvote post")
+}
+
+func (s PostVoteControllerTest) Test_ChangeVoteOnPost() {
+	post := model.NewPost(s.Auth.User.ID)
+	err := s.API.GetDB().Insert(new(model.Post), post, "id")
+	s.Nil(err)
+
+	votesUp := model.NewPostVotesUp(post.ID, s.Auth.User.ID)
+	err = s.API.GetDB().Insert(new(model.PostVotesUp), votesUp, "id")
+	s.Nil(err)
+
+	response := s.JSON(Post, fmt.Sprintf("/api/v1/post/%d/vote/down", post.ID), nil)
+
+	s.Equal(response.Status, fasthttp.StatusCreated)
+	data, _ := response.Success.Data.(map[string]interface{})
+	s.Equal(data["score"], float64(-1))
+	s.Equal(data["vote"], float64(-1))
+
+	var count int64
+	s.API.GetDB().DB.Get(&count, fmt.Sprintf(`
+		SELECT count(v.id) FROM %s AS v WHERE v.post_id = $1
+	`, votesUp.TableName()),
+		post.ID)
+	s.Equal(count, int64(0))
+
+	defaultLogger.LogInfo("Change vote on post")
+}
+
+func (s PostVoteControllerTest) Test_RetractVoteOnPost() {
+	post := model.NewPost(s.Auth.User.ID)
+	err := s.API.GetDB().Insert(new(model.Post), post, "id")
+	s.Nil(err)
+
+	votesDown := model.NewPostVotesDown(post.ID, s.Auth.User.ID)
+	err = s.API.GetDB().Insert(new(model.PostVotesDown), votesDown, "id")
+	s.Nil(err)
+
+	response := s.JSON(Delete, fmt.Sprintf("/api/v1/post/%d/vote", post.ID), nil)
+
+	s.Equal(response.Status, fasthttp.StatusNoContent)
+
+	score, _ := s.API.GetCache().ZScore(cmn.GetRedisKey("post", "scores"),
+		strconv.FormatInt(post.ID, 10)).Result()
+	s.Equal(score, float64(0))
+
+	defaultLogger.LogInfo("Retract vote on post")
+}
+
+func (s PostVoteControllerTest) Test_Should_400Err_VotePostWithInvalidDirection() {
+	post := model.NewPost(s.Auth.User.ID)
+	err := s.API.GetDB().Insert(new(model.Post), post, "id")
+	s.Nil(err)
+
+	response := s.JSON(Post, fmt.Sprintf("/api/v1/post/%d/vote/sideways", post.ID), nil)
+
+	s.Equal(response.Status, fasthttp.StatusBadRequest)
+
+	defaultLogger.LogInfo("Should be 400 error vote post with invalid direction")
+}
+
+func (s PostVoteControllerTest) Test_Should_422Err_VotePostIfNotExists() {
+	response := s.JSON(Post, "/api/v1/post/999999999/vote/up", nil)
+
+	s.Equal(response.Status, fasthttp.StatusUnprocessableEntity)
+
+	defaultLogger.LogInfo("Should be 422 error vote post if does not exists")
+}
+
+func (s PostVoteControllerTest) TearDownSuite() {
+	TearDownSuite(s.Suite)
+}
+
+func Test_PostVoteController(t *testing.T) {
+	s := PostVoteControllerTest{NewSuite()}
+	Run(t, s)
+}
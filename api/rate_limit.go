@@ -0,0 +1,193 @@
+// Copyright 2019 StreetByters Community
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with
+// this work for additional information regarding copyright ownership.
+// The ASF licenses this file to You under the Apache License, Version 2.0
+// (the "License"); you may not use this file except in compliance with
+// the License.  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"context"
+	"fmt"
+	"forgolang_forum/cmn"
+	"forgolang_forum/database"
+	"github.com/fate-lovely/phi"
+	pluggableError "github.com/streetbyters/agente/errors"
+	"github.com/ulule/limiter/v3"
+	sredis "github.com/ulule/limiter/v3/drivers/store/redis"
+	"github.com/valyala/fasthttp"
+	"strconv"
+	"time"
+)
+
+// RateLimitPolicy formatted rates of an action for each trust level, levels
+// without a rate use the rate of the closest lower level. Moderators use the
+// moderator rate and are exempt when it is empty. Anonymous requests are
+// limited by their IP with the anonymous rate and are exempt when it is empty.
+type RateLimitPolicy struct {
+	Levels    map[database.TrustLevel]string
+	Moderator string
+	Anonymous string
+}
+
+// RateLimitPolicies rate limit policies of actions, routes apply them with
+// RateLimit.Apply
+var RateLimitPolicies = map[string]RateLimitPolicy{
+	"auth": {Anonymous: "10-M"},
+	"post": {Levels: map[database.TrustLevel]string{
+		database.NewUser: "5-H",
+		database.Basic:   "10-H",
+		database.Member:  "20-H",
+		database.Regular: "30-H",
+	}},
+	"comment": {Levels: map[database.TrustLevel]string{
+		database.NewUser: "10-H",
+		database.Basic:   "30-H",
+		database.Member:  "60-H",
+	}},
+	"edit": {Levels: map[database.TrustLevel]string{
+		database.NewUser: "20-H",
+		database.Basic:   "60-H",
+	}},
+	"vote": {Levels: map[database.TrustLevel]string{
+		database.NewUser: "30-H",
+		database.Basic:   "100-H",
+		database.Member:  "200-H",
+	}},
+	"flag": {Levels: map[database.TrustLevel]string{
+		database.NewUser: "5-H",
+		database.Basic:   "20-H",
+	}},
+	"upload": {Levels: map[database.TrustLevel]string{
+		database.NewUser: "10-H",
+		database.Member:  "30-H",
+	}, Moderator: "100-H"},
+}
+
+// RateLimit per user rate limit middleware
+type RateLimit struct {
+	*API
+	Store      limiter.Store
+	Limiters   map[string]map[database.TrustLevel]*limiter.Limiter
+	Moderators map[string]*limiter.Limiter
+	Anonymous  map[string]*limiter.Limiter
+}
+
+// NewRateLimit generate middleware with limiters of the rate limit policies
+func NewRateLimit(api *API) *RateLimit {
+	store, err := sredis.NewStoreWithOptions(api.App.Cache, limiter.StoreOptions{
+		Prefix:   cmn.GetRedisKey("rate_limit"),
+		MaxRetry: 4,
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	m := &RateLimit{API: api, Store: store}
+	m.Limiters = make(map[string]map[database.TrustLevel]*limiter.Limiter)
+	m.Moderators = make(map[string]*limiter.Limiter)
+	m.Anonymous = make(map[string]*limiter.Limiter)
+	for action, policy := range RateLimitPolicies {
+		m.Limiters[action] = make(map[database.TrustLevel]*limiter.Limiter)
+		for level, formatted := range policy.Levels {
+			m.Limiters[action][level] = m.newLimiter(formatted)
+		}
+		if policy.Moderator != "" {
+			m.Moderators[action] = m.newLimiter(policy.Moderator)
+		}
+		if policy.Anonymous != "" {
+			m.Anonymous[action] = m.newLimiter(policy.Anonymous)
+		}
+	}
+
+	return m
+}
+
+func (m *RateLimit) newLimiter(formatted string) *limiter.Limiter {
+	rate, err := limiter.NewRateFromFormatted(formatted)
+	if err != nil {
+		panic(err)
+	}
+	return limiter.New(m.Store, rate)
+}
+
+// Apply rate limit policy of the action keyed by the authenticated user, or
+// by the IP of anonymous requests
+func (m *RateLimit) Apply(action string) func(next phi.HandlerFunc) phi.HandlerFunc {
+	return func(next phi.HandlerFunc) phi.HandlerFunc {
+		return func(ctx *fasthttp.RequestCtx) {
+			l := m.GetLimiter(ctx, action)
+			if l == nil {
+				next(ctx)
+				return
+			}
+
+			var userID int64
+			key := fmt.Sprintf("%s:ip:%s", action, ctx.RemoteIP().String())
+			if authContext := m.GetOptionalAuthContext(ctx); authContext != nil {
+				userID = authContext.ID
+				key = fmt.Sprintf("%s:%d", action, userID)
+			}
+			limit, err := l.Get(context.Background(), key)
+			if err != nil {
+				m.App.Logger.LogError(err, "rate limit")
+				next(ctx)
+				return
+			}
+
+			reset := limit.Reset - time.Now().Unix()
+			if reset < 0 {
+				reset = 0
+			}
+			ctx.Response.Header.Set("RateLimit-Limit", strconv.FormatInt(limit.Limit, 10))
+			ctx.Response.Header.Set("RateLimit-Remaining", strconv.FormatInt(limit.Remaining, 10))
+			ctx.Response.Header.Set("RateLimit-Reset", strconv.FormatInt(reset, 10))
+
+			if limit.Reached {
+				m.App.Logger.Warn().
+					Str("action", action).
+					Int64("user_id", userID).
+					Str("ip", ctx.RemoteIP().String()).
+					Str("path", string(ctx.Path())).
+					Int64("limit", limit.Limit).
+					Msg("rate limit reached")
+
+				ctx.Response.Header.Set("Retry-After", strconv.FormatInt(reset, 10))
+				panic(pluggableError.New("rate limit reached",
+					fasthttp.StatusTooManyRequests,
+					fasthttp.StatusMessage(fasthttp.StatusTooManyRequests)))
+			}
+
+			next(ctx)
+		}
+	}
+}
+
+// GetLimiter limiter of the action for the current user, nil when the user
+// is exempt
+func (m *RateLimit) GetLimiter(ctx *fasthttp.RequestCtx, action string) *limiter.Limiter {
+	if m.GetOptionalAuthContext(ctx) == nil {
+		return m.Anonymous[action]
+	}
+	if m.IsModerator(ctx) {
+		return m.Moderators[action]
+	}
+
+	for level := m.GetTrustLevel(ctx); level >= database.NewUser; level-- {
+		if l, ok := m.Limiters[action][level]; ok {
+			return l
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,92 @@
+package api
+
+import (
+	"fmt"
+	"forgolang_forum/database"
+	"forgolang_forum/database/model"
+	model2 "forgolang_forum/model"
+	"github.com/ulule/limiter/v3"
+	"github.com/valyala/fasthttp"
+	"testing"
+)
+
+type RateLimitTest struct {
+	*Suite
+}
+
+func (s RateLimitTest) SetupSuite() {
+	SetupSuite(s.Suite)
+	UserAuth(s.Suite)
+}
+
+// flagUsers flag new users until the flag rate limit of a new user is
+// exceeded, it returns the status of the last request
+func (s RateLimitTest) flagUsers(typ string) int {
+	rate, err := limiter.NewRateFromFormatted(RateLimitPolicies["flag"].Levels[database.NewUser])
+	s.Nil(err)
+
+	var targetIDs []int64
+	for i := int64(0); i <= rate.Limit; i++ {
+		UserAuth(s.Suite, "user")
+		targetIDs = append(targetIDs, s.Auth.User.ID)
+	}
+
+	UserAuth(s.Suite, typ)
+
+	flag := new(model.Flag)
+	flag.Reason = database.Spam
+	var status int
+	for i, targetID := range targetIDs {
+		response := s.JSON(Post, fmt.Sprintf("/api/v1/user/%d/flag", targetID), flag)
+		status = response.Status
+		if i < len(targetIDs)-1 {
+			s.Equal(status, fasthttp.StatusCreated)
+		}
+	}
+
+	return status
+}
+
+func (s RateLimitTest) Test_Should_429Err_NewUserExceedsFlagRate() {
+	s.Equal(s.flagUsers("user"), fasthttp.StatusTooManyRequests)
+
+	UserAuth(s.Suite)
+
+	defaultLogger.LogInfo("Should be 429 error new user exceeds flag rate")
+}
+
+func (s RateLimitTest) Test_ModeratorIsExemptFromFlagRate() {
+	s.Equal(s.flagUsers("moderator"), fasthttp.StatusCreated)
+
+	UserAuth(s.Suite)
+
+	defaultLogger.LogInfo("Moderator is exempt from flag rate")
+}
+
+func (s RateLimitTest) Test_Should_429Err_AnonymousExceedsSignInRate() {
+	rate, err := limiter.NewRateFromFormatted(RateLimitPolicies["auth"].Anonymous)
+	s.Nil(err)
+
+	loginRequest := model2.LoginRequest{
+		ID:       "unknown",
+		Password: "wrong",
+	}
+	for i := int64(0); i < rate.Limit; i++ {
+		response := s.JSON(Post, "/api/v1/auth/sign_in", loginRequest)
+		s.NotEqual(response.Status, fasthttp.StatusTooManyRequests)
+	}
+
+	response := s.JSON(Post, "/api/v1/auth/sign_in", loginRequest)
+	s.Equal(response.Status, fasthttp.StatusTooManyRequests)
+
+	defaultLogger.LogInfo("Should be 429 error anonymous exceeds sign in rate")
+}
+
+func (s RateLimitTest) TearDownSuite() {
+	TearDownSuite(s.Suite)
+}
+
+func Test_RateLimit(t *testing.T) {
+	s := RateLimitTest{NewSuite()}
+	Run(t, s)
+}
@@ -0,0 +1,111 @@
+// Copyright 2019 StreetByters Community
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with
+// this work for additional information regarding copyright ownership.
+// The ASF licenses this file to You under the Apache License, Version 2.0
+// (the "License"); you may not use this file except in compliance with
+// the License.  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"fmt"
+	"forgolang_forum/database"
+	"forgolang_forum/database/model"
+	model2 "forgolang_forum/model"
+	"forgolang_forum/tasks"
+	"github.com/fate-lovely/phi"
+	"github.com/valyala/fasthttp"
+	"gopkg.in/guregu/null.v3/zero"
+	"strconv"
+)
+
+// ReputationController reputation ledger of users api controller
+type ReputationController struct {
+	Controller
+	*API
+	Model model.ReputationEvent
+}
+
+// Index list reputation ledger entries of a user
+func (c ReputationController) Index(ctx *fasthttp.RequestCtx) {
+	paginate, _, _ := c.Paginate(ctx, "id", "inserted_at")
+
+	var reputationEvents []model.ReputationEvent
+	c.GetDB().QueryWithModel(fmt.Sprintf(`
+		SELECT r.* FROM %s AS r WHERE r.user_id::text = $1::text
+		ORDER BY r.%s %s
+		LIMIT $2 OFFSET $3
+	`, c.Model.TableName(), paginate.OrderField, paginate.OrderBy),
+		&reputationEvents,
+		phi.URLParam(ctx, "userID"),
+		paginate.Limit,
+		paginate.Offset)
+
+	var count int64
+	c.GetDB().DB.Get(&count, fmt.Sprintf(`
+		SELECT count(r.id) FROM %s AS r WHERE r.user_id::text = $1::text
+	`, c.Model.TableName()),
+		phi.URLParam(ctx, "userID"))
+
+	c.JSONResponse(ctx, model2.ResponseSuccess{
+		Data:       reputationEvents,
+		TotalCount: count,
+	}, fasthttp.StatusOK)
+}
+
+// Create deduct reputation of a user as a moderator penalty
+func (c ReputationController) Create(ctx *fasthttp.RequestCtx) {
+	userID, err := strconv.ParseInt(phi.URLParam(ctx, "userID"), 10, 64)
+	if err != nil {
+		c.JSONResponse(ctx, model2.ResponseError{
+			Detail: fasthttp.StatusMessage(fasthttp.StatusBadRequest),
+		}, fasthttp.StatusBadRequest)
+		return
+	}
+
+	reputationEvent := model.NewReputationEvent(userID, database.Penalty)
+	c.JSONBody(ctx, &reputationEvent)
+	reputationEvent.UserID = userID
+	reputationEvent.Reason = database.Penalty
+	reputationEvent.PostID = zero.Int{}
+	reputationEvent.CommentID = zero.Int{}
+	reputationEvent.SourceUserID.SetValid(c.GetAuthContext(ctx).ID)
+	reputationEvent.Note.SetValid(c.App.TextPolicy.Sanitize(reputationEvent.Note.String))
+
+	errs, err := database.ValidateStruct(reputationEvent)
+	if reputationEvent.Amount >= 0 {
+		if errs == nil {
+			errs = make(map[string]string)
+		}
+		errs["amount"] = "must be negative"
+	}
+	if err != nil || len(errs) > 0 {
+		c.JSONResponse(ctx, model2.ResponseError{
+			Errors: errs,
+			Detail: fasthttp.StatusMessage(fasthttp.StatusUnprocessableEntity),
+		}, fasthttp.StatusUnprocessableEntity)
+		return
+	}
+
+	err = tasks.AddReputation(c.App, reputationEvent)
+	if errs, err := database.ValidateConstraint(err, reputationEvent); err != nil {
+		c.JSONResponse(ctx, model2.ResponseError{
+			Errors: errs,
+			Detail: fasthttp.StatusMessage(fasthttp.StatusUnprocessableEntity),
+		}, fasthttp.StatusUnprocessableEntity)
+		return
+	}
+
+	c.JSONResponse(ctx, model2.ResponseSuccessOne{
+		Data: reputationEvent,
+	}, fasthttp.StatusCreated)
+}
@@ -0,0 +1,124 @@
+package api
+
+import (
+	"fmt"
+	"forgolang_forum/database/model"
+	"forgolang_forum/tasks"
+	"github.com/valyala/fasthttp"
+	"testing"
+)
+
+type ReputationControllerTest struct {
+	*Suite
+}
+
+func (s ReputationControllerTest) SetupSuite() {
+	SetupSuite(s.Suite)
+	UserAuth(s.Suite)
+}
+
+func (s ReputationControllerTest) createUser(name string) *model.User {
+	pwd := "12345"
+	user := model.NewUser(&pwd)
+	user.Username = name
+	user.Email = fmt.Sprintf("%s@mail.com", name)
+	err := s.API.GetDB().Insert(new(model.User), user, "id")
+	s.Nil(err)
+
+	return user
+}
+
+func (s ReputationControllerTest) Test_VotesChangeReputationOfAuthor() {
+	author := s.createUser("test-reputation-author")
+
+	post := model.NewPost(author.ID)
+	err := s.API.GetDB().Insert(new(model.Post), post, "id")
+	s.Nil(err)
+
+	response := s.JSON(Post, fmt.Sprintf("/api/v1/post/%d/vote/up", post.ID), nil)
+	s.Equal(response.Status, fasthttp.StatusCreated)
+	s.Equal(tasks.GetReputation(s.API.App, author.ID), int64(10))
+
+	// voting again does not award twice
+	response = s.JSON(Post, fmt.Sprintf("/api/v1/post/%d/vote/up", post.ID), nil)
+	s.Equal(response.Status, fasthttp.StatusCreated)
+	s.Equal(tasks.GetReputation(s.API.App, author.ID), int64(10))
+
+	response = s.JSON(Post, fmt.Sprintf("/api/v1/post/%d/vote/down", post.ID), nil)
+	s.Equal(response.Status, fasthttp.StatusCreated)
+	s.Equal(tasks.GetReputation(s.API.App, author.ID), int64(-2))
+
+	response = s.JSON(Delete, fmt.Sprintf("/api/v1/post/%d/vote", post.ID), nil)
+	s.Equal(response.Status, fasthttp.StatusNoContent)
+	s.Equal(tasks.GetReputation(s.API.App, author.ID), int64(0))
+
+	response = s.JSON(Get, fmt.Sprintf("/api/v1/user/%d/reputation", author.ID), nil)
+	s.Equal(response.Status, fasthttp.StatusOK)
+	s.Equal(response.Success.TotalCount, int64(4))
+
+	defaultLogger.LogInfo("Votes change reputation of author")
+}
+
+func (s ReputationControllerTest) Test_CreatePenalty() {
+	user := s.createUser("test-reputation-penalty")
+
+	reputationEvent := new(model.ReputationEvent)
+	reputationEvent.Amount = 20
+	response := s.JSON(Post, fmt.Sprintf("/api/v1/user/%d/reputation", user.ID), reputationEvent)
+	s.Equal(response.Status, fasthttp.StatusUnprocessableEntity)
+
+	reputationEvent.Amount = -20
+	reputationEvent.Note.SetValid("Spam links")
+	response = s.JSON(Post, fmt.Sprintf("/api/v1/user/%d/reputation", user.ID), reputationEvent)
+	s.Equal(response.Status, fasthttp.StatusCreated)
+	data, _ := response.Success.Data.(map[string]interface{})
+	s.Equal(data["reason"], "penalty")
+	s.Equal(tasks.GetReputation(s.API.App, user.ID), int64(-20))
+
+	defaultLogger.LogInfo("Create penalty")
+}
+
+func (s ReputationControllerTest) Test_AwardFirstPostBadge() {
+	user := s.createUser("test-reputation-badge")
+
+	post := model.NewPost(user.ID)
+	err := s.API.GetDB().Insert(new(model.Post), post, "id")
+	s.Nil(err)
+
+	s.Nil(tasks.AwardBadges(s.API.App, nil))
+	s.Nil(tasks.AwardBadges(s.API.App, nil))
+
+	var badges []string
+	err = s.API.GetDB().DB.Select(&badges, `SELECT ub.badge FROM user_badges AS ub WHERE ub.user_id = $1`, user.ID)
+	s.Nil(err)
+	s.Equal(badges, []string{"first_post"})
+
+	defaultLogger.LogInfo("Award first post badge")
+}
+
+func (s ReputationControllerTest) Test_Should_403Err_DownvoteWithoutReputation() {
+	post := model.NewPost(s.Auth.User.ID)
+	err := s.API.GetDB().Insert(new(model.Post), post, "id")
+	s.Nil(err)
+
+	UserAuth(s.Suite, "user")
+
+	response := s.JSON(Post, fmt.Sprintf("/api/v1/post/%d/vote/down", post.ID), nil)
+	s.Equal(response.Status, fasthttp.StatusForbidden)
+
+	response = s.JSON(Post, fmt.Sprintf("/api/v1/post/%d/vote/up", post.ID), nil)
+	s.Equal(response.Status, fasthttp.StatusCreated)
+
+	UserAuth(s.Suite)
+
+	defaultLogger.LogInfo("Should be 403 error downvote without reputation")
+}
+
+func (s ReputationControllerTest) TearDownSuite() {
+	TearDownSuite(s.Suite)
+}
+
+func Test_ReputationController(t *testing.T) {
+	s := ReputationControllerTest{NewSuite()}
+	Run(t, s)
+}
@@ -0,0 +1,52 @@
+// Copyright 2019 StreetByters Community
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with
+// this work for additional information regarding copyright ownership.
+// The ASF licenses this file to You under the Apache License, Version 2.0
+// (the "License"); you may not use this file except in compliance with
+// the License.  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"github.com/fate-lovely/phi"
+	"github.com/valyala/fasthttp"
+	"strconv"
+)
+
+// ReputationPolicy reputation authorization
+type ReputationPolicy struct {
+	Policy
+	*API
+}
+
+// Index method for reputation api authorization, users list only their own
+// ledger while moderators list any ledger
+func (p ReputationPolicy) Index(next phi.HandlerFunc) phi.HandlerFunc {
+	return p.API.Authorization.Apply(next, "ReputationController", "Index",
+		func(ctx *fasthttp.RequestCtx) bool {
+			if p.IsModerator(ctx) {
+				return true
+			}
+			i, err := strconv.ParseInt(phi.URLParam(ctx, "userID"), 10, 64)
+			return err == nil && i == p.GetAuthContext(ctx).ID
+		})
+}
+
+// Create method for reputation api authorization, penalties are given by
+// moderators to other users
+func (p ReputationPolicy) Create(next phi.HandlerFunc) phi.HandlerFunc {
+	return p.API.Authorization.Apply(next, "ReputationController", "Create",
+		func(ctx *fasthttp.RequestCtx) bool {
+			i, err := strconv.ParseInt(phi.URLParam(ctx, "userID"), 10, 64)
+			return err == nil && i != p.GetAuthContext(ctx).ID
+		})
+}
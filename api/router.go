@@ -22,11 +22,8 @@ import (
 	"errors"
 	"fmt"
 	"forgolang_forum/model"
-	"github.com/akdilsiz/limiterphi"
 	"github.com/fate-lovely/phi"
 	errors2 "github.com/streetbyters/agente/errors"
-	"github.com/ulule/limiter/v3"
-	sredis "github.com/ulule/limiter/v3/drivers/store/redis"
 	"github.com/valyala/fasthttp"
 	"os"
 	"strconv"
@@ -74,23 +71,12 @@ func NewRouter(api *API) *Router {
 
 	prefix = fmt.Sprintf("%s/%s", hostname, b64[0:10])
 
-	rate, _ := limiter.NewRateFromFormatted("50-S")
-	store, err := sredis.NewStoreWithOptions(api.App.Cache, limiter.StoreOptions{
-		Prefix:   "forgolang.com",
-		MaxRetry: 4,
-	})
-	if err != nil {
-		panic(err)
-	}
-	rateMiddleware := limiterphi.NewMiddleware(limiter.New(store, rate))
-
 	r := phi.NewRouter()
 
 	r.Use(router.requestID)
 	r.Use(router.recover)
 	r.Use(router.logger)
 	r.Use(router.cors)
-	r.Use(rateMiddleware.Handle)
 	r.Use(router.language)
 
 	r.NotFound(router.notFound)
@@ -103,12 +89,14 @@ func NewRouter(api *API) *Router {
 
 	r.Route(routerPrefix, func(r phi.Router) {
 		r.Get("/heartbeat", HeartbeartController{API: api}.Show)
+		r.Post("/tools/gofmt", GofmtController{API: api}.Create)
 		// Auth routes
 		r.Route("/auth", func(r phi.Router) {
-			r.Post("/sign_in", LoginController{API: api}.Create)
-			r.Post("/token", TokenController{API: api}.Create)
-			r.Post("/register", RegisterController{API: api}.Create)
-			r.Post("/confirmation/{userID}/{code}", ConfirmationController{API: api}.Create)
+			r.With(api.RateLimit.Apply("auth")).Post("/sign_in", LoginController{API: api}.Create)
+			r.With(api.RateLimit.Apply("auth")).Post("/token", TokenController{API: api}.Create)
+			r.With(api.RateLimit.Apply("auth")).Post("/register", RegisterController{API: api}.Create)
+			r.With(api.RateLimit.Apply("auth")).
+				Post("/confirmation/{userID}/{code}", ConfirmationController{API: api}.Create)
 
 			// Third-party routes
 			r.Get("/github", AuthController{API: api}.Github)
@@ -135,9 +123,9 @@ func NewRouter(api *API) *Router {
 				// Category Post Routes
 				r.Group(func(r phi.Router) {
 					cpC := CategoryPostController{API: api}
-					r.Get("/post", cpC.Index)
+					r.With(api.JWTAuth.Identify).Get("/post", cpC.Index)
 					r.Route("/post/{postID}", func(r phi.Router) {
-						r.Get("/", cpC.Show)
+						r.With(api.JWTAuth.Identify).Get("/", cpC.Show)
 					})
 				})
 			})
@@ -159,31 +147,138 @@ func NewRouter(api *API) *Router {
 			}
 		})
 
+		// Tag routes
+		r.Group(func(r phi.Router) {
+			tC := TagController{API: api}
+			r.Get("/tag", tC.Index)
+			r.Get("/tag/autocomplete", tC.Autocomplete)
+			r.With(api.JWTAuth.Verify, TagPolicy{API: api}.Create).Post("/tag", tC.Create)
+			r.Route("/tag/{tagID}", func(r phi.Router) {
+				r.Get("/", tC.Show)
+				r.With(api.JWTAuth.Verify, TagPolicy{API: api}.Update).Put("/", tC.Update)
+				r.With(api.JWTAuth.Verify, TagPolicy{API: api}.Delete).Delete("/", tC.Delete)
+				r.With(api.JWTAuth.Verify, TagPolicy{API: api}.Merge).Post("/merge", tC.Merge)
+
+				// TagLanguage routes
+				r.With(api.JWTAuth.Verify, TagLanguagePolicy{API: api}.Create).
+					Post("/language", TagLanguageController{API: api}.Create)
+
+				// TagSynonym routes
+				tsC := TagSynonymController{API: api}
+				r.With(api.JWTAuth.Verify, TagSynonymPolicy{API: api}.Create).Post("/synonym", tsC.Create)
+				r.With(api.JWTAuth.Verify, TagSynonymPolicy{API: api}.Delete).Delete("/synonym", tsC.Delete)
+			})
+			router.Routes["TagController"] = make(map[string][]string)
+			router.Routes["TagController"]["superadmin"] = []string{
+				"Create",
+				"Update",
+				"Delete",
+				"Merge",
+			}
+			router.Routes["TagController"]["moderator"] = []string{
+				"Create",
+				"Update",
+				"Delete",
+				"Merge",
+			}
+			router.Routes["TagController"]["user"] = []string{
+				"Create",
+			}
+			router.Routes["TagLanguageController"] = make(map[string][]string)
+			router.Routes["TagLanguageController"]["superadmin"] = []string{
+				"Create",
+			}
+			router.Routes["TagLanguageController"]["moderator"] = []string{
+				"Create",
+			}
+			router.Routes["TagSynonymController"] = make(map[string][]string)
+			router.Routes["TagSynonymController"]["superadmin"] = []string{
+				"Create",
+				"Delete",
+			}
+			router.Routes["TagSynonymController"]["moderator"] = []string{
+				"Create",
+				"Delete",
+			}
+		})
+
 		// Post Routes
 		r.Group(func(r phi.Router) {
 			pC := PostController{API: api}
-			r.With(api.JWTAuth.Verify, PostPolicy{API: api}.Create).Post("/post", pC.Create)
+			r.With(api.JWTAuth.Verify, PostPolicy{API: api}.Create, api.RateLimit.Apply("post")).
+				Post("/post", pC.Create)
+			r.With(api.JWTAuth.Verify, PostDraftPolicy{API: api}.Index).Get("/post/draft",
+				PostDraftController{API: api}.Index)
+			r.Get("/post/pinned", PostPinController{API: api}.Index)
+			r.Get("/post/similar", SimilarPostController{API: api}.Index)
 			r.Route("/post/{postID}", func(r phi.Router) {
 				r.With(api.JWTAuth.Verify, PostPolicy{API: api}.Delete).Delete("/", pC.Delete)
+				r.With(api.JWTAuth.Verify, PostPolicy{API: api}.Restore).Post("/restore", pC.Restore)
+				r.With(api.JWTAuth.Verify, PostPolicy{API: api}.Publish).Post("/publish", pC.Publish)
+				r.With(api.JWTAuth.Identify).Get("/related", SimilarPostController{API: api}.Related)
+
+				pstC := PostStateController{API: api}
+				r.With(api.JWTAuth.Verify, PostStatePolicy{API: api}.Index).Get("/state", pstC.Index)
+				r.With(api.JWTAuth.Verify, PostStatePolicy{API: api}.Create).Post("/state", pstC.Create)
 				psC := PostSlugController{API: api}
 				r.With(api.JWTAuth.Verify, PostSlugPolicy{API: api}.Create).Post("/slug", psC.Create)
 
 				pdC := PostDetailController{API: api}
-				r.With(api.JWTAuth.Verify, PostDetailPolicy{API: api}.Create).Post("/detail", pdC.Create)
+				r.With(api.JWTAuth.Verify, PostDetailPolicy{API: api}.Create, api.RateLimit.Apply("edit")).
+					Post("/detail", pdC.Create)
+
+				pvC := PostVoteController{API: api}
+				r.With(api.JWTAuth.Verify, PostVotePolicy{API: api}.Create, api.RateLimit.Apply("vote")).
+					Post("/vote/{direction}", pvC.Create)
+				r.With(api.JWTAuth.Verify, PostVotePolicy{API: api}.Delete).Delete("/vote", pvC.Delete)
+
+				ptC := PostTagController{API: api}
+				r.With(api.JWTAuth.Verify, PostTagPolicy{API: api}.Create).Post("/tag", ptC.Create)
+				r.With(api.JWTAuth.Verify, PostTagPolicy{API: api}.Delete).Delete("/tag/{tagID}", ptC.Delete)
 
 				pcaC := PostCategoryAssignmentController{API: api}
 				r.With(api.JWTAuth.Verify, PostCategoryAssignmentPolicy{API: api}.Create).Post("/category_assignment",
 					pcaC.Create)
 
-				r.Get("/comment", PostCommentController{API: api}.Index)
-				r.With(api.JWTAuth.Verify, PostCommentPolicy{API: api}.Create).Post("/comment",
-					PostCommentController{API: api}.Create)
+				r.With(api.JWTAuth.Verify, FlagPolicy{API: api}.Create, api.RateLimit.Apply("flag")).
+					Post("/flag", FlagController{API: api}.Create)
+
+				plC := PollController{API: api}
+				r.With(api.JWTAuth.Identify).Get("/poll", plC.Show)
+				r.With(api.JWTAuth.Verify, PollPolicy{API: api}.Create).Post("/poll", plC.Create)
+				r.With(api.JWTAuth.Verify, PollPolicy{API: api}.Update).Put("/poll", plC.Update)
+				r.With(api.JWTAuth.Verify, PollPolicy{API: api}.Delete).Delete("/poll", plC.Delete)
+				r.With(api.JWTAuth.Verify, PollPolicy{API: api}.Vote, api.RateLimit.Apply("vote")).
+					Post("/poll/vote", plC.Vote)
+				r.With(api.JWTAuth.Verify, PollPolicy{API: api}.Unvote).Delete("/poll/vote", plC.Unvote)
+
+				r.With(api.JWTAuth.Identify).Get("/comment", PostCommentController{API: api}.Index)
+				r.With(api.JWTAuth.Verify, PostCommentPolicy{API: api}.Create, api.RateLimit.Apply("comment")).
+					Post("/comment", PostCommentController{API: api}.Create)
+				r.With(api.JWTAuth.Identify).Get("/comment/tree", PostCommentTreeController{API: api}.Index)
 				r.Route("/comment/{commentID}", func(r phi.Router) {
+					r.With(api.JWTAuth.Identify).Get("/", PostCommentController{API: api}.Show)
 					r.With(api.JWTAuth.Verify, PostCommentPolicy{API: api}.Delete).Delete("/",
 						PostCommentController{API: api}.Delete)
+					r.With(api.JWTAuth.Verify, PostCommentPolicy{API: api}.Restore).Post("/restore",
+						PostCommentController{API: api}.Restore)
 
-					r.With(api.JWTAuth.Verify, PostCommentDetailPolicy{API: api}.Create).
+					r.With(api.JWTAuth.Verify, PostCommentDetailPolicy{API: api}.Create, api.RateLimit.Apply("edit")).
 						Post("/detail", PostCommentDetailController{API: api}.Create)
+					r.With(api.JWTAuth.Identify).Get("/history", PostCommentDetailController{API: api}.Index)
+
+					pcvC := PostCommentVoteController{API: api}
+					r.With(api.JWTAuth.Verify, PostCommentVotePolicy{API: api}.Create, api.RateLimit.Apply("vote")).
+						Post("/vote/{direction}", pcvC.Create)
+					r.With(api.JWTAuth.Verify, PostCommentVotePolicy{API: api}.Delete).
+						Delete("/vote", pcvC.Delete)
+
+					paC := PostAnswerController{API: api}
+					r.With(api.JWTAuth.Verify, PostAnswerPolicy{API: api}.Create).Post("/accept", paC.Create)
+					r.With(api.JWTAuth.Verify, PostAnswerPolicy{API: api}.Delete).Delete("/accept", paC.Delete)
+
+					r.With(api.JWTAuth.Verify, FlagPolicy{API: api}.Create, api.RateLimit.Apply("flag")).
+						Post("/flag", FlagController{API: api}.Create)
 				})
 			})
 		})
@@ -191,14 +286,38 @@ func NewRouter(api *API) *Router {
 		router.Routes["PostController"]["superadmin"] = []string{
 			"Create",
 			"Delete",
+			"Restore",
+			"Publish",
 		}
 		router.Routes["PostController"]["moderator"] = []string{
 			"Create",
 			"Delete",
+			"Restore",
+			"Publish",
 		}
 		router.Routes["PostController"]["user"] = []string{
 			"Create",
 			"Delete",
+			"Publish",
+		}
+		router.Routes["PostDraftController"] = make(map[string][]string)
+		router.Routes["PostDraftController"]["superadmin"] = []string{
+			"Index",
+		}
+		router.Routes["PostDraftController"]["moderator"] = []string{
+			"Index",
+		}
+		router.Routes["PostDraftController"]["user"] = []string{
+			"Index",
+		}
+		router.Routes["PostStateController"] = make(map[string][]string)
+		router.Routes["PostStateController"]["superadmin"] = []string{
+			"Index",
+			"Create",
+		}
+		router.Routes["PostStateController"]["moderator"] = []string{
+			"Index",
+			"Create",
 		}
 		router.Routes["PostSlugController"] = make(map[string][]string)
 		router.Routes["PostSlugController"]["superadmin"] = []string{
@@ -214,6 +333,58 @@ func NewRouter(api *API) *Router {
 		router.Routes["PostDetailController"]["user"] = []string{
 			"Create",
 		}
+		router.Routes["PostVoteController"] = make(map[string][]string)
+		router.Routes["PostVoteController"]["superadmin"] = []string{
+			"Create",
+			"Delete",
+		}
+		router.Routes["PostVoteController"]["moderator"] = []string{
+			"Create",
+			"Delete",
+		}
+		router.Routes["PostVoteController"]["user"] = []string{
+			"Create",
+			"Delete",
+		}
+		router.Routes["PostCommentVoteController"] = make(map[string][]string)
+		router.Routes["PostCommentVoteController"]["superadmin"] = []string{
+			"Create",
+			"Delete",
+		}
+		router.Routes["PostCommentVoteController"]["moderator"] = []string{
+			"Create",
+			"Delete",
+		}
+		router.Routes["PostCommentVoteController"]["user"] = []string{
+			"Create",
+			"Delete",
+		}
+		router.Routes["PostTagController"] = make(map[string][]string)
+		router.Routes["PostTagController"]["superadmin"] = []string{
+			"Create",
+			"Delete",
+		}
+		router.Routes["PostTagController"]["moderator"] = []string{
+			"Create",
+			"Delete",
+		}
+		router.Routes["PostTagController"]["user"] = []string{
+			"Create",
+			"Delete",
+		}
+		router.Routes["PostAnswerController"] = make(map[string][]string)
+		router.Routes["PostAnswerController"]["superadmin"] = []string{
+			"Create",
+			"Delete",
+		}
+		router.Routes["PostAnswerController"]["moderator"] = []string{
+			"Create",
+			"Delete",
+		}
+		router.Routes["PostAnswerController"]["user"] = []string{
+			"Create",
+			"Delete",
+		}
 		router.Routes["PostCategoryAssignmentController"] = make(map[string][]string)
 		router.Routes["PostCategoryAssignmentController"]["superadmin"] = []string{
 			"Create",
@@ -224,14 +395,38 @@ func NewRouter(api *API) *Router {
 		router.Routes["PostCategoryAssignmentController"]["user"] = []string{
 			"Create",
 		}
+		router.Routes["PollController"] = make(map[string][]string)
+		router.Routes["PollController"]["superadmin"] = []string{
+			"Create",
+			"Update",
+			"Delete",
+			"Vote",
+			"Unvote",
+		}
+		router.Routes["PollController"]["moderator"] = []string{
+			"Create",
+			"Update",
+			"Delete",
+			"Vote",
+			"Unvote",
+		}
+		router.Routes["PollController"]["user"] = []string{
+			"Create",
+			"Update",
+			"Delete",
+			"Vote",
+			"Unvote",
+		}
 		router.Routes["PostCommentController"] = make(map[string][]string)
 		router.Routes["PostCommentController"]["superadmin"] = []string{
 			"Create",
 			"Delete",
+			"Restore",
 		}
 		router.Routes["PostCommentController"]["moderator"] = []string{
 			"Create",
 			"Delete",
+			"Restore",
 		}
 		router.Routes["PostCommentController"]["user"] = []string{
 			"Create",
@@ -248,6 +443,167 @@ func NewRouter(api *API) *Router {
 			"Create",
 		}
 
+		// Realtime Routes
+		r.With(api.JWTAuth.FromQuery, api.JWTAuth.Verify, EventPolicy{API: api}.Index).Get("/events",
+			EventController{API: api}.Index)
+		router.Routes["EventController"] = make(map[string][]string)
+		router.Routes["EventController"]["superadmin"] = []string{
+			"Index",
+		}
+		router.Routes["EventController"]["moderator"] = []string{
+			"Index",
+		}
+		router.Routes["EventController"]["user"] = []string{
+			"Index",
+		}
+
+		// Subscription Routes
+		r.Group(func(r phi.Router) {
+			sC := SubscriptionController{API: api}
+			r.With(api.JWTAuth.Verify, SubscriptionPolicy{API: api}.Index).Get("/subscription", sC.Index)
+			r.With(api.JWTAuth.Verify, SubscriptionPolicy{API: api}.Create).Post("/subscription", sC.Create)
+			r.With(api.JWTAuth.Verify, SubscriptionPolicy{API: api}.Delete).Delete("/subscription/{subscriptionID}",
+				sC.Delete)
+			r.Get("/subscription/{subscriptionID}/unsubscribe", sC.Unsubscribe)
+		})
+		router.Routes["SubscriptionController"] = make(map[string][]string)
+		router.Routes["SubscriptionController"]["superadmin"] = []string{
+			"Index",
+			"Create",
+			"Delete",
+		}
+		router.Routes["SubscriptionController"]["moderator"] = []string{
+			"Index",
+			"Create",
+			"Delete",
+		}
+		router.Routes["SubscriptionController"]["user"] = []string{
+			"Index",
+			"Create",
+			"Delete",
+		}
+
+		// Bookmark Routes
+		r.Group(func(r phi.Router) {
+			bC := BookmarkController{API: api}
+			r.With(api.JWTAuth.Verify, BookmarkPolicy{API: api}.Index).Get("/bookmark", bC.Index)
+			r.With(api.JWTAuth.Verify, BookmarkPolicy{API: api}.Create).Post("/bookmark", bC.Create)
+			r.With(api.JWTAuth.Verify, BookmarkPolicy{API: api}.Update).Put("/bookmark/{bookmarkID}", bC.Update)
+			r.With(api.JWTAuth.Verify, BookmarkPolicy{API: api}.Delete).Delete("/bookmark/{bookmarkID}", bC.Delete)
+		})
+		router.Routes["BookmarkController"] = make(map[string][]string)
+		router.Routes["BookmarkController"]["superadmin"] = []string{
+			"Index",
+			"Create",
+			"Update",
+			"Delete",
+		}
+		router.Routes["BookmarkController"]["moderator"] = []string{
+			"Index",
+			"Create",
+			"Update",
+			"Delete",
+		}
+		router.Routes["BookmarkController"]["user"] = []string{
+			"Index",
+			"Create",
+			"Update",
+			"Delete",
+		}
+
+		// Flag Routes
+		r.Group(func(r phi.Router) {
+			fC := FlagController{API: api}
+			r.With(api.JWTAuth.Verify, FlagPolicy{API: api}.Index).Get("/flag", fC.Index)
+			r.With(api.JWTAuth.Verify, FlagPolicy{API: api}.Show).Get("/flag/{flagID}", fC.Show)
+			r.With(api.JWTAuth.Verify, FlagPolicy{API: api}.Resolve).Post("/flag/{flagID}/resolve", fC.Resolve)
+		})
+		router.Routes["FlagController"] = make(map[string][]string)
+		router.Routes["FlagController"]["superadmin"] = []string{
+			"Index",
+			"Show",
+			"Create",
+			"Resolve",
+		}
+		router.Routes["FlagController"]["moderator"] = []string{
+			"Index",
+			"Show",
+			"Create",
+			"Resolve",
+		}
+		router.Routes["FlagController"]["user"] = []string{
+			"Create",
+		}
+
+		// Follow Routes
+		r.Group(func(r phi.Router) {
+			fC := FollowController{API: api}
+			r.With(api.JWTAuth.Verify, FollowPolicy{API: api}.Index).Get("/follow", fC.Index)
+			r.With(api.JWTAuth.Verify, FollowPolicy{API: api}.Create).Post("/follow", fC.Create)
+			r.With(api.JWTAuth.Verify, FollowPolicy{API: api}.Delete).Delete("/follow/{followID}", fC.Delete)
+		})
+		router.Routes["FollowController"] = make(map[string][]string)
+		router.Routes["FollowController"]["superadmin"] = []string{
+			"Index",
+			"Create",
+			"Delete",
+		}
+		router.Routes["FollowController"]["moderator"] = []string{
+			"Index",
+			"Create",
+			"Delete",
+		}
+		router.Routes["FollowController"]["user"] = []string{
+			"Index",
+			"Create",
+			"Delete",
+		}
+
+		// Feed Routes
+		r.Group(func(r phi.Router) {
+			fC := FeedController{API: api}
+			r.With(api.JWTAuth.Verify, FeedPolicy{API: api}.Index).Get("/feed", fC.Index)
+		})
+		router.Routes["FeedController"] = make(map[string][]string)
+		router.Routes["FeedController"]["superadmin"] = []string{
+			"Index",
+		}
+		router.Routes["FeedController"]["moderator"] = []string{
+			"Index",
+		}
+		router.Routes["FeedController"]["user"] = []string{
+			"Index",
+		}
+
+		// Notification Routes
+		r.Group(func(r phi.Router) {
+			nC := NotificationController{API: api}
+			r.With(api.JWTAuth.Verify, NotificationPolicy{API: api}.Index).Get("/notification", nC.Index)
+			r.With(api.JWTAuth.Verify, NotificationPolicy{API: api}.Unread).Get("/notification/unread", nC.Unread)
+			r.With(api.JWTAuth.Verify, NotificationPolicy{API: api}.ReadAll).Post("/notification/read", nC.ReadAll)
+			r.With(api.JWTAuth.Verify, NotificationPolicy{API: api}.Read).Post("/notification/{notificationID}/read",
+				nC.Read)
+		})
+		router.Routes["NotificationController"] = make(map[string][]string)
+		router.Routes["NotificationController"]["superadmin"] = []string{
+			"Index",
+			"Unread",
+			"Read",
+			"ReadAll",
+		}
+		router.Routes["NotificationController"]["moderator"] = []string{
+			"Index",
+			"Unread",
+			"Read",
+			"ReadAll",
+		}
+		router.Routes["NotificationController"]["user"] = []string{
+			"Index",
+			"Unread",
+			"Read",
+			"ReadAll",
+		}
+
 		r.Group(func(r phi.Router) {
 			r.Use(api.JWTAuth.Verify)
 			// Sign out route
@@ -265,11 +621,17 @@ func NewRouter(api *API) *Router {
 
 			uC := UploadController{API: api}
 
-			r.Post("/upload", uC.Create)
+			r.With(UploadPolicy{API: api}.Create, api.RateLimit.Apply("upload")).Post("/upload", uC.Create)
 			router.Routes["UploadController"] = make(map[string][]string)
 			router.Routes["UploadController"]["superadmin"] = []string{
 				"Create",
 			}
+			router.Routes["UploadController"]["moderator"] = []string{
+				"Create",
+			}
+			router.Routes["UploadController"]["user"] = []string{
+				"Create",
+			}
 
 			//User Routes
 			r.Group(func(r phi.Router) {
@@ -288,6 +650,46 @@ func NewRouter(api *API) *Router {
 					router.Routes["UserRoleAssignmentController"]["superadmin"] = []string{
 						"Create",
 					}
+
+					// Reputation routes
+					r.With(ReputationPolicy{API: api}.Index).Get("/reputation", ReputationController{API: api}.Index)
+					r.With(ReputationPolicy{API: api}.Create).Post("/reputation", ReputationController{API: api}.Create)
+					router.Routes["ReputationController"] = make(map[string][]string)
+					router.Routes["ReputationController"]["superadmin"] = []string{
+						"Index",
+						"Create",
+					}
+					router.Routes["ReputationController"]["moderator"] = []string{
+						"Index",
+						"Create",
+					}
+					router.Routes["ReputationController"]["user"] = []string{
+						"Index",
+					}
+
+					// Trust level routes
+					tlC := TrustLevelController{API: api}
+					r.With(TrustLevelPolicy{API: api}.Show).Get("/trust_level", tlC.Show)
+					r.With(TrustLevelPolicy{API: api}.Create).Post("/trust_level", tlC.Create)
+					r.With(TrustLevelPolicy{API: api}.Delete).Delete("/trust_level", tlC.Delete)
+					router.Routes["TrustLevelController"] = make(map[string][]string)
+					router.Routes["TrustLevelController"]["superadmin"] = []string{
+						"Show",
+						"Create",
+						"Delete",
+					}
+					router.Routes["TrustLevelController"]["moderator"] = []string{
+						"Show",
+						"Create",
+						"Delete",
+					}
+					router.Routes["TrustLevelController"]["user"] = []string{
+						"Show",
+					}
+
+					// Flag routes
+					r.With(FlagPolicy{API: api}.Create, api.RateLimit.Apply("flag")).Post("/flag",
+						FlagController{API: api}.Create)
 				})
 				router.Routes["UserController"] = make(map[string][]string)
 				router.Routes["UserController"]["superadmin"] = []string{
@@ -0,0 +1,129 @@
+// Copyright 2019 StreetByters Community
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with
+// this work for additional information regarding copyright ownership.
+// The ASF licenses this file to You under the Apache License, Version 2.0
+// (the "License"); you may not use this file except in compliance with
+// the License.  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"forgolang_forum/database/model"
+	model2 "forgolang_forum/model"
+	"github.com/olivere/elastic/v7"
+	"github.com/valyala/fasthttp"
+	"strconv"
+	"strings"
+)
+
+const (
+	// DefaultSimilarPostLimit number of similar posts listed by default
+	DefaultSimilarPostLimit = 5
+	// MaxSimilarPostLimit maximum number of similar posts listed
+	MaxSimilarPostLimit = 20
+)
+
+// SimilarPostController similar and related posts api controller
+type SimilarPostController struct {
+	Controller
+	*API
+}
+
+// Index list posts similar to the title and the content of a question being
+// typed, so the author is warned about possible duplicates
+func (c SimilarPostController) Index(ctx *fasthttp.RequestCtx) {
+	queryParams := c.ParseQuery(ctx)
+
+	text := strings.TrimSpace(queryParams["title"] + "\n" + queryParams["content"])
+	if len(text) < 3 {
+		c.JSONResponse(ctx, model2.ResponseSuccess{
+			Data:       []model.PostDEP{},
+			TotalCount: 0,
+		}, fasthttp.StatusOK)
+		return
+	}
+
+	posts := c.search(elastic.NewMoreLikeThisQuery().LikeText(text), c.limit(ctx))
+
+	c.JSONResponse(ctx, model2.ResponseSuccess{
+		Data:       posts,
+		TotalCount: int64(len(posts)),
+	}, fasthttp.StatusOK)
+}
+
+// Related list posts related to a post for the related posts block of
+// thread views
+func (c SimilarPostController) Related(ctx *fasthttp.RequestCtx) {
+	post := PostPolicy{API: c.API}.GetPost(ctx)
+	if post.ID == 0 || !c.IsPostVisible(ctx, post.ID) {
+		c.JSONResponse(ctx, model2.ResponseError{
+			Detail: fasthttp.StatusMessage(fasthttp.StatusNotFound),
+		}, fasthttp.StatusNotFound)
+		return
+	}
+
+	item := elastic.NewMoreLikeThisQueryItem().
+		Index("posts").
+		Id(strconv.FormatInt(post.ID, 10))
+	posts := c.search(elastic.NewMoreLikeThisQuery().LikeItems(item), c.limit(ctx))
+
+	c.JSONResponse(ctx, model2.ResponseSuccess{
+		Data:       posts,
+		TotalCount: int64(len(posts)),
+	}, fasthttp.StatusOK)
+}
+
+// search posts matching the more like this query over titles and contents,
+// only published posts are indexed. Terms occurring once are kept since
+// threads are short.
+func (c SimilarPostController) search(query *elastic.MoreLikeThisQuery, limit int) []model.PostDEP {
+	query = query.
+		Field("title", "content").
+		MinTermFreq(1).
+		MinDocFreq(1).
+		MaxQueryTerms(25).
+		MinimumShouldMatch("30%")
+
+	posts := make([]model.PostDEP, 0)
+	results, err := c.App.ElasticClient.Search("posts").
+		Query(query).
+		FetchSourceContext(elastic.NewFetchSourceContext(true).
+			Include("id", "author_id", "author_username", "slug", "title", "description", "tags", "inserted_at")).
+		Size(limit).
+		Do(context.TODO())
+	if err != nil {
+		c.App.Logger.LogError(err, "similar posts")
+		return posts
+	}
+
+	for _, v := range results.Hits.Hits {
+		var p model.PostDEP
+		b, _ := v.Source.MarshalJSON()
+		json.Unmarshal(b, &p)
+		posts = append(posts, p)
+	}
+
+	return posts
+}
+
+func (c SimilarPostController) limit(ctx *fasthttp.RequestCtx) int {
+	limit, err := strconv.Atoi(c.ParseQuery(ctx)["limit"])
+	if err != nil || limit <= 0 {
+		return DefaultSimilarPostLimit
+	}
+	if limit > MaxSimilarPostLimit {
+		return MaxSimilarPostLimit
+	}
+	return limit
+}
@@ -0,0 +1,91 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"forgolang_forum/database/model"
+	"forgolang_forum/tasks"
+	"github.com/valyala/fasthttp"
+	"net/url"
+	"testing"
+)
+
+type SimilarPostControllerTest struct {
+	*Suite
+}
+
+func (s SimilarPostControllerTest) SetupSuite() {
+	SetupSuite(s.Suite)
+	UserAuth(s.Suite)
+}
+
+// post index a new published post with the given title and content
+func (s SimilarPostControllerTest) post(title, content string) *model.Post {
+	post := model.NewPost(s.Auth.User.ID)
+	err := s.API.GetDB().Insert(new(model.Post), post, "id")
+	s.Nil(err)
+	postDetail := model.NewPostDetail(post.ID, s.Auth.User.ID)
+	postDetail.Title = title
+	postDetail.Content = content
+	err = s.API.GetDB().Insert(new(model.PostDetail), postDetail, "id")
+	s.Nil(err)
+	s.Nil(tasks.IndexPost(s.API.App, post.ID))
+
+	return post
+}
+
+// ids identifiers of the listed posts
+func (s SimilarPostControllerTest) ids(response *TestResponse) []int64 {
+	var ids []int64
+	posts, _ := response.Success.Data.([]interface{})
+	for _, p := range posts {
+		ids = append(ids, int64(p.(map[string]interface{})["id"].(float64)))
+	}
+	return ids
+}
+
+func (s SimilarPostControllerTest) Test_ListSimilarAndRelatedPosts() {
+	first := s.post("How to cancel a quokkaworker goroutine with context",
+		"I start a quokkaworker goroutine and want to cancel it with a context when the request ends")
+	second := s.post("Cancel quokkaworker goroutine when context is done",
+		"My quokkaworker goroutine does not stop after the context is cancelled, how to cancel it")
+	third := s.post("Embedding static files into the binary",
+		"Which package embeds html templates and static files into a go binary")
+	_, err := s.API.App.ElasticClient.Refresh("posts").Do(context.TODO())
+	s.Nil(err)
+
+	query := url.Values{}
+	query.Set("title", "cancel quokkaworker goroutine")
+	query.Set("content", "how do I cancel a running quokkaworker")
+	response := s.JSON(Get, "/api/v1/post/similar?"+query.Encode(), nil)
+	s.Equal(response.Status, fasthttp.StatusOK)
+	ids := s.ids(response)
+	s.Contains(ids, first.ID)
+	s.Contains(ids, second.ID)
+	s.NotContains(ids, third.ID)
+
+	response = s.JSON(Get, fmt.Sprintf("/api/v1/post/%d/related", first.ID), nil)
+	s.Equal(response.Status, fasthttp.StatusOK)
+	ids = s.ids(response)
+	s.Contains(ids, second.ID)
+	s.NotContains(ids, first.ID)
+	s.NotContains(ids, third.ID)
+
+	defaultLogger.LogInfo("List similar and related posts")
+}
+
+func (s SimilarPostControllerTest) Test_Should_404Err_RelatedPostsOfNotExistsPost() {
+	response := s.JSON(Get, "/api/v1/post/999999999/related", nil)
+	s.Equal(response.Status, fasthttp.StatusNotFound)
+
+	defaultLogger.LogInfo("Should be 404 error related posts of not exists post")
+}
+
+func (s SimilarPostControllerTest) TearDownSuite() {
+	TearDownSuite(s.Suite)
+}
+
+func Test_SimilarPostController(t *testing.T) {
+	s := SimilarPostControllerTest{NewSuite()}
+	Run(t, s)
+}
@@ -0,0 +1,159 @@
+// Copyright 2019 StreetByters Community
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with
+// this work for additional information regarding copyright ownership.
+// The ASF licenses this file to You under the Apache License, Version 2.0
+// (the "License"); you may not use this file except in compliance with
+// the License.  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"fmt"
+	"forgolang_forum/database"
+	"forgolang_forum/database/model"
+	model2 "forgolang_forum/model"
+	"forgolang_forum/utils"
+	"github.com/fate-lovely/phi"
+	"github.com/valyala/fasthttp"
+)
+
+// SubscriptionController post and category subscriptions of the current
+// user api controller
+type SubscriptionController struct {
+	Controller
+	*API
+	Model model.Subscription
+}
+
+// Index list subscriptions of the current user
+func (c SubscriptionController) Index(ctx *fasthttp.RequestCtx) {
+	paginate, _, _ := c.Paginate(ctx, "id", "updated_at", "inserted_at")
+
+	var subscriptions []model.Subscription
+	c.GetDB().QueryWithModel(fmt.Sprintf(`
+		SELECT s.* FROM %s AS s WHERE s.user_id = $1
+		ORDER BY s.%s %s
+		LIMIT $2 OFFSET $3
+	`, c.Model.TableName(), paginate.OrderField, paginate.OrderBy),
+		&subscriptions,
+		c.GetAuthContext(ctx).ID,
+		paginate.Limit,
+		paginate.Offset)
+
+	var count int64
+	c.GetDB().DB.Get(&count, fmt.Sprintf(`
+		SELECT count(s.id) FROM %s AS s WHERE s.user_id = $1
+	`, c.Model.TableName()),
+		c.GetAuthContext(ctx).ID)
+
+	c.JSONResponse(ctx, model2.ResponseSuccess{
+		Data:       subscriptions,
+		TotalCount: count,
+	}, fasthttp.StatusOK)
+}
+
+// Create watch a post or a category, the level and frequency of an
+// existing subscription are replaced
+func (c SubscriptionController) Create(ctx *fasthttp.RequestCtx) {
+	subscription := model.NewSubscription(c.GetAuthContext(ctx).ID)
+	c.JSONBody(ctx, &subscription)
+	subscription.UserID = c.GetAuthContext(ctx).ID
+
+	errs, err := database.ValidateStruct(subscription)
+	if subscription.PostID.Valid == subscription.CategoryID.Valid {
+		if errs == nil {
+			errs = make(map[string]string)
+		}
+		errs["post_id"] = "either post or category is required"
+	}
+	if err != nil || len(errs) > 0 {
+		c.JSONResponse(ctx, model2.ResponseError{
+			Errors: errs,
+			Detail: fasthttp.StatusMessage(fasthttp.StatusUnprocessableEntity),
+		}, fasthttp.StatusUnprocessableEntity)
+		return
+	}
+
+	target := "(user_id, post_id) WHERE post_id IS NOT NULL"
+	if subscription.CategoryID.Valid {
+		target = "(user_id, category_id) WHERE category_id IS NOT NULL"
+	}
+
+	err = c.GetDB().DB.Get(subscription, fmt.Sprintf(`
+		INSERT INTO %s (user_id, post_id, category_id, level, frequency) VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT %s DO UPDATE SET
+			level = EXCLUDED.level,
+			frequency = EXCLUDED.frequency,
+			updated_at = (CURRENT_TIMESTAMP at time zone 'utc')
+		RETURNING *
+	`, c.Model.TableName(), target),
+		subscription.UserID,
+		subscription.PostID,
+		subscription.CategoryID,
+		subscription.Level,
+		subscription.Frequency)
+	if errs, err := database.ValidateConstraint(err, subscription); err != nil {
+		c.JSONResponse(ctx, model2.ResponseError{
+			Errors: errs,
+			Detail: fasthttp.StatusMessage(fasthttp.StatusUnprocessableEntity),
+		}, fasthttp.StatusUnprocessableEntity)
+		return
+	}
+
+	c.JSONResponse(ctx, model2.ResponseSuccessOne{
+		Data: subscription,
+	}, fasthttp.StatusCreated)
+}
+
+// Delete remove a subscription of the current user
+func (c SubscriptionController) Delete(ctx *fasthttp.RequestCtx) {
+	c.GetDB().Delete(c.Model.TableName(), "id::text = $1::text AND user_id = $2",
+		phi.URLParam(ctx, "subscriptionID"),
+		c.GetAuthContext(ctx).ID).Force()
+
+	c.JSONResponse(ctx, nil, fasthttp.StatusNoContent)
+}
+
+// Unsubscribe stop emails of a subscription with the signed link in the
+// emails, the subscription is kept on the normal level so it still
+// overrides subscriptions of categories
+func (c SubscriptionController) Unsubscribe(ctx *fasthttp.RequestCtx) {
+	if !utils.VerifySignature(c.App.Config.SecretKey, c.ParseQuery(ctx)["signature"],
+		"subscription", phi.URLParam(ctx, "subscriptionID")) {
+		c.JSONResponse(ctx, model2.ResponseError{
+			Errors: map[string]string{
+				"signature": "is not valid",
+			},
+			Detail: fasthttp.StatusMessage(fasthttp.StatusForbidden),
+		}, fasthttp.StatusForbidden)
+		return
+	}
+
+	var subscription model.Subscription
+	err := c.GetDB().DB.Get(&subscription, fmt.Sprintf(`
+		UPDATE %s SET level = $2, updated_at = (CURRENT_TIMESTAMP at time zone 'utc')
+		WHERE id::text = $1::text
+		RETURNING *
+	`, c.Model.TableName()),
+		phi.URLParam(ctx, "subscriptionID"),
+		database.Normal)
+	if err != nil {
+		c.JSONResponse(ctx, model2.ResponseError{
+			Detail: fasthttp.StatusMessage(fasthttp.StatusNotFound),
+		}, fasthttp.StatusNotFound)
+		return
+	}
+
+	c.JSONResponse(ctx, model2.ResponseSuccessOne{
+		Data: subscription,
+	}, fasthttp.StatusOK)
+}
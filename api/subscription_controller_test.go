@@ -0,0 +1,127 @@
+package api
+
+import (
+	"fmt"
+	"forgolang_forum/database"
+	"forgolang_forum/database/model"
+	"forgolang_forum/tasks"
+	"forgolang_forum/utils"
+	"github.com/valyala/fasthttp"
+	"gopkg.in/guregu/null.v3/zero"
+	"testing"
+)
+
+type SubscriptionControllerTest struct {
+	*Suite
+}
+
+func (s SubscriptionControllerTest) SetupSuite() {
+	SetupSuite(s.Suite)
+	UserAuth(s.Suite)
+}
+
+func (s SubscriptionControllerTest) Test_CreateAndUpdatePostSubscription() {
+	post := model.NewPost(s.Auth.User.ID)
+	err := s.API.GetDB().Insert(new(model.Post), post, "id")
+	s.Nil(err)
+
+	subscription := model.NewSubscription(s.Auth.User.ID)
+	subscription.PostID.SetValid(post.ID)
+	subscription.Level = database.Watching
+	subscription.Frequency = database.Daily
+
+	response := s.JSON(Post, "/api/v1/subscription", subscription)
+	s.Equal(response.Status, fasthttp.StatusCreated)
+	data, _ := response.Success.Data.(map[string]interface{})
+	s.Equal(data["level"], "watching")
+	s.Equal(data["frequency"], "daily")
+	id := data["id"]
+
+	subscription.Level = database.Muted
+	response = s.JSON(Post, "/api/v1/subscription", subscription)
+	s.Equal(response.Status, fasthttp.StatusCreated)
+	data, _ = response.Success.Data.(map[string]interface{})
+	s.Equal(data["id"], id)
+	s.Equal(data["level"], "muted")
+
+	s.Equal(tasks.GetWatchLevel(s.API.App, s.Auth.User.ID, post.ID), database.Muted)
+
+	defaultLogger.LogInfo("Create and update post subscription")
+}
+
+func (s SubscriptionControllerTest) Test_Should_422Err_CreateSubscriptionWithoutTarget() {
+	subscription := model.NewSubscription(s.Auth.User.ID)
+	subscription.Level = database.Watching
+
+	response := s.JSON(Post, "/api/v1/subscription", subscription)
+	s.Equal(response.Status, fasthttp.StatusUnprocessableEntity)
+
+	subscription.PostID.SetValid(999999999)
+	subscription.Level = "loud"
+	response = s.JSON(Post, "/api/v1/subscription", subscription)
+	s.Equal(response.Status, fasthttp.StatusUnprocessableEntity)
+
+	defaultLogger.LogInfo("Should be 422 error create subscription without target")
+}
+
+func (s SubscriptionControllerTest) Test_MutedPostIsNotNotified() {
+	pwd := "12345"
+	user := model.NewUser(&pwd)
+	user.Username = "test-subscription-voter"
+	user.Email = "test-subscription-voter@mail.com"
+	err := s.API.GetDB().Insert(new(model.User), user, "id")
+	s.Nil(err)
+
+	post := model.NewPost(s.Auth.User.ID)
+	err = s.API.GetDB().Insert(new(model.Post), post, "id")
+	s.Nil(err)
+
+	subscription := model.NewSubscription(s.Auth.User.ID)
+	subscription.PostID.SetValid(post.ID)
+	subscription.Level = database.Muted
+	err = s.API.GetDB().Insert(new(model.Subscription), subscription, "id")
+	s.Nil(err)
+
+	notification := model.NewNotification(s.Auth.User.ID, database.VoteNotification, post.ID, zero.Int{})
+	notification.ActorID.SetValid(user.ID)
+	s.Nil(tasks.Notify(s.API.App, notification))
+	s.Equal(notification.ID, int64(0))
+
+	defaultLogger.LogInfo("Muted post is not notified")
+}
+
+func (s SubscriptionControllerTest) Test_UnsubscribeWithSignedLink() {
+	category := model.NewCategory()
+	category.Title = "Subscription Category"
+	category.Slug = "subscription-category"
+	err := s.API.GetDB().Insert(new(model.Category), category, "id")
+	s.Nil(err)
+
+	subscription := model.NewSubscription(s.Auth.User.ID)
+	subscription.CategoryID.SetValid(category.ID)
+	subscription.Level = database.Watching
+	err = s.API.GetDB().Insert(new(model.Subscription), subscription, "id")
+	s.Nil(err)
+
+	response := s.JSON(Get, fmt.Sprintf("/api/v1/subscription/%d/unsubscribe?signature=invalid",
+		subscription.ID), nil)
+	s.Equal(response.Status, fasthttp.StatusForbidden)
+
+	signature := utils.Sign(s.API.App.Config.SecretKey, "subscription", fmt.Sprintf("%d", subscription.ID))
+	response = s.JSON(Get, fmt.Sprintf("/api/v1/subscription/%d/unsubscribe?signature=%s",
+		subscription.ID, signature), nil)
+	s.Equal(response.Status, fasthttp.StatusOK)
+	data, _ := response.Success.Data.(map[string]interface{})
+	s.Equal(data["level"], "normal")
+
+	defaultLogger.LogInfo("Unsubscribe with signed link")
+}
+
+func (s SubscriptionControllerTest) TearDownSuite() {
+	TearDownSuite(s.Suite)
+}
+
+func Test_SubscriptionController(t *testing.T) {
+	s := SubscriptionControllerTest{NewSuite()}
+	Run(t, s)
+}
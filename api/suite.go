@@ -156,6 +156,7 @@ func NewSuite() *Suite {
 		RedisDB:            viper.GetInt("REDIS_DB"),
 		ElasticHost:        viper.GetString("ELASTIC_HOST"),
 		ElasticPort:        viper.GetInt("ELASTIC_PORT"),
+		FlagHideThreshold:  viper.GetInt64("FLAG_HIDE_THRESHOLD"),
 		GithubClientID:     viper.GetString("GITHUB_CLIENT_ID"),
 		GithubClientSecret: viper.GetString("GITHUB_CLIENT_SECRET"),
 	}
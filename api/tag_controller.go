@@ -17,12 +17,21 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"forgolang_forum/cmn"
+	"forgolang_forum/database"
 	"forgolang_forum/database/model"
 	model2 "forgolang_forum/model"
+	"github.com/fate-lovely/phi"
+	"github.com/go-redis/redis"
+	"github.com/lib/pq"
+	"github.com/olivere/elastic/v7"
 	"github.com/valyala/fasthttp"
+	"sort"
+	"strconv"
+	"strings"
 )
 
 // TagController special classifications api controller
@@ -32,52 +41,528 @@ type TagController struct {
 	Model model.Tag
 }
 
+// Index list all tags with usage counts and localized names
 func (c TagController) Index(ctx *fasthttp.RequestCtx) {
-	paginate, _, _ := c.Paginate(ctx, "count", "name")
+	paginate, _, _ := c.Paginate(ctx, "id", "count", "name")
 
-	var count int64
 	var tags []model.Tag
-
-	if s, err := c.App.Cache.SMembers(cmn.GetRedisKey("tag", "all")).Result(); err == nil {
-		var ts []string
+	if s, err := c.App.Cache.SMembers(cmn.GetRedisKey("tag", "all")).Result(); err == nil && len(s) > 0 {
 		for _, v := range s {
 			var t model.Tag
 			json.Unmarshal([]byte(v), &t)
 			tags = append(tags, t)
-			ts = append(ts, fmt.Sprintf("%s:%d", cmn.GetRedisKey("tag", "count"), t.ID))
 		}
+	} else {
+		c.GetDB().QueryWithModel(fmt.Sprintf(`
+			SELECT t.* FROM %s AS t
+		`, c.Model.TableName()),
+			&tags)
+
+		var ts []interface{}
+		for _, t := range tags {
+			ts = append(ts, t.ToJSON())
+		}
+		if len(ts) > 0 {
+			c.App.Cache.SAdd(cmn.GetRedisKey("tag", "all"), ts...)
+		}
+	}
+
+	var ids []int64
+	for _, t := range tags {
+		ids = append(ids, t.ID)
+	}
+	counts := c.GetCounts(ids...)
+	for i := range tags {
+		tags[i].Count.SetValid(counts[tags[i].ID])
+	}
+
+	sort.Slice(tags, func(i, j int) bool {
+		if paginate.OrderBy == "asc" {
+			i, j = j, i
+		}
+		switch paginate.OrderField {
+		case "count":
+			if tags[i].Count.Int64 != tags[j].Count.Int64 {
+				return tags[i].Count.Int64 > tags[j].Count.Int64
+			}
+		case "name":
+			if tags[i].Name != tags[j].Name {
+				return tags[i].Name > tags[j].Name
+			}
+		}
+		return tags[i].ID > tags[j].ID
+	})
+
+	count := int64(len(tags))
+	start := paginate.Offset
+	if start > count {
+		start = count
+	}
+	end := start + int64(paginate.Limit)
+	if end > count {
+		end = count
+	}
+	tags = tags[start:end]
+
+	var pageIDs []int64
+	for _, t := range tags {
+		pageIDs = append(pageIDs, t.ID)
+	}
+	languages := c.GetLanguages(pageIDs...)
+	for i := range tags {
+		tags[i].Languages = languages[tags[i].ID]
+	}
+
+	c.JSONResponse(ctx, model2.ResponseSuccess{
+		Data:       tags,
+		TotalCount: count,
+	}, fasthttp.StatusOK)
+}
+
+// Show a tag with given identifier or name
+func (c TagController) Show(ctx *fasthttp.RequestCtx) {
+	var tag model.Tag
+	c.GetDB().QueryRowWithModel(fmt.Sprintf(`
+		SELECT t.* FROM %s AS t WHERE t.id::text = $1::text OR lower(t.name) = lower($1)
+	`, c.Model.TableName()),
+		&tag,
+		phi.URLParam(ctx, "tagID")).Force()
+
+	tag.Count.SetValid(c.GetCounts(tag.ID)[tag.ID])
+	tag.Languages = c.GetLanguages(tag.ID)[tag.ID]
+
+	c.JSONResponse(ctx, model2.ResponseSuccessOne{
+		Data: tag,
+	}, fasthttp.StatusOK)
+}
+
+// Create tag with valid params
+func (c TagController) Create(ctx *fasthttp.RequestCtx) {
+	tag := new(model.Tag)
+	c.JSONBody(ctx, &tag)
+	tag.Name = NormalizeTagName(tag.Name)
+
+	if errs, err := database.ValidateStruct(tag); err != nil {
+		c.JSONResponse(ctx, model2.ResponseError{
+			Errors: errs,
+			Detail: fasthttp.StatusMessage(fasthttp.StatusUnprocessableEntity),
+		}, fasthttp.StatusUnprocessableEntity)
+		return
+	}
+
+	err := c.GetDB().Insert(new(model.Tag), tag, "id", "inserted_at", "updated_at")
+	if errs, err := database.ValidateConstraint(err, tag); err != nil {
+		c.JSONResponse(ctx, model2.ResponseError{
+			Errors: errs,
+			Detail: fasthttp.StatusMessage(fasthttp.StatusUnprocessableEntity),
+		}, fasthttp.StatusUnprocessableEntity)
+		return
+	}
 
-		//count, _ := c.App.Cache.SCard(cmn.GetRedisKey("tag", "all")).Result()
-		//
-		//tss, _ := c.App.Cache.MGet(ts...).Result()
-		//for _, tc := range tss {
-		//	fmt.Println(tc)
-		//}
+	// the cached tag list is loaded again with the new tag on the next read
+	c.App.Cache.Del(cmn.GetRedisKey("tag", "all"))
+	c.addNames(tag.Name)
 
-		c.JSONResponse(ctx, model2.ResponseSuccess{
-			Data:       tags,
-			TotalCount: count,
-		}, fasthttp.StatusOK)
+	c.JSONResponse(ctx, model2.ResponseSuccessOne{
+		Data: tag,
+	}, fasthttp.StatusCreated)
+}
+
+// Update rename tag with given identifier
+func (c TagController) Update(ctx *fasthttp.RequestCtx) {
+	tag := new(model.Tag)
+	c.GetDB().QueryRowWithModel(fmt.Sprintf(`
+		SELECT t.* FROM %s AS t WHERE t.id::text = $1::text
+	`, c.Model.TableName()),
+		tag,
+		phi.URLParam(ctx, "tagID")).Force()
+
+	var tagRequest model.Tag
+	c.JSONBody(ctx, &tagRequest)
+	tagRequest.Name = NormalizeTagName(tagRequest.Name)
+
+	if errs, err := database.ValidateStruct(tagRequest); err != nil {
+		c.JSONResponse(ctx, model2.ResponseError{
+			Errors: errs,
+			Detail: fasthttp.StatusMessage(fasthttp.StatusUnprocessableEntity),
+		}, fasthttp.StatusUnprocessableEntity)
+		return
+	}
+
+	tagRequest.InsertedAt = tag.InsertedAt
+	tagRequest.SynonymOfID = tag.SynonymOfID
+	name := tag.Name
+
+	err := c.GetDB().Update(tag, &tagRequest, nil, "id", "inserted_at", "updated_at")
+	if errs, err := database.ValidateConstraint(err, tag); err != nil {
+		c.JSONResponse(ctx, model2.ResponseError{
+			Errors: errs,
+			Detail: fasthttp.StatusMessage(fasthttp.StatusUnprocessableEntity),
+		}, fasthttp.StatusUnprocessableEntity)
 		return
 	}
 
-	c.GetDB().QueryWithModel(fmt.Sprintf(`
-		SELECT t.* FROM %s AS t
-		ORDER BY %s %s
-	`, c.Model.TableName(), paginate.OrderField, paginate.OrderBy),
-		&tags)
+	c.App.Cache.Del(cmn.GetRedisKey("tag", "all"))
+	c.App.Cache.ZRem(cmn.GetRedisKey("tag", "names"), name)
+	c.addNames(tag.Name)
 
-	c.GetDB().DB.Get(&count, fmt.Sprintf("SELECT count(*) FROM %s", c.Model.TableName()))
+	c.JSONResponse(ctx, model2.ResponseSuccessOne{
+		Data: tag,
+	}, fasthttp.StatusOK)
+}
 
-	var ts []interface{}
+// Delete tag with given identifier, post tags are removed with it
+func (c TagController) Delete(ctx *fasthttp.RequestCtx) {
+	var tag model.Tag
+	c.GetDB().QueryRowWithModel(fmt.Sprintf(`
+		SELECT t.* FROM %s AS t WHERE t.id::text = $1::text
+	`, c.Model.TableName()),
+		&tag,
+		phi.URLParam(ctx, "tagID")).Force()
+
+	c.GetDB().Delete(c.Model.TableName(), "id = $1", tag.ID).Force()
+
+	c.App.Cache.Del(fmt.Sprintf("%s:%d", cmn.GetRedisKey("tag", "count"), tag.ID))
+	c.App.Cache.ZRem(cmn.GetRedisKey("tag", "names"), tag.Name)
+	// synonyms of the tag are detached by the database
+	c.App.Cache.Del(cmn.GetRedisKey("tag", "all"))
+
+	c.JSONResponse(ctx, nil, fasthttp.StatusNoContent)
+}
+
+// Autocomplete tags starting with the query param. Synonyms are suggested
+// as their canonical tags, the most used tags come first.
+func (c TagController) Autocomplete(ctx *fasthttp.RequestCtx) {
+	queryParams := c.ParseQuery(ctx)
+	prefix := NormalizeTagName(queryParams["query"])
+
+	limit := 10
+	if val, err := strconv.Atoi(queryParams["limit"]); err == nil && val > 0 && val <= 40 {
+		limit = val
+	}
+
+	key := cmn.GetRedisKey("tag", "names")
+	if n, _ := c.GetCache().Exists(key).Result(); n == 0 {
+		var names []string
+		c.GetDB().DB.Select(&names, fmt.Sprintf(`
+			SELECT lower(t.name) FROM %s AS t
+		`, c.Model.TableName()))
+		var members []redis.Z
+		for _, name := range names {
+			members = append(members, redis.Z{Member: name})
+		}
+		if len(members) > 0 {
+			c.GetCache().ZAdd(key, members...)
+		}
+	}
+
+	// names are ordered lexicographically, a wider window of them is ranked
+	// by usage so popular tags are not cut off by their neighbours
+	names, _ := c.GetCache().ZRangeByLex(key, redis.ZRangeBy{
+		Min:   "[" + prefix,
+		Max:   "[" + prefix + "\xff",
+		Count: int64(limit * 5),
+	}).Result()
+
+	tags, _ := c.FindByNames(names)
+
+	var ids []int64
 	for _, t := range tags {
-		ts = append(ts, t.ToJSON())
+		ids = append(ids, t.ID)
+	}
+	counts := c.GetCounts(ids...)
+	for i := range tags {
+		tags[i].Count.SetValid(counts[tags[i].ID])
 	}
 
-	c.App.Cache.SAdd(cmn.GetRedisKey("tags", "all"), ts...)
+	sort.SliceStable(tags, func(i, j int) bool {
+		if tags[i].Count.Int64 != tags[j].Count.Int64 {
+			return tags[i].Count.Int64 > tags[j].Count.Int64
+		}
+		return tags[i].Name < tags[j].Name
+	})
+	if len(tags) > limit {
+		tags = tags[:limit]
+	}
 
 	c.JSONResponse(ctx, model2.ResponseSuccess{
 		Data:       tags,
-		TotalCount: count,
+		TotalCount: int64(len(tags)),
 	}, fasthttp.StatusOK)
-}
\ No newline at end of file
+}
+
+// Merge move posts of the tag to the target tag and keep the tag as a
+// synonym of the target. Post tags are rewritten in a single transaction,
+// so their source users are kept.
+func (c TagController) Merge(ctx *fasthttp.RequestCtx) {
+	var tag model.Tag
+	c.GetDB().QueryRowWithModel(fmt.Sprintf(`
+		SELECT t.* FROM %s AS t WHERE t.id::text = $1::text
+	`, c.Model.TableName()),
+		&tag,
+		phi.URLParam(ctx, "tagID")).Force()
+
+	target, ok := c.findTarget(ctx, tag)
+	if !ok {
+		return
+	}
+
+	var postTag model.PostTag
+	var err error
+	db := c.GetDB().Transaction(func(tx *database.Tx) error {
+		if err = tx.DB.Error; err != nil {
+			return err
+		}
+
+		_, err = tx.DB.Tx.Exec(fmt.Sprintf(`
+			UPDATE %s AS pt SET tag_id = $2
+			WHERE pt.tag_id = $1 AND NOT EXISTS (
+				SELECT 1 FROM %s AS pt2 WHERE pt2.post_id = pt.post_id AND pt2.tag_id = $2
+			)
+		`, postTag.TableName(), postTag.TableName()),
+			tag.ID,
+			target.ID)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.DB.Tx.Exec(fmt.Sprintf(`
+			DELETE FROM %s WHERE tag_id = $1
+		`, postTag.TableName()),
+			tag.ID)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.DB.Tx.Exec(fmt.Sprintf(`
+			UPDATE %s SET synonym_of_id = $2, updated_at = now() WHERE id = $1 OR synonym_of_id = $1
+		`, c.Model.TableName()),
+			tag.ID,
+			target.ID)
+		return err
+	})
+	if err == nil {
+		err = db.Error
+	}
+	if err != nil {
+		c.JSONResponse(ctx, model2.ResponseError{
+			Detail: fasthttp.StatusMessage(fasthttp.StatusInternalServerError),
+		}, fasthttp.StatusInternalServerError)
+		return
+	}
+
+	c.App.Cache.Del(cmn.GetRedisKey("tag", "all"),
+		fmt.Sprintf("%s:%d", cmn.GetRedisKey("tag", "count"), tag.ID),
+		fmt.Sprintf("%s:%d", cmn.GetRedisKey("tag", "count"), target.ID))
+
+	c.App.ElasticClient.UpdateByQuery("posts").
+		Query(elastic.NewTermQuery("tags.keyword", tag.Name)).
+		Script(elastic.NewScript(`
+			ctx._source.tags.removeIf(t -> t == params.from);
+			if (!ctx._source.tags.contains(params.to)) { ctx._source.tags.add(params.to) }
+		`).Params(map[string]interface{}{
+			"from": tag.Name,
+			"to":   target.Name,
+		})).
+		Do(context.TODO())
+
+	target.Count.SetValid(c.GetCounts(target.ID)[target.ID])
+
+	c.JSONResponse(ctx, model2.ResponseSuccessOne{
+		Data: target,
+	}, fasthttp.StatusOK)
+}
+
+// findTarget canonical tag of synonym and merge requests, a tag can not
+// target itself or one of its own synonyms
+func (c TagController) findTarget(ctx *fasthttp.RequestCtx, tag model.Tag) (model.Tag, bool) {
+	var target model.Tag
+	var request model2.TagTargetRequest
+	c.JSONBody(ctx, &request)
+
+	if errs, err := database.ValidateStruct(request); err != nil {
+		c.JSONResponse(ctx, model2.ResponseError{
+			Errors: errs,
+			Detail: fasthttp.StatusMessage(fasthttp.StatusUnprocessableEntity),
+		}, fasthttp.StatusUnprocessableEntity)
+		return target, false
+	}
+
+	err := c.GetDB().DB.Get(&target, fmt.Sprintf(`
+		SELECT ct.* FROM %s AS t
+		INNER JOIN %s AS ct ON ct.id = COALESCE(t.synonym_of_id, t.id)
+		WHERE t.id = $1
+	`, c.Model.TableName(), c.Model.TableName()),
+		request.TagID)
+	if err != nil || target.ID == tag.ID {
+		c.JSONResponse(ctx, model2.ResponseError{
+			Errors: map[string]string{
+				"tag_id": "is not valid",
+			},
+			Detail: fasthttp.StatusMessage(fasthttp.StatusUnprocessableEntity),
+		}, fasthttp.StatusUnprocessableEntity)
+		return target, false
+	}
+
+	return target, true
+}
+
+// addNames add tag names to the autocomplete set, a set which is not cached
+// yet is loaded with every name on the next autocomplete
+func (c TagController) addNames(names ...string) {
+	key := cmn.GetRedisKey("tag", "names")
+	if n, _ := c.GetCache().Exists(key).Result(); n == 0 {
+		return
+	}
+
+	var members []redis.Z
+	for _, name := range names {
+		members = append(members, redis.Z{Member: strings.ToLower(name)})
+	}
+	if len(members) > 0 {
+		c.GetCache().ZAdd(key, members...)
+	}
+}
+
+// GetCounts cached usage counts of tags, missing counts are loaded from
+// post tags and cached
+func (c TagController) GetCounts(ids ...int64) map[int64]int64 {
+	counts := make(map[int64]int64)
+	if len(ids) == 0 {
+		return counts
+	}
+
+	var keys []string
+	for _, id := range ids {
+		keys = append(keys, fmt.Sprintf("%s:%d", cmn.GetRedisKey("tag", "count"), id))
+	}
+
+	var missing []int64
+	values, _ := c.GetCache().MGet(keys...).Result()
+	for i, id := range ids {
+		if i >= len(values) || values[i] == nil {
+			missing = append(missing, id)
+			continue
+		}
+		var count int64
+		fmt.Sscan(values[i].(string), &count)
+		counts[id] = count
+	}
+
+	if len(missing) > 0 {
+		var postTag model.PostTag
+		var rows []struct {
+			TagID int64 `db:"tag_id"`
+			Count int64 `db:"count"`
+		}
+		c.GetDB().DB.Select(&rows, fmt.Sprintf(`
+			SELECT pt.tag_id, count(pt.id) as count FROM %s AS pt
+			WHERE pt.tag_id = ANY($1)
+			GROUP BY pt.tag_id
+		`, postTag.TableName()),
+			pq.Array(missing))
+		for _, r := range rows {
+			counts[r.TagID] = r.Count
+		}
+
+		pipe := c.GetCache().Pipeline()
+		for _, id := range missing {
+			pipe.Set(fmt.Sprintf("%s:%d", cmn.GetRedisKey("tag", "count"), id), counts[id], 0)
+		}
+		pipe.Exec()
+	}
+
+	return counts
+}
+
+// IncrCount change cached usage counts of tags, counts which are not cached
+// yet are loaded on the next read
+func (c TagController) IncrCount(delta int64, ids ...int64) {
+	for _, id := range ids {
+		key := fmt.Sprintf("%s:%d", cmn.GetRedisKey("tag", "count"), id)
+		if n, _ := c.GetCache().Exists(key).Result(); n > 0 {
+			c.GetCache().IncrBy(key, delta)
+		}
+	}
+}
+
+// GetLanguages latest localized names of tags for each language
+func (c TagController) GetLanguages(ids ...int64) map[int64][]model.TagLanguage {
+	languages := make(map[int64][]model.TagLanguage)
+	if len(ids) == 0 {
+		return languages
+	}
+
+	var keys []string
+	for _, id := range ids {
+		keys = append(keys, fmt.Sprintf("%s:%d", cmn.GetRedisKey("tag", "languages"), id))
+	}
+
+	s, err := c.GetCache().SUnion(keys...).Result()
+	if err != nil && err != redis.Nil {
+		return languages
+	}
+	latest := make(map[string]model.TagLanguage)
+	for _, cachedLang := range s {
+		var tl model.TagLanguage
+		json.Unmarshal([]byte(cachedLang), &tl)
+		key := fmt.Sprintf("%d:%d", tl.TagID, tl.LanguageID)
+		if l, ok := latest[key]; !ok || l.ID < tl.ID {
+			latest[key] = tl
+		}
+	}
+	for _, tl := range latest {
+		languages[tl.TagID] = append(languages[tl.TagID], tl)
+	}
+
+	return languages
+}
+
+// FindByNames tags with given names and the names which do not exist.
+// Synonyms are resolved to their canonical tags.
+func (c TagController) FindByNames(names []string) ([]model.Tag, []string) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	var normalized []string
+	for _, name := range names {
+		normalized = append(normalized, NormalizeTagName(name))
+	}
+
+	var rows []struct {
+		model.Tag
+		Match string `db:"matched_name"`
+	}
+	c.GetDB().DB.Select(&rows, fmt.Sprintf(`
+		SELECT ct.*, lower(t.name) as matched_name FROM %s AS t
+		INNER JOIN %s AS ct ON ct.id = COALESCE(t.synonym_of_id, t.id)
+		WHERE lower(t.name) = ANY($1)
+	`, c.Model.TableName(), c.Model.TableName()),
+		pq.Array(normalized))
+
+	var tags []model.Tag
+	found := make(map[string]bool)
+	seen := make(map[int64]bool)
+	for _, r := range rows {
+		found[r.Match] = true
+		if !seen[r.ID] {
+			seen[r.ID] = true
+			tags = append(tags, r.Tag)
+		}
+	}
+
+	var missing []string
+	for _, name := range normalized {
+		if !found[name] {
+			missing = append(missing, name)
+		}
+	}
+
+	return tags, missing
+}
+
+// NormalizeTagName tag names are kept lowercase with dashes instead of spaces
+func NormalizeTagName(name string) string {
+	return strings.Join(strings.Fields(strings.ToLower(name)), "-")
+}
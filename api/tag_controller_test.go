@@ -0,0 +1,313 @@
+package api
+
+import (
+	"fmt"
+	"forgolang_forum/cmn"
+	"forgolang_forum/database/model"
+	"github.com/valyala/fasthttp"
+	"testing"
+)
+
+type TagControllerTest struct {
+	*Suite
+}
+
+func (s TagControllerTest) SetupSuite() {
+	SetupSuite(s.Suite)
+	UserAuth(s.Suite)
+}
+
+func (s TagControllerTest) Test_ListAllTagsWithCounts() {
+	s.API.GetCache().Del(cmn.GetRedisKey("tag", "all"))
+
+	var tags []*model.Tag
+	for i := 0; i < 3; i++ {
+		tag := model.NewTag()
+		tag.Name = fmt.Sprintf("list-tag-%d", i)
+		err := s.API.GetDB().Insert(new(model.Tag), tag, "id", "inserted_at", "updated_at")
+		s.Nil(err)
+		tags = append(tags, tag)
+	}
+
+	post := model.NewPost(s.Auth.User.ID)
+	err := s.API.GetDB().Insert(new(model.Post), post, "id")
+	s.Nil(err)
+	postTag := model.NewPostTag(post.ID)
+	postTag.TagID = tags[1].ID
+	err = s.API.GetDB().Insert(new(model.PostTag), postTag, "id")
+	s.Nil(err)
+
+	response := s.JSON(Get, "/api/v1/tag?order_field=count&order_by=desc", nil)
+
+	s.Equal(response.Status, fasthttp.StatusOK)
+	s.Greater(response.Success.TotalCount, int64(2))
+	data, _ := response.Success.Data.([]interface{})
+	s.Equal(data[0].(map[string]interface{})["id"], float64(tags[1].ID))
+	s.Equal(data[0].(map[string]interface{})["count"], float64(1))
+
+	members, _ := s.API.GetCache().SCard(cmn.GetRedisKey("tag", "all")).Result()
+	s.Greater(members, int64(2))
+
+	defaultLogger.LogInfo("List all tags with counts")
+}
+
+func (s TagControllerTest) Test_CreateTagWithValidParams() {
+	tag := model.NewTag()
+	tag.Name = "Go Modules"
+
+	response := s.JSON(Post, "/api/v1/tag", tag)
+
+	s.Equal(response.Status, fasthttp.StatusCreated)
+	data, _ := response.Success.Data.(map[string]interface{})
+	s.Greater(data["id"], float64(0))
+	s.Equal(data["name"], "go-modules")
+
+	defaultLogger.LogInfo("Create tag with valid params")
+}
+
+func (s TagControllerTest) Test_ListAllTagsAfterCreateWithColdCache() {
+	existing := model.NewTag()
+	existing.Name = "cold-cache-tag"
+	err := s.API.GetDB().Insert(new(model.Tag), existing, "id", "inserted_at", "updated_at")
+	s.Nil(err)
+	s.API.GetCache().Del(cmn.GetRedisKey("tag", "all"))
+
+	tag := model.NewTag()
+	tag.Name = "created-after-flush"
+	response := s.JSON(Post, "/api/v1/tag", tag)
+	s.Equal(response.Status, fasthttp.StatusCreated)
+
+	response = s.JSON(Get, "/api/v1/tag?order_field=id&order_by=desc", nil)
+
+	s.Equal(response.Status, fasthttp.StatusOK)
+	var names []interface{}
+	for _, t := range response.Success.Data.([]interface{}) {
+		names = append(names, t.(map[string]interface{})["name"])
+	}
+	s.Contains(names, "cold-cache-tag")
+	s.Contains(names, "created-after-flush")
+
+	defaultLogger.LogInfo("List all tags after create with cold cache")
+}
+
+func (s TagControllerTest) Test_Should_422Err_CreateTagIfNameNotUnique() {
+	tag := model.NewTag()
+	tag.Name = "unique-tag"
+	err := s.API.GetDB().Insert(new(model.Tag), tag, "id", "inserted_at", "updated_at")
+	s.Nil(err)
+
+	response := s.JSON(Post, "/api/v1/tag", tag)
+
+	s.Equal(response.Status, fasthttp.StatusUnprocessableEntity)
+	data, _ := response.Error.Errors.(map[string]interface{})
+	s.Equal(data["name"], "has been already taken")
+
+	defaultLogger.LogInfo("Should be 422 error create tag if name has been already taken")
+}
+
+func (s TagControllerTest) Test_UpdateTagWithValidParams() {
+	tag := model.NewTag()
+	tag.Name = "rename-tag"
+	err := s.API.GetDB().Insert(new(model.Tag), tag, "id", "inserted_at", "updated_at")
+	s.Nil(err)
+
+	tagRequest := model.NewTag()
+	tagRequest.Name = "renamed-tag"
+
+	response := s.JSON(Put, fmt.Sprintf("/api/v1/tag/%d", tag.ID), tagRequest)
+
+	s.Equal(response.Status, fasthttp.StatusOK)
+	data, _ := response.Success.Data.(map[string]interface{})
+	s.Equal(data["name"], "renamed-tag")
+
+	defaultLogger.LogInfo("Update tag with valid params")
+}
+
+func (s TagControllerTest) Test_ShowAndDeleteTagWithGivenIdentifier() {
+	tag := model.NewTag()
+	tag.Name = "delete-tag"
+	err := s.API.GetDB().Insert(new(model.Tag), tag, "id", "inserted_at", "updated_at")
+	s.Nil(err)
+
+	response := s.JSON(Get, fmt.Sprintf("/api/v1/tag/%s", tag.Name), nil)
+
+	s.Equal(response.Status, fasthttp.StatusOK)
+	data, _ := response.Success.Data.(map[string]interface{})
+	s.Equal(data["id"], float64(tag.ID))
+	s.Equal(data["count"], float64(0))
+
+	response = s.JSON(Delete, fmt.Sprintf("/api/v1/tag/%d", tag.ID), nil)
+
+	s.Equal(response.Status, fasthttp.StatusNoContent)
+
+	response = s.JSON(Get, fmt.Sprintf("/api/v1/tag/%d", tag.ID), nil)
+
+	s.Equal(response.Status, fasthttp.StatusNotFound)
+
+	defaultLogger.LogInfo("Show and delete tag with given identifier")
+}
+
+func (s TagControllerTest) Test_CreateTagLanguageWithValidParams() {
+	tag := model.NewTag()
+	tag.Name = "language-tag"
+	err := s.API.GetDB().Insert(new(model.Tag), tag, "id", "inserted_at", "updated_at")
+	s.Nil(err)
+
+	tagLanguage := new(model.TagLanguage)
+	tagLanguage.LanguageID = s.API.GetLanguage("tr-TR").ID
+	tagLanguage.Name = "dil-etiketi"
+
+	response := s.JSON(Post, fmt.Sprintf("/api/v1/tag/%d/language", tag.ID), tagLanguage)
+
+	s.Equal(response.Status, fasthttp.StatusCreated)
+	data, _ := response.Success.Data.(map[string]interface{})
+	s.Equal(data["tag_id"], float64(tag.ID))
+	s.Equal(data["name"], "dil-etiketi")
+
+	response = s.JSON(Get, fmt.Sprintf("/api/v1/tag/%d", tag.ID), nil)
+
+	s.Equal(response.Status, fasthttp.StatusOK)
+	data, _ = response.Success.Data.(map[string]interface{})
+	languages := data["languages"].([]interface{})
+	s.Equal(len(languages), 1)
+	s.Equal(languages[0].(map[string]interface{})["name"], "dil-etiketi")
+
+	defaultLogger.LogInfo("Create tag language with valid params")
+}
+
+func (s TagControllerTest) Test_AutocompleteTagsAfterCreateWithColdCache() {
+	existing := model.NewTag()
+	existing.Name = "flushed-existing"
+	err := s.API.GetDB().Insert(new(model.Tag), existing, "id", "inserted_at", "updated_at")
+	s.Nil(err)
+	s.API.GetCache().Del(cmn.GetRedisKey("tag", "names"))
+
+	tag := model.NewTag()
+	tag.Name = "flushed-created"
+	response := s.JSON(Post, "/api/v1/tag", tag)
+	s.Equal(response.Status, fasthttp.StatusCreated)
+
+	response = s.JSON(Get, "/api/v1/tag/autocomplete?query=flushed", nil)
+
+	s.Equal(response.Status, fasthttp.StatusOK)
+	s.Equal(response.Success.TotalCount, int64(2))
+
+	defaultLogger.LogInfo("Autocomplete tags after create with cold cache")
+}
+
+func (s TagControllerTest) Test_AutocompleteTagsWithGivenPrefix() {
+	s.API.GetCache().Del(cmn.GetRedisKey("tag", "names"))
+
+	var tags []*model.Tag
+	for _, name := range []string{"complete-alpha", "complete-beta", "other-tag"} {
+		tag := model.NewTag()
+		tag.Name = name
+		err := s.API.GetDB().Insert(new(model.Tag), tag, "id", "inserted_at", "updated_at")
+		s.Nil(err)
+		tags = append(tags, tag)
+	}
+
+	synonym := model.NewTag()
+	synonym.Name = "complete-gamma"
+	synonym.SynonymOfID.SetValid(tags[0].ID)
+	err := s.API.GetDB().Insert(new(model.Tag), synonym, "id", "inserted_at", "updated_at")
+	s.Nil(err)
+
+	post := model.NewPost(s.Auth.User.ID)
+	err = s.API.GetDB().Insert(new(model.Post), post, "id")
+	s.Nil(err)
+	postTag := model.NewPostTag(post.ID)
+	postTag.TagID = tags[1].ID
+	err = s.API.GetDB().Insert(new(model.PostTag), postTag, "id")
+	s.Nil(err)
+
+	response := s.JSON(Get, "/api/v1/tag/autocomplete?query=Complete", nil)
+
+	s.Equal(response.Status, fasthttp.StatusOK)
+	s.Equal(response.Success.TotalCount, int64(2))
+	data, _ := response.Success.Data.([]interface{})
+	s.Equal(data[0].(map[string]interface{})["name"], "complete-beta")
+	s.Equal(data[1].(map[string]interface{})["name"], "complete-alpha")
+
+	defaultLogger.LogInfo("Autocomplete tags with given prefix")
+}
+
+func (s TagControllerTest) Test_MergeTagIntoTargetTag() {
+	var tags []*model.Tag
+	for _, name := range []string{"merge-source", "merge-target"} {
+		tag := model.NewTag()
+		tag.Name = name
+		err := s.API.GetDB().Insert(new(model.Tag), tag, "id", "inserted_at", "updated_at")
+		s.Nil(err)
+		tags = append(tags, tag)
+	}
+
+	var posts []*model.Post
+	for i := 0; i < 2; i++ {
+		post := model.NewPost(s.Auth.User.ID)
+		err := s.API.GetDB().Insert(new(model.Post), post, "id")
+		s.Nil(err)
+		posts = append(posts, post)
+
+		postTag := model.NewPostTag(post.ID)
+		postTag.TagID = tags[0].ID
+		postTag.SourceUserID.SetValid(s.Auth.User.ID)
+		err = s.API.GetDB().Insert(new(model.PostTag), postTag, "id")
+		s.Nil(err)
+	}
+	postTag := model.NewPostTag(posts[1].ID)
+	postTag.TagID = tags[1].ID
+	err := s.API.GetDB().Insert(new(model.PostTag), postTag, "id")
+	s.Nil(err)
+
+	response := s.JSON(Post, fmt.Sprintf("/api/v1/tag/%d/merge", tags[0].ID), map[string]interface{}{
+		"tag_id": tags[1].ID,
+	})
+
+	s.Equal(response.Status, fasthttp.StatusOK)
+	data, _ := response.Success.Data.(map[string]interface{})
+	s.Equal(data["id"], float64(tags[1].ID))
+	s.Equal(data["count"], float64(2))
+
+	var sourceUserID int64
+	err = s.API.GetDB().DB.Get(&sourceUserID, fmt.Sprintf(`
+		SELECT pt.source_user_id FROM %s AS pt WHERE pt.post_id = $1 AND pt.tag_id = $2
+	`, postTag.TableName()),
+		posts[0].ID,
+		tags[1].ID)
+	s.Nil(err)
+	s.Equal(sourceUserID, s.Auth.User.ID)
+
+	var synonymOfID int64
+	s.API.GetDB().DB.Get(&synonymOfID, fmt.Sprintf(`
+		SELECT t.synonym_of_id FROM %s AS t WHERE t.id = $1
+	`, tags[0].TableName()),
+		tags[0].ID)
+	s.Equal(synonymOfID, tags[1].ID)
+
+	defaultLogger.LogInfo("Merge tag into target tag")
+}
+
+func (s TagControllerTest) Test_Should_422Err_MergeTagIntoItself() {
+	tag := model.NewTag()
+	tag.Name = "merge-itself"
+	err := s.API.GetDB().Insert(new(model.Tag), tag, "id", "inserted_at", "updated_at")
+	s.Nil(err)
+
+	response := s.JSON(Post, fmt.Sprintf("/api/v1/tag/%d/merge", tag.ID), map[string]interface{}{
+		"tag_id": tag.ID,
+	})
+
+	s.Equal(response.Status, fasthttp.StatusUnprocessableEntity)
+
+	defaultLogger.LogInfo("Should be 422 error merge tag into itself")
+}
+
+func (s TagControllerTest) TearDownSuite() {
+	TearDownSuite(s.Suite)
+}
+
+func Test_TagController(t *testing.T) {
+	s := TagControllerTest{NewSuite()}
+	Run(t, s)
+}
@@ -0,0 +1,75 @@
+// Copyright 2019 StreetByters Community
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with
+// this work for additional information regarding copyright ownership.
+// The ASF licenses this file to You under the Apache License, Version 2.0
+// (the "License"); you may not use this file except in compliance with
+// the License.  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"fmt"
+	"forgolang_forum/cmn"
+	"forgolang_forum/database"
+	model2 "forgolang_forum/database/model"
+	"forgolang_forum/model"
+	"github.com/fate-lovely/phi"
+	"github.com/valyala/fasthttp"
+	"strconv"
+)
+
+// TagLanguageController tag localization(L10N) api structure
+type TagLanguageController struct {
+	Controller
+	*API
+}
+
+// Create tag language with valid params, a new name of the same language
+// replaces the previous one
+func (c TagLanguageController) Create(ctx *fasthttp.RequestCtx) {
+	tagID, err := strconv.ParseInt(phi.URLParam(ctx, "tagID"), 10, 64)
+	if err != nil {
+		c.JSONResponse(ctx, model.ResponseError{
+			Detail: fasthttp.StatusMessage(fasthttp.StatusBadRequest),
+		}, fasthttp.StatusBadRequest)
+		return
+	}
+
+	tagLanguage := new(model2.TagLanguage)
+	c.JSONBody(ctx, &tagLanguage)
+	tagLanguage.TagID = tagID
+	tagLanguage.SourceUserID.SetValid(c.GetAuthContext(ctx).ID)
+	if errs, err := database.ValidateStruct(tagLanguage); err != nil {
+		c.JSONResponse(ctx, model.ResponseError{
+			Errors: errs,
+			Detail: fasthttp.StatusMessage(fasthttp.StatusUnprocessableEntity),
+		}, fasthttp.StatusUnprocessableEntity)
+		return
+	}
+
+	err = c.GetDB().Insert(new(model2.TagLanguage), tagLanguage, "id", "inserted_at")
+	if errs, err := database.ValidateConstraint(err, tagLanguage); err != nil {
+		c.JSONResponse(ctx, model.ResponseError{
+			Errors: errs,
+			Detail: fasthttp.StatusMessage(fasthttp.StatusUnprocessableEntity),
+		}, fasthttp.StatusUnprocessableEntity)
+		return
+	}
+
+	c.GetCache().SAdd(fmt.Sprintf("%s:%d",
+		cmn.GetRedisKey("tag", "languages"),
+		tagID), tagLanguage.ToJSON())
+
+	c.JSONResponse(ctx, model.ResponseSuccessOne{
+		Data: tagLanguage,
+	}, fasthttp.StatusCreated)
+}
@@ -0,0 +1,83 @@
+// Copyright 2019 StreetByters Community
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with
+// this work for additional information regarding copyright ownership.
+// The ASF licenses this file to You under the Apache License, Version 2.0
+// (the "License"); you may not use this file except in compliance with
+// the License.  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"fmt"
+	"forgolang_forum/cmn"
+	"forgolang_forum/database/model"
+	model2 "forgolang_forum/model"
+	"github.com/fate-lovely/phi"
+	"github.com/valyala/fasthttp"
+)
+
+// TagSynonymController tag synonyms api controller
+type TagSynonymController struct {
+	Controller
+	*API
+	Model model.Tag
+}
+
+// Create declare the tag as a synonym of the target tag, new posts using
+// the tag or its synonyms are tagged with the target
+func (c TagSynonymController) Create(ctx *fasthttp.RequestCtx) {
+	var tag model.Tag
+	c.GetDB().QueryRowWithModel(fmt.Sprintf(`
+		SELECT t.* FROM %s AS t WHERE t.id::text = $1::text
+	`, c.Model.TableName()),
+		&tag,
+		phi.URLParam(ctx, "tagID")).Force()
+
+	tC := TagController{API: c.API}
+	target, ok := tC.findTarget(ctx, tag)
+	if !ok {
+		return
+	}
+
+	c.GetDB().DB.Exec(fmt.Sprintf(`
+		UPDATE %s SET synonym_of_id = $2, updated_at = now() WHERE id = $1 OR synonym_of_id = $1
+	`, c.Model.TableName()),
+		tag.ID,
+		target.ID)
+
+	c.App.Cache.Del(cmn.GetRedisKey("tag", "all"))
+
+	tag.SynonymOfID.SetValid(target.ID)
+
+	c.JSONResponse(ctx, model2.ResponseSuccessOne{
+		Data: tag,
+	}, fasthttp.StatusCreated)
+}
+
+// Delete make the tag canonical again
+func (c TagSynonymController) Delete(ctx *fasthttp.RequestCtx) {
+	var tag model.Tag
+	c.GetDB().QueryRowWithModel(fmt.Sprintf(`
+		SELECT t.* FROM %s AS t WHERE t.id::text = $1::text AND t.synonym_of_id IS NOT NULL
+	`, c.Model.TableName()),
+		&tag,
+		phi.URLParam(ctx, "tagID")).Force()
+
+	c.GetDB().DB.Exec(fmt.Sprintf(`
+		UPDATE %s SET synonym_of_id = NULL, updated_at = now() WHERE id = $1
+	`, c.Model.TableName()),
+		tag.ID)
+
+	c.App.Cache.Del(cmn.GetRedisKey("tag", "all"))
+
+	c.JSONResponse(ctx, nil, fasthttp.StatusNoContent)
+}
@@ -0,0 +1,114 @@
+package api
+
+import (
+	"fmt"
+	"forgolang_forum/database/model"
+	"github.com/valyala/fasthttp"
+	"testing"
+)
+
+type TagSynonymControllerTest struct {
+	*Suite
+}
+
+func (s TagSynonymControllerTest) SetupSuite() {
+	SetupSuite(s.Suite)
+	UserAuth(s.Suite)
+}
+
+func (s TagSynonymControllerTest) Test_CreateTagSynonymAndRemapNewPosts() {
+	var tags []*model.Tag
+	for _, name := range []string{"golang", "go"} {
+		tag := model.NewTag()
+		tag.Name = name
+		err := s.API.GetDB().Insert(new(model.Tag), tag, "id", "inserted_at", "updated_at")
+		s.Nil(err)
+		tags = append(tags, tag)
+	}
+
+	response := s.JSON(Post, fmt.Sprintf("/api/v1/tag/%d/synonym", tags[0].ID), map[string]interface{}{
+		"tag_id": tags[1].ID,
+	})
+
+	s.Equal(response.Status, fasthttp.StatusCreated)
+	data, _ := response.Success.Data.(map[string]interface{})
+	s.Equal(data["synonym_of_id"], float64(tags[1].ID))
+
+	postDep := new(model.PostDEP)
+	postDep.Title.SetValid("Post title synonym")
+	postDep.Content.SetValid("Post content synonym")
+	postDep.Tags = []string{"golang"}
+
+	response = s.JSON(Post, "/api/v1/post", postDep)
+
+	s.Equal(response.Status, fasthttp.StatusCreated)
+	data, _ = response.Success.Data.(map[string]interface{})
+	s.Equal(data["tags"], []interface{}{"go"})
+
+	post := model.NewPost(s.Auth.User.ID)
+	err := s.API.GetDB().Insert(new(model.Post), post, "id")
+	s.Nil(err)
+
+	response = s.JSON(Post, fmt.Sprintf("/api/v1/post/%d/tag", post.ID), map[string]interface{}{
+		"tag_id": tags[0].ID,
+	})
+
+	s.Equal(response.Status, fasthttp.StatusCreated)
+	data, _ = response.Success.Data.(map[string]interface{})
+	s.Equal(data["tag_id"], float64(tags[1].ID))
+
+	defaultLogger.LogInfo("Create tag synonym and remap new posts")
+}
+
+func (s TagSynonymControllerTest) Test_DeleteTagSynonym() {
+	target := model.NewTag()
+	target.Name = "synonym-target"
+	err := s.API.GetDB().Insert(new(model.Tag), target, "id", "inserted_at", "updated_at")
+	s.Nil(err)
+
+	tag := model.NewTag()
+	tag.Name = "synonym-source"
+	tag.SynonymOfID.SetValid(target.ID)
+	err = s.API.GetDB().Insert(new(model.Tag), tag, "id", "inserted_at", "updated_at")
+	s.Nil(err)
+
+	response := s.JSON(Delete, fmt.Sprintf("/api/v1/tag/%d/synonym", tag.ID), nil)
+
+	s.Equal(response.Status, fasthttp.StatusNoContent)
+
+	response = s.JSON(Delete, fmt.Sprintf("/api/v1/tag/%d/synonym", tag.ID), nil)
+
+	s.Equal(response.Status, fasthttp.StatusNotFound)
+
+	defaultLogger.LogInfo("Delete tag synonym")
+}
+
+func (s TagSynonymControllerTest) Test_Should_422Err_CreateTagSynonymOfOwnSynonym() {
+	tag := model.NewTag()
+	tag.Name = "synonym-cycle"
+	err := s.API.GetDB().Insert(new(model.Tag), tag, "id", "inserted_at", "updated_at")
+	s.Nil(err)
+
+	synonym := model.NewTag()
+	synonym.Name = "synonym-cycle-of"
+	synonym.SynonymOfID.SetValid(tag.ID)
+	err = s.API.GetDB().Insert(new(model.Tag), synonym, "id", "inserted_at", "updated_at")
+	s.Nil(err)
+
+	response := s.JSON(Post, fmt.Sprintf("/api/v1/tag/%d/synonym", tag.ID), map[string]interface{}{
+		"tag_id": synonym.ID,
+	})
+
+	s.Equal(response.Status, fasthttp.StatusUnprocessableEntity)
+
+	defaultLogger.LogInfo("Should be 422 error create tag synonym of own synonym")
+}
+
+func (s TagSynonymControllerTest) TearDownSuite() {
+	TearDownSuite(s.Suite)
+}
+
+func Test_TagSynonymController(t *testing.T) {
+	s := TagSynonymControllerTest{NewSuite()}
+	Run(t, s)
+}
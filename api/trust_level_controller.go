@@ -0,0 +1,111 @@
+// Copyright 2019 StreetByters Community
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with
+// this work for additional information regarding copyright ownership.
+// The ASF licenses this file to You under the Apache License, Version 2.0
+// (the "License"); you may not use this file except in compliance with
+// the License.  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"database/sql"
+	"forgolang_forum/database"
+	"forgolang_forum/database/model"
+	model2 "forgolang_forum/model"
+	"forgolang_forum/tasks"
+	"github.com/fate-lovely/phi"
+	"github.com/valyala/fasthttp"
+	"strconv"
+	"time"
+)
+
+// TrustLevelController trust levels of users api controller
+type TrustLevelController struct {
+	Controller
+	*API
+	Model model.UserTrustLevel
+}
+
+// Show current trust level of a user with the activity it is computed from
+func (c TrustLevelController) Show(ctx *fasthttp.RequestCtx) {
+	stats, err := tasks.GetTrustStats(c.App, c.userID(ctx))
+	if err == sql.ErrNoRows {
+		c.JSONResponse(ctx, model2.ResponseError{
+			Detail: fasthttp.StatusMessage(fasthttp.StatusNotFound),
+		}, fasthttp.StatusNotFound)
+		return
+	}
+	stats.Limit = tasks.TrustLimits[stats.Level]
+
+	c.JSONResponse(ctx, model2.ResponseSuccessOne{
+		Data: stats,
+	}, fasthttp.StatusOK)
+}
+
+// Create override the trust level of a user, the level is locked until a
+// moderator unlocks it
+func (c TrustLevelController) Create(ctx *fasthttp.RequestCtx) {
+	userTrustLevel := model.NewUserTrustLevel(c.userID(ctx), database.NewUser)
+	c.JSONBody(ctx, &userTrustLevel)
+	userTrustLevel.UserID = c.userID(ctx)
+	userTrustLevel.Locked = true
+	userTrustLevel.SourceUserID.SetValid(c.GetAuthContext(ctx).ID)
+
+	c.save(ctx, userTrustLevel)
+}
+
+// Delete unlock the trust level of a user, the level is computed from the
+// activity of the user again
+func (c TrustLevelController) Delete(ctx *fasthttp.RequestCtx) {
+	stats, err := tasks.GetTrustStats(c.App, c.userID(ctx))
+	if err == sql.ErrNoRows {
+		c.JSONResponse(ctx, model2.ResponseError{
+			Detail: fasthttp.StatusMessage(fasthttp.StatusNotFound),
+		}, fasthttp.StatusNotFound)
+		return
+	}
+
+	userTrustLevel := model.NewUserTrustLevel(stats.UserID, stats.ComputeLevel(time.Now().UTC()))
+	userTrustLevel.SourceUserID.SetValid(c.GetAuthContext(ctx).ID)
+
+	c.save(ctx, userTrustLevel)
+}
+
+// save validate and append a trust level of a user
+func (c TrustLevelController) save(ctx *fasthttp.RequestCtx, userTrustLevel *model.UserTrustLevel) {
+	if errs, err := database.ValidateStruct(userTrustLevel); err != nil {
+		c.JSONResponse(ctx, model2.ResponseError{
+			Errors: errs,
+			Detail: fasthttp.StatusMessage(fasthttp.StatusUnprocessableEntity),
+		}, fasthttp.StatusUnprocessableEntity)
+		return
+	}
+
+	err := tasks.SetTrustLevel(c.App, userTrustLevel)
+	if errs, err := database.ValidateConstraint(err, userTrustLevel); err != nil {
+		c.JSONResponse(ctx, model2.ResponseError{
+			Errors: errs,
+			Detail: fasthttp.StatusMessage(fasthttp.StatusUnprocessableEntity),
+		}, fasthttp.StatusUnprocessableEntity)
+		return
+	}
+
+	c.JSONResponse(ctx, model2.ResponseSuccessOne{
+		Data: userTrustLevel,
+	}, fasthttp.StatusCreated)
+}
+
+// userID user identifier of the route, policies reject invalid identifiers
+func (c TrustLevelController) userID(ctx *fasthttp.RequestCtx) int64 {
+	userID, _ := strconv.ParseInt(phi.URLParam(ctx, "userID"), 10, 64)
+	return userID
+}
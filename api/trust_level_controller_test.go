@@ -0,0 +1,146 @@
+package api
+
+import (
+	"fmt"
+	"forgolang_forum/database"
+	"forgolang_forum/database/model"
+	"forgolang_forum/tasks"
+	"github.com/valyala/fasthttp"
+	"testing"
+)
+
+type TrustLevelControllerTest struct {
+	*Suite
+}
+
+func (s TrustLevelControllerTest) SetupSuite() {
+	SetupSuite(s.Suite)
+	UserAuth(s.Suite)
+}
+
+func (s TrustLevelControllerTest) Test_ShowTrustLevelOfNewUser() {
+	UserAuth(s.Suite, "user")
+
+	response := s.JSON(Get, fmt.Sprintf("/api/v1/user/%d/trust_level", s.Auth.User.ID), nil)
+	s.Equal(response.Status, fasthttp.StatusOK)
+	data, _ := response.Success.Data.(map[string]interface{})
+	s.Equal(data["level"], float64(database.NewUser))
+	s.Equal(data["locked"], false)
+
+	UserAuth(s.Suite)
+
+	defaultLogger.LogInfo("Show trust level of new user")
+}
+
+func (s TrustLevelControllerTest) Test_LockAndUnlockTrustLevel() {
+	UserAuth(s.Suite, "user")
+	userID := s.Auth.User.ID
+	s.Equal(tasks.GetTrustLevel(s.API.App, userID), database.NewUser)
+
+	UserAuth(s.Suite, "moderator")
+
+	userTrustLevel := model.NewUserTrustLevel(userID, database.Regular)
+	response := s.JSON(Post, fmt.Sprintf("/api/v1/user/%d/trust_level", userID), userTrustLevel)
+	s.Equal(response.Status, fasthttp.StatusCreated)
+	s.Equal(tasks.GetTrustLevel(s.API.App, userID), database.Regular)
+
+	// locked levels are kept by the trust level task
+	s.Nil(tasks.RefreshTrustLevels(s.API.App, nil))
+	s.Equal(tasks.GetTrustLevel(s.API.App, userID), database.Regular)
+
+	response = s.JSON(Delete, fmt.Sprintf("/api/v1/user/%d/trust_level", userID), nil)
+	s.Equal(response.Status, fasthttp.StatusCreated)
+	s.Equal(tasks.GetTrustLevel(s.API.App, userID), database.NewUser)
+
+	UserAuth(s.Suite)
+
+	defaultLogger.LogInfo("Lock and unlock trust level")
+}
+
+func (s TrustLevelControllerTest) Test_Should_422Err_LockInvalidTrustLevel() {
+	UserAuth(s.Suite, "user")
+	userID := s.Auth.User.ID
+
+	UserAuth(s.Suite, "moderator")
+
+	userTrustLevel := model.NewUserTrustLevel(userID, database.TrustLevel(9))
+	response := s.JSON(Post, fmt.Sprintf("/api/v1/user/%d/trust_level", userID), userTrustLevel)
+	s.Equal(response.Status, fasthttp.StatusUnprocessableEntity)
+
+	UserAuth(s.Suite)
+
+	defaultLogger.LogInfo("Should be 422 error lock invalid trust level")
+}
+
+func (s TrustLevelControllerTest) Test_Should_403Err_LockOwnTrustLevel() {
+	UserAuth(s.Suite, "user")
+
+	userTrustLevel := model.NewUserTrustLevel(s.Auth.User.ID, database.Leader)
+	response := s.JSON(Post, fmt.Sprintf("/api/v1/user/%d/trust_level", s.Auth.User.ID), userTrustLevel)
+	s.Equal(response.Status, fasthttp.StatusForbidden)
+
+	UserAuth(s.Suite)
+
+	defaultLogger.LogInfo("Should be 403 error lock own trust level")
+}
+
+func (s TrustLevelControllerTest) Test_Should_403Err_NewUserExceedsPostsPerDay() {
+	UserAuth(s.Suite, "user")
+
+	for i := int64(0); i < tasks.TrustLimits[database.NewUser].PostsPerDay; i++ {
+		post := model.NewPost(s.Auth.User.ID)
+		err := s.API.GetDB().Insert(new(model.Post), post, "id")
+		s.Nil(err)
+	}
+
+	postDep := new(model.PostDEP)
+	postDep.Title.SetValid("Too many posts")
+	postDep.Content.SetValid("Too many posts for a new user")
+	response := s.JSON(Post, "/api/v1/post", postDep)
+	s.Equal(response.Status, fasthttp.StatusForbidden)
+
+	UserAuth(s.Suite)
+
+	defaultLogger.LogInfo("Should be 403 error new user exceeds posts per day")
+}
+
+func (s TrustLevelControllerTest) Test_Should_422Err_NewUserPostsTooManyLinks() {
+	UserAuth(s.Suite, "user")
+
+	postDep := new(model.PostDEP)
+	postDep.Title.SetValid("Too many links")
+	postDep.Content.SetValid("https://a.com https://b.com https://c.com")
+	response := s.JSON(Post, "/api/v1/post", postDep)
+	s.Equal(response.Status, fasthttp.StatusUnprocessableEntity)
+	data, _ := response.Error.Errors.(map[string]interface{})
+	s.Equal(data["content"], "has too many links for your trust level")
+
+	UserAuth(s.Suite)
+
+	defaultLogger.LogInfo("Should be 422 error new user posts too many links")
+}
+
+func (s TrustLevelControllerTest) Test_Should_403Err_NewUserUploadsAndCreatesTag() {
+	UserAuth(s.Suite, "user")
+
+	response := s.JSON(Post, "/api/v1/upload", nil)
+	s.Equal(response.Status, fasthttp.StatusForbidden)
+
+	tag := new(model.Tag)
+	tag.Name = "trust-level-tag"
+	response = s.JSON(Post, "/api/v1/tag", tag)
+	s.Equal(response.Status, fasthttp.StatusForbidden)
+
+	UserAuth(s.Suite)
+
+	defaultLogger.LogInfo("Should be 403 error new user uploads and creates tag")
+}
+
+func (s TrustLevelControllerTest) TearDownSuite() {
+	TearDownSuite(s.Suite)
+}
+
+func Test_TrustLevelController(t *testing.T) {
+	s := TrustLevelControllerTest{NewSuite()}
+	Run(t, s)
+}
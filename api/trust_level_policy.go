@@ -0,0 +1,62 @@
+// Copyright 2019 StreetByters Community
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with
+// this work for additional information regarding copyright ownership.
+// The ASF licenses this file to You under the Apache License, Version 2.0
+// (the "License"); you may not use this file except in compliance with
+// the License.  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"github.com/fate-lovely/phi"
+	"github.com/valyala/fasthttp"
+	"strconv"
+)
+
+// TrustLevelPolicy trust level authorization
+type TrustLevelPolicy struct {
+	Policy
+	*API
+}
+
+// Show method for trust level api authorization, users see only their own
+// trust level while moderators see any trust level
+func (p TrustLevelPolicy) Show(next phi.HandlerFunc) phi.HandlerFunc {
+	return p.API.Authorization.Apply(next, "TrustLevelController", "Show",
+		func(ctx *fasthttp.RequestCtx) bool {
+			if p.IsModerator(ctx) {
+				return true
+			}
+			i, err := strconv.ParseInt(phi.URLParam(ctx, "userID"), 10, 64)
+			return err == nil && i == p.GetAuthContext(ctx).ID
+		})
+}
+
+// Create method for trust level api authorization, moderators override
+// trust levels of other users
+func (p TrustLevelPolicy) Create(next phi.HandlerFunc) phi.HandlerFunc {
+	return p.API.Authorization.Apply(next, "TrustLevelController", "Create",
+		func(ctx *fasthttp.RequestCtx) bool {
+			i, err := strconv.ParseInt(phi.URLParam(ctx, "userID"), 10, 64)
+			return err == nil && i != p.GetAuthContext(ctx).ID
+		})
+}
+
+// Delete method for trust level api authorization, moderators unlock trust
+// levels of other users
+func (p TrustLevelPolicy) Delete(next phi.HandlerFunc) phi.HandlerFunc {
+	return p.API.Authorization.Apply(next, "TrustLevelController", "Delete",
+		func(ctx *fasthttp.RequestCtx) bool {
+			i, err := strconv.ParseInt(phi.URLParam(ctx, "userID"), 10, 64)
+			return err == nil && i != p.GetAuthContext(ctx).ID
+		})
+}
@@ -36,6 +36,7 @@ import (
 	"path/filepath"
 	"strings"
 	"syscall"
+	"time"
 )
 
 var (
@@ -49,6 +50,7 @@ var (
 	genSecret  bool
 	task       bool
 	name       string
+	interval   time.Duration
 )
 
 func main() {
@@ -64,6 +66,7 @@ func main() {
 	flag.BoolVar(&genSecret, "genSecretEnv", false, "Generate secret env file")
 	flag.BoolVar(&task, "task", false, "Run a tasks")
 	flag.StringVar(&name, "name", "", "The name of the task to be run")
+	flag.DurationVar(&interval, "interval", 0, "Run the task repeatedly with given interval")
 	flag.Parse()
 
 	if appPath == "" {
@@ -129,6 +132,7 @@ func main() {
 		RedisDB:            viper.GetInt("REDIS_DB"),
 		ElasticHost:        viper.GetString("ELASTIC_HOST"),
 		ElasticPort:        viper.GetInt("ELASTIC_PORT"),
+		FlagHideThreshold:  viper.GetInt64("FLAG_HIDE_THRESHOLD"),
 		GithubClientID:     viper.GetString("GITHUB_CLIENT_ID"),
 		GithubClientSecret: viper.GetString("GITHUB_CLIENT_SECRET"),
 	}
@@ -154,6 +158,14 @@ func main() {
 	_ts := make(map[string]func(app *cmn.App, args interface{}) error)
 	_ts["GenerateBase"] = tasks.GenerateBase
 	_ts["GenerateRolePermissions"] = tasks.GenerateRolePermissions
+	_ts["RefreshPostRankings"] = tasks.RefreshPostRankings
+	_ts["RefreshCommentRanks"] = tasks.RefreshCommentRanks
+	_ts["PublishScheduledPosts"] = tasks.PublishScheduledPosts
+	_ts["PurgeDeletedContent"] = tasks.PurgeDeletedContent
+	_ts["SendSubscriptionDigests"] = tasks.SendSubscriptionDigests
+	_ts["SendBookmarkReminders"] = tasks.SendBookmarkReminders
+	_ts["AwardBadges"] = tasks.AwardBadges
+	_ts["RefreshTrustLevels"] = tasks.RefreshTrustLevels
 	// Tasks
 
 	if migrate {
@@ -175,10 +187,25 @@ func main() {
 		if !ok {
 			logger.LogFatal(errors.New("task not found"))
 		}
-		if err := _t(newApp, taskArgs); err != nil {
-			panic(err)
+		if interval <= 0 {
+			if err := _t(newApp, taskArgs); err != nil {
+				panic(err)
+			}
+			return
+		}
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if err := _t(newApp, taskArgs); err != nil {
+				logger.LogError(err, fmt.Sprintf("%s task failed", name))
+			}
+			select {
+			case <-ticker.C:
+			case <-newApp.Channel:
+				return
+			}
 		}
-		return
 	}
 
 	go func() {
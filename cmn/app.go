@@ -99,29 +99,58 @@ func NewApp(config *model.Config, logger *utils.Logger) *App {
 	RedisKeys["permissions"] = "permissions"
 	RedisKeys["routes"] = "routes"
 	RedisKeys["user"] = map[string]string{
-		"one":         "user",
-		"permissions": "user:permissions",
-		"permission":  "user:permission",
+		"one":           "user",
+		"permissions":   "user:permissions",
+		"permission":    "user:permission",
+		"reputation":    "user:reputation",
+		"trust":         "user:trust",
+		"visit":         "user:visit",
+		"flag_accuracy": "user:flag_accuracy",
 	}
 	RedisKeys["category"] = map[string]string{
 		"all":       "categories",
 		"one":       "category",
 		"slug":      "category:slug",
 		"languages": "category:languages",
+		"posts":     "category:posts:count",
 	}
 	RedisKeys["tag"] = map[string]string{
-		"all":   "tags",
-		"one":   "tag",
-		"count": "tag:count",
+		"all":       "tags",
+		"one":       "tag",
+		"count":     "tag:count",
+		"languages": "tag:languages",
+		"names":     "tag:names",
 	}
 	RedisKeys["post"] = map[string]string{
-		"all":   "posts",
-		"one":   "post",
-		"count": "post:count",
+		"all":    "posts",
+		"one":    "post",
+		"count":  "post:count",
+		"scores": "post:scores",
+		"hot":    "post:ranking:hot",
+		"top":    "post:ranking:top",
+		"active": "post:ranking:active",
 	}
 	RedisKeys["comment"] = map[string]string{
 		"count": "post:comments:count",
 	}
+	RedisKeys["notification"] = map[string]string{
+		"unread": "user:notifications:unread",
+	}
+	RedisKeys["event"] = map[string]string{
+		"channel":  "events:channel",
+		"stream":   "events:stream",
+		"sequence": "events:sequence",
+	}
+	RedisKeys["feed"] = map[string]string{
+		"user": "user:feed",
+	}
+	RedisKeys["poll"] = map[string]string{
+		"tally": "poll:tally",
+	}
+	RedisKeys["rate_limit"] = "rate_limit"
+	RedisKeys["spam"] = map[string]string{
+		"model": "spam:model",
+	}
 
 	app.Queue = NewQueue(app).StartAll()
 	app.Github = github.NewGithub(config)
@@ -52,6 +52,148 @@ const (
 	WaitForConfirmation State = "wait_for_confirmation"
 )
 
+// PostStatus for post lifecycle
+type PostStatus string
+
+const (
+	// Draft post is visible only to its author
+	Draft PostStatus = "draft"
+	// Scheduled post is published by the scheduler at its publish time
+	Scheduled PostStatus = "scheduled"
+	// Published post is listed and indexed
+	Published PostStatus = "published"
+)
+
+// PostState for post moderation state
+type PostState string
+
+const (
+	// Open post accepts comments and votes
+	Open PostState = "open"
+	// Locked post accepts comments only from moderators
+	Locked PostState = "locked"
+	// Closed post accepts neither comments from non moderators nor votes
+	Closed PostState = "closed"
+	// Archived post is read-only
+	Archived PostState = "archived"
+	// Duplicate post is closed as a duplicate of another post
+	Duplicate PostState = "duplicate"
+)
+
+// NotificationType for in-app notifications
+type NotificationType string
+
+const (
+	// ReplyNotification someone replied to a post or a comment of the user
+	ReplyNotification NotificationType = "reply"
+	// MentionNotification someone mentioned the user
+	MentionNotification NotificationType = "mention"
+	// AnswerNotification a comment of the user is accepted as the answer
+	AnswerNotification NotificationType = "answer"
+	// VoteNotification someone upvoted a post or a comment of the user
+	VoteNotification NotificationType = "vote"
+	// ModerationNotification a moderator acted on a post or a comment of the user
+	ModerationNotification NotificationType = "moderation"
+	// ReminderNotification a bookmark of the user is due
+	ReminderNotification NotificationType = "reminder"
+)
+
+// WatchLevel for post and category subscriptions
+type WatchLevel string
+
+const (
+	// Muted subscription silences notifications of the target
+	Muted WatchLevel = "muted"
+	// Normal subscription keeps in-app notifications without emails
+	Normal WatchLevel = "normal"
+	// Watching subscription sends emails for new replies
+	Watching WatchLevel = "watching"
+)
+
+// DigestFrequency for subscription emails
+type DigestFrequency string
+
+const (
+	// Immediately new replies are emailed one by one
+	Immediately DigestFrequency = "immediately"
+	// Daily new replies are emailed as a daily digest
+	Daily DigestFrequency = "daily"
+	// Weekly new replies are emailed as a weekly digest
+	Weekly DigestFrequency = "weekly"
+)
+
+// ReputationReason for reputation ledger entries
+type ReputationReason string
+
+const (
+	// PostUpvoted a post of the user is upvoted
+	PostUpvoted ReputationReason = "post_upvoted"
+	// PostDownvoted a post of the user is downvoted
+	PostDownvoted ReputationReason = "post_downvoted"
+	// CommentUpvoted a comment of the user is upvoted
+	CommentUpvoted ReputationReason = "comment_upvoted"
+	// CommentDownvoted a comment of the user is downvoted
+	CommentDownvoted ReputationReason = "comment_downvoted"
+	// AnswerAccepted a comment of the user is accepted as the answer
+	AnswerAccepted ReputationReason = "answer_accepted"
+	// Penalty a moderator deducted points of the user
+	Penalty ReputationReason = "penalty"
+)
+
+// TrustLevel for automatic and manual user trust levels
+type TrustLevel int64
+
+const (
+	// NewUser freshly registered user
+	NewUser TrustLevel = iota
+	// Basic user who read around for a while
+	Basic
+	// Member user who visits regularly and received likes
+	Member
+	// Regular long time user without flagged content
+	Regular
+	// Leader user promoted by moderators only
+	Leader
+)
+
+// FlagReason for content and user flags
+type FlagReason string
+
+const (
+	// Spam flagged content advertises or is generated
+	Spam FlagReason = "spam"
+	// OffTopic flagged content does not belong to the discussion
+	OffTopic FlagReason = "off_topic"
+	// Abusive flagged content or user harasses others
+	Abusive FlagReason = "abusive"
+	// OtherReason flag is explained by its note
+	OtherReason FlagReason = "other"
+)
+
+// FlagStatus for moderator resolutions of flags
+type FlagStatus string
+
+const (
+	// Pending flag waits in the moderation queue
+	Pending FlagStatus = "pending"
+	// Agreed moderator agreed with the flag
+	Agreed FlagStatus = "agreed"
+	// Disagreed moderator disagreed with the flag
+	Disagreed FlagStatus = "disagreed"
+	// Deferred moderator left the flag without a decision
+	Deferred FlagStatus = "deferred"
+)
+
+// SpamLabel for moderator decisions the spam model is trained with
+type SpamLabel string
+
+const (
+	// SpamContent content is spam
+	SpamContent SpamLabel = "spam"
+	// HamContent content is not spam
+	HamContent SpamLabel = "ham"
+)
+
 // OTC one time code type
 type OTC string
 
@@ -81,3 +223,13 @@ const (
 	// Auth app type for third-party
 	Auth TParty = "auth"
 )
+
+// Vote direction type for post and comment votes
+type Vote string
+
+const (
+	// VoteUp positive vote
+	VoteUp Vote = "up"
+	// VoteDown negative vote
+	VoteDown Vote = "down"
+)
@@ -0,0 +1,73 @@
+// Copyright 2019 StreetByters Community
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with
+// this work for additional information regarding copyright ownership.
+// The ASF licenses this file to You under the Apache License, Version 2.0
+// (the "License"); you may not use this file except in compliance with
+// the License.  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"fmt"
+	"forgolang_forum/database"
+	"gopkg.in/guregu/null.v3/zero"
+	"time"
+)
+
+// Bookmark private bookmark of a user on a post or a comment with an
+// optional note and reminder
+type Bookmark struct {
+	database.DBInterface `json:"-"`
+	ID                   int64       `db:"id" json:"id"`
+	UserID               int64       `db:"user_id" json:"user_id" foreign:"fk_bookmarks_user_id" validate:"required"`
+	PostID               int64       `db:"post_id" json:"post_id" foreign:"fk_bookmarks_post_id" unique:"bookmarks_unique" validate:"required"`
+	CommentID            zero.Int    `db:"comment_id" json:"comment_id" foreign:"fk_bookmarks_comment_id"`
+	Note                 zero.String `db:"note" json:"note" validate:"lte=10240"`
+	RemindAt             zero.Time   `db:"remind_at" json:"remind_at"`
+	RemindedAt           zero.Time   `db:"reminded_at" json:"reminded_at"`
+	PostTitle            zero.String `db:"post_title" json:"post_title" read_after_writes:"true"`
+	Comment              zero.String `db:"comment" json:"comment" read_after_writes:"true"`
+	UpdatedAt            time.Time   `db:"updated_at" json:"updated_at"`
+	InsertedAt           time.Time   `db:"inserted_at" json:"inserted_at"`
+}
+
+// NewBookmark generate bookmark structure
+func NewBookmark(userID, postID int64) *Bookmark {
+	return &Bookmark{UserID: userID, PostID: postID}
+}
+
+// TableName bookmarks database
+func (m Bookmark) TableName() string {
+	return "bookmarks"
+}
+
+// ToJSON bookmark structure to json string
+func (m Bookmark) ToJSON() string {
+	return database.ToJSON(m)
+}
+
+// Query generate for bookmarks of a user with the latest title of their
+// posts and the latest version of their comments, callers filter with the
+// user identifier given as the first parameter
+func (m Bookmark) Query() string {
+	postDetail := new(PostDetail)
+	commentDetail := new(PostCommentDetail)
+
+	return fmt.Sprintf(`
+		SELECT
+			b.*,
+			(SELECT pd.title FROM %s AS pd WHERE pd.post_id = b.post_id ORDER BY pd.id DESC LIMIT 1) as post_title,
+			(SELECT cd.comment FROM %s AS cd WHERE cd.comment_id = b.comment_id ORDER BY cd.id DESC LIMIT 1) as comment
+		FROM %s AS b
+		WHERE b.user_id = $1
+	`, postDetail.TableName(), commentDetail.TableName(), m.TableName())
+}
@@ -29,6 +29,7 @@ type Category struct {
 	Title                string             `db:"title" json:"title" validate:"required,gte=3,lte=128"`
 	Description          zero.String        `db:"description" json:"description" validate:"lte=240"`
 	Slug                 string             `db:"slug" json:"slug" unique:"categories_slug_unique"`
+	QA                   bool               `db:"qa" json:"qa"`
 	InsertedAt           time.Time          `db:"inserted_at" json:"inserted_at"`
 	UpdatedAt            time.Time          `db:"updated_at" json:"updated_at"`
 	Languages            []CategoryLanguage `json:"languages"`
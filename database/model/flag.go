@@ -0,0 +1,78 @@
+// Copyright 2019 StreetByters Community
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with
+// this work for additional information regarding copyright ownership.
+// The ASF licenses this file to You under the Apache License, Version 2.0
+// (the "License"); you may not use this file except in compliance with
+// the License.  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"forgolang_forum/database"
+	"github.com/lib/pq"
+	"gopkg.in/guregu/null.v3/zero"
+	"time"
+)
+
+// Flag report of a post, a comment or a user. Flags of a comment carry its
+// post and flags of content carry its author as the target user, so flags of
+// the same target are grouped in the moderation queue. Flags of the spam
+// classifier have no flagging user.
+type Flag struct {
+	database.DBInterface `json:"-"`
+	ID                   int64               `db:"id" json:"id"`
+	UserID               zero.Int            `db:"user_id" json:"user_id" foreign:"fk_flags_user_id" unique:"flags_user_target_unique"`
+	TargetUserID         int64               `db:"target_user_id" json:"target_user_id" foreign:"fk_flags_target_user_id" validate:"required"`
+	PostID               zero.Int            `db:"post_id" json:"post_id" foreign:"fk_flags_post_id"`
+	CommentID            zero.Int            `db:"comment_id" json:"comment_id" foreign:"fk_flags_comment_id"`
+	Reason               database.FlagReason `db:"reason" json:"reason" validate:"required,oneof=spam off_topic abusive other"`
+	Note                 zero.String         `db:"note" json:"note" validate:"lte=1024"`
+	Trusted              bool                `db:"trusted" json:"trusted"`
+	Status               database.FlagStatus `db:"status" json:"status"`
+	ResolvedByID         zero.Int            `db:"resolved_by_id" json:"resolved_by_id" foreign:"fk_flags_resolved_by_id"`
+	ResolvedAt           zero.Time           `db:"resolved_at" json:"resolved_at"`
+	InsertedAt           time.Time           `db:"inserted_at" json:"inserted_at"`
+	Username             zero.String         `db:"username" json:"username,omitempty" read_after_writes:"true"`
+	Accuracy             zero.Float          `db:"accuracy" json:"accuracy,omitempty" read_after_writes:"true"`
+}
+
+// NewFlag generate flag structure
+func NewFlag(userID int64) *Flag {
+	return &Flag{UserID: zero.IntFrom(userID), Status: database.Pending}
+}
+
+// TableName flags database
+func (m Flag) TableName() string {
+	return "flags"
+}
+
+// ToJSON flag structure to json string
+func (m Flag) ToJSON() string {
+	return database.ToJSON(m)
+}
+
+// FlagQueueItem pending flags of a target aggregated for the moderation
+// queue, its identifier is the first pending flag of the target which
+// moderators resolve the target with
+type FlagQueueItem struct {
+	ID             int64          `db:"id" json:"id"`
+	TargetUserID   int64          `db:"target_user_id" json:"target_user_id"`
+	TargetUsername string         `db:"target_username" json:"target_username"`
+	PostID         zero.Int       `db:"post_id" json:"post_id"`
+	CommentID      zero.Int       `db:"comment_id" json:"comment_id"`
+	Count          int64          `db:"count" json:"count"`
+	TrustedCount   int64          `db:"trusted_count" json:"trusted_count"`
+	Reasons        pq.StringArray `db:"reasons" json:"reasons"`
+	Hidden         bool           `db:"hidden" json:"hidden"`
+	FirstFlaggedAt time.Time      `db:"first_flagged_at" json:"first_flagged_at"`
+	LastFlaggedAt  time.Time      `db:"last_flagged_at" json:"last_flagged_at"`
+}
@@ -0,0 +1,50 @@
+// Copyright 2019 StreetByters Community
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with
+// this work for additional information regarding copyright ownership.
+// The ASF licenses this file to You under the Apache License, Version 2.0
+// (the "License"); you may not use this file except in compliance with
+// the License.  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"forgolang_forum/database"
+	"gopkg.in/guregu/null.v3/zero"
+	"time"
+)
+
+// Follow a user following another user, a tag or a category, new posts of
+// followed targets are merged into the feed of the user
+type Follow struct {
+	database.DBInterface `json:"-"`
+	ID                   int64     `db:"id" json:"id"`
+	UserID               int64     `db:"user_id" json:"user_id" foreign:"fk_follows_user_id" validate:"required"`
+	FollowedUserID       zero.Int  `db:"followed_user_id" json:"followed_user_id" foreign:"fk_follows_followed_user_id"`
+	TagID                zero.Int  `db:"tag_id" json:"tag_id" foreign:"fk_follows_tag_id"`
+	CategoryID           zero.Int  `db:"category_id" json:"category_id" foreign:"fk_follows_category_id"`
+	InsertedAt           time.Time `db:"inserted_at" json:"inserted_at"`
+}
+
+// NewFollow generate follow structure
+func NewFollow(userID int64) *Follow {
+	return &Follow{UserID: userID}
+}
+
+// TableName follows database
+func (m Follow) TableName() string {
+	return "follows"
+}
+
+// ToJSON follow structure to json string
+func (m Follow) ToJSON() string {
+	return database.ToJSON(m)
+}
@@ -0,0 +1,51 @@
+// Copyright 2019 StreetByters Community
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with
+// this work for additional information regarding copyright ownership.
+// The ASF licenses this file to You under the Apache License, Version 2.0
+// (the "License"); you may not use this file except in compliance with
+// the License.  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"forgolang_forum/database"
+	"gopkg.in/guregu/null.v3/zero"
+	"time"
+)
+
+// Mention user mentioned in a post or a comment, mentions which are edited
+// out are kept as removed so users are notified once
+type Mention struct {
+	database.DBInterface `json:"-"`
+	ID                   int64     `db:"id" json:"id"`
+	UserID               int64     `db:"user_id" json:"user_id" foreign:"fk_mentions_user_id" unique:"mentions_unique" validate:"required"`
+	PostID               int64     `db:"post_id" json:"post_id" foreign:"fk_mentions_post_id" validate:"required"`
+	CommentID            zero.Int  `db:"comment_id" json:"comment_id" foreign:"fk_mentions_comment_id"`
+	SourceUserID         zero.Int  `db:"source_user_id" json:"source_user_id" foreign:"fk_mentions_source_user_id"`
+	RemovedAt            zero.Time `db:"removed_at" json:"removed_at"`
+	InsertedAt           time.Time `db:"inserted_at" json:"inserted_at"`
+}
+
+// NewMention generate mention structure
+func NewMention(userID, postID int64) *Mention {
+	return &Mention{UserID: userID, PostID: postID}
+}
+
+// TableName mentions database
+func (m Mention) TableName() string {
+	return "mentions"
+}
+
+// ToJSON mention structure to json string
+func (m Mention) ToJSON() string {
+	return database.ToJSON(m)
+}
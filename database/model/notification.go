@@ -0,0 +1,99 @@
+// Copyright 2019 StreetByters Community
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with
+// this work for additional information regarding copyright ownership.
+// The ASF licenses this file to You under the Apache License, Version 2.0
+// (the "License"); you may not use this file except in compliance with
+// the License.  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"fmt"
+	"forgolang_forum/database"
+	"github.com/lib/pq"
+	"gopkg.in/guregu/null.v3/zero"
+	"time"
+)
+
+// Notification in-app notification of a user. Unread notifications of the
+// same group collect their actors, e.g. upvotes of a post.
+type Notification struct {
+	database.DBInterface `json:"-"`
+	ID                   int64                     `db:"id" json:"id"`
+	UserID               int64                     `db:"user_id" json:"user_id" foreign:"fk_notifications_user_id" validate:"required"`
+	Type                 database.NotificationType `db:"type" json:"type" validate:"required"`
+	ActorID              zero.Int                  `db:"actor_id" json:"actor_id" foreign:"fk_notifications_actor_id"`
+	ActorIDs             pq.Int64Array             `db:"actor_ids" json:"-"`
+	PostID               int64                     `db:"post_id" json:"post_id" foreign:"fk_notifications_post_id" validate:"required"`
+	CommentID            zero.Int                  `db:"comment_id" json:"comment_id" foreign:"fk_notifications_comment_id"`
+	Action               zero.String               `db:"action" json:"action"`
+	GroupKey             string                    `db:"group_key" json:"-" validate:"required"`
+	ReadAt               zero.Time                 `db:"read_at" json:"read_at"`
+	ActorUsername        zero.String               `db:"actor_username" json:"actor_username" read_after_writes:"true"`
+	ActorCount           int64                     `db:"actor_count" json:"actor_count" read_after_writes:"true"`
+	UpdatedAt            time.Time                 `db:"updated_at" json:"updated_at"`
+	InsertedAt           time.Time                 `db:"inserted_at" json:"inserted_at"`
+}
+
+// NewNotification generate notification structure, notifications of the
+// same type and target are grouped
+func NewNotification(userID int64, typ database.NotificationType, postID int64, commentID zero.Int) *Notification {
+	target := fmt.Sprintf("post:%d", postID)
+	if commentID.Valid {
+		target = fmt.Sprintf("comment:%d", commentID.Int64)
+	}
+
+	return &Notification{
+		UserID:    userID,
+		Type:      typ,
+		PostID:    postID,
+		CommentID: commentID,
+		GroupKey:  fmt.Sprintf("%s:%s", typ, target),
+	}
+}
+
+// NewModerationNotification generate notification structure of a moderator
+// action, different actions on the same target are not grouped
+func NewModerationNotification(userID int64, action string, postID int64, commentID zero.Int) *Notification {
+	notification := NewNotification(userID, database.ModerationNotification, postID, commentID)
+	notification.Action.SetValid(action)
+	notification.GroupKey = fmt.Sprintf("%s:%s", notification.GroupKey, action)
+
+	return notification
+}
+
+// TableName notifications database
+func (m Notification) TableName() string {
+	return "notifications"
+}
+
+// ToJSON notification structure to json string
+func (m Notification) ToJSON() string {
+	return database.ToJSON(m)
+}
+
+// Query generate for notifications of a user with the latest actor and the
+// number of actors, callers filter with the user identifier given as the
+// first parameter
+func (m Notification) Query() string {
+	user := new(User)
+
+	return fmt.Sprintf(`
+		SELECT
+			n.*,
+			u.username as actor_username,
+			cardinality(n.actor_ids) as actor_count
+		FROM %s AS n
+		LEFT OUTER JOIN %s AS u ON n.actor_id = u.id
+		WHERE n.user_id = $1
+	`, m.TableName(), user.TableName())
+}
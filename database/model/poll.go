@@ -0,0 +1,107 @@
+// Copyright 2019 StreetByters Community
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with
+// this work for additional information regarding copyright ownership.
+// The ASF licenses this file to You under the Apache License, Version 2.0
+// (the "License"); you may not use this file except in compliance with
+// the License.  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"forgolang_forum/database"
+	"github.com/lib/pq"
+	"gopkg.in/guregu/null.v3/zero"
+	"time"
+)
+
+// Poll poll attached to a post with single or multiple choice options,
+// options of polls having votes can not be changed
+type Poll struct {
+	database.DBInterface `json:"-"`
+	ID                   int64         `db:"id" json:"id"`
+	PostID               int64         `db:"post_id" json:"post_id" foreign:"fk_polls_post_id" unique:"polls_post_id_unique" validate:"required"`
+	Question             string        `db:"question" json:"question" validate:"required,gte=3,lte=255"`
+	Multiple             bool          `db:"multiple" json:"multiple"`
+	PublicVoters         bool          `db:"public_voters" json:"public_voters"`
+	HideResults          bool          `db:"hide_results" json:"hide_results"`
+	ClosesAt             zero.Time     `db:"closes_at" json:"closes_at"`
+	Closed               bool          `db:"closed" json:"closed" read_after_writes:"true"`
+	Voters               int64         `json:"voters"`
+	ResultsHidden        bool          `json:"results_hidden"`
+	Vote                 pq.Int64Array `json:"vote,omitempty"`
+	Options              []PollOption  `json:"options" validate:"gte=2,lte=20,dive"`
+	UpdatedAt            time.Time     `db:"updated_at" json:"updated_at"`
+	InsertedAt           time.Time     `db:"inserted_at" json:"inserted_at"`
+}
+
+// NewPoll generate poll structure
+func NewPoll(postID int64) *Poll {
+	return &Poll{PostID: postID}
+}
+
+// TableName polls database
+func (m Poll) TableName() string {
+	return "polls"
+}
+
+// ToJSON poll structure to json string
+func (m Poll) ToJSON() string {
+	return database.ToJSON(m)
+}
+
+// PollOption option of a poll, votes are hidden until the poll closes if
+// the author wants that and voters are listed only for public polls
+type PollOption struct {
+	database.DBInterface `json:"-"`
+	ID                   int64          `db:"id" json:"id"`
+	PollID               int64          `db:"poll_id" json:"poll_id" foreign:"fk_poll_options_poll_id"`
+	Body                 string         `db:"body" json:"body" validate:"required,lte=255"`
+	Position             int64          `db:"position" json:"position"`
+	Votes                zero.Int       `json:"votes"`
+	VoterNames           pq.StringArray `db:"voter_names" json:"voters,omitempty" read_after_writes:"true"`
+}
+
+// TableName poll options database
+func (m PollOption) TableName() string {
+	return "poll_options"
+}
+
+// ToJSON poll option structure to json string
+func (m PollOption) ToJSON() string {
+	return database.ToJSON(m)
+}
+
+// PollVote options a user chose in a poll, users have one vote per poll
+type PollVote struct {
+	database.DBInterface `json:"-"`
+	ID                   int64         `db:"id" json:"id"`
+	PollID               int64         `db:"poll_id" json:"poll_id" foreign:"fk_poll_votes_poll_id" unique:"poll_votes_unique" validate:"required"`
+	UserID               int64         `db:"user_id" json:"user_id" foreign:"fk_poll_votes_user_id" validate:"required"`
+	OptionIDs            pq.Int64Array `db:"option_ids" json:"option_ids" validate:"required,gte=1"`
+	UpdatedAt            time.Time     `db:"updated_at" json:"updated_at"`
+	InsertedAt           time.Time     `db:"inserted_at" json:"inserted_at"`
+}
+
+// NewPollVote generate poll vote structure
+func NewPollVote(pollID, userID int64) *PollVote {
+	return &PollVote{PollID: pollID, UserID: userID}
+}
+
+// TableName poll votes database
+func (m PollVote) TableName() string {
+	return "poll_votes"
+}
+
+// ToJSON poll vote structure to json string
+func (m PollVote) ToJSON() string {
+	return database.ToJSON(m)
+}
@@ -18,6 +18,8 @@ package model
 
 import (
 	"forgolang_forum/database"
+	"forgolang_forum/utils"
+	"github.com/lib/pq"
 	"gopkg.in/guregu/null.v3/zero"
 	"time"
 )
@@ -25,14 +27,21 @@ import (
 // Post discussion topics created by users
 type Post struct {
 	database.DBInterface `json:"-"`
-	ID                   int64     `db:"id" json:"id"`
-	AuthorID             int64     `db:"author_id" json:"author_id" foreign:"fk_posts_author_id" validate:"required"`
-	InsertedAt           time.Time `db:"inserted_at" json:"inserted_at"`
+	ID                   int64               `db:"id" json:"id"`
+	AuthorID             int64               `db:"author_id" json:"author_id" foreign:"fk_posts_author_id" validate:"required"`
+	Status               database.PostStatus `db:"status" json:"status"`
+	PublishAt            zero.Time           `db:"publish_at" json:"publish_at"`
+	PublishedAt          zero.Time           `db:"published_at" json:"published_at"`
+	DeletedAt            zero.Time           `db:"deleted_at" json:"deleted_at" read_after_writes:"true"`
+	DeletedByID          zero.Int            `db:"deleted_by_id" json:"deleted_by_id" foreign:"fk_posts_deleted_by_id" read_after_writes:"true"`
+	DeleteReason         zero.String         `db:"delete_reason" json:"delete_reason" read_after_writes:"true"`
+	Score                int64               `db:"score" json:"-" read_after_writes:"true"`
+	InsertedAt           time.Time           `db:"inserted_at" json:"inserted_at"`
 }
 
 // NewPost generate post struct
 func NewPost(authorID int64) *Post {
-	return &Post{AuthorID: authorID}
+	return &Post{AuthorID: authorID, Status: database.Published}
 }
 
 // TableName post database
@@ -56,5 +65,46 @@ type PostDEP struct {
 	Description          zero.String               `db:"description" json:"description,omitempty"`
 	Content              zero.String               `db:"content" json:"content,omitempty" validate:"required,gte=20,lte=10240"`
 	CategoryAssignments  *[]PostCategoryAssignment `db:"category_assignments" json:"category_assignments,omitempty"`
+	Tags                 pq.StringArray            `db:"tags" json:"tags,omitempty" validate:"lte=5"`
+	Status               database.PostStatus       `db:"status" json:"status,omitempty" validate:"omitempty,oneof=draft scheduled published"`
+	PublishAt            zero.Time                 `db:"publish_at" json:"publish_at,omitempty"`
+	State                database.PostState        `db:"state" json:"state,omitempty"`
+	PinnedGlobally       bool                      `db:"pinned_globally" json:"pinned_globally"`
+	PinnedInCategory     bool                      `db:"pinned_in_category" json:"pinned_in_category"`
+	DuplicateOfID        zero.Int                  `db:"duplicate_of_id" json:"duplicate_of_id,omitempty"`
+	DuplicateOfSlug      zero.String               `db:"duplicate_of_slug" json:"duplicate_of_slug,omitempty"`
+	RedirectTo           zero.String               `json:"redirect_to,omitempty"`
+	Score                int64                     `db:"score" json:"score"`
+	Vote                 int64                     `db:"vote" json:"vote"`
+	Solved               bool                      `db:"solved" json:"solved"`
+	DeletedAt            zero.Time                 `db:"deleted_at" json:"deleted_at,omitempty"`
+	RemovedBy            zero.String               `db:"removed_by" json:"removed_by,omitempty"`
+	DeleteReason         zero.String               `db:"delete_reason" json:"delete_reason,omitempty"`
+	Mentions             pq.StringArray            `db:"mentions" json:"mentions,omitempty"`
+	AcceptedAnswer       *PostComment              `json:"accepted_answer,omitempty"`
+	Gofmt                bool                      `json:"gofmt,omitempty"`
+	CodeWarnings         []utils.GoCodeWarning     `json:"code_warnings,omitempty"`
+	HeldForReview        bool                      `json:"held_for_review,omitempty"`
 	InsertedAt           time.Time                 `db:"inserted_at" json:"inserted_at"`
 }
+
+// LinkMentions render mentions in the content as links to user profiles
+func (m *PostDEP) LinkMentions(baseURL string) {
+	if m.Content.Valid {
+		m.Content.SetValid(utils.LinkMentions(m.Content.String, m.Mentions, baseURL))
+	}
+}
+
+// Tombstone hide the content of a removed post, only its removal marker is
+// left
+func (m *PostDEP) Tombstone() {
+	if m.DeletedAt.Valid {
+		m.Title = zero.String{}
+		m.Description = zero.String{}
+		m.Content = zero.String{}
+		m.Tags = nil
+		m.DeleteReason = zero.String{}
+		m.Mentions = nil
+		m.AcceptedAnswer = nil
+	}
+}
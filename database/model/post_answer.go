@@ -0,0 +1,48 @@
+// Copyright 2019 StreetByters Community
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with
+// this work for additional information regarding copyright ownership.
+// The ASF licenses this file to You under the Apache License, Version 2.0
+// (the "License"); you may not use this file except in compliance with
+// the License.  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"forgolang_forum/database"
+	"gopkg.in/guregu/null.v3/zero"
+	"time"
+)
+
+// PostAnswer accepted answer of a question post
+type PostAnswer struct {
+	database.DBInterface `json:"-"`
+	ID                   int64     `db:"id" json:"id"`
+	PostID               int64     `db:"post_id" json:"post_id" foreign:"fk_post_answers_post_id" unique:"post_answers_post_unique" validate:"required"`
+	CommentID            int64     `db:"comment_id" json:"comment_id" foreign:"fk_post_answers_comment_id" validate:"required"`
+	SourceUserID         zero.Int  `db:"source_user_id" json:"source_user_id" foreign:"fk_post_answers_source_user_id"`
+	InsertedAt           time.Time `db:"inserted_at" json:"inserted_at"`
+}
+
+// NewPostAnswer generate post answer structure
+func NewPostAnswer(postID, commentID int64) *PostAnswer {
+	return &PostAnswer{PostID: postID, CommentID: commentID}
+}
+
+// TableName post answers database
+func (m PostAnswer) TableName() string {
+	return "post_answers"
+}
+
+// ToJSON post answer structure to json string
+func (m PostAnswer) ToJSON() string {
+	return database.ToJSON(m)
+}
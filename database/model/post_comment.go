@@ -17,7 +17,10 @@
 package model
 
 import (
+	"fmt"
 	"forgolang_forum/database"
+	"forgolang_forum/utils"
+	"github.com/lib/pq"
 	"gopkg.in/guregu/null.v3/zero"
 	"time"
 )
@@ -25,11 +28,29 @@ import (
 // PostComment Users comments on discussion topics
 type PostComment struct {
 	database.DBInterface `json:"-"`
-	ID                   int64     `db:"id" json:"id"`
-	PostID               int64     `db:"post_id" json:"post_id" foreign:"fk_post_comments_post_id" validate:"required"`
-	UserID               int64     `db:"user_id" json:"user_id" foreign:"fk_post_comments_user_id" validate:"required"`
-	ParentID             zero.Int  `db:"parent_id" json:"parent_id" foreign:"fk_post_comments_parent_id"`
-	InsertedAt           time.Time `db:"inserted_at" json:"inserted_at"`
+	ID                   int64          `db:"id" json:"id"`
+	PostID               int64          `db:"post_id" json:"post_id" foreign:"fk_post_comments_post_id" validate:"required"`
+	UserID               int64          `db:"user_id" json:"user_id" foreign:"fk_post_comments_user_id" validate:"required"`
+	ParentID             zero.Int       `db:"parent_id" json:"parent_id" foreign:"fk_post_comments_parent_id"`
+	VotesUp              int64          `db:"votes_up" json:"votes_up" read_after_writes:"true"`
+	VotesDown            int64          `db:"votes_down" json:"votes_down" read_after_writes:"true"`
+	Score                int64          `db:"score" json:"score" read_after_writes:"true"`
+	Rank                 float64        `db:"rank" json:"-" read_after_writes:"true"`
+	Comment              zero.String    `db:"comment" json:"comment" read_after_writes:"true"`
+	Edited               bool           `db:"edited" json:"edited" read_after_writes:"true"`
+	EditedAt             zero.Time      `db:"edited_at" json:"edited_at" read_after_writes:"true"`
+	EditedByModerator    bool           `db:"edited_by_moderator" json:"edited_by_moderator" read_after_writes:"true"`
+	AuthorUsername       zero.String    `db:"author_username" json:"author_username" read_after_writes:"true"`
+	ReplyCount           int64          `db:"reply_count" json:"reply_count" read_after_writes:"true"`
+	Accepted             bool           `db:"accepted" json:"accepted" read_after_writes:"true"`
+	DeletedAt            zero.Time      `db:"deleted_at" json:"deleted_at" read_after_writes:"true"`
+	DeletedByID          zero.Int       `db:"deleted_by_id" json:"deleted_by_id" foreign:"fk_post_comments_deleted_by_id" read_after_writes:"true"`
+	DeleteReason         zero.String    `db:"delete_reason" json:"delete_reason" read_after_writes:"true"`
+	RemovedBy            zero.String    `db:"removed_by" json:"removed_by,omitempty" read_after_writes:"true"`
+	Mentions             pq.StringArray `db:"mentions" json:"mentions,omitempty" read_after_writes:"true"`
+	Replies              []*PostComment `json:"replies,omitempty"`
+	RepliesCursor        string         `json:"replies_cursor,omitempty"`
+	InsertedAt           time.Time      `db:"inserted_at" json:"inserted_at"`
 }
 
 // NewPostComment generate post comment structure
@@ -46,3 +67,75 @@ func (m PostComment) TableName() string {
 func (m PostComment) ToJSON() string {
 	return database.ToJSON(m)
 }
+
+// LinkMentions render mentions in the thread as links to user profiles
+func (m *PostComment) LinkMentions(baseURL string) {
+	if m.Comment.Valid {
+		m.Comment.SetValid(utils.LinkMentions(m.Comment.String, m.Mentions, baseURL))
+	}
+	for _, reply := range m.Replies {
+		reply.LinkMentions(baseURL)
+	}
+}
+
+// Tombstone hide the content of removed comments in the thread, only their
+// removal markers are left
+func (m *PostComment) Tombstone() {
+	if m.DeletedAt.Valid {
+		m.Comment = zero.String{}
+		m.DeletedByID = zero.Int{}
+		m.DeleteReason = zero.String{}
+		m.Mentions = nil
+	}
+	for _, reply := range m.Replies {
+		reply.Tombstone()
+	}
+}
+
+// Query generate for post comments with latest detail, edit marker, author,
+// accepted answer marker, removal marker, mentioned usernames, reply and vote counts. Rank is the stored Wilson score lower bound of the votes,
+// callers filter with the post identifier given as the first parameter.
+func (m PostComment) Query() string {
+	votesUp := new(PostCommentVotesUp)
+	votesDown := new(PostCommentVotesDown)
+	detail := new(PostCommentDetail)
+	user := new(User)
+	answer := new(PostAnswer)
+	mention := new(Mention)
+
+	return fmt.Sprintf(`
+		SELECT
+			c.*,
+			cd.comment as comment,
+			COALESCE(cd.versions > 1, false) as edited,
+			CASE WHEN cd.versions > 1 THEN cd.inserted_at END as edited_at,
+			COALESCE(cd.versions > 1 AND cd.moderated, false) as edited_by_moderator,
+			u.username as author_username,
+			(SELECT count(r.id) FROM %s AS r WHERE r.parent_id = c.id) as reply_count,
+			EXISTS (SELECT 1 FROM %s AS a WHERE a.comment_id = c.id) as accepted,
+			CASE WHEN c.deleted_at IS NULL THEN NULL
+				WHEN c.deleted_by_id = c.user_id THEN 'author' ELSE 'moderator' END as removed_by,
+			ARRAY(
+				SELECT mu.username FROM %s AS m INNER JOIN %s AS mu ON m.user_id = mu.id
+				WHERE m.comment_id = c.id AND m.removed_at IS NULL ORDER BY mu.username
+			) as mentions,
+			cv.votes_up as votes_up,
+			cv.votes_down as votes_down,
+			cv.votes_up - cv.votes_down as score
+		FROM %s AS c
+		LEFT JOIN LATERAL (
+			SELECT
+				(SELECT count(v.id) FROM %s AS v WHERE v.comment_id = c.id) as votes_up,
+				(SELECT count(v.id) FROM %s AS v WHERE v.comment_id = c.id) as votes_down
+		) AS cv ON true
+		LEFT JOIN LATERAL (
+			SELECT
+				d.comment, d.inserted_at, d.moderated,
+				(SELECT count(d2.id) FROM %s AS d2 WHERE d2.comment_id = c.id) as versions
+			FROM %s AS d WHERE d.comment_id = c.id ORDER BY d.id DESC LIMIT 1
+		) AS cd ON true
+		LEFT OUTER JOIN %s AS u ON c.user_id = u.id
+		WHERE c.post_id = $1
+	`, m.TableName(), answer.TableName(), mention.TableName(), user.TableName(), m.TableName(), votesUp.TableName(), votesDown.TableName(), detail.TableName(),
+		detail.TableName(), user.TableName())
+}
@@ -18,17 +18,27 @@ package model
 
 import (
 	"forgolang_forum/database"
+	"forgolang_forum/utils"
+	"gopkg.in/guregu/null.v3/zero"
 	"time"
 )
 
 // PostCommentDetail Users comment detail on discussion topics
 type PostCommentDetail struct {
 	database.DBInterface `json:"-"`
-	ID                   int64     `db:"id" json:"id"`
-	PostID               int64     `db:"post_id" json:"post_id" foreign:"fk_post_comment_details_post_id" validate:"required"`
-	CommentID            int64     `db:"comment_id" json:"comment_id" foreign:"fk_post_comment_details_comment_id" validate:"required"`
-	Comment              string    `db:"comment" json:"comment" validate:"required,gte=5,lte=10240"`
-	InsertedAt           time.Time `db:"inserted_at" json:"inserted_at"`
+	ID                   int64                 `db:"id" json:"id"`
+	PostID               int64                 `db:"post_id" json:"post_id" foreign:"fk_post_comment_details_post_id" validate:"required"`
+	CommentID            int64                 `db:"comment_id" json:"comment_id" foreign:"fk_post_comment_details_comment_id" validate:"required"`
+	SourceUserID         zero.Int              `db:"source_user_id" json:"source_user_id" foreign:"fk_post_comment_details_source_user_id"`
+	SourceUsername       zero.String           `db:"source_username" json:"source_username,omitempty" read_after_writes:"true"`
+	Comment              string                `db:"comment" json:"comment" validate:"required,gte=5,lte=10240"`
+	Reason               zero.String           `db:"reason" json:"reason" validate:"lte=255"`
+	Moderated            bool                  `db:"moderated" json:"moderated"`
+	Gofmt                bool                  `json:"gofmt,omitempty"`
+	CodeWarnings         []utils.GoCodeWarning `json:"code_warnings,omitempty"`
+	HeldForReview        bool                  `json:"held_for_review,omitempty"`
+	Diff                 []utils.DiffLine      `json:"diff,omitempty"`
+	InsertedAt           time.Time             `db:"inserted_at" json:"inserted_at"`
 }
 
 // NewPostCommentDetail generate post comment detail structure
@@ -25,23 +25,23 @@ import (
 type PostCommentVotesDown struct {
 	database.DBInterface `json:"-"`
 	ID                   int64     `db:"id" json:"id"`
-	PostID               int64     `db:"post_id" json:"post_id" foreign:"fk_post_comment_votes_up_post_id" unique:"post_comment_votes_up_post_comment_user_unique" validate:"required"`
-	CommentID            int64     `db:"comment_id" json:"comment_id" foreign:"fk_post_comment_votes_up_comment_id" unique:"post_comment_votes_up_post_comment_user_unique" validate:"required"`
-	UserID               int64     `db:"user_id" json:"user_id" foreign:"fk_post_comment_votes_up_user_id" unique:"post_comment_votes_up_post_comment_user_unique" validate:"required"`
+	PostID               int64     `db:"post_id" json:"post_id" foreign:"fk_post_comment_votes_down_post_id" unique:"post_comment_votes_down_post_user_unique" validate:"required"`
+	CommentID            int64     `db:"comment_id" json:"comment_id" foreign:"fk_post_comment_votes_down_comment_id" unique:"post_comment_votes_down_post_user_unique" validate:"required"`
+	UserID               int64     `db:"user_id" json:"user_id" foreign:"fk_post_comment_votes_down_user_id" unique:"post_comment_votes_down_post_user_unique" validate:"required"`
 	InsertedAt           time.Time `db:"inserted_at" json:"inserted_at"`
 }
 
-// NewPostCommentVotesDown generate post comment votes up structure
+// NewPostCommentVotesDown generate post comment votes down structure
 func NewPostCommentVotesDown(postID, commentID, userID int64) *PostCommentVotesDown {
 	return &PostCommentVotesDown{PostID: postID, UserID: userID, CommentID: commentID}
 }
 
-// TableName post comment votes up database
+// TableName post comment votes down database
 func (m PostCommentVotesDown) TableName() string {
-	return "post_comment_votes_up"
+	return "post_comment_votes_down"
 }
 
-// ToJSON post comment votes up structure to json string
+// ToJSON post comment votes down structure to json string
 func (m PostCommentVotesDown) ToJSON() string {
 	return database.ToJSON(m)
 }
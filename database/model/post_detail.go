@@ -18,6 +18,7 @@ package model
 
 import (
 	"forgolang_forum/database"
+	"forgolang_forum/utils"
 	"gopkg.in/guregu/null.v3/zero"
 	"time"
 )
@@ -25,13 +26,15 @@ import (
 // PostDetail discussion topic content details
 type PostDetail struct {
 	database.DBInterface `json:"-"`
-	ID                   int64       `db:"id" json:"id"`
-	PostID               int64       `db:"post_id" json:"post_id" foreign:"fk_post_details_post_id" validate:"required"`
-	SourceUserID         zero.Int    `db:"source_user_id" json:"source_user_id" foreign:"fk_post_details_source_user_id"`
-	Title                string      `db:"title" json:"title" validate:"required,gte=3,lte=200"`
-	Description          zero.String `db:"description" json:"description"`
-	Content              string      `db:"content" json:"content" validate:"required" validate:"gte=5,lte=10240"`
-	InsertedAt           time.Time   `db:"inserted_at" json:"inserted_at"`
+	ID                   int64                 `db:"id" json:"id"`
+	PostID               int64                 `db:"post_id" json:"post_id" foreign:"fk_post_details_post_id" validate:"required"`
+	SourceUserID         zero.Int              `db:"source_user_id" json:"source_user_id" foreign:"fk_post_details_source_user_id"`
+	Title                string                `db:"title" json:"title" validate:"required,gte=3,lte=200"`
+	Description          zero.String           `db:"description" json:"description"`
+	Content              string                `db:"content" json:"content" validate:"required" validate:"gte=5,lte=10240"`
+	Gofmt                bool                  `json:"gofmt,omitempty"`
+	CodeWarnings         []utils.GoCodeWarning `json:"code_warnings,omitempty"`
+	InsertedAt           time.Time             `db:"inserted_at" json:"inserted_at"`
 }
 
 // NewPostDetail generate post detail struct
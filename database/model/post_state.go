@@ -0,0 +1,54 @@
+// Copyright 2019 StreetByters Community
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with
+// this work for additional information regarding copyright ownership.
+// The ASF licenses this file to You under the Apache License, Version 2.0
+// (the "License"); you may not use this file except in compliance with
+// the License.  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"forgolang_forum/database"
+	"gopkg.in/guregu/null.v3/zero"
+	"time"
+)
+
+// PostState post moderation state and pins, the latest state of a post is
+// the current one. Duplicate states point to the post readers are sent to.
+type PostState struct {
+	database.DBInterface `json:"-"`
+	ID                   int64              `db:"id" json:"id"`
+	PostID               int64              `db:"post_id" json:"post_id" foreign:"fk_post_states_post_id" validate:"required"`
+	State                database.PostState `db:"state" json:"state" validate:"required,oneof=open locked closed archived duplicate"`
+	PinnedGlobally       bool               `db:"pinned_globally" json:"pinned_globally"`
+	PinnedInCategory     bool               `db:"pinned_in_category" json:"pinned_in_category"`
+	PinOrder             int64              `db:"pin_order" json:"pin_order"`
+	Reason               zero.String        `db:"reason" json:"reason" validate:"lte=255"`
+	DuplicateOfID        zero.Int           `db:"duplicate_of_id" json:"duplicate_of_id" foreign:"fk_post_states_duplicate_of_id"`
+	SourceUserID         zero.Int           `db:"source_user_id" json:"source_user_id" foreign:"fk_post_states_source_user_id"`
+	InsertedAt           time.Time          `db:"inserted_at" json:"inserted_at"`
+}
+
+// NewPostState generate post state structure
+func NewPostState(postID int64) *PostState {
+	return &PostState{PostID: postID, State: database.Open}
+}
+
+// TableName post state database
+func (m PostState) TableName() string {
+	return "post_states"
+}
+
+// ToJSON post state structure to json string
+func (m PostState) ToJSON() string {
+	return database.ToJSON(m)
+}
@@ -28,7 +28,7 @@ type PostTag struct {
 	ID                   int64     `db:"id" json:"id"`
 	PostID               int64     `db:"post_id" json:"post_id" foreign:"fk_post_tags_post_id" unique:"post_tags_post_tag_unique" validate:"required"`
 	TagID                int64     `db:"tag_id" json:"tag_id" foreign:"fk_post_tags_tag_id" unique:"post_tags_post_tag_unique" validate:"required"`
-	SourceUserID         zero.Int  `db:"source_user_id" json:"source_user_id,omitempty"`
+	SourceUserID         zero.Int  `db:"source_user_id" json:"source_user_id,omitempty" foreign:"fk_post_tags_source_user_id"`
 	InsertedAt           time.Time `db:"inserted_at" json:"inserted_at"`
 }
 
@@ -25,19 +25,19 @@ import (
 type PostVotesDown struct {
 	database.DBInterface `json:"-"`
 	ID                   int64     `db:"id" json:"id"`
-	PostID               int64     `db:"post_id" json:"post_id" foreign:"fk_post_votes_up_post_id" unique:"post_votes_up_post_user_unique" validate:"required"`
-	UserID               int64     `db:"user_id" json:"user_id" foreign:"fk_post_votes_up_user_id" unique:"post_votes_up_post_user_unique" validate:"required"`
+	PostID               int64     `db:"post_id" json:"post_id" foreign:"fk_post_votes_down_post_id" unique:"post_votes_down_post_user_unique" validate:"required"`
+	UserID               int64     `db:"user_id" json:"user_id" foreign:"fk_post_votes_down_user_id" unique:"post_votes_down_post_user_unique" validate:"required"`
 	InsertedAt           time.Time `db:"inserted_at" json:"inserted_at"`
 }
 
-// NewPostVotesDown generate post votes up structure
+// NewPostVotesDown generate post votes down structure
 func NewPostVotesDown(postID, userID int64) *PostVotesDown {
 	return &PostVotesDown{PostID: postID, UserID: userID}
 }
 
 // TableName post votes down database
 func (m PostVotesDown) TableName() string {
-	return "post_votes_up"
+	return "post_votes_down"
 }
 
 // ToJSON post votes down structure to json string
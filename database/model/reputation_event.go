@@ -0,0 +1,54 @@
+// Copyright 2019 StreetByters Community
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with
+// this work for additional information regarding copyright ownership.
+// The ASF licenses this file to You under the Apache License, Version 2.0
+// (the "License"); you may not use this file except in compliance with
+// the License.  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"forgolang_forum/database"
+	"gopkg.in/guregu/null.v3/zero"
+	"time"
+)
+
+// ReputationEvent append-only reputation ledger entry of a user, the
+// reputation of a user is the sum of its entries. Points given for a vote
+// or an answer are taken back with a new entry of the same source.
+type ReputationEvent struct {
+	database.DBInterface `json:"-"`
+	ID                   int64                     `db:"id" json:"id"`
+	UserID               int64                     `db:"user_id" json:"user_id" foreign:"fk_reputation_events_user_id" validate:"required"`
+	Reason               database.ReputationReason `db:"reason" json:"reason" validate:"required"`
+	Amount               int64                     `db:"amount" json:"amount" validate:"required"`
+	PostID               zero.Int                  `db:"post_id" json:"post_id" foreign:"fk_reputation_events_post_id"`
+	CommentID            zero.Int                  `db:"comment_id" json:"comment_id" foreign:"fk_reputation_events_comment_id"`
+	SourceUserID         zero.Int                  `db:"source_user_id" json:"source_user_id" foreign:"fk_reputation_events_source_user_id"`
+	Note                 zero.String               `db:"note" json:"note" validate:"lte=1024"`
+	InsertedAt           time.Time                 `db:"inserted_at" json:"inserted_at"`
+}
+
+// NewReputationEvent generate reputation ledger entry structure
+func NewReputationEvent(userID int64, reason database.ReputationReason) *ReputationEvent {
+	return &ReputationEvent{UserID: userID, Reason: reason}
+}
+
+// TableName reputation events database
+func (m ReputationEvent) TableName() string {
+	return "reputation_events"
+}
+
+// ToJSON reputation event structure to json string
+func (m ReputationEvent) ToJSON() string {
+	return database.ToJSON(m)
+}
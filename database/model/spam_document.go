@@ -0,0 +1,61 @@
+// Copyright 2019 StreetByters Community
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with
+// this work for additional information regarding copyright ownership.
+// The ASF licenses this file to You under the Apache License, Version 2.0
+// (the "License"); you may not use this file except in compliance with
+// the License.  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"forgolang_forum/database"
+	"github.com/lib/pq"
+	"gopkg.in/guregu/null.v3/zero"
+	"time"
+)
+
+// SpamDocument post or comment labeled as spam or ham by a moderator with
+// the tokens the spam model learned from it. Documents are kept after their
+// content is purged, so the model can forget them when they are relabeled.
+type SpamDocument struct {
+	database.DBInterface `json:"-"`
+	ID                   int64              `db:"id" json:"id"`
+	PostID               int64              `db:"post_id" json:"post_id" validate:"required"`
+	CommentID            zero.Int           `db:"comment_id" json:"comment_id"`
+	Label                database.SpamLabel `db:"label" json:"label" validate:"required,oneof=spam ham"`
+	Tokens               pq.StringArray     `db:"tokens" json:"tokens"`
+	SourceUserID         zero.Int           `db:"source_user_id" json:"source_user_id" foreign:"fk_spam_documents_source_user_id"`
+	UpdatedAt            time.Time          `db:"updated_at" json:"updated_at"`
+	InsertedAt           time.Time          `db:"inserted_at" json:"inserted_at"`
+}
+
+// TableName spam documents database
+func (m SpamDocument) TableName() string {
+	return "spam_documents"
+}
+
+// ToJSON spam document structure to json string
+func (m SpamDocument) ToJSON() string {
+	return database.ToJSON(m)
+}
+
+// SpamToken token counts of the spam model
+type SpamToken struct {
+	Token string `db:"token" json:"token"`
+	Spam  int64  `db:"spam" json:"spam"`
+	Ham   int64  `db:"ham" json:"ham"`
+}
+
+// TableName spam tokens database
+func (m SpamToken) TableName() string {
+	return "spam_tokens"
+}
@@ -0,0 +1,57 @@
+// Copyright 2019 StreetByters Community
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with
+// this work for additional information regarding copyright ownership.
+// The ASF licenses this file to You under the Apache License, Version 2.0
+// (the "License"); you may not use this file except in compliance with
+// the License.  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"forgolang_forum/database"
+	"gopkg.in/guregu/null.v3/zero"
+	"time"
+)
+
+// Subscription watch level of a user on a post or a whole category, a post
+// subscription overrides the subscription of its categories
+type Subscription struct {
+	database.DBInterface `json:"-"`
+	ID                   int64                    `db:"id" json:"id"`
+	UserID               int64                    `db:"user_id" json:"user_id" foreign:"fk_subscriptions_user_id" validate:"required"`
+	PostID               zero.Int                 `db:"post_id" json:"post_id" foreign:"fk_subscriptions_post_id"`
+	CategoryID           zero.Int                 `db:"category_id" json:"category_id" foreign:"fk_subscriptions_category_id"`
+	Level                database.WatchLevel      `db:"level" json:"level" validate:"required,oneof=muted normal watching"`
+	Frequency            database.DigestFrequency `db:"frequency" json:"frequency" validate:"required,oneof=immediately daily weekly"`
+	LastSentAt           zero.Time                `db:"last_sent_at" json:"last_sent_at"`
+	UpdatedAt            time.Time                `db:"updated_at" json:"updated_at"`
+	InsertedAt           time.Time                `db:"inserted_at" json:"inserted_at"`
+}
+
+// NewSubscription generate subscription structure
+func NewSubscription(userID int64) *Subscription {
+	return &Subscription{
+		UserID:    userID,
+		Level:     database.Normal,
+		Frequency: database.Immediately,
+	}
+}
+
+// TableName subscriptions database
+func (m Subscription) TableName() string {
+	return "subscriptions"
+}
+
+// ToJSON subscription structure to json string
+func (m Subscription) ToJSON() string {
+	return database.ToJSON(m)
+}
@@ -25,11 +25,13 @@ import (
 // Tag special classifications
 type Tag struct {
 	database.DBInterface `json:"-"`
-	ID                   int64     `db:"id" json:"id"`
-	Name                 string    `db:"name" json:"name" validate:"required,gte=2,lte=32"`
-	Count                zero.Int  `json:"count,omitempty"`
-	InsertedAt           time.Time `db:"inserted_at" json:"inserted_at"`
-	UpdatedAt            time.Time `db:"updated_at" json:"updated_at"`
+	ID                   int64         `db:"id" json:"id"`
+	Name                 string        `db:"name" json:"name" unique:"tags_name_unique" validate:"required,gte=2,lte=32"`
+	SynonymOfID          zero.Int      `db:"synonym_of_id" json:"synonym_of_id" foreign:"fk_tags_synonym_of_id"`
+	Count                zero.Int      `json:"count,omitempty"`
+	InsertedAt           time.Time     `db:"inserted_at" json:"inserted_at"`
+	UpdatedAt            time.Time     `db:"updated_at" json:"updated_at"`
+	Languages            []TagLanguage `json:"languages,omitempty"`
 }
 
 // NewTag generate tag structure
@@ -19,6 +19,7 @@ package model
 import (
 	"forgolang_forum/database"
 	"forgolang_forum/model"
+	"gopkg.in/guregu/null.v3/zero"
 	"time"
 )
 
@@ -28,7 +29,7 @@ type TagLanguage struct {
 	ID           int64     `db:"id" json:"id"`
 	TagID        int64     `db:"tag_id" json:"tag_id" foreign:"fk_tag_languages_tag_id" validate:"required"`
 	LanguageID   int64     `db:"language_id" json:"language_id" foreign:"fk_tag_languages_language_id" validate:"required"`
-	SourceUserID int64     `db:"source_user_id" json:"source_user_id" foreign:"fk_tag_languages_source_user_id"`
+	SourceUserID zero.Int  `db:"source_user_id" json:"source_user_id" foreign:"fk_tag_languages_source_user_id"`
 	Name         string    `db:"name" json:"name" validate:"required,gte=3,lte=32"`
 	InsertedAt   time.Time `db:"inserted_at" json:"inserted_at"`
 }
@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"forgolang_forum/database"
 	"forgolang_forum/utils"
+	"github.com/lib/pq"
 	"gopkg.in/guregu/null.v3/zero"
 	"time"
 )
@@ -27,23 +28,27 @@ import (
 // User Authentication/authorization base database model
 type User struct {
 	database.DBInterface `json:"-"`
-	ID                   int64       `db:"id" json:"id"`
-	Username             string      `db:"username" json:"username" unique:"users_username_unique_index" validate:"required"`
-	PasswordDigest       zero.String `db:"password_digest" json:"-"`
-	Password             string      `json:"password"`
-	Email                string      `db:"email" json:"email" unique:"users_email_unique_index" validate:"required,email"`
-	EmailHidden          bool        `db:"email_hidden" json:"email_hidden"`
-	Bio                  zero.String `db:"bio" json:"bio" validate:"lte=10240"`
-	Url                  zero.String `db:"url" json:"url" validate:"lte=200"`
-	IsActive             bool        `db:"is_active" json:"is_active"`
-	Avatar               zero.String `db:"avatar" json:"avatar"`
-	Role                 zero.String `db:"role" json:"role,omitempty"`
-	RoleAssignmentID     zero.Int    `db:"role_assignment_id" json:"role_assignment_id,omitempty"`
-	State                zero.String `db:"state" json:"state,omitempty"`
-	TPartyName           zero.String `db:"tparty_name" json:"tparty_name,omitempty"`
-	TPartyData           zero.String `db:"tparty_data" json:"tparty_data,omitempty"`
-	InsertedAt           time.Time   `db:"inserted_at" json:"inserted_at"`
-	UpdatedAt            time.Time   `db:"updated_at" json:"updated_at"`
+	ID                   int64          `db:"id" json:"id"`
+	Username             string         `db:"username" json:"username" unique:"users_username_unique_index" validate:"required"`
+	PasswordDigest       zero.String    `db:"password_digest" json:"-"`
+	Password             string         `json:"password"`
+	Email                string         `db:"email" json:"email" unique:"users_email_unique_index" validate:"required,email"`
+	EmailHidden          bool           `db:"email_hidden" json:"email_hidden"`
+	Bio                  zero.String    `db:"bio" json:"bio" validate:"lte=10240"`
+	Url                  zero.String    `db:"url" json:"url" validate:"lte=200"`
+	IsActive             bool           `db:"is_active" json:"is_active"`
+	Avatar               zero.String    `db:"avatar" json:"avatar"`
+	Role                 zero.String    `db:"role" json:"role,omitempty"`
+	RoleAssignmentID     zero.Int       `db:"role_assignment_id" json:"role_assignment_id,omitempty"`
+	State                zero.String    `db:"state" json:"state,omitempty"`
+	TPartyName           zero.String    `db:"tparty_name" json:"tparty_name,omitempty"`
+	TPartyData           zero.String    `db:"tparty_data" json:"tparty_data,omitempty"`
+	FollowerCount        int64          `db:"follower_count" json:"follower_count" read_after_writes:"true"`
+	FollowingCount       int64          `db:"following_count" json:"following_count" read_after_writes:"true"`
+	Reputation           int64          `db:"reputation" json:"reputation" read_after_writes:"true"`
+	Badges               pq.StringArray `db:"badges" json:"badges" read_after_writes:"true"`
+	InsertedAt           time.Time      `db:"inserted_at" json:"inserted_at"`
+	UpdatedAt            time.Time      `db:"updated_at" json:"updated_at"`
 }
 
 // NewUser user generate with default data
@@ -81,6 +86,9 @@ func (d User) Query(force bool) string {
 	userState := new(UserState)
 	userComeBack := new(UserComebackApp)
 	thirdParty := new(ThirdParty)
+	follow := new(Follow)
+	reputationEvent := new(ReputationEvent)
+	userBadge := new(UserBadge)
 
 	var query string
 
@@ -96,10 +104,15 @@ func (d User) Query(force bool) string {
 			us.state as state,
 			tp.name as tparty_name,
 			uca.data as tparty_data,
+			(SELECT count(f.id) FROM %s AS f WHERE f.followed_user_id = u.id) as follower_count,
+			(SELECT count(f.id) FROM %s AS f WHERE f.user_id = u.id) as following_count,
+			(SELECT COALESCE(sum(re.amount), 0) FROM %s AS re WHERE re.user_id = u.id) as reputation,
+			ARRAY(SELECT ub.badge FROM %s AS ub WHERE ub.user_id = u.id ORDER BY ub.id) as badges,
 			u.inserted_at as inserted_at,
 			u.updated_at as updated_at
 		FROM %s AS u
-	`, d.TableName())
+	`, follow.TableName(), follow.TableName(), reputationEvent.TableName(), userBadge.TableName(),
+		d.TableName())
 
 	if force {
 		return fmt.Sprintf(`%s
@@ -0,0 +1,59 @@
+// Copyright 2019 StreetByters Community
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with
+// this work for additional information regarding copyright ownership.
+// The ASF licenses this file to You under the Apache License, Version 2.0
+// (the "License"); you may not use this file except in compliance with
+// the License.  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"forgolang_forum/database"
+	"time"
+)
+
+// UserVisit a day the user visited the forum
+type UserVisit struct {
+	database.DBInterface `json:"-"`
+	ID                   int64     `db:"id" json:"id"`
+	UserID               int64     `db:"user_id" json:"user_id" foreign:"fk_user_visits_user_id" validate:"required"`
+	VisitedOn            time.Time `db:"visited_on" json:"visited_on"`
+}
+
+// TableName user visits database
+func (m UserVisit) TableName() string {
+	return "user_visits"
+}
+
+// ToJSON user visit structure to json string
+func (m UserVisit) ToJSON() string {
+	return database.ToJSON(m)
+}
+
+// PostRead a post the user read
+type PostRead struct {
+	database.DBInterface `json:"-"`
+	ID                   int64     `db:"id" json:"id"`
+	UserID               int64     `db:"user_id" json:"user_id" foreign:"fk_post_reads_user_id" validate:"required"`
+	PostID               int64     `db:"post_id" json:"post_id" foreign:"fk_post_reads_post_id" validate:"required"`
+	InsertedAt           time.Time `db:"inserted_at" json:"inserted_at"`
+}
+
+// TableName post reads database
+func (m PostRead) TableName() string {
+	return "post_reads"
+}
+
+// ToJSON post read structure to json string
+func (m PostRead) ToJSON() string {
+	return database.ToJSON(m)
+}
@@ -0,0 +1,47 @@
+// Copyright 2019 StreetByters Community
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with
+// this work for additional information regarding copyright ownership.
+// The ASF licenses this file to You under the Apache License, Version 2.0
+// (the "License"); you may not use this file except in compliance with
+// the License.  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"forgolang_forum/database"
+	"time"
+)
+
+// UserBadge badge awarded to a user by the badge rules, badges are never
+// taken back
+type UserBadge struct {
+	database.DBInterface `json:"-"`
+	ID                   int64     `db:"id" json:"id"`
+	UserID               int64     `db:"user_id" json:"user_id" foreign:"fk_user_badges_user_id" validate:"required"`
+	Badge                string    `db:"badge" json:"badge" unique:"user_badges_user_badge_unique" validate:"required"`
+	InsertedAt           time.Time `db:"inserted_at" json:"inserted_at"`
+}
+
+// NewUserBadge generate user badge structure
+func NewUserBadge(userID int64, badge string) *UserBadge {
+	return &UserBadge{UserID: userID, Badge: badge}
+}
+
+// TableName user badges database
+func (m UserBadge) TableName() string {
+	return "user_badges"
+}
+
+// ToJSON user badge structure to json string
+func (m UserBadge) ToJSON() string {
+	return database.ToJSON(m)
+}
@@ -0,0 +1,51 @@
+// Copyright 2019 StreetByters Community
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with
+// this work for additional information regarding copyright ownership.
+// The ASF licenses this file to You under the Apache License, Version 2.0
+// (the "License"); you may not use this file except in compliance with
+// the License.  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"forgolang_forum/database"
+	"gopkg.in/guregu/null.v3/zero"
+	"time"
+)
+
+// UserTrustLevel trust level history of a user, the latest entry is the
+// current level. Locked levels are set by moderators and kept until they
+// are unlocked, other levels are computed by the trust level task.
+type UserTrustLevel struct {
+	database.DBInterface `json:"-"`
+	ID                   int64               `db:"id" json:"id"`
+	UserID               int64               `db:"user_id" json:"user_id" foreign:"fk_user_trust_levels_user_id" validate:"required"`
+	Level                database.TrustLevel `db:"level" json:"level" validate:"gte=0,lte=4"`
+	Locked               bool                `db:"locked" json:"locked"`
+	SourceUserID         zero.Int            `db:"source_user_id" json:"source_user_id" foreign:"fk_user_trust_levels_source_user_id"`
+	InsertedAt           time.Time           `db:"inserted_at" json:"inserted_at"`
+}
+
+// NewUserTrustLevel generate user trust level structure
+func NewUserTrustLevel(userID int64, level database.TrustLevel) *UserTrustLevel {
+	return &UserTrustLevel{UserID: userID, Level: level}
+}
+
+// TableName user trust levels database
+func (m UserTrustLevel) TableName() string {
+	return "user_trust_levels"
+}
+
+// ToJSON user trust level structure to json string
+func (m UserTrustLevel) ToJSON() string {
+	return database.ToJSON(m)
+}
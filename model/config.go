@@ -48,6 +48,7 @@ type Config struct {
 	ElasticHost        string `json:"elastic_host"`
 	ElasticPort        int    `json:"elastic_port"`
 	CDNUrl             string `json:"cdn_url"`
+	FlagHideThreshold  int64  `json:"flag_hide_threshold"`
 	GithubClientID     string `json:"github_client_id"`
 	GithubClientSecret string `json:"github_client_secret"`
 }
@@ -0,0 +1,68 @@
+// Copyright 2019 StreetByters Community
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with
+// this work for additional information regarding copyright ownership.
+// The ASF licenses this file to You under the Apache License, Version 2.0
+// (the "License"); you may not use this file except in compliance with
+// the License.  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Event realtime event structure, identifiers are redis stream entry
+// identifiers which are taken from a global sequence, so clients can resume
+// from the last event they received on any topic
+type Event struct {
+	ID    string          `json:"id"`
+	Topic string          `json:"topic"`
+	Type  string          `json:"type"`
+	Data  json.RawMessage `json:"data"`
+}
+
+// PostTopic event topic of a post thread
+func PostTopic(postID int64) string {
+	return fmt.Sprintf("post:%d", postID)
+}
+
+// CategoryTopic event topic of a category
+func CategoryTopic(categoryID int64) string {
+	return fmt.Sprintf("category:%d", categoryID)
+}
+
+// UserTopic personal event topic of a user
+func UserTopic(userID int64) string {
+	return fmt.Sprintf("user:%d", userID)
+}
+
+// After event is newer than the given event identifier
+func (m Event) After(id string) bool {
+	ms, seq := parseEventID(m.ID)
+	idMs, idSeq := parseEventID(id)
+
+	return ms > idMs || (ms == idMs && seq > idSeq)
+}
+
+func parseEventID(id string) (int64, int64) {
+	parts := strings.SplitN(id, "-", 2)
+	ms, _ := strconv.ParseInt(parts[0], 10, 64)
+	var seq int64
+	if len(parts) > 1 {
+		seq, _ = strconv.ParseInt(parts[1], 10, 64)
+	}
+
+	return ms, seq
+}
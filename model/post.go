@@ -0,0 +1,30 @@
+// Copyright 2019 StreetByters Community
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with
+// this work for additional information regarding copyright ownership.
+// The ASF licenses this file to You under the Apache License, Version 2.0
+// (the "License"); you may not use this file except in compliance with
+// the License.  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import "gopkg.in/guregu/null.v3/zero"
+
+// PostPublishRequest publish or schedule a draft post request structure
+type PostPublishRequest struct {
+	PublishAt zero.Time `json:"publish_at"`
+}
+
+// DeleteRequest remove a post or comment request structure, the reason is
+// kept with the tombstone
+type DeleteRequest struct {
+	Reason string `json:"reason" validate:"lte=255"`
+}
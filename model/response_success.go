@@ -50,3 +50,20 @@ func (r ResponseSuccessOne) ToJSON() string {
 	}
 	return string(body)
 }
+
+// ResponseSuccessCursor rest api success response structure of keyset
+// paginated listings, the next cursor is empty on the last page
+type ResponseSuccessCursor struct {
+	ResponseInterface `json:"-"`
+	Data              interface{} `json:"data"`
+	NextCursor        string      `json:"next_cursor"`
+}
+
+// ToJSON response structure to json string
+func (r ResponseSuccessCursor) ToJSON() string {
+	body, err := json.Marshal(r)
+	if err != nil {
+		return ""
+	}
+	return string(body)
+}
@@ -0,0 +1,26 @@
+// Copyright 2019 StreetByters Community
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with
+// this work for additional information regarding copyright ownership.
+// The ASF licenses this file to You under the Apache License, Version 2.0
+// (the "License"); you may not use this file except in compliance with
+// the License.  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+// VoteResponse post and comment vote result structure
+type VoteResponse struct {
+	PostID    int64   `json:"post_id"`
+	CommentID int64   `json:"comment_id,omitempty"`
+	Score     int64   `json:"score"`
+	Rank      float64 `json:"rank,omitempty"`
+	Vote      int64   `json:"vote"`
+}
@@ -0,0 +1,4 @@
+ALTER TABLE post_comment_details DROP CONSTRAINT IF EXISTS fk_post_comment_details_source_user_id;
+ALTER TABLE post_comment_details DROP COLUMN IF EXISTS moderated;
+ALTER TABLE post_comment_details DROP COLUMN IF EXISTS reason;
+ALTER TABLE post_comment_details DROP COLUMN IF EXISTS source_user_id;
\ No newline at end of file
@@ -0,0 +1,9 @@
+ALTER TABLE post_comment_details ADD COLUMN IF NOT EXISTS source_user_id bigint null;
+ALTER TABLE post_comment_details ADD COLUMN IF NOT EXISTS reason varchar(255) null;
+ALTER TABLE post_comment_details ADD COLUMN IF NOT EXISTS moderated boolean not null default false;
+
+ALTER TABLE post_comment_details ADD CONSTRAINT fk_post_comment_details_source_user_id FOREIGN KEY (source_user_id)
+    REFERENCES users(id) ON UPDATE cascade ON DELETE set null;
+
+UPDATE post_comment_details AS d SET source_user_id = c.user_id
+FROM post_comments AS c WHERE d.comment_id = c.id AND d.source_user_id IS NULL;
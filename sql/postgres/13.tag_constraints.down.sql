@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS post_tags_tag_id;
+ALTER TABLE post_tags DROP CONSTRAINT IF EXISTS fk_post_tags_source_user_id;
+DROP INDEX IF EXISTS tags_name_unique;
\ No newline at end of file
@@ -0,0 +1,6 @@
+CREATE UNIQUE INDEX IF NOT EXISTS tags_name_unique ON tags USING btree(lower(name));
+
+ALTER TABLE post_tags ADD CONSTRAINT fk_post_tags_source_user_id FOREIGN KEY (source_user_id)
+    REFERENCES users(id) ON UPDATE cascade ON DELETE set null;
+
+CREATE INDEX IF NOT EXISTS post_tags_tag_id ON post_tags USING btree(tag_id);
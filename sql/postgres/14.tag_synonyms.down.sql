@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS tags_synonym_of_id;
+
+ALTER TABLE tags DROP CONSTRAINT IF EXISTS tags_synonym_of_self;
+
+ALTER TABLE tags DROP CONSTRAINT IF EXISTS fk_tags_synonym_of_id;
+
+ALTER TABLE tags DROP COLUMN IF EXISTS synonym_of_id;
@@ -0,0 +1,8 @@
+ALTER TABLE tags ADD COLUMN synonym_of_id bigint;
+
+ALTER TABLE tags ADD CONSTRAINT fk_tags_synonym_of_id FOREIGN KEY (synonym_of_id)
+    REFERENCES tags(id) ON UPDATE cascade ON DELETE set null;
+
+ALTER TABLE tags ADD CONSTRAINT tags_synonym_of_self CHECK (synonym_of_id <> id);
+
+CREATE INDEX IF NOT EXISTS tags_synonym_of_id ON tags USING btree(synonym_of_id);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS post_answers;
+
+ALTER TABLE categories DROP COLUMN IF EXISTS qa;
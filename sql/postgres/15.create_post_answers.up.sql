@@ -0,0 +1,19 @@
+ALTER TABLE categories ADD COLUMN qa boolean NOT NULL DEFAULT false;
+
+CREATE TABLE IF NOT EXISTS post_answers (
+    id BIGSERIAL NOT NULL PRIMARY KEY,
+    post_id bigint not null,
+    comment_id bigint not null,
+    source_user_id bigint null,
+    inserted_at TIMESTAMP WITHOUT TIME ZONE DEFAULT (CURRENT_TIMESTAMP at time zone 'utc'),
+
+    CONSTRAINT fk_post_answers_post_id FOREIGN KEY (post_id)
+        REFERENCES posts(id) ON UPDATE cascade ON DELETE cascade,
+    CONSTRAINT fk_post_answers_comment_id FOREIGN KEY (comment_id)
+        REFERENCES post_comments(id) ON UPDATE cascade ON DELETE cascade,
+    CONSTRAINT fk_post_answers_source_user_id FOREIGN KEY (source_user_id)
+        REFERENCES users(id) ON UPDATE cascade ON DELETE set null
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS post_answers_post_unique ON post_answers USING btree(post_id);
+CREATE INDEX IF NOT EXISTS post_answers_comment_id ON post_answers USING btree(comment_id);
@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS posts_scheduled;
+DROP INDEX IF EXISTS posts_author_status;
+
+ALTER TABLE posts DROP COLUMN IF EXISTS published_at;
+ALTER TABLE posts DROP COLUMN IF EXISTS publish_at;
+ALTER TABLE posts DROP COLUMN IF EXISTS status;
+
+DROP TYPE IF EXISTS post_status;
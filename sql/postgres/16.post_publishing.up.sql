@@ -0,0 +1,10 @@
+CREATE TYPE post_status AS ENUM ('draft', 'scheduled', 'published');
+
+ALTER TABLE posts ADD COLUMN status post_status NOT NULL DEFAULT 'published';
+ALTER TABLE posts ADD COLUMN publish_at TIMESTAMP WITHOUT TIME ZONE NULL;
+ALTER TABLE posts ADD COLUMN published_at TIMESTAMP WITHOUT TIME ZONE NULL;
+
+UPDATE posts SET published_at = inserted_at;
+
+CREATE INDEX IF NOT EXISTS posts_author_status ON posts USING btree(author_id, status);
+CREATE INDEX IF NOT EXISTS posts_scheduled ON posts USING btree(publish_at) WHERE status = 'scheduled';
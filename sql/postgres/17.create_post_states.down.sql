@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS post_states;
+
+DROP TYPE IF EXISTS post_state;
@@ -0,0 +1,20 @@
+CREATE TYPE post_state AS ENUM ('open', 'locked', 'closed', 'archived');
+
+CREATE TABLE IF NOT EXISTS post_states (
+    id BIGSERIAL NOT NULL PRIMARY KEY,
+    post_id bigint not null,
+    state post_state not null default 'open',
+    pinned_globally boolean not null default false,
+    pinned_in_category boolean not null default false,
+    pin_order integer not null default 0,
+    reason varchar(255) null,
+    source_user_id bigint null,
+    inserted_at TIMESTAMP WITHOUT TIME ZONE DEFAULT (CURRENT_TIMESTAMP at time zone 'utc'),
+
+    CONSTRAINT fk_post_states_post_id FOREIGN KEY (post_id)
+        REFERENCES posts(id) ON UPDATE cascade ON DELETE cascade,
+    CONSTRAINT fk_post_states_source_user_id FOREIGN KEY (source_user_id)
+        REFERENCES users(id) ON UPDATE cascade ON DELETE set null
+);
+
+CREATE INDEX IF NOT EXISTS post_states_post_id ON post_states USING btree(post_id, id DESC);
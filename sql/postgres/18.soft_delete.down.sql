@@ -0,0 +1,12 @@
+DROP INDEX IF EXISTS post_comments_deleted_at;
+DROP INDEX IF EXISTS posts_deleted_at;
+
+ALTER TABLE post_comments DROP CONSTRAINT IF EXISTS fk_post_comments_deleted_by_id;
+ALTER TABLE post_comments DROP COLUMN IF EXISTS delete_reason;
+ALTER TABLE post_comments DROP COLUMN IF EXISTS deleted_by_id;
+ALTER TABLE post_comments DROP COLUMN IF EXISTS deleted_at;
+
+ALTER TABLE posts DROP CONSTRAINT IF EXISTS fk_posts_deleted_by_id;
+ALTER TABLE posts DROP COLUMN IF EXISTS delete_reason;
+ALTER TABLE posts DROP COLUMN IF EXISTS deleted_by_id;
+ALTER TABLE posts DROP COLUMN IF EXISTS deleted_at;
@@ -0,0 +1,14 @@
+ALTER TABLE posts ADD COLUMN deleted_at TIMESTAMP WITHOUT TIME ZONE NULL;
+ALTER TABLE posts ADD COLUMN deleted_by_id bigint NULL;
+ALTER TABLE posts ADD COLUMN delete_reason varchar(255) NULL;
+ALTER TABLE posts ADD CONSTRAINT fk_posts_deleted_by_id FOREIGN KEY (deleted_by_id)
+    REFERENCES users(id) ON UPDATE cascade ON DELETE set null;
+
+ALTER TABLE post_comments ADD COLUMN deleted_at TIMESTAMP WITHOUT TIME ZONE NULL;
+ALTER TABLE post_comments ADD COLUMN deleted_by_id bigint NULL;
+ALTER TABLE post_comments ADD COLUMN delete_reason varchar(255) NULL;
+ALTER TABLE post_comments ADD CONSTRAINT fk_post_comments_deleted_by_id FOREIGN KEY (deleted_by_id)
+    REFERENCES users(id) ON UPDATE cascade ON DELETE set null;
+
+CREATE INDEX IF NOT EXISTS posts_deleted_at ON posts USING btree(deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS post_comments_deleted_at ON post_comments USING btree(deleted_at) WHERE deleted_at IS NOT NULL;
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS mentions_post_id;
+DROP INDEX IF EXISTS mentions_unique;
+DROP TABLE IF EXISTS mentions;
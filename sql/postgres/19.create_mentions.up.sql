@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS mentions (
+    id BIGSERIAL NOT NULL PRIMARY KEY,
+    user_id bigint not null,
+    post_id bigint not null,
+    comment_id bigint null,
+    source_user_id bigint null,
+    removed_at TIMESTAMP WITHOUT TIME ZONE NULL,
+    inserted_at TIMESTAMP WITHOUT TIME ZONE DEFAULT (CURRENT_TIMESTAMP at time zone 'utc'),
+
+    CONSTRAINT fk_mentions_user_id FOREIGN KEY (user_id)
+        REFERENCES users(id) ON UPDATE cascade ON DELETE cascade,
+    CONSTRAINT fk_mentions_post_id FOREIGN KEY (post_id)
+        REFERENCES posts(id) ON UPDATE cascade ON DELETE cascade,
+    CONSTRAINT fk_mentions_comment_id FOREIGN KEY (comment_id)
+        REFERENCES post_comments(id) ON UPDATE cascade ON DELETE cascade,
+    CONSTRAINT fk_mentions_source_user_id FOREIGN KEY (source_user_id)
+        REFERENCES users(id) ON UPDATE cascade ON DELETE set null
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS mentions_unique ON mentions USING btree(user_id, post_id, COALESCE(comment_id, 0));
+CREATE INDEX IF NOT EXISTS mentions_post_id ON mentions USING btree(post_id, comment_id);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS notifications_user_id;
+DROP INDEX IF EXISTS notifications_unread_group;
+DROP TABLE IF EXISTS notifications;
+DROP TYPE IF EXISTS notification_type;
@@ -0,0 +1,28 @@
+CREATE TYPE notification_type AS ENUM ('reply', 'mention', 'answer', 'vote', 'moderation');
+
+CREATE TABLE IF NOT EXISTS notifications (
+    id BIGSERIAL NOT NULL PRIMARY KEY,
+    user_id bigint not null,
+    type notification_type not null,
+    actor_id bigint null,
+    actor_ids bigint[] not null default '{}',
+    post_id bigint not null,
+    comment_id bigint null,
+    action varchar(50) null,
+    group_key varchar(255) not null,
+    read_at TIMESTAMP WITHOUT TIME ZONE NULL,
+    updated_at TIMESTAMP WITHOUT TIME ZONE DEFAULT (CURRENT_TIMESTAMP at time zone 'utc'),
+    inserted_at TIMESTAMP WITHOUT TIME ZONE DEFAULT (CURRENT_TIMESTAMP at time zone 'utc'),
+
+    CONSTRAINT fk_notifications_user_id FOREIGN KEY (user_id)
+        REFERENCES users(id) ON UPDATE cascade ON DELETE cascade,
+    CONSTRAINT fk_notifications_actor_id FOREIGN KEY (actor_id)
+        REFERENCES users(id) ON UPDATE cascade ON DELETE set null,
+    CONSTRAINT fk_notifications_post_id FOREIGN KEY (post_id)
+        REFERENCES posts(id) ON UPDATE cascade ON DELETE cascade,
+    CONSTRAINT fk_notifications_comment_id FOREIGN KEY (comment_id)
+        REFERENCES post_comments(id) ON UPDATE cascade ON DELETE cascade
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS notifications_unread_group ON notifications USING btree(user_id, group_key) WHERE read_at IS NULL;
+CREATE INDEX IF NOT EXISTS notifications_user_id ON notifications USING btree(user_id, updated_at DESC);
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS subscriptions_category_id;
+DROP INDEX IF EXISTS subscriptions_post_id;
+DROP INDEX IF EXISTS subscriptions_user_category;
+DROP INDEX IF EXISTS subscriptions_user_post;
+DROP TABLE IF EXISTS subscriptions;
+DROP TYPE IF EXISTS digest_frequency;
+DROP TYPE IF EXISTS watch_level;
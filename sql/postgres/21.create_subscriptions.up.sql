@@ -0,0 +1,27 @@
+CREATE TYPE watch_level AS ENUM ('muted', 'normal', 'watching');
+CREATE TYPE digest_frequency AS ENUM ('immediately', 'daily', 'weekly');
+
+CREATE TABLE IF NOT EXISTS subscriptions (
+    id BIGSERIAL NOT NULL PRIMARY KEY,
+    user_id bigint not null,
+    post_id bigint null,
+    category_id bigint null,
+    level watch_level not null default 'normal',
+    frequency digest_frequency not null default 'immediately',
+    last_sent_at TIMESTAMP WITHOUT TIME ZONE NULL,
+    updated_at TIMESTAMP WITHOUT TIME ZONE DEFAULT (CURRENT_TIMESTAMP at time zone 'utc'),
+    inserted_at TIMESTAMP WITHOUT TIME ZONE DEFAULT (CURRENT_TIMESTAMP at time zone 'utc'),
+
+    CONSTRAINT fk_subscriptions_user_id FOREIGN KEY (user_id)
+        REFERENCES users(id) ON UPDATE cascade ON DELETE cascade,
+    CONSTRAINT fk_subscriptions_post_id FOREIGN KEY (post_id)
+        REFERENCES posts(id) ON UPDATE cascade ON DELETE cascade,
+    CONSTRAINT fk_subscriptions_category_id FOREIGN KEY (category_id)
+        REFERENCES categories(id) ON UPDATE cascade ON DELETE cascade,
+    CONSTRAINT subscriptions_target CHECK ((post_id IS NULL) != (category_id IS NULL))
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS subscriptions_user_post ON subscriptions USING btree(user_id, post_id) WHERE post_id IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS subscriptions_user_category ON subscriptions USING btree(user_id, category_id) WHERE category_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS subscriptions_post_id ON subscriptions USING btree(post_id) WHERE level = 'watching';
+CREATE INDEX IF NOT EXISTS subscriptions_category_id ON subscriptions USING btree(category_id) WHERE level = 'watching';
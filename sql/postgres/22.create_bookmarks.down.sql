@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS bookmarks_remind_at;
+DROP INDEX IF EXISTS bookmarks_unique;
+DROP TABLE IF EXISTS bookmarks;
+
+DELETE FROM notifications WHERE type = 'reminder';
+ALTER TYPE notification_type RENAME TO notification_type_old;
+CREATE TYPE notification_type AS ENUM ('reply', 'mention', 'answer', 'vote', 'moderation');
+ALTER TABLE notifications ALTER COLUMN type TYPE notification_type USING type::text::notification_type;
+DROP TYPE notification_type_old;
@@ -0,0 +1,26 @@
+ALTER TYPE notification_type RENAME TO notification_type_old;
+CREATE TYPE notification_type AS ENUM ('reply', 'mention', 'answer', 'vote', 'moderation', 'reminder');
+ALTER TABLE notifications ALTER COLUMN type TYPE notification_type USING type::text::notification_type;
+DROP TYPE notification_type_old;
+
+CREATE TABLE IF NOT EXISTS bookmarks (
+    id BIGSERIAL NOT NULL PRIMARY KEY,
+    user_id bigint not null,
+    post_id bigint not null,
+    comment_id bigint null,
+    note text null,
+    remind_at TIMESTAMP WITHOUT TIME ZONE NULL,
+    reminded_at TIMESTAMP WITHOUT TIME ZONE NULL,
+    updated_at TIMESTAMP WITHOUT TIME ZONE DEFAULT (CURRENT_TIMESTAMP at time zone 'utc'),
+    inserted_at TIMESTAMP WITHOUT TIME ZONE DEFAULT (CURRENT_TIMESTAMP at time zone 'utc'),
+
+    CONSTRAINT fk_bookmarks_user_id FOREIGN KEY (user_id)
+        REFERENCES users(id) ON UPDATE cascade ON DELETE cascade,
+    CONSTRAINT fk_bookmarks_post_id FOREIGN KEY (post_id)
+        REFERENCES posts(id) ON UPDATE cascade ON DELETE cascade,
+    CONSTRAINT fk_bookmarks_comment_id FOREIGN KEY (comment_id)
+        REFERENCES post_comments(id) ON UPDATE cascade ON DELETE cascade
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS bookmarks_unique ON bookmarks USING btree(user_id, post_id, COALESCE(comment_id, 0));
+CREATE INDEX IF NOT EXISTS bookmarks_remind_at ON bookmarks USING btree(remind_at) WHERE reminded_at IS NULL;
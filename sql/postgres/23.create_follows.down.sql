@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS follows_category_id;
+DROP INDEX IF EXISTS follows_tag_id;
+DROP INDEX IF EXISTS follows_followed_user_id;
+DROP INDEX IF EXISTS follows_user_category;
+DROP INDEX IF EXISTS follows_user_tag;
+DROP INDEX IF EXISTS follows_user_followed_user;
+DROP TABLE IF EXISTS follows;
@@ -0,0 +1,26 @@
+CREATE TABLE IF NOT EXISTS follows (
+    id BIGSERIAL NOT NULL PRIMARY KEY,
+    user_id bigint not null,
+    followed_user_id bigint null,
+    tag_id bigint null,
+    category_id bigint null,
+    inserted_at TIMESTAMP WITHOUT TIME ZONE DEFAULT (CURRENT_TIMESTAMP at time zone 'utc'),
+
+    CONSTRAINT fk_follows_user_id FOREIGN KEY (user_id)
+        REFERENCES users(id) ON UPDATE cascade ON DELETE cascade,
+    CONSTRAINT fk_follows_followed_user_id FOREIGN KEY (followed_user_id)
+        REFERENCES users(id) ON UPDATE cascade ON DELETE cascade,
+    CONSTRAINT fk_follows_tag_id FOREIGN KEY (tag_id)
+        REFERENCES tags(id) ON UPDATE cascade ON DELETE cascade,
+    CONSTRAINT fk_follows_category_id FOREIGN KEY (category_id)
+        REFERENCES categories(id) ON UPDATE cascade ON DELETE cascade,
+    CONSTRAINT follows_target CHECK (num_nonnulls(followed_user_id, tag_id, category_id) = 1),
+    CONSTRAINT follows_not_self CHECK (followed_user_id IS NULL OR followed_user_id != user_id)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS follows_user_followed_user ON follows USING btree(user_id, followed_user_id) WHERE followed_user_id IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS follows_user_tag ON follows USING btree(user_id, tag_id) WHERE tag_id IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS follows_user_category ON follows USING btree(user_id, category_id) WHERE category_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS follows_followed_user_id ON follows USING btree(followed_user_id) WHERE followed_user_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS follows_tag_id ON follows USING btree(tag_id) WHERE tag_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS follows_category_id ON follows USING btree(category_id) WHERE category_id IS NOT NULL;
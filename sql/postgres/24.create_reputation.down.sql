@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS user_badges_user_badge_unique;
+DROP TABLE IF EXISTS user_badges;
+DROP INDEX IF EXISTS reputation_events_user_id;
+DROP TABLE IF EXISTS reputation_events;
+DROP TYPE IF EXISTS reputation_reason;
@@ -0,0 +1,36 @@
+CREATE TYPE reputation_reason AS ENUM ('post_upvoted', 'post_downvoted', 'comment_upvoted', 'comment_downvoted', 'answer_accepted', 'penalty');
+
+CREATE TABLE IF NOT EXISTS reputation_events (
+    id BIGSERIAL NOT NULL PRIMARY KEY,
+    user_id bigint not null,
+    reason reputation_reason not null,
+    amount integer not null,
+    post_id bigint null,
+    comment_id bigint null,
+    source_user_id bigint null,
+    note text null,
+    inserted_at TIMESTAMP WITHOUT TIME ZONE DEFAULT (CURRENT_TIMESTAMP at time zone 'utc'),
+
+    CONSTRAINT fk_reputation_events_user_id FOREIGN KEY (user_id)
+        REFERENCES users(id) ON UPDATE cascade ON DELETE cascade,
+    CONSTRAINT fk_reputation_events_post_id FOREIGN KEY (post_id)
+        REFERENCES posts(id) ON UPDATE cascade ON DELETE set null,
+    CONSTRAINT fk_reputation_events_comment_id FOREIGN KEY (comment_id)
+        REFERENCES post_comments(id) ON UPDATE cascade ON DELETE set null,
+    CONSTRAINT fk_reputation_events_source_user_id FOREIGN KEY (source_user_id)
+        REFERENCES users(id) ON UPDATE cascade ON DELETE set null
+);
+
+CREATE INDEX IF NOT EXISTS reputation_events_user_id ON reputation_events USING btree(user_id, reason);
+
+CREATE TABLE IF NOT EXISTS user_badges (
+    id BIGSERIAL NOT NULL PRIMARY KEY,
+    user_id bigint not null,
+    badge varchar(64) not null,
+    inserted_at TIMESTAMP WITHOUT TIME ZONE DEFAULT (CURRENT_TIMESTAMP at time zone 'utc'),
+
+    CONSTRAINT fk_user_badges_user_id FOREIGN KEY (user_id)
+        REFERENCES users(id) ON UPDATE cascade ON DELETE cascade
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS user_badges_user_badge_unique ON user_badges USING btree(user_id, badge);
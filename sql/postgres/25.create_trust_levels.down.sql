@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS user_trust_levels_user_id;
+DROP TABLE IF EXISTS user_trust_levels;
+DROP INDEX IF EXISTS post_reads_user_post_unique;
+DROP TABLE IF EXISTS post_reads;
+DROP INDEX IF EXISTS user_visits_user_day_unique;
+DROP TABLE IF EXISTS user_visits;
@@ -0,0 +1,41 @@
+CREATE TABLE IF NOT EXISTS user_visits (
+    id BIGSERIAL NOT NULL PRIMARY KEY,
+    user_id bigint not null,
+    visited_on date not null,
+
+    CONSTRAINT fk_user_visits_user_id FOREIGN KEY (user_id)
+        REFERENCES users(id) ON UPDATE cascade ON DELETE cascade
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS user_visits_user_day_unique ON user_visits USING btree(user_id, visited_on);
+
+CREATE TABLE IF NOT EXISTS post_reads (
+    id BIGSERIAL NOT NULL PRIMARY KEY,
+    user_id bigint not null,
+    post_id bigint not null,
+    inserted_at TIMESTAMP WITHOUT TIME ZONE DEFAULT (CURRENT_TIMESTAMP at time zone 'utc'),
+
+    CONSTRAINT fk_post_reads_user_id FOREIGN KEY (user_id)
+        REFERENCES users(id) ON UPDATE cascade ON DELETE cascade,
+    CONSTRAINT fk_post_reads_post_id FOREIGN KEY (post_id)
+        REFERENCES posts(id) ON UPDATE cascade ON DELETE cascade
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS post_reads_user_post_unique ON post_reads USING btree(user_id, post_id);
+
+CREATE TABLE IF NOT EXISTS user_trust_levels (
+    id BIGSERIAL NOT NULL PRIMARY KEY,
+    user_id bigint not null,
+    level smallint not null,
+    locked boolean not null default false,
+    source_user_id bigint null,
+    inserted_at TIMESTAMP WITHOUT TIME ZONE DEFAULT (CURRENT_TIMESTAMP at time zone 'utc'),
+
+    CONSTRAINT fk_user_trust_levels_user_id FOREIGN KEY (user_id)
+        REFERENCES users(id) ON UPDATE cascade ON DELETE cascade,
+    CONSTRAINT fk_user_trust_levels_source_user_id FOREIGN KEY (source_user_id)
+        REFERENCES users(id) ON UPDATE cascade ON DELETE set null,
+    CONSTRAINT user_trust_levels_level CHECK (level BETWEEN 0 AND 4)
+);
+
+CREATE INDEX IF NOT EXISTS user_trust_levels_user_id ON user_trust_levels USING btree(user_id, id DESC);
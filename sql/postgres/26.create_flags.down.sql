@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS flags_status;
+DROP INDEX IF EXISTS flags_user_target_unique;
+DROP TABLE IF EXISTS flags;
+DROP TYPE IF EXISTS flag_status;
+DROP TYPE IF EXISTS flag_reason;
@@ -0,0 +1,33 @@
+CREATE TYPE flag_reason AS ENUM ('spam', 'off_topic', 'abusive', 'other');
+CREATE TYPE flag_status AS ENUM ('pending', 'agreed', 'disagreed', 'deferred');
+
+CREATE TABLE IF NOT EXISTS flags (
+    id BIGSERIAL NOT NULL PRIMARY KEY,
+    user_id bigint not null,
+    target_user_id bigint not null,
+    post_id bigint null,
+    comment_id bigint null,
+    reason flag_reason not null,
+    note text null,
+    trusted boolean not null default false,
+    status flag_status not null default 'pending',
+    resolved_by_id bigint null,
+    resolved_at TIMESTAMP WITHOUT TIME ZONE null,
+    inserted_at TIMESTAMP WITHOUT TIME ZONE DEFAULT (CURRENT_TIMESTAMP at time zone 'utc'),
+
+    CONSTRAINT fk_flags_user_id FOREIGN KEY (user_id)
+        REFERENCES users(id) ON UPDATE cascade ON DELETE cascade,
+    CONSTRAINT fk_flags_target_user_id FOREIGN KEY (target_user_id)
+        REFERENCES users(id) ON UPDATE cascade ON DELETE cascade,
+    CONSTRAINT fk_flags_post_id FOREIGN KEY (post_id)
+        REFERENCES posts(id) ON UPDATE cascade ON DELETE cascade,
+    CONSTRAINT fk_flags_comment_id FOREIGN KEY (comment_id)
+        REFERENCES post_comments(id) ON UPDATE cascade ON DELETE cascade,
+    CONSTRAINT fk_flags_resolved_by_id FOREIGN KEY (resolved_by_id)
+        REFERENCES users(id) ON UPDATE cascade ON DELETE set null,
+    CONSTRAINT flags_comment_post CHECK (comment_id IS NULL OR post_id IS NOT NULL)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS flags_user_target_unique ON flags
+    USING btree(user_id, target_user_id, COALESCE(post_id, 0), COALESCE(comment_id, 0));
+CREATE INDEX IF NOT EXISTS flags_status ON flags USING btree(status, target_user_id, post_id, comment_id);
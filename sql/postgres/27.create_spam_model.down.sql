@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS spam_tokens;
+DROP INDEX IF EXISTS spam_documents_content_unique;
+DROP TABLE IF EXISTS spam_documents;
+DROP TYPE IF EXISTS spam_label;
+DELETE FROM flags WHERE user_id IS NULL;
+ALTER TABLE flags ALTER COLUMN user_id SET NOT NULL;
@@ -0,0 +1,26 @@
+ALTER TABLE flags ALTER COLUMN user_id DROP NOT NULL;
+
+CREATE TYPE spam_label AS ENUM ('spam', 'ham');
+
+CREATE TABLE IF NOT EXISTS spam_documents (
+    id BIGSERIAL NOT NULL PRIMARY KEY,
+    post_id bigint not null,
+    comment_id bigint null,
+    label spam_label not null,
+    tokens text[] not null default '{}',
+    source_user_id bigint null,
+    updated_at TIMESTAMP WITHOUT TIME ZONE DEFAULT (CURRENT_TIMESTAMP at time zone 'utc'),
+    inserted_at TIMESTAMP WITHOUT TIME ZONE DEFAULT (CURRENT_TIMESTAMP at time zone 'utc'),
+
+    CONSTRAINT fk_spam_documents_source_user_id FOREIGN KEY (source_user_id)
+        REFERENCES users(id) ON UPDATE cascade ON DELETE set null
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS spam_documents_content_unique ON spam_documents
+    USING btree(post_id, COALESCE(comment_id, 0));
+
+CREATE TABLE IF NOT EXISTS spam_tokens (
+    token text NOT NULL PRIMARY KEY,
+    spam bigint not null default 0,
+    ham bigint not null default 0
+);
@@ -0,0 +1,10 @@
+ALTER TABLE post_states DROP CONSTRAINT IF EXISTS fk_post_states_duplicate_of_id;
+ALTER TABLE post_states DROP COLUMN IF EXISTS duplicate_of_id;
+
+UPDATE post_states SET state = 'closed' WHERE state = 'duplicate';
+ALTER TYPE post_state RENAME TO post_state_old;
+CREATE TYPE post_state AS ENUM ('open', 'locked', 'closed', 'archived');
+ALTER TABLE post_states ALTER COLUMN state DROP DEFAULT;
+ALTER TABLE post_states ALTER COLUMN state TYPE post_state USING state::text::post_state;
+ALTER TABLE post_states ALTER COLUMN state SET DEFAULT 'open';
+DROP TYPE post_state_old;
@@ -0,0 +1,10 @@
+ALTER TYPE post_state RENAME TO post_state_old;
+CREATE TYPE post_state AS ENUM ('open', 'locked', 'closed', 'archived', 'duplicate');
+ALTER TABLE post_states ALTER COLUMN state DROP DEFAULT;
+ALTER TABLE post_states ALTER COLUMN state TYPE post_state USING state::text::post_state;
+ALTER TABLE post_states ALTER COLUMN state SET DEFAULT 'open';
+DROP TYPE post_state_old;
+
+ALTER TABLE post_states ADD COLUMN IF NOT EXISTS duplicate_of_id bigint null;
+ALTER TABLE post_states ADD CONSTRAINT fk_post_states_duplicate_of_id FOREIGN KEY (duplicate_of_id)
+    REFERENCES posts(id) ON UPDATE cascade ON DELETE set null;
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS poll_votes_unique;
+DROP TABLE IF EXISTS poll_votes;
+DROP INDEX IF EXISTS poll_options_poll_id;
+DROP TABLE IF EXISTS poll_options;
+DROP INDEX IF EXISTS polls_post_id_unique;
+DROP TABLE IF EXISTS polls;
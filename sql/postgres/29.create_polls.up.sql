@@ -0,0 +1,44 @@
+CREATE TABLE IF NOT EXISTS polls (
+    id BIGSERIAL NOT NULL PRIMARY KEY,
+    post_id bigint not null,
+    question varchar(255) not null,
+    multiple boolean not null default false,
+    public_voters boolean not null default false,
+    hide_results boolean not null default false,
+    closes_at TIMESTAMP WITHOUT TIME ZONE NULL,
+    updated_at TIMESTAMP WITHOUT TIME ZONE DEFAULT (CURRENT_TIMESTAMP at time zone 'utc'),
+    inserted_at TIMESTAMP WITHOUT TIME ZONE DEFAULT (CURRENT_TIMESTAMP at time zone 'utc'),
+
+    CONSTRAINT fk_polls_post_id FOREIGN KEY (post_id)
+        REFERENCES posts(id) ON UPDATE cascade ON DELETE cascade
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS polls_post_id_unique ON polls USING btree(post_id);
+
+CREATE TABLE IF NOT EXISTS poll_options (
+    id BIGSERIAL NOT NULL PRIMARY KEY,
+    poll_id bigint not null,
+    body varchar(255) not null,
+    position integer not null default 0,
+
+    CONSTRAINT fk_poll_options_poll_id FOREIGN KEY (poll_id)
+        REFERENCES polls(id) ON UPDATE cascade ON DELETE cascade
+);
+
+CREATE INDEX IF NOT EXISTS poll_options_poll_id ON poll_options USING btree(poll_id, position);
+
+CREATE TABLE IF NOT EXISTS poll_votes (
+    id BIGSERIAL NOT NULL PRIMARY KEY,
+    poll_id bigint not null,
+    user_id bigint not null,
+    option_ids bigint[] not null,
+    updated_at TIMESTAMP WITHOUT TIME ZONE DEFAULT (CURRENT_TIMESTAMP at time zone 'utc'),
+    inserted_at TIMESTAMP WITHOUT TIME ZONE DEFAULT (CURRENT_TIMESTAMP at time zone 'utc'),
+
+    CONSTRAINT fk_poll_votes_poll_id FOREIGN KEY (poll_id)
+        REFERENCES polls(id) ON UPDATE cascade ON DELETE cascade,
+    CONSTRAINT fk_poll_votes_user_id FOREIGN KEY (user_id)
+        REFERENCES users(id) ON UPDATE cascade ON DELETE cascade
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS poll_votes_unique ON poll_votes USING btree(poll_id, user_id);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS post_comments_post_rank;
+
+ALTER TABLE post_comments DROP COLUMN IF EXISTS rank;
@@ -0,0 +1,3 @@
+ALTER TABLE post_comments ADD COLUMN rank double precision NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS post_comments_post_rank ON post_comments USING btree(post_id, rank);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS posts_score;
+
+ALTER TABLE posts DROP COLUMN IF EXISTS score;
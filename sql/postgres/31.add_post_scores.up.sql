@@ -0,0 +1,7 @@
+ALTER TABLE posts ADD COLUMN score bigint NOT NULL DEFAULT 0;
+
+UPDATE posts AS p SET score =
+    (SELECT count(v.id) FROM post_votes_up AS v WHERE v.post_id = p.id) -
+    (SELECT count(v.id) FROM post_votes_down AS v WHERE v.post_id = p.id);
+
+CREATE INDEX IF NOT EXISTS posts_score ON posts USING btree(score);
@@ -0,0 +1,140 @@
+// Copyright 2019 StreetByters Community
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with
+// this work for additional information regarding copyright ownership.
+// The ASF licenses this file to You under the Apache License, Version 2.0
+// (the "License"); you may not use this file except in compliance with
+// the License.  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tasks
+
+import (
+	"fmt"
+	"forgolang_forum/cmn"
+	"forgolang_forum/database"
+	"forgolang_forum/database/model"
+	model2 "forgolang_forum/model"
+)
+
+// Badge rule of a badge, the query selects identifiers of users who earned
+// the badge as user_id
+type Badge struct {
+	Code        string `json:"code"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Query       string `json:"-"`
+}
+
+// wellReceivedScore net votes of a well-received post or comment
+const wellReceivedScore = 10
+
+// Badges rules evaluated by AwardBadges
+func Badges() []Badge {
+	var post model.Post
+	var postDetail model.PostDetail
+	var comment model.PostComment
+	var commentDetail model.PostCommentDetail
+	var postAnswer model.PostAnswer
+	var votesUp model.PostVotesUp
+	var votesDown model.PostVotesDown
+	var commentVotesUp model.PostCommentVotesUp
+	var commentVotesDown model.PostCommentVotesDown
+
+	return []Badge{
+		{
+			Code:        "first_post",
+			Name:        "First Post",
+			Description: "Published a first post",
+			Query: fmt.Sprintf(`
+				SELECT DISTINCT p.author_id as user_id FROM %s AS p
+				WHERE p.status = '%s' AND p.deleted_at IS NULL
+			`, post.TableName(), database.Published),
+		},
+		{
+			Code:        "first_answer",
+			Name:        "First Answer",
+			Description: "A first comment is accepted as an answer",
+			Query: fmt.Sprintf(`
+				SELECT DISTINCT c.user_id FROM %s AS a
+				INNER JOIN %s AS c ON a.comment_id = c.id
+				WHERE c.deleted_at IS NULL
+			`, postAnswer.TableName(), comment.TableName()),
+		},
+		{
+			Code:        "mentor",
+			Name:        "Mentor",
+			Description: "10 comments are accepted as answers",
+			Query: fmt.Sprintf(`
+				SELECT c.user_id FROM %s AS a
+				INNER JOIN %s AS c ON a.comment_id = c.id
+				WHERE c.deleted_at IS NULL
+				GROUP BY c.user_id HAVING count(a.id) >= 10
+			`, postAnswer.TableName(), comment.TableName()),
+		},
+		{
+			Code:        "gopher",
+			Name:        "Gopher",
+			Description: fmt.Sprintf("A post or comment with a Go snippet reached %d votes", wellReceivedScore),
+			Query: fmt.Sprintf(`
+				SELECT p.author_id as user_id FROM %s AS p
+				INNER JOIN %s AS pd ON p.id = pd.post_id
+				LEFT OUTER JOIN %s AS pd2 ON pd.post_id = pd2.post_id AND pd.id < pd2.id
+				WHERE pd2.id IS NULL AND p.status = '%s' AND p.deleted_at IS NULL AND
+					pd.content LIKE '%%`+"```go"+`%%' AND
+					(SELECT count(v.id) FROM %s AS v WHERE v.post_id = p.id) -
+						(SELECT count(v.id) FROM %s AS v WHERE v.post_id = p.id) >= %d
+				UNION
+				SELECT c.user_id FROM %s AS c
+				INNER JOIN %s AS cd ON c.id = cd.comment_id
+				LEFT OUTER JOIN %s AS cd2 ON cd.comment_id = cd2.comment_id AND cd.id < cd2.id
+				WHERE cd2.id IS NULL AND c.deleted_at IS NULL AND
+					cd.comment LIKE '%%`+"```go"+`%%' AND
+					(SELECT count(v.id) FROM %s AS v WHERE v.comment_id = c.id) -
+						(SELECT count(v.id) FROM %s AS v WHERE v.comment_id = c.id) >= %d
+			`, post.TableName(), postDetail.TableName(), postDetail.TableName(), database.Published,
+				votesUp.TableName(), votesDown.TableName(), wellReceivedScore,
+				comment.TableName(), commentDetail.TableName(), commentDetail.TableName(),
+				commentVotesUp.TableName(), commentVotesDown.TableName(), wellReceivedScore),
+		},
+	}
+}
+
+// AwardBadges evaluate badge rules and award badges users earned since the
+// last run, awarded badges are kept even if the rule no longer matches
+func AwardBadges(app *cmn.App, args interface{}) error {
+	var userBadge model.UserBadge
+
+	awarded := 0
+	for _, badge := range Badges() {
+		var userIDs []int64
+		err := app.Database.DB.Select(&userIDs, fmt.Sprintf(`
+			INSERT INTO %s (user_id, badge)
+			SELECT b.user_id, $1 FROM (%s) AS b
+			ON CONFLICT (user_id, badge) DO NOTHING
+			RETURNING user_id
+		`, userBadge.TableName(), badge.Query),
+			badge.Code)
+		if err != nil {
+			return err
+		}
+
+		for _, userID := range userIDs {
+			clearUserCache(app, userID)
+		}
+		awarded += len(userIDs)
+	}
+
+	if app.Mode != model2.Test {
+		app.Logger.LogInfo(fmt.Sprintf("Awarded %d badges", awarded))
+	}
+
+	return nil
+}
@@ -0,0 +1,97 @@
+// Copyright 2019 StreetByters Community
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with
+// this work for additional information regarding copyright ownership.
+// The ASF licenses this file to You under the Apache License, Version 2.0
+// (the "License"); you may not use this file except in compliance with
+// the License.  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tasks
+
+import (
+	"fmt"
+	"forgolang_forum/cmn"
+	"forgolang_forum/database"
+	"forgolang_forum/database/model"
+)
+
+// SendBookmarkReminders notify and email users of their bookmarks which are
+// due. Reminders are marked before they are sent, so they go out once even
+// when the task runs on several instances.
+func SendBookmarkReminders(app *cmn.App, args interface{}) error {
+	var bookmark model.Bookmark
+	var user model.User
+
+	var due []struct {
+		ID     int64 `db:"id"`
+		UserID int64 `db:"user_id"`
+	}
+	err := app.Database.DB.Select(&due, fmt.Sprintf(`
+		UPDATE %s SET reminded_at = (CURRENT_TIMESTAMP at time zone 'utc')
+		WHERE remind_at <= (CURRENT_TIMESTAMP at time zone 'utc') AND reminded_at IS NULL
+		RETURNING id, user_id
+	`, bookmark.TableName()))
+	if err != nil {
+		return err
+	}
+
+	for _, d := range due {
+		var b model.Bookmark
+		if err := app.Database.DB.Get(&b, fmt.Sprintf(`
+			%s AND b.id = $2
+		`, bookmark.Query()),
+			d.UserID,
+			d.ID); err != nil {
+			return err
+		}
+
+		notification := model.NewNotification(b.UserID, database.ReminderNotification, b.PostID, b.CommentID)
+		if err := Notify(app, notification); err != nil {
+			return err
+		}
+
+		var u model.User
+		if err := app.Database.DB.Get(&u, fmt.Sprintf(`
+			SELECT u.id, u.username, u.email FROM %s AS u WHERE u.id = $1 AND u.is_active
+		`, user.TableName()),
+			b.UserID); err != nil {
+			continue
+		}
+
+		url := fmt.Sprintf("%s/post/%d", app.Config.UIHost, b.PostID)
+		if b.CommentID.Valid {
+			url = fmt.Sprintf("%s#comment-%d", url, b.CommentID.Int64)
+		}
+
+		err := app.Queue.Email.Publish(cmn.QueueEmailBody{
+			Recipients: []string{u.Email},
+			Subject:    fmt.Sprintf("Forgolang.com | Reminder: %s", b.PostTitle.String),
+			Type:       "reminder",
+			Template:   "reminder",
+			Params: struct {
+				Username string
+				Title    string
+				Note     string
+				URL      string
+			}{
+				Username: u.Username,
+				Title:    b.PostTitle.String,
+				Note:     b.Note.String,
+				URL:      url,
+			},
+		}.ToJSON())
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
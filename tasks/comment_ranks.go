@@ -0,0 +1,99 @@
+// Copyright 2019 StreetByters Community
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with
+// this work for additional information regarding copyright ownership.
+// The ASF licenses this file to You under the Apache License, Version 2.0
+// (the "License"); you may not use this file except in compliance with
+// the License.  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tasks
+
+import (
+	"fmt"
+	"forgolang_forum/cmn"
+	"forgolang_forum/database/model"
+	model2 "forgolang_forum/model"
+	"forgolang_forum/utils"
+)
+
+// commentVotes vote counts of a post comment
+type commentVotes struct {
+	CommentID int64 `db:"comment_id"`
+	Up        int64 `db:"up"`
+	Down      int64 `db:"down"`
+}
+
+// RefreshCommentRank count the votes of a post comment and store its Wilson
+// lower bound rank, comment listings sort on the stored rank. It returns the
+// net score and the rank.
+func RefreshCommentRank(app *cmn.App, commentID int64) (int64, float64, error) {
+	var votesUp model.PostCommentVotesUp
+	var votesDown model.PostCommentVotesDown
+	var postComment model.PostComment
+
+	votes := commentVotes{CommentID: commentID}
+	err := app.Database.DB.Get(&votes, fmt.Sprintf(`
+		SELECT
+			$1::bigint as comment_id,
+			(SELECT count(v.id) FROM %s AS v WHERE v.comment_id = $1) as up,
+			(SELECT count(v.id) FROM %s AS v WHERE v.comment_id = $1) as down
+	`, votesUp.TableName(), votesDown.TableName()),
+		commentID)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	rank := utils.WilsonLowerBound(votes.Up, votes.Down)
+	_, err = app.Database.DB.Exec(fmt.Sprintf(`UPDATE %s SET rank = $1 WHERE id = $2`,
+		postComment.TableName()),
+		rank,
+		commentID)
+
+	return votes.Up - votes.Down, rank, err
+}
+
+// RefreshCommentRanks store the ranks of all voted post comments again, ranks
+// are kept up to date by votes so this is only needed after the ranking
+// formula changed
+func RefreshCommentRanks(app *cmn.App, args interface{}) error {
+	var votesUp model.PostCommentVotesUp
+	var votesDown model.PostCommentVotesDown
+	var postComment model.PostComment
+
+	var rows []commentVotes
+	err := app.Database.DB.Select(&rows, fmt.Sprintf(`
+		SELECT v.comment_id, sum(v.up) as up, sum(v.down) as down FROM (
+			SELECT comment_id, 1 as up, 0 as down FROM %s
+			UNION ALL
+			SELECT comment_id, 0 as up, 1 as down FROM %s
+		) AS v
+		GROUP BY v.comment_id
+	`, votesUp.TableName(), votesDown.TableName()))
+	if err != nil {
+		return err
+	}
+
+	for _, r := range rows {
+		_, err := app.Database.DB.Exec(fmt.Sprintf(`UPDATE %s SET rank = $1 WHERE id = $2`,
+			postComment.TableName()),
+			utils.WilsonLowerBound(r.Up, r.Down),
+			r.CommentID)
+		if err != nil {
+			return err
+		}
+	}
+
+	if app.Mode != model2.Test {
+		app.Logger.LogInfo(fmt.Sprintf("Refreshed %d comment ranks", len(rows)))
+	}
+
+	return nil
+}
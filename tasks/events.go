@@ -0,0 +1,158 @@
+// Copyright 2019 StreetByters Community
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with
+// this work for additional information regarding copyright ownership.
+// The ASF licenses this file to You under the Apache License, Version 2.0
+// (the "License"); you may not use this file except in compliance with
+// the License.  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tasks
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"forgolang_forum/cmn"
+	"forgolang_forum/database/model"
+	model2 "forgolang_forum/model"
+	"github.com/go-redis/redis"
+	"sort"
+	"strings"
+)
+
+// EventHistory number of events kept for each topic to replay to
+// reconnecting clients
+const EventHistory = 1000
+
+// publishEventScript take the next identifier of the global sequence, append
+// the event to the history of its topic and publish it in one step, so
+// events reach the history and subscribers in the order of their
+// identifiers. Published messages are the identifier and the event body
+// separated by a space.
+var publishEventScript = redis.NewScript(`
+	local id = redis.call('INCR', KEYS[1]) .. '-0'
+	redis.call('XADD', KEYS[2], 'MAXLEN', '~', ARGV[1], id, 'event', ARGV[2])
+	redis.call('PUBLISH', ARGV[3], id .. ' ' .. ARGV[2])
+	return id
+`)
+
+// PublishEvent append an event to the history of its topic and fan it out
+// to subscribers of all api instances through redis pub/sub. Identifiers
+// come from a global sequence instead of the stream clock, so events of
+// different topics are ordered and never collide.
+func PublishEvent(app *cmn.App, topic, typ string, data interface{}) error {
+	event := model2.Event{
+		Topic: topic,
+		Type:  typ,
+	}
+
+	var err error
+	if event.Data, err = json.Marshal(data); err != nil {
+		return err
+	}
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	err = publishEventScript.Run(app.Cache,
+		[]string{cmn.GetRedisKey("event", "sequence"), EventStreamKey(topic)},
+		EventHistory,
+		string(body),
+		EventChannel(topic)).Err()
+	if err != nil {
+		app.Logger.LogError(err, fmt.Sprintf("publish %s event to %s", typ, topic))
+	}
+
+	return err
+}
+
+// ParseEventMessage event of a message published by PublishEvent
+func ParseEventMessage(payload string) (model2.Event, error) {
+	var event model2.Event
+	parts := strings.SplitN(payload, " ", 2)
+	if len(parts) != 2 {
+		return event, errors.New("event message is not valid")
+	}
+	if err := json.Unmarshal([]byte(parts[1]), &event); err != nil {
+		return event, err
+	}
+	event.ID = parts[0]
+
+	return event, nil
+}
+
+// PublishPostEvents announce a published post to subscribers of its
+// categories
+func PublishPostEvents(app *cmn.App, postID int64) error {
+	var postCategoryAssignment model.PostCategoryAssignment
+	var categoryIDs []int64
+	err := app.Database.DB.Select(&categoryIDs, fmt.Sprintf(`
+		SELECT DISTINCT pca.category_id FROM %s AS pca WHERE pca.post_id = $1
+	`, postCategoryAssignment.TableName()),
+		postID)
+	if err != nil {
+		return err
+	}
+
+	for _, categoryID := range categoryIDs {
+		err := PublishEvent(app, model2.CategoryTopic(categoryID), "post", map[string]interface{}{
+			"post_id":     postID,
+			"category_id": categoryID,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ReplayEvents events of the given topics after the given event identifier
+// in the order they are published
+func ReplayEvents(app *cmn.App, topics []string, lastEventID string) ([]model2.Event, error) {
+	var events []model2.Event
+	for _, topic := range topics {
+		messages, err := app.Cache.XRange(EventStreamKey(topic), lastEventID, "+").Result()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, message := range messages {
+			var event model2.Event
+			body, _ := message.Values["event"].(string)
+			if err := json.Unmarshal([]byte(body), &event); err != nil {
+				return nil, err
+			}
+			event.ID = message.ID
+			// the range is inclusive, the last event is already received
+			if event.After(lastEventID) {
+				events = append(events, event)
+			}
+		}
+	}
+
+	sort.Slice(events, func(i, j int) bool {
+		return events[j].After(events[i].ID)
+	})
+
+	return events, nil
+}
+
+// EventChannel redis pub/sub channel of a topic
+func EventChannel(topic string) string {
+	return fmt.Sprintf("%s:%s", cmn.GetRedisKey("event", "channel"), topic)
+}
+
+// EventStreamKey redis stream key of the event history of a topic
+func EventStreamKey(topic string) string {
+	return fmt.Sprintf("%s:%s", cmn.GetRedisKey("event", "stream"), topic)
+}
@@ -0,0 +1,257 @@
+// Copyright 2019 StreetByters Community
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with
+// this work for additional information regarding copyright ownership.
+// The ASF licenses this file to You under the Apache License, Version 2.0
+// (the "License"); you may not use this file except in compliance with
+// the License.  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tasks
+
+import (
+	"database/sql"
+	"fmt"
+	"forgolang_forum/cmn"
+	"forgolang_forum/database"
+	"forgolang_forum/database/model"
+	"forgolang_forum/utils"
+	"github.com/go-redis/redis"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// FeedSize number of posts kept in the precomputed feed of a user, older
+// posts are computed at read time
+const FeedSize = 500
+
+// FeedTTL a user is active while the feed was read in this window, feeds of
+// active users are precomputed and fanned out to
+const FeedTTL = 7 * 24 * time.Hour
+
+// FeedEntry a post of a feed with its feed score
+type FeedEntry struct {
+	ID    int64 `db:"id"`
+	Score int64 `db:"score"`
+}
+
+// Before entry is ordered before the given keyset position
+func (e FeedEntry) Before(score, id int64) bool {
+	return e.Score < score || (e.Score == score && e.ID < id)
+}
+
+// FeedKey redis key of the precomputed feed of a user
+func FeedKey(userID int64) string {
+	return fmt.Sprintf("%s:%d", cmn.GetRedisKey("feed", "user"), userID)
+}
+
+// FeedStart keyset position of the first page of a feed
+func FeedStart() (int64, int64) {
+	return math.MaxInt64, math.MaxInt64
+}
+
+// feedVotesQuery net votes of the post p
+func feedVotesQuery() string {
+	var votesUp model.PostVotesUp
+	var votesDown model.PostVotesDown
+
+	return fmt.Sprintf(`((SELECT count(pvu.id) FROM %s AS pvu WHERE pvu.post_id = p.id) -
+		(SELECT count(pvd.id) FROM %s AS pvd WHERE pvd.post_id = p.id))`,
+		votesUp.TableName(), votesDown.TableName())
+}
+
+// ComputeFeed compute a page of the feed of a user from the follows after
+// the given keyset position. Posts of followed users, tags and categories
+// are merged and posts of the user are left out.
+func ComputeFeed(app *cmn.App, userID, score, id int64, limit int) ([]FeedEntry, error) {
+	var post model.Post
+	var follow model.Follow
+	var postTag model.PostTag
+	var postCategoryAssignment model.PostCategoryAssignment
+
+	entries := make([]FeedEntry, 0)
+	err := app.Database.DB.Select(&entries, fmt.Sprintf(`
+		SELECT f.id, f.score FROM (
+			SELECT p.id as id, %s as score FROM %s AS p
+			WHERE p.status = $5 AND p.deleted_at IS NULL AND p.author_id != $1 AND (
+				p.author_id IN (SELECT f.followed_user_id FROM %s AS f WHERE f.user_id = $1) OR
+				EXISTS (
+					SELECT 1 FROM %s AS pt INNER JOIN %s AS f ON pt.tag_id = f.tag_id
+					WHERE pt.post_id = p.id AND f.user_id = $1
+				) OR
+				EXISTS (
+					SELECT 1 FROM %s AS pca INNER JOIN %s AS f ON pca.category_id = f.category_id
+					WHERE pca.post_id = p.id AND f.user_id = $1
+				)
+			)
+		) AS f
+		WHERE (f.score, f.id) < ($2, $3)
+		ORDER BY f.score DESC, f.id DESC
+		LIMIT $4
+	`, utils.FeedScoreQuery("p.published_at", feedVotesQuery()), post.TableName(), follow.TableName(),
+		postTag.TableName(), follow.TableName(), postCategoryAssignment.TableName(), follow.TableName()),
+		userID,
+		score,
+		id,
+		limit,
+		database.Published)
+
+	return entries, err
+}
+
+// WarmFeed precompute the feed of a user into redis, the user is active
+// until the feed expires
+func WarmFeed(app *cmn.App, userID int64) error {
+	score, id := FeedStart()
+	entries, err := ComputeFeed(app, userID, score, id, FeedSize)
+	if err != nil || len(entries) == 0 {
+		return err
+	}
+
+	members := make([]redis.Z, 0, len(entries))
+	for _, e := range entries {
+		members = append(members, redis.Z{
+			Score:  float64(e.Score),
+			Member: strconv.FormatInt(e.ID, 10),
+		})
+	}
+
+	// the feed is built on a temporary key and renamed so readers never see
+	// a partial feed
+	key := FeedKey(userID)
+	tmpKey := fmt.Sprintf("%s:tmp", key)
+	pipe := app.Cache.TxPipeline()
+	pipe.Del(tmpKey)
+	pipe.ZAdd(tmpKey, members...)
+	pipe.Rename(tmpKey, key)
+	pipe.Expire(key, FeedTTL)
+	_, err = pipe.Exec()
+
+	return err
+}
+
+// ReadFeed a page of the feed of a user after the given keyset position.
+// Feeds of active users are read from redis, the feed of an inactive user
+// is computed and precomputed for the next reads.
+func ReadFeed(app *cmn.App, userID, score, id int64, limit int) ([]FeedEntry, error) {
+	key := FeedKey(userID)
+	if n, _ := app.Cache.Exists(key).Result(); n == 0 {
+		entries, err := ComputeFeed(app, userID, score, id, limit)
+		if err == nil {
+			go WarmFeed(app, userID)
+		}
+		return entries, err
+	}
+	app.Cache.Expire(key, FeedTTL)
+
+	members, err := app.Cache.ZRevRangeByScoreWithScores(key, redis.ZRangeBy{
+		Max: strconv.FormatInt(score, 10),
+		Min: "-inf",
+	}).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	// members with the same score are ordered by their identifiers like the
+	// computed feed instead of lexicographically
+	entries := make([]FeedEntry, 0, len(members))
+	for _, m := range members {
+		memberID, _ := strconv.ParseInt(m.Member.(string), 10, 64)
+		if e := (FeedEntry{ID: memberID, Score: int64(m.Score)}); e.Before(score, id) {
+			entries = append(entries, e)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[j].Before(entries[i].Score, entries[i].ID)
+	})
+	if len(entries) >= limit {
+		return entries[:limit], nil
+	}
+
+	// precomputed feeds are capped, older posts are computed at read time
+	if n, _ := app.Cache.ZCard(key).Result(); n < FeedSize {
+		return entries, nil
+	}
+	if len(entries) > 0 {
+		score, id = entries[len(entries)-1].Score, entries[len(entries)-1].ID
+	}
+	older, err := ComputeFeed(app, userID, score, id, limit-len(entries))
+	if err != nil {
+		return nil, err
+	}
+
+	return append(entries, older...), nil
+}
+
+// FanOutPost add a published post to the precomputed feeds of active users
+// following its author, tags or categories. Feeds are capped to the newest
+// posts and scores of posts already in a feed are refreshed.
+func FanOutPost(app *cmn.App, postID int64) error {
+	var post model.Post
+	var follow model.Follow
+	var postTag model.PostTag
+	var postCategoryAssignment model.PostCategoryAssignment
+
+	var entry FeedEntry
+	err := app.Database.DB.Get(&entry, fmt.Sprintf(`
+		SELECT p.id as id, %s as score FROM %s AS p
+		WHERE p.id = $1 AND p.status = $2 AND p.deleted_at IS NULL
+	`, utils.FeedScoreQuery("p.published_at", feedVotesQuery()), post.TableName()),
+		postID,
+		database.Published)
+	if err == sql.ErrNoRows {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	var followers []int64
+	err = app.Database.DB.Select(&followers, fmt.Sprintf(`
+		SELECT DISTINCT f.user_id FROM %s AS f
+		INNER JOIN %s AS p ON p.id = $1
+		WHERE f.user_id != p.author_id AND (
+			f.followed_user_id = p.author_id OR
+			f.tag_id IN (SELECT pt.tag_id FROM %s AS pt WHERE pt.post_id = p.id) OR
+			f.category_id IN (SELECT pca.category_id FROM %s AS pca WHERE pca.post_id = p.id)
+		)
+	`, follow.TableName(), post.TableName(), postTag.TableName(), postCategoryAssignment.TableName()),
+		postID)
+	if err != nil {
+		return err
+	}
+
+	member := redis.Z{
+		Score:  float64(entry.Score),
+		Member: strconv.FormatInt(entry.ID, 10),
+	}
+	for _, userID := range followers {
+		key := FeedKey(userID)
+		if n, _ := app.Cache.Exists(key).Result(); n == 0 {
+			continue
+		}
+
+		pipe := app.Cache.Pipeline()
+		pipe.ZAdd(key, member)
+		pipe.ZRemRangeByRank(key, 0, -FeedSize-1)
+		if _, err := pipe.Exec(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// RemoveFeed drop the precomputed feed of a user after the follows of the
+// user changed, it is computed again on the next read
+func RemoveFeed(app *cmn.App, userID int64) {
+	app.Cache.Del(FeedKey(userID))
+}
@@ -0,0 +1,302 @@
+// Copyright 2019 StreetByters Community
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with
+// this work for additional information regarding copyright ownership.
+// The ASF licenses this file to You under the Apache License, Version 2.0
+// (the "License"); you may not use this file except in compliance with
+// the License.  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tasks
+
+import (
+	"context"
+	"fmt"
+	"forgolang_forum/cmn"
+	"forgolang_forum/database"
+	"forgolang_forum/database/model"
+	"strconv"
+	"time"
+)
+
+// DefaultFlagHideThreshold trusted flags which hide a post or a comment
+// when the threshold is not configured
+const DefaultFlagHideThreshold = 3
+
+// FlagTrustedAccuracy accuracy flaggers need, besides the basic trust
+// level, for their flags to count towards hiding content
+const FlagTrustedAccuracy = 0.5
+
+// FlagHideReason delete reason of content hidden by flags, hidden content
+// has no deleting user until a moderator agrees with its flags
+const FlagHideReason = "flagged"
+
+// FlagAccuracyKey redis key of the cached flag accuracy of a user
+func FlagAccuracyKey(userID int64) string {
+	return fmt.Sprintf("%s:%d", cmn.GetRedisKey("user", "flag_accuracy"), userID)
+}
+
+// GetFlagAccuracy share of the resolved flags of a user moderators agreed
+// with. Deferred flags are not counted and users without resolved flags
+// start from an even accuracy.
+func GetFlagAccuracy(app *cmn.App, userID int64) float64 {
+	if accuracy, err := app.Cache.Get(FlagAccuracyKey(userID)).Float64(); err == nil {
+		return accuracy
+	}
+
+	var flag model.Flag
+	var counts struct {
+		Agreed    int64 `db:"agreed"`
+		Disagreed int64 `db:"disagreed"`
+	}
+	app.Database.DB.Get(&counts, fmt.Sprintf(`
+		SELECT
+			count(f.id) FILTER (WHERE f.status = $2) as agreed,
+			count(f.id) FILTER (WHERE f.status = $3) as disagreed
+		FROM %s AS f WHERE f.user_id = $1
+	`, flag.TableName()),
+		userID,
+		database.Agreed,
+		database.Disagreed)
+	accuracy := float64(counts.Agreed+1) / float64(counts.Agreed+counts.Disagreed+2)
+	app.Cache.Set(FlagAccuracyKey(userID), accuracy, time.Hour)
+
+	return accuracy
+}
+
+// IsTrustedFlagger flags of the user count towards hiding content
+func IsTrustedFlagger(app *cmn.App, userID int64) bool {
+	return GetTrustLevel(app, userID) >= database.Basic && GetFlagAccuracy(app, userID) >= FlagTrustedAccuracy
+}
+
+// AddFlag insert a flag and hide its post or comment once enough trusted
+// users flagged it, users are not hidden by flags
+func AddFlag(app *cmn.App, flag *model.Flag) error {
+	if !flag.Trusted && flag.UserID.Valid {
+		flag.Trusted = IsTrustedFlagger(app, flag.UserID.Int64)
+	}
+	flag.Status = database.Pending
+	if err := app.Database.Insert(new(model.Flag), flag, "id", "inserted_at"); err != nil {
+		return err
+	}
+
+	if !flag.PostID.Valid {
+		return nil
+	}
+
+	threshold := app.Config.FlagHideThreshold
+	if threshold <= 0 {
+		threshold = DefaultFlagHideThreshold
+	}
+
+	var count int64
+	app.Database.DB.Get(&count, fmt.Sprintf(`
+		SELECT count(f.id) FROM %s AS f
+		WHERE f.status = $1 AND f.trusted AND f.target_user_id = $2 AND
+			f.post_id IS NOT DISTINCT FROM $3 AND f.comment_id IS NOT DISTINCT FROM $4
+	`, flag.TableName()),
+		database.Pending,
+		flag.TargetUserID,
+		flag.PostID,
+		flag.CommentID)
+	if count < threshold {
+		return nil
+	}
+
+	return hideFlagged(app, flag)
+}
+
+// ResolveFlags resolve the pending flags of the target of a flag. Agreeing
+// keeps flagged content hidden as removed by the moderator, disagreeing
+// brings back content hidden by flags. Accuracies of the flaggers are
+// recomputed on their next read. Resolutions of content flagged as spam
+// train the spam model, and content held by the classifier is published
+// when it is restored.
+func ResolveFlags(app *cmn.App, flag *model.Flag, status database.FlagStatus, moderatorID int64) (int64, error) {
+	var resolved []model.Flag
+	err := app.Database.DB.Select(&resolved, fmt.Sprintf(`
+		UPDATE %s SET status = $5, resolved_by_id = $6, resolved_at = (CURRENT_TIMESTAMP at time zone 'utc')
+		WHERE status = $1 AND target_user_id = $2 AND
+			post_id IS NOT DISTINCT FROM $3 AND comment_id IS NOT DISTINCT FROM $4
+		RETURNING *
+	`, flag.TableName()),
+		database.Pending,
+		flag.TargetUserID,
+		flag.PostID,
+		flag.CommentID,
+		status,
+		moderatorID)
+	if err != nil {
+		return 0, err
+	}
+
+	spam, held := false, false
+	for _, f := range resolved {
+		if f.UserID.Valid {
+			app.Cache.Del(FlagAccuracyKey(f.UserID.Int64))
+		}
+		spam = spam || f.Reason == database.Spam
+		held = held || (f.Reason == database.Spam && !f.UserID.Valid)
+	}
+
+	if flag.PostID.Valid && len(resolved) > 0 {
+		switch status {
+		case database.Agreed:
+			err = removeFlagged(app, flag, moderatorID)
+		case database.Disagreed:
+			err = restoreFlagged(app, flag, held)
+		}
+		if err == nil && spam && status != database.Deferred {
+			err = TrainSpam(app, flag, status == database.Agreed, moderatorID)
+		}
+	}
+
+	return int64(len(resolved)), err
+}
+
+// hideFlagged hide the post or the comment of a flag as a tombstone
+func hideFlagged(app *cmn.App, flag *model.Flag) error {
+	var post model.Post
+	var postComment model.PostComment
+
+	var ids []int64
+	var err error
+	if flag.CommentID.Valid {
+		err = app.Database.DB.Select(&ids, fmt.Sprintf(`
+			UPDATE %s SET deleted_at = (CURRENT_TIMESTAMP at time zone 'utc'), delete_reason = $2
+			WHERE id = $1 AND deleted_at IS NULL
+			RETURNING id
+		`, postComment.TableName()),
+			flag.CommentID.Int64,
+			FlagHideReason)
+	} else {
+		err = app.Database.DB.Select(&ids, fmt.Sprintf(`
+			UPDATE %s SET deleted_at = (CURRENT_TIMESTAMP at time zone 'utc'), delete_reason = $2
+			WHERE id = $1 AND deleted_at IS NULL
+			RETURNING id
+		`, post.TableName()),
+			flag.PostID.Int64,
+			FlagHideReason)
+	}
+	if err != nil || len(ids) == 0 {
+		return err
+	}
+
+	hideCounters(app, flag, true)
+	notification := model.NewModerationNotification(flag.TargetUserID, "hidden", flag.PostID.Int64, flag.CommentID)
+
+	return Notify(app, notification)
+}
+
+// removeFlagged remove the post or the comment of agreed flags as the
+// moderator, content hidden by flags keeps its tombstone
+func removeFlagged(app *cmn.App, flag *model.Flag, moderatorID int64) error {
+	var post model.Post
+	var postComment model.PostComment
+
+	table := post.TableName()
+	id := flag.PostID.Int64
+	if flag.CommentID.Valid {
+		table = postComment.TableName()
+		id = flag.CommentID.Int64
+	}
+
+	var hidden []bool
+	err := app.Database.DB.Select(&hidden, fmt.Sprintf(`
+		UPDATE %s AS t SET
+			deleted_at = COALESCE(t.deleted_at, (CURRENT_TIMESTAMP at time zone 'utc')),
+			deleted_by_id = $2, delete_reason = COALESCE(t.delete_reason, $3)
+		FROM (SELECT id, deleted_at IS NOT NULL AS hidden FROM %s WHERE id = $1) AS o
+		WHERE t.id = o.id AND (t.deleted_at IS NULL OR (t.deleted_by_id IS NULL AND t.delete_reason = $3))
+		RETURNING o.hidden
+	`, table, table),
+		id,
+		moderatorID,
+		FlagHideReason)
+	if err != nil || len(hidden) == 0 {
+		return err
+	}
+
+	if !hidden[0] {
+		hideCounters(app, flag, true)
+	}
+	notification := model.NewModerationNotification(flag.TargetUserID, "removed", flag.PostID.Int64, flag.CommentID)
+	notification.ActorID.SetValid(moderatorID)
+
+	return Notify(app, notification)
+}
+
+// restoreFlagged bring back the post or the comment of disagreed flags if
+// it is hidden by flags, content held by the classifier is published
+func restoreFlagged(app *cmn.App, flag *model.Flag, held bool) error {
+	var post model.Post
+	var postComment model.PostComment
+
+	table := post.TableName()
+	id := flag.PostID.Int64
+	if flag.CommentID.Valid {
+		table = postComment.TableName()
+		id = flag.CommentID.Int64
+	}
+
+	result, err := app.Database.DB.Exec(fmt.Sprintf(`
+		UPDATE %s SET deleted_at = NULL, deleted_by_id = NULL, delete_reason = NULL
+		WHERE id = $1 AND deleted_at IS NOT NULL AND deleted_by_id IS NULL AND delete_reason = $2
+	`, table),
+		id,
+		FlagHideReason)
+	if err != nil {
+		return err
+	}
+	n, _ := result.RowsAffected()
+	if n == 0 {
+		return nil
+	}
+	hideCounters(app, flag, false)
+	if held {
+		return releaseHeld(app, flag)
+	}
+
+	return nil
+}
+
+// hideCounters update the search index and cached counters of content
+// hidden or shown again, rankings pick shown posts up on their next refresh
+func hideCounters(app *cmn.App, flag *model.Flag, hidden bool) {
+	if flag.CommentID.Valid {
+		key := fmt.Sprintf("%s:%d", cmn.GetRedisKey("comment", "count"), flag.PostID.Int64)
+		if hidden {
+			app.Cache.Decr(key)
+		} else {
+			app.Cache.Incr(key)
+		}
+		return
+	}
+
+	var post model.Post
+	var status database.PostStatus
+	app.Database.DB.Get(&status, fmt.Sprintf(`SELECT p.status FROM %s AS p WHERE p.id = $1`, post.TableName()),
+		flag.PostID.Int64)
+	if status != database.Published {
+		return
+	}
+
+	if hidden {
+		app.ElasticClient.Delete().
+			Index("posts").
+			Id(strconv.FormatInt(flag.PostID.Int64, 10)).
+			Do(context.TODO())
+		UncountPublishedPost(app, flag.PostID.Int64)
+		RemovePostRankings(app, flag.PostID.Int64)
+	} else {
+		IndexPost(app, flag.PostID.Int64)
+		CountPublishedPost(app, flag.PostID.Int64)
+	}
+}
@@ -0,0 +1,99 @@
+// Copyright 2019 StreetByters Community
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with
+// this work for additional information regarding copyright ownership.
+// The ASF licenses this file to You under the Apache License, Version 2.0
+// (the "License"); you may not use this file except in compliance with
+// the License.  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tasks
+
+import (
+	"fmt"
+	"forgolang_forum/cmn"
+	"forgolang_forum/database"
+	"forgolang_forum/database/model"
+	"forgolang_forum/utils"
+	"github.com/lib/pq"
+	"gopkg.in/guregu/null.v3/zero"
+)
+
+// SyncMentions resolve @username mentions in the content of a post or a
+// comment and store them as the current mention set. Unknown, inactive and
+// banned users and the author are ignored. Users mentioned for the first
+// time are returned and notified, users mentioned again after an edit are
+// not.
+func SyncMentions(app *cmn.App, postID int64, commentID zero.Int, authorID, sourceUserID int64,
+	content string) ([]int64, error) {
+	var user model.User
+	var userState model.UserState
+	var mention model.Mention
+
+	var userIDs []int64
+	if usernames := utils.ParseMentions(content); len(usernames) > 0 {
+		err := app.Database.DB.Select(&userIDs, fmt.Sprintf(`
+			SELECT u.id FROM %s AS u
+			LEFT JOIN LATERAL (
+				SELECT us.state FROM %s AS us WHERE us.user_id = u.id ORDER BY us.id DESC LIMIT 1
+			) AS us ON true
+			WHERE lower(u.username) = ANY($1) AND u.id != $2 AND u.is_active AND
+				(us.state IS NULL OR us.state != $3)
+		`, user.TableName(), userState.TableName()),
+			pq.Array(usernames),
+			authorID,
+			database.Banned)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var mentioned []int64
+	if len(userIDs) > 0 {
+		err := app.Database.DB.Select(&mentioned, fmt.Sprintf(`
+			INSERT INTO %s (user_id, post_id, comment_id, source_user_id)
+			SELECT u.id, $2::bigint, $3::bigint, $4::bigint FROM unnest($1::bigint[]) AS u(id)
+			ON CONFLICT (user_id, post_id, COALESCE(comment_id, 0)) DO NOTHING
+			RETURNING user_id
+		`, mention.TableName()),
+			pq.Array(userIDs),
+			postID,
+			commentID,
+			sourceUserID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// mentions which are edited out are marked as removed and brought back
+	// without notifying again when they are added later
+	_, err := app.Database.DB.Exec(fmt.Sprintf(`
+		UPDATE %s SET removed_at = CASE
+			WHEN user_id = ANY($3) THEN NULL
+			ELSE COALESCE(removed_at, (CURRENT_TIMESTAMP at time zone 'utc')) END
+		WHERE post_id = $1 AND comment_id IS NOT DISTINCT FROM $2
+	`, mention.TableName()),
+		postID,
+		commentID,
+		pq.Array(userIDs))
+	if err != nil {
+		return mentioned, err
+	}
+
+	for _, userID := range mentioned {
+		notification := model.NewNotification(userID, database.MentionNotification, postID, commentID)
+		notification.ActorID.SetValid(authorID)
+		if err := Notify(app, notification); err != nil {
+			return mentioned, err
+		}
+	}
+
+	return mentioned, nil
+}
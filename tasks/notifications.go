@@ -0,0 +1,107 @@
+// Copyright 2019 StreetByters Community
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with
+// this work for additional information regarding copyright ownership.
+// The ASF licenses this file to You under the Apache License, Version 2.0
+// (the "License"); you may not use this file except in compliance with
+// the License.  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tasks
+
+import (
+	"fmt"
+	"forgolang_forum/cmn"
+	"forgolang_forum/database"
+	"forgolang_forum/database/model"
+	model2 "forgolang_forum/model"
+)
+
+// Notify store a notification for its user. An unread notification of the
+// same group takes the new actor instead, so users see one notification
+// like "5 people upvoted your post". Users are not notified of their own
+// actions and of posts they muted, except for moderator actions and their
+// own reminders.
+func Notify(app *cmn.App, notification *model.Notification) error {
+	if notification.ActorID.Valid && notification.ActorID.Int64 == notification.UserID {
+		return nil
+	}
+	if notification.Type != database.ModerationNotification && notification.Type != database.ReminderNotification &&
+		GetWatchLevel(app, notification.UserID, notification.PostID) == database.Muted {
+		return nil
+	}
+
+	// a concurrent notification of the same group may be inserted between
+	// the update and the insert, the update is tried once more then
+	for i := 0; i < 2; i++ {
+		var ids []int64
+		err := app.Database.DB.Select(&ids, fmt.Sprintf(`
+			UPDATE %s SET
+				actor_id = COALESCE($3, actor_id),
+				actor_ids = CASE WHEN $3::bigint IS NULL OR $3 = ANY(actor_ids) THEN actor_ids
+					ELSE array_append(actor_ids, $3::bigint) END,
+				updated_at = (CURRENT_TIMESTAMP at time zone 'utc')
+			WHERE user_id = $1 AND group_key = $2 AND read_at IS NULL
+			RETURNING id
+		`, notification.TableName()),
+			notification.UserID,
+			notification.GroupKey,
+			notification.ActorID)
+		if err != nil {
+			return err
+		}
+		if len(ids) > 0 {
+			notification.ID = ids[0]
+			return publishNotification(app, notification)
+		}
+
+		err = app.Database.DB.Select(&ids, fmt.Sprintf(`
+			INSERT INTO %s (user_id, type, actor_id, actor_ids, post_id, comment_id, action, group_key)
+			VALUES ($1, $2, $3, CASE WHEN $3::bigint IS NULL THEN '{}' ELSE ARRAY[$3::bigint] END, $4, $5, $6, $7)
+			ON CONFLICT (user_id, group_key) WHERE read_at IS NULL DO NOTHING
+			RETURNING id
+		`, notification.TableName()),
+			notification.UserID,
+			notification.Type,
+			notification.ActorID,
+			notification.PostID,
+			notification.CommentID,
+			notification.Action,
+			notification.GroupKey)
+		if err != nil {
+			return err
+		}
+		if len(ids) > 0 {
+			notification.ID = ids[0]
+			key := fmt.Sprintf("%s:%d", cmn.GetRedisKey("notification", "unread"), notification.UserID)
+			if exists, _ := app.Cache.Exists(key).Result(); exists > 0 {
+				app.Cache.Incr(key)
+			}
+			return publishNotification(app, notification)
+		}
+	}
+
+	return nil
+}
+
+// publishNotification send the notification with its actors to the
+// personal event topic of its user
+func publishNotification(app *cmn.App, notification *model.Notification) error {
+	err := app.Database.DB.Get(notification, fmt.Sprintf(`
+		%s AND n.id = $2
+	`, notification.Query()),
+		notification.UserID,
+		notification.ID)
+	if err != nil {
+		return err
+	}
+
+	return PublishEvent(app, model2.UserTopic(notification.UserID), "notification", notification)
+}
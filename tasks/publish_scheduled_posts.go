@@ -0,0 +1,259 @@
+// Copyright 2019 StreetByters Community
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with
+// this work for additional information regarding copyright ownership.
+// The ASF licenses this file to You under the Apache License, Version 2.0
+// (the "License"); you may not use this file except in compliance with
+// the License.  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tasks
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"forgolang_forum/cmn"
+	"forgolang_forum/database"
+	"forgolang_forum/database/model"
+	model2 "forgolang_forum/model"
+	"github.com/gosimple/slug"
+	"gopkg.in/guregu/null.v3/zero"
+	"strconv"
+)
+
+// PublishScheduledPosts publish scheduled posts whose publish time has come
+func PublishScheduledPosts(app *cmn.App, args interface{}) error {
+	var post model.Post
+	var ids []int64
+	err := app.Database.DB.Select(&ids, fmt.Sprintf(`
+		SELECT p.id FROM %s AS p
+		WHERE p.status = $1 AND p.publish_at <= (CURRENT_TIMESTAMP at time zone 'utc') AND
+			p.deleted_at IS NULL
+		ORDER BY p.publish_at ASC
+	`, post.TableName()),
+		database.Scheduled)
+	if err != nil {
+		return err
+	}
+
+	var published int
+	for _, id := range ids {
+		ok, err := PublishPost(app, id)
+		if err != nil {
+			return err
+		}
+		if ok {
+			published++
+		}
+	}
+
+	if app.Mode != model2.Test {
+		app.Logger.LogInfo(fmt.Sprintf("Published %d scheduled posts", published))
+	}
+
+	return nil
+}
+
+// PublishPost publish a draft or scheduled post. The post gets the slug of
+// its latest title, which is suffixed with the post identifier when another
+// post took it in the meantime. Posts are indexed and counted only after
+// they are published.
+func PublishPost(app *cmn.App, postID int64) (bool, error) {
+	var post model.Post
+	var postSlug model.PostSlug
+	var postDetail model.PostDetail
+
+	// the status flip and the slug are committed together, a failed slug
+	// leaves the post unpublished so it is picked up again
+	var err error
+	var published bool
+	db := app.Database.Transaction(func(tx *database.Tx) error {
+		if err = tx.DB.Error; err != nil {
+			return err
+		}
+
+		var result sql.Result
+		result, err = tx.DB.Tx.Exec(fmt.Sprintf(`
+			UPDATE %s SET status = $2, published_at = (CURRENT_TIMESTAMP at time zone 'utc'), publish_at = NULL
+			WHERE id = $1 AND status != $2 AND deleted_at IS NULL
+		`, post.TableName()),
+			postID,
+			database.Published)
+		if err != nil {
+			return err
+		}
+		if n, _ := result.RowsAffected(); n == 0 {
+			return nil
+		}
+
+		err = tx.DB.Tx.Get(&postDetail, fmt.Sprintf(`
+			SELECT pd.title, pd.content FROM %s AS pd WHERE pd.post_id = $1 ORDER BY pd.id DESC LIMIT 1
+		`, postDetail.TableName()),
+			postID)
+		if err != nil {
+			return err
+		}
+
+		postSlug.Slug = slug.Make(postDetail.Title)
+		var taken bool
+		err = tx.DB.Tx.Get(&taken, fmt.Sprintf(`
+			SELECT EXISTS (SELECT 1 FROM %s AS ps WHERE ps.slug = $1 AND ps.post_id != $2)
+		`, postSlug.TableName()),
+			postSlug.Slug,
+			postID)
+		if err != nil {
+			return err
+		}
+		if taken {
+			postSlug.Slug = fmt.Sprintf("%s-%d", postSlug.Slug, postID)
+		}
+
+		_, err = tx.DB.Tx.Exec(fmt.Sprintf(`
+			INSERT INTO %s (post_id, source_user_id, slug)
+			SELECT p.id, p.author_id, $2 FROM %s AS p WHERE p.id = $1
+			ON CONFLICT DO NOTHING
+		`, postSlug.TableName(), post.TableName()),
+			postID,
+			postSlug.Slug)
+		if err != nil {
+			return err
+		}
+
+		published = true
+		return nil
+	})
+	if err == nil {
+		err = db.Error
+	}
+	if err != nil || !published {
+		return false, err
+	}
+
+	if err := IndexPost(app, postID); err != nil {
+		return false, err
+	}
+	CountPublishedPost(app, postID)
+
+	// mentions in drafts are notified once the post is published
+	app.Database.DB.Get(&post, fmt.Sprintf(`
+		SELECT p.* FROM %s AS p WHERE p.id = $1
+	`, post.TableName()),
+		postID)
+	if _, err := SyncMentions(app, postID, zero.Int{}, post.AuthorID, post.AuthorID, postDetail.Content); err != nil {
+		return false, err
+	}
+
+	if err := PublishPostEvents(app, postID); err != nil {
+		return false, err
+	}
+	if err := FanOutPost(app, postID); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// IndexPost index latest details of a published post to elasticsearch
+func IndexPost(app *cmn.App, postID int64) error {
+	var post model.PostDEP
+	var p model.Post
+	var postSlug model.PostSlug
+	var postDetail model.PostDetail
+	var postCategoryAssignment model.PostCategoryAssignment
+	var postTag model.PostTag
+	var tag model.Tag
+	var user model.User
+	var postAnswer model.PostAnswer
+	err := app.Database.DB.Get(&post, fmt.Sprintf(`
+		SELECT
+			p.id as id, p.author_id as author_id, u.username as author_username,
+			p.status as status, p.inserted_at as inserted_at, ps.slug as slug, pd.title as title,
+			pd.description as description, pd.content as content,
+			ARRAY(
+				SELECT t.name FROM %s AS pt INNER JOIN %s AS t ON pt.tag_id = t.id
+				WHERE pt.post_id = p.id ORDER BY t.name
+			) as tags,
+			EXISTS (SELECT 1 FROM %s AS pa WHERE pa.post_id = p.id) as solved
+		FROM %s AS p
+		LEFT OUTER JOIN %s AS ps ON p.id = ps.post_id
+		LEFT OUTER JOIN %s AS ps2 ON ps.post_id = ps2.post_id AND ps.id < ps2.id
+		INNER JOIN %s AS pd ON p.id = pd.post_id
+		LEFT OUTER JOIN %s AS pd2 ON pd.post_id = pd2.post_id AND pd.id < pd2.id
+		INNER JOIN %s AS u ON p.author_id = u.id
+		WHERE ps2.id IS NULL AND pd2.id IS NULL AND p.id = $1
+	`, postTag.TableName(), tag.TableName(), postAnswer.TableName(), p.TableName(), postSlug.TableName(),
+		postSlug.TableName(), postDetail.TableName(), postDetail.TableName(), user.TableName()),
+		postID)
+	if err != nil {
+		return err
+	}
+
+	var categoryIDs []int64
+	app.Database.DB.Select(&categoryIDs, fmt.Sprintf(`
+		SELECT pca.category_id FROM %s AS pca WHERE pca.post_id = $1 ORDER BY pca.id ASC
+	`, postCategoryAssignment.TableName()),
+		postID)
+
+	_, err = app.ElasticClient.Index().
+		Index("posts").
+		Id(strconv.FormatInt(postID, 10)).
+		BodyJson(post).
+		Do(context.TODO())
+	if err != nil {
+		return err
+	}
+
+	if len(categoryIDs) > 0 {
+		_, err = app.ElasticClient.Update().
+			Index("posts").
+			Id(strconv.FormatInt(postID, 10)).
+			Doc(map[string]interface{}{
+				"category_assignments": categoryIDs,
+			}).
+			Do(context.TODO())
+	}
+
+	return err
+}
+
+// CountPublishedPost increase cached post counters of a published post and
+// its categories, counters which are not cached yet are loaded on the next
+// read
+func CountPublishedPost(app *cmn.App, postID int64) {
+	countPost(app, postID, 1)
+}
+
+// UncountPublishedPost decrease cached post counters of a published post
+// and its categories when the post is removed
+func UncountPublishedPost(app *cmn.App, postID int64) {
+	countPost(app, postID, -1)
+}
+
+// countPost change cached post counters of a post and its categories
+func countPost(app *cmn.App, postID int64, n int64) {
+	var postCategoryAssignment model.PostCategoryAssignment
+	var categoryIDs []int64
+	app.Database.DB.Select(&categoryIDs, fmt.Sprintf(`
+		SELECT DISTINCT pca.category_id FROM %s AS pca WHERE pca.post_id = $1
+	`, postCategoryAssignment.TableName()),
+		postID)
+
+	keys := []string{cmn.GetRedisKey("post", "count")}
+	for _, id := range categoryIDs {
+		keys = append(keys, fmt.Sprintf("%s:%d", cmn.GetRedisKey("category", "posts"), id))
+	}
+
+	for _, key := range keys {
+		if exists, _ := app.Cache.Exists(key).Result(); exists > 0 {
+			app.Cache.IncrBy(key, n)
+		}
+	}
+}
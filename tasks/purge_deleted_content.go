@@ -0,0 +1,87 @@
+// Copyright 2019 StreetByters Community
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with
+// this work for additional information regarding copyright ownership.
+// The ASF licenses this file to You under the Apache License, Version 2.0
+// (the "License"); you may not use this file except in compliance with
+// the License.  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tasks
+
+import (
+	"fmt"
+	"forgolang_forum/cmn"
+	"forgolang_forum/database"
+	"forgolang_forum/database/model"
+	model2 "forgolang_forum/model"
+	"time"
+)
+
+// DeletedContentRetention removed posts and comments are kept as tombstones
+// for this long so moderators can restore them
+const DeletedContentRetention = 30 * 24 * time.Hour
+
+// PurgeDeletedContent hard delete posts and comments which were removed
+// before the retention period. Removed comments are purged only once they
+// have no replies left, so live replies keep their tombstone parent. Content
+// with flags waiting for moderators is kept.
+func PurgeDeletedContent(app *cmn.App, args interface{}) error {
+	var post model.Post
+	var postComment model.PostComment
+	var flag model.Flag
+	before := time.Now().UTC().Add(-DeletedContentRetention)
+
+	var postIDs []int64
+	err := app.Database.DB.Select(&postIDs, fmt.Sprintf(`
+		DELETE FROM %s AS p
+		WHERE p.deleted_at < $1 AND NOT EXISTS (
+			SELECT 1 FROM %s AS f WHERE f.post_id = p.id AND f.status = $2
+		)
+		RETURNING p.id
+	`, post.TableName(), flag.TableName()),
+		before,
+		database.Pending)
+	if err != nil {
+		return err
+	}
+	for _, id := range postIDs {
+		app.Cache.Del(fmt.Sprintf("%s:%d", cmn.GetRedisKey("comment", "count"), id))
+	}
+
+	// each pass purges the leaves of removed comment chains
+	var comments int64
+	for {
+		result, err := app.Database.DB.Exec(fmt.Sprintf(`
+			DELETE FROM %s AS c
+			WHERE c.deleted_at < $1 AND NOT EXISTS (
+				SELECT 1 FROM %s AS r WHERE r.parent_id = c.id
+			) AND NOT EXISTS (
+				SELECT 1 FROM %s AS f WHERE f.comment_id = c.id AND f.status = $2
+			)
+		`, postComment.TableName(), postComment.TableName(), flag.TableName()),
+			before,
+			database.Pending)
+		if err != nil {
+			return err
+		}
+		n, _ := result.RowsAffected()
+		if n == 0 {
+			break
+		}
+		comments += n
+	}
+
+	if app.Mode != model2.Test {
+		app.Logger.LogInfo(fmt.Sprintf("Purged %d posts and %d comments", len(postIDs), comments))
+	}
+
+	return nil
+}
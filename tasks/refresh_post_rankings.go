@@ -0,0 +1,217 @@
+// Copyright 2019 StreetByters Community
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with
+// this work for additional information regarding copyright ownership.
+// The ASF licenses this file to You under the Apache License, Version 2.0
+// (the "License"); you may not use this file except in compliance with
+// the License.  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tasks
+
+import (
+	"fmt"
+	"forgolang_forum/cmn"
+	"forgolang_forum/database"
+	"forgolang_forum/database/model"
+	model2 "forgolang_forum/model"
+	"forgolang_forum/utils"
+	"github.com/go-redis/redis"
+	"strconv"
+	"time"
+)
+
+// postRanking ranking fields of a post in a category
+type postRanking struct {
+	ID           int64     `db:"id"`
+	CategoryID   int64     `db:"category_id"`
+	Score        int64     `db:"score"`
+	Comments     int64     `db:"comments"`
+	InsertedAt   time.Time `db:"inserted_at"`
+	LastActivity time.Time `db:"last_activity"`
+}
+
+// windowScores net votes cast on posts within the duration
+func windowScores(app *cmn.App, duration time.Duration) (map[int64]int64, error) {
+	var votesUp model.PostVotesUp
+	var votesDown model.PostVotesDown
+
+	var rows []struct {
+		PostID int64 `db:"post_id"`
+		Score  int64 `db:"score"`
+	}
+	err := app.Database.DB.Select(&rows, fmt.Sprintf(`
+		SELECT v.post_id, sum(v.vote) as score FROM (
+			SELECT pvu.post_id, 1 as vote FROM %s AS pvu WHERE pvu.inserted_at > $1
+			UNION ALL
+			SELECT pvd.post_id, -1 as vote FROM %s AS pvd WHERE pvd.inserted_at > $1
+		) AS v
+		GROUP BY v.post_id
+	`, votesUp.TableName(), votesDown.TableName()),
+		time.Now().UTC().Add(-duration))
+	if err != nil {
+		return nil, err
+	}
+
+	scores := make(map[int64]int64)
+	for _, r := range rows {
+		scores[r.PostID] = r.Score
+	}
+
+	return scores, nil
+}
+
+// rankingKeys existing ranking keys of the ranking, keys are scanned so the
+// shared redis is not blocked
+func rankingKeys(app *cmn.App, ranking string) ([]string, error) {
+	var keys []string
+	var cursor uint64
+	for {
+		batch, next, err := app.Cache.Scan(cursor, fmt.Sprintf("%s:*", cmn.GetRedisKey("post", ranking)),
+			100).Result()
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, batch...)
+		if cursor = next; cursor == 0 {
+			return keys, nil
+		}
+	}
+}
+
+// RefreshPostRankings precompute hot, top and active post rankings of each
+// category into redis sorted sets
+func RefreshPostRankings(app *cmn.App, args interface{}) error {
+	var post model.Post
+	var postCategoryAssignment model.PostCategoryAssignment
+	var postComment model.PostComment
+	var votesUp model.PostVotesUp
+	var votesDown model.PostVotesDown
+
+	var rankings []postRanking
+	err := app.Database.DB.Select(&rankings, fmt.Sprintf(`
+		SELECT
+			p.id as id, pca.category_id as category_id,
+			COALESCE(p.published_at, p.inserted_at) as inserted_at,
+			(SELECT count(pvu.id) FROM %s AS pvu WHERE pvu.post_id = p.id) -
+				(SELECT count(pvd.id) FROM %s AS pvd WHERE pvd.post_id = p.id) as score,
+			(SELECT count(pc.id) FROM %s AS pc WHERE pc.post_id = p.id AND pc.deleted_at IS NULL) as comments,
+			COALESCE((SELECT max(pc.inserted_at) FROM %s AS pc WHERE pc.post_id = p.id),
+				p.published_at, p.inserted_at) as last_activity
+		FROM %s AS p
+		INNER JOIN %s AS pca ON p.id = pca.post_id
+		WHERE p.status = $1 AND p.deleted_at IS NULL
+	`, votesUp.TableName(), votesDown.TableName(), postComment.TableName(), postComment.TableName(),
+		post.TableName(), postCategoryAssignment.TableName()),
+		database.Published)
+	if err != nil {
+		return err
+	}
+
+	// top rankings of a window count only the votes cast within the window,
+	// posts published within the window are ranked even without votes
+	now := time.Now().UTC()
+	windows := make(map[string]map[int64]int64)
+	for window, duration := range utils.RankingWindows {
+		if duration > 0 {
+			if windows[window], err = windowScores(app, duration); err != nil {
+				return err
+			}
+		}
+	}
+
+	sets := make(map[string][]redis.Z)
+	for _, r := range rankings {
+		member := strconv.FormatInt(r.ID, 10)
+
+		key := fmt.Sprintf("%s:%d", cmn.GetRedisKey("post", "hot"), r.CategoryID)
+		sets[key] = append(sets[key], redis.Z{
+			Score:  utils.HotScore(r.Score, r.Comments, r.InsertedAt),
+			Member: member,
+		})
+
+		key = fmt.Sprintf("%s:%d", cmn.GetRedisKey("post", "active"), r.CategoryID)
+		sets[key] = append(sets[key], redis.Z{
+			Score:  float64(r.LastActivity.Unix()),
+			Member: member,
+		})
+
+		for window, duration := range utils.RankingWindows {
+			score := r.Score
+			if duration > 0 {
+				var voted bool
+				score, voted = windows[window][r.ID]
+				if !voted && r.InsertedAt.Before(now.Add(-duration)) {
+					continue
+				}
+			}
+			key = fmt.Sprintf("%s:%s:%d", cmn.GetRedisKey("post", "top"), window, r.CategoryID)
+			sets[key] = append(sets[key], redis.Z{
+				Score:  float64(score),
+				Member: member,
+			})
+		}
+	}
+
+	// rankings of categories without posts in a window are removed
+	for _, ranking := range []string{"hot", "active", "top"} {
+		keys, err := rankingKeys(app, ranking)
+		if err != nil {
+			return err
+		}
+		for _, key := range keys {
+			if _, ok := sets[key]; !ok {
+				app.Cache.Del(key)
+			}
+		}
+	}
+
+	// sets are built on a temporary key and renamed so listings never see
+	// a partial ranking
+	for key, members := range sets {
+		tmpKey := fmt.Sprintf("%s:tmp", key)
+		pipe := app.Cache.TxPipeline()
+		pipe.Del(tmpKey)
+		pipe.ZAdd(tmpKey, members...)
+		pipe.Rename(tmpKey, key)
+		if _, err := pipe.Exec(); err != nil {
+			return err
+		}
+	}
+
+	if app.Mode != model2.Test {
+		app.Logger.LogInfo(fmt.Sprintf("Refreshed %d post rankings", len(sets)))
+	}
+
+	return nil
+}
+
+// RemovePostRankings remove a post from precomputed rankings of its
+// categories, the post is ranked again on the next refresh if it is listed
+func RemovePostRankings(app *cmn.App, postID int64) {
+	var postCategoryAssignment model.PostCategoryAssignment
+
+	var categoryIDs []int64
+	app.Database.DB.Select(&categoryIDs, fmt.Sprintf(`
+		SELECT pca.category_id FROM %s AS pca WHERE pca.post_id = $1
+	`, postCategoryAssignment.TableName()),
+		postID)
+
+	member := strconv.FormatInt(postID, 10)
+	pipe := app.Cache.Pipeline()
+	for _, categoryID := range categoryIDs {
+		pipe.ZRem(fmt.Sprintf("%s:%d", cmn.GetRedisKey("post", "hot"), categoryID), member)
+		pipe.ZRem(fmt.Sprintf("%s:%d", cmn.GetRedisKey("post", "active"), categoryID), member)
+		for window := range utils.RankingWindows {
+			pipe.ZRem(fmt.Sprintf("%s:%s:%d", cmn.GetRedisKey("post", "top"), window, categoryID), member)
+		}
+	}
+	pipe.Exec()
+}
@@ -0,0 +1,175 @@
+// Copyright 2019 StreetByters Community
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with
+// this work for additional information regarding copyright ownership.
+// The ASF licenses this file to You under the Apache License, Version 2.0
+// (the "License"); you may not use this file except in compliance with
+// the License.  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tasks
+
+import (
+	"database/sql"
+	"fmt"
+	"forgolang_forum/cmn"
+	"forgolang_forum/database"
+	"forgolang_forum/database/model"
+	"gopkg.in/guregu/null.v3/zero"
+	"time"
+)
+
+// ReputationPoints points of each reputation reason, penalties are given by
+// moderators
+var ReputationPoints = map[database.ReputationReason]int64{
+	database.PostUpvoted:      10,
+	database.PostDownvoted:    -2,
+	database.CommentUpvoted:   5,
+	database.CommentDownvoted: -2,
+	database.AnswerAccepted:   15,
+}
+
+// ReputationThresholds reputation users need for actions, policies check
+// them with the reputation of the current user
+var ReputationThresholds = map[string]int64{
+	"downvote": 50,
+}
+
+// ReputationKey redis key of the cached reputation of a user
+func ReputationKey(userID int64) string {
+	return fmt.Sprintf("%s:%d", cmn.GetRedisKey("user", "reputation"), userID)
+}
+
+// GetReputation reputation of a user, the sum of its ledger entries
+func GetReputation(app *cmn.App, userID int64) int64 {
+	if reputation, err := app.Cache.Get(ReputationKey(userID)).Int64(); err == nil {
+		return reputation
+	}
+
+	var reputationEvent model.ReputationEvent
+	var reputation int64
+	app.Database.DB.Get(&reputation, fmt.Sprintf(`
+		SELECT COALESCE(sum(r.amount), 0) FROM %s AS r WHERE r.user_id = $1
+	`, reputationEvent.TableName()),
+		userID)
+	app.Cache.Set(ReputationKey(userID), reputation, time.Hour)
+
+	return reputation
+}
+
+// AddReputation append an entry to the reputation ledger
+func AddReputation(app *cmn.App, reputationEvent *model.ReputationEvent) error {
+	if err := app.Database.Insert(new(model.ReputationEvent), reputationEvent, "id", "inserted_at"); err != nil {
+		return err
+	}
+	app.Cache.Del(ReputationKey(reputationEvent.UserID))
+	clearUserCache(app, reputationEvent.UserID)
+
+	return nil
+}
+
+// SyncReputation append the difference between the points the source of
+// the entry is worth now and the points the ledger already holds for it,
+// so votes can change direction or be retracted without editing entries.
+// Users do not earn reputation from themselves.
+func SyncReputation(app *cmn.App, reputationEvent *model.ReputationEvent, awarded bool) error {
+	if reputationEvent.SourceUserID.Valid && reputationEvent.SourceUserID.Int64 == reputationEvent.UserID {
+		return nil
+	}
+
+	var amount int64
+	if awarded {
+		amount = ReputationPoints[reputationEvent.Reason]
+	}
+
+	err := app.Database.DB.Get(reputationEvent, fmt.Sprintf(`
+		INSERT INTO %s (user_id, reason, amount, post_id, comment_id, source_user_id)
+		SELECT $1, $2, d.amount, $3, $4, $5 FROM (
+			SELECT $6 - COALESCE(sum(r.amount), 0) as amount FROM %s AS r
+			WHERE r.user_id = $1 AND r.reason = $2 AND r.post_id IS NOT DISTINCT FROM $3 AND
+				r.comment_id IS NOT DISTINCT FROM $4 AND r.source_user_id IS NOT DISTINCT FROM $5
+		) AS d
+		WHERE d.amount != 0
+		RETURNING *
+	`, reputationEvent.TableName(), reputationEvent.TableName()),
+		reputationEvent.UserID,
+		reputationEvent.Reason,
+		reputationEvent.PostID,
+		reputationEvent.CommentID,
+		reputationEvent.SourceUserID,
+		amount)
+	if err == sql.ErrNoRows {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	app.Cache.Del(ReputationKey(reputationEvent.UserID))
+	clearUserCache(app, reputationEvent.UserID)
+
+	return nil
+}
+
+// SyncVoteReputation sync the reputation the vote of a user gives to the
+// author of a post or a comment, vote is 1 for up, -1 for down and 0 for no
+// vote
+func SyncVoteReputation(app *cmn.App, authorID, voterID, postID int64, commentID zero.Int, vote int64) error {
+	up, down := database.PostUpvoted, database.PostDownvoted
+	if commentID.Valid {
+		up, down = database.CommentUpvoted, database.CommentDownvoted
+	}
+
+	for reason, awarded := range map[database.ReputationReason]bool{up: vote > 0, down: vote < 0} {
+		reputationEvent := model.NewReputationEvent(authorID, reason)
+		reputationEvent.PostID.SetValid(postID)
+		reputationEvent.CommentID = commentID
+		reputationEvent.SourceUserID.SetValid(voterID)
+		if err := SyncReputation(app, reputationEvent, awarded); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SyncAnswerReputation sync the reputation of the author of a comment that
+// is accepted as the answer of a post or no longer accepted. The points are
+// given by the post author, so accepting an own answer earns nothing.
+func SyncAnswerReputation(app *cmn.App, commentID int64, accepted bool) error {
+	var post model.Post
+	var comment model.PostComment
+
+	var authors struct {
+		PostID       int64 `db:"post_id"`
+		UserID       int64 `db:"user_id"`
+		PostAuthorID int64 `db:"author_id"`
+	}
+	err := app.Database.DB.Get(&authors, fmt.Sprintf(`
+		SELECT c.post_id, c.user_id, p.author_id FROM %s AS c
+		INNER JOIN %s AS p ON c.post_id = p.id
+		WHERE c.id = $1
+	`, comment.TableName(), post.TableName()),
+		commentID)
+	if err != nil {
+		return err
+	}
+
+	reputationEvent := model.NewReputationEvent(authors.UserID, database.AnswerAccepted)
+	reputationEvent.PostID.SetValid(authors.PostID)
+	reputationEvent.CommentID.SetValid(commentID)
+	reputationEvent.SourceUserID.SetValid(authors.PostAuthorID)
+
+	return SyncReputation(app, reputationEvent, accepted)
+}
+
+// clearUserCache drop the cached user, reputation and badges are shown
+// with the user
+func clearUserCache(app *cmn.App, userID int64) {
+	app.Cache.Del(fmt.Sprintf("%s:%d", cmn.GetRedisKey("user", "one"), userID))
+}
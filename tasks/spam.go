@@ -0,0 +1,398 @@
+// Copyright 2019 StreetByters Community
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with
+// this work for additional information regarding copyright ownership.
+// The ASF licenses this file to You under the Apache License, Version 2.0
+// (the "License"); you may not use this file except in compliance with
+// the License.  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tasks
+
+import (
+	"database/sql"
+	"fmt"
+	"forgolang_forum/cmn"
+	"forgolang_forum/database"
+	"forgolang_forum/database/model"
+	model2 "forgolang_forum/model"
+	"forgolang_forum/utils"
+	"github.com/go-redis/redis"
+	"github.com/lib/pq"
+	"gopkg.in/guregu/null.v3/zero"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SpamThreshold spam score from which new posts and comments are held for
+// review in the moderation queue
+const SpamThreshold = 0.9
+
+// SpamModelKey redis key of the hash caching the spam model, besides the
+// counters of the model each token has a spam and a ham count field
+func SpamModelKey() string {
+	return cmn.GetRedisKey("spam", "model")
+}
+
+// spamModelFields hash fields of the counters of the model and of the counts
+// of the given tokens, in the order they are read into a model
+func spamModelFields(tokens []string) []string {
+	fields := []string{"spam_documents", "ham_documents", "spam_total", "ham_total", "vocabulary"}
+	for _, token := range tokens {
+		fields = append(fields, "spam:"+token, "ham:"+token)
+	}
+
+	return fields
+}
+
+// readSpamModel model of the hash fields read for the given tokens
+func readSpamModel(values []interface{}, tokens []string) *utils.SpamModel {
+	count := func(v interface{}) int64 {
+		s, _ := v.(string)
+		n, _ := strconv.ParseInt(s, 10, 64)
+		return n
+	}
+
+	m := utils.NewSpamModel()
+	m.SpamDocuments = count(values[0])
+	m.HamDocuments = count(values[1])
+	m.SpamTotal = count(values[2])
+	m.HamTotal = count(values[3])
+	m.Vocabulary = count(values[4])
+	for i, token := range tokens {
+		if n := count(values[5+2*i]); n > 0 {
+			m.SpamTokens[token] = n
+		}
+		if n := count(values[6+2*i]); n > 0 {
+			m.HamTokens[token] = n
+		}
+	}
+
+	return m
+}
+
+// GetSpamModel naive Bayes spam model learned from moderator decisions with
+// the counts of the given tokens. The model is loaded from the database into
+// redis when it is not cached, the cache expires daily so it converges to
+// the database.
+func GetSpamModel(app *cmn.App, tokens []string) *utils.SpamModel {
+	values, err := app.Cache.HMGet(SpamModelKey(), spamModelFields(tokens)...).Result()
+	if err == nil && values[0] != nil {
+		return readSpamModel(values, tokens)
+	}
+
+	m := utils.NewSpamModel()
+	var spamDocument model.SpamDocument
+	var spamToken model.SpamToken
+	var documents []struct {
+		Label database.SpamLabel `db:"label"`
+		Count int64              `db:"count"`
+	}
+	app.Database.DB.Select(&documents, fmt.Sprintf(`
+		SELECT d.label, count(d.id) as count FROM %s AS d GROUP BY d.label
+	`, spamDocument.TableName()))
+	for _, d := range documents {
+		if d.Label == database.SpamContent {
+			m.SpamDocuments = d.Count
+		} else {
+			m.HamDocuments = d.Count
+		}
+	}
+
+	var rows []model.SpamToken
+	app.Database.DB.Select(&rows, fmt.Sprintf(`
+		SELECT t.* FROM %s AS t WHERE t.spam > 0 OR t.ham > 0
+	`, spamToken.TableName()))
+	fields := make(map[string]interface{})
+	for _, t := range rows {
+		if t.Spam > 0 {
+			m.SpamTokens[t.Token] = t.Spam
+			m.SpamTotal += t.Spam
+			fields["spam:"+t.Token] = t.Spam
+		}
+		if t.Ham > 0 {
+			m.HamTokens[t.Token] = t.Ham
+			m.HamTotal += t.Ham
+			fields["ham:"+t.Token] = t.Ham
+		}
+	}
+	m.Vocabulary = int64(len(rows))
+	fields["spam_documents"] = m.SpamDocuments
+	fields["ham_documents"] = m.HamDocuments
+	fields["spam_total"] = m.SpamTotal
+	fields["ham_total"] = m.HamTotal
+	fields["vocabulary"] = m.Vocabulary
+
+	pipe := app.Cache.TxPipeline()
+	pipe.Del(SpamModelKey())
+	pipe.HMSet(SpamModelKey(), fields)
+	pipe.Expire(SpamModelKey(), 24*time.Hour)
+	pipe.Exec()
+
+	return m
+}
+
+// learnSpam apply a training to the cached model, the document previously
+// learned with the other label is forgotten first. The counts are updated
+// optimistically and the model is loaded again when they keep conflicting.
+func learnSpam(app *cmn.App, previous *model.SpamDocument, tokens []string, spam bool) error {
+	key := SpamModelKey()
+	all := tokens
+	if previous != nil {
+		all = append(append([]string{}, previous.Tokens...), tokens...)
+	}
+	fields := spamModelFields(all)
+
+	for i := 0; i < 3; i++ {
+		err := app.Cache.Watch(func(tx *redis.Tx) error {
+			values, err := tx.HMGet(key, fields...).Result()
+			if err != nil || values[0] == nil {
+				// a model which is not cached is loaded with the training
+				return err
+			}
+
+			m := readSpamModel(values, all)
+			if previous != nil {
+				m.Learn(previous.Tokens, previous.Label == database.SpamContent, -1)
+			}
+			m.Learn(tokens, spam, 1)
+
+			_, err = tx.Pipelined(func(pipe redis.Pipeliner) error {
+				pipe.HMSet(key, map[string]interface{}{
+					"spam_documents": m.SpamDocuments,
+					"ham_documents":  m.HamDocuments,
+					"spam_total":     m.SpamTotal,
+					"ham_total":      m.HamTotal,
+					"vocabulary":     m.Vocabulary,
+				})
+				for _, token := range all {
+					for label, counts := range map[string]map[string]int64{"spam": m.SpamTokens, "ham": m.HamTokens} {
+						if n := counts[token]; n > 0 {
+							pipe.HSet(key, label+":"+token, n)
+						} else {
+							pipe.HDel(key, label+":"+token)
+						}
+					}
+				}
+				return nil
+			})
+			return err
+		}, key)
+		if err != redis.TxFailedErr {
+			return err
+		}
+	}
+
+	return app.Cache.Del(key).Err()
+}
+
+// ScoreSpam probability of the given text being spam
+func ScoreSpam(app *cmn.App, text string) float64 {
+	tokens := utils.SpamTokens(text)
+	return GetSpamModel(app, tokens).Score(tokens)
+}
+
+// HoldSpam flag a new post or comment scored as spam and hide it in the
+// transaction which saves it, so held content is never public. Held posts
+// are inserted unpublished and held comments are uncounted by the caller
+// when they were shown. Moderators release held content by disagreeing with
+// the flag.
+func HoldSpam(tx *database.Tx, authorID, postID int64, commentID zero.Int, score float64) (bool, error) {
+	if err := tx.DB.Error; err != nil {
+		return false, err
+	}
+
+	flag := new(model.Flag)
+	flag.TargetUserID = authorID
+	flag.PostID.SetValid(postID)
+	flag.CommentID = commentID
+	flag.Reason = database.Spam
+	flag.Note.SetValid(fmt.Sprintf("spam score %.2f", score))
+	flag.Trusted = true
+	flag.Status = database.Pending
+	if err := tx.DB.Insert(new(model.Flag), flag, "id", "inserted_at"); err != nil {
+		return false, err
+	}
+
+	var post model.Post
+	var postComment model.PostComment
+	table := post.TableName()
+	id := postID
+	if commentID.Valid {
+		table = postComment.TableName()
+		id = commentID.Int64
+	}
+
+	result, err := tx.DB.Tx.Exec(fmt.Sprintf(`
+		UPDATE %s SET deleted_at = (CURRENT_TIMESTAMP at time zone 'utc'), delete_reason = $2
+		WHERE id = $1 AND deleted_at IS NULL
+	`, table),
+		id,
+		FlagHideReason)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+
+	return n > 0, err
+}
+
+// releaseHeld publish a post or a comment held by the classifier once it is
+// restored. Held posts are published as due scheduled posts, held comments
+// are announced as replies, or as edits when an earlier text was shown.
+func releaseHeld(app *cmn.App, flag *model.Flag) error {
+	if !flag.CommentID.Valid {
+		var post model.Post
+		var due bool
+		app.Database.DB.Get(&due, fmt.Sprintf(`
+			SELECT p.status = $2 AND p.publish_at <= (CURRENT_TIMESTAMP at time zone 'utc')
+			FROM %s AS p WHERE p.id = $1
+		`, post.TableName()),
+			flag.PostID.Int64,
+			database.Scheduled)
+		if !due {
+			return nil
+		}
+		_, err := PublishPost(app, flag.PostID.Int64)
+		return err
+	}
+
+	var postComment model.PostComment
+	var postCommentDetail model.PostCommentDetail
+	var details []model.PostCommentDetail
+	err := app.Database.DB.Select(&details, fmt.Sprintf(`
+		SELECT d.* FROM %s AS d WHERE d.comment_id = $1 ORDER BY d.id DESC
+	`, postCommentDetail.TableName()),
+		flag.CommentID.Int64)
+	if err != nil || len(details) == 0 {
+		return err
+	}
+
+	var authorID int64
+	app.Database.DB.Get(&authorID, fmt.Sprintf(`SELECT c.user_id FROM %s AS c WHERE c.id = $1`,
+		postComment.TableName()),
+		flag.CommentID.Int64)
+	_, err = SyncMentions(app, flag.PostID.Int64, flag.CommentID, authorID, details[0].SourceUserID.Int64,
+		details[0].Comment)
+	if err != nil {
+		return err
+	}
+
+	if len(details) == 1 {
+		return PublishReply(app, flag.PostID.Int64, flag.CommentID.Int64)
+	}
+
+	return PublishEvent(app, model2.PostTopic(flag.PostID.Int64), "comment_edited", details[0])
+}
+
+// TrainSpam label the post or the comment of a flag as spam or ham and
+// learn its tokens. Relabeled content is forgotten with its previous label
+// first, content labeled the same again is learned once.
+func TrainSpam(app *cmn.App, flag *model.Flag, spam bool, moderatorID int64) error {
+	label := database.HamContent
+	if spam {
+		label = database.SpamContent
+	}
+	tokens := utils.SpamTokens(spamText(app, flag))
+
+	var spamDocument model.SpamDocument
+	var spamToken model.SpamToken
+
+	var err error
+	var learned bool
+	var previous *model.SpamDocument
+	db := app.Database.Transaction(func(tx *database.Tx) error {
+		if err = tx.DB.Error; err != nil {
+			return err
+		}
+
+		document := new(model.SpamDocument)
+		err = tx.DB.Tx.Get(document, fmt.Sprintf(`
+			SELECT d.* FROM %s AS d WHERE d.post_id = $1 AND d.comment_id IS NOT DISTINCT FROM $2 FOR UPDATE
+		`, spamDocument.TableName()),
+			flag.PostID,
+			flag.CommentID)
+		if err == sql.ErrNoRows {
+			err = nil
+		} else if err != nil {
+			return err
+		} else if document.Label == label {
+			return nil
+		} else {
+			previous = document
+			_, err = tx.DB.Tx.Exec(fmt.Sprintf(`
+				UPDATE %s SET %s = %s - 1 WHERE token = ANY($1)
+			`, spamToken.TableName(), previous.Label, previous.Label),
+				previous.Tokens)
+			if err != nil {
+				return err
+			}
+		}
+
+		_, err = tx.DB.Tx.Exec(fmt.Sprintf(`
+			INSERT INTO %s (post_id, comment_id, label, tokens, source_user_id) VALUES ($1, $2, $3, $4, $5)
+			ON CONFLICT (post_id, COALESCE(comment_id, 0)) DO UPDATE SET
+				label = EXCLUDED.label, tokens = EXCLUDED.tokens, source_user_id = EXCLUDED.source_user_id,
+				updated_at = (CURRENT_TIMESTAMP at time zone 'utc')
+		`, spamDocument.TableName()),
+			flag.PostID,
+			flag.CommentID,
+			label,
+			pq.Array(tokens),
+			moderatorID)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.DB.Tx.Exec(fmt.Sprintf(`
+			INSERT INTO %s AS t (token, %s) SELECT u.token, 1 FROM unnest($1::text[]) AS u(token)
+			ON CONFLICT (token) DO UPDATE SET %s = t.%s + 1
+		`, spamToken.TableName(), label, label, label),
+			pq.Array(tokens))
+		if err != nil {
+			return err
+		}
+
+		learned = true
+		return nil
+	})
+	if err == nil {
+		err = db.Error
+	}
+	if err != nil || !learned {
+		return err
+	}
+
+	return learnSpam(app, previous, tokens, spam)
+}
+
+// spamText title, description and content of the latest detail of the post
+// of a flag, or the latest detail of its comment
+func spamText(app *cmn.App, flag *model.Flag) string {
+	var parts []string
+	if flag.CommentID.Valid {
+		var postCommentDetail model.PostCommentDetail
+		app.Database.DB.Select(&parts, fmt.Sprintf(`
+			SELECT cd.comment FROM %s AS cd WHERE cd.comment_id = $1 ORDER BY cd.id DESC LIMIT 1
+		`, postCommentDetail.TableName()),
+			flag.CommentID.Int64)
+	} else {
+		var postDetail model.PostDetail
+		app.Database.DB.Select(&parts, fmt.Sprintf(`
+			SELECT unnest(ARRAY[pd.title, COALESCE(pd.description, ''), pd.content]) FROM (
+				SELECT * FROM %s AS d WHERE d.post_id = $1 ORDER BY d.id DESC LIMIT 1
+			) AS pd
+		`, postDetail.TableName()),
+			flag.PostID.Int64)
+	}
+
+	return strings.Join(parts, "\n")
+}
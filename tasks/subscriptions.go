@@ -0,0 +1,332 @@
+// Copyright 2019 StreetByters Community
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with
+// this work for additional information regarding copyright ownership.
+// The ASF licenses this file to You under the Apache License, Version 2.0
+// (the "License"); you may not use this file except in compliance with
+// the License.  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tasks
+
+import (
+	"fmt"
+	"forgolang_forum/cmn"
+	"forgolang_forum/database"
+	"forgolang_forum/database/model"
+	model2 "forgolang_forum/model"
+	"forgolang_forum/utils"
+	"github.com/lib/pq"
+	"gopkg.in/guregu/null.v3/zero"
+	"strconv"
+	"time"
+)
+
+// DigestIntervals periods of subscription digests
+var DigestIntervals = map[database.DigestFrequency]time.Duration{
+	database.Daily:  24 * time.Hour,
+	database.Weekly: 7 * 24 * time.Hour,
+}
+
+// watcher effective subscription of a user on a post
+type watcher struct {
+	SubscriptionID int64  `db:"subscription_id"`
+	UserID         int64  `db:"user_id"`
+	Username       string `db:"username"`
+	Email          string `db:"email"`
+}
+
+// digestThread new replies of a thread in a digest
+type digestThread struct {
+	Title   string
+	URL     string
+	Replies int64
+}
+
+// digestSection threads of a subscription in a digest
+type digestSection struct {
+	Title          string
+	UnsubscribeURL string
+	Threads        []digestThread
+}
+
+// effectiveSubscriptions query of the subscription of each user that
+// applies to the post given as the first parameter, post subscriptions
+// override category subscriptions
+func effectiveSubscriptions() string {
+	var subscription model.Subscription
+	var postCategoryAssignment model.PostCategoryAssignment
+
+	return fmt.Sprintf(`
+		SELECT DISTINCT ON (s.user_id) s.* FROM %s AS s
+		WHERE s.post_id = $1 OR s.category_id IN (
+			SELECT pca.category_id FROM %s AS pca WHERE pca.post_id = $1
+		)
+		ORDER BY s.user_id, s.post_id IS NOT NULL DESC, s.id ASC
+	`, subscription.TableName(), postCategoryAssignment.TableName())
+}
+
+// GetWatchLevel watch level of a user on a post, users without a
+// subscription are on the normal level
+func GetWatchLevel(app *cmn.App, userID, postID int64) database.WatchLevel {
+	level := database.Normal
+	app.Database.DB.Get(&level, fmt.Sprintf(`
+		SELECT es.level FROM (%s) AS es WHERE es.user_id = $2
+	`, effectiveSubscriptions()),
+		postID,
+		userID)
+
+	return level
+}
+
+// PublishReply announce a new comment once its text is saved and it is not
+// held for review. The author of the parent comment, or of the post for top
+// level comments, is notified, watchers of the post are emailed and
+// subscribers of the thread get the comment event.
+func PublishReply(app *cmn.App, postID, commentID int64) error {
+	var comment model.PostComment
+	err := app.Database.DB.Get(&comment, fmt.Sprintf(`
+		%s AND c.id = $2
+	`, comment.Query()),
+		postID,
+		commentID)
+	if err != nil {
+		return err
+	}
+
+	var post model.Post
+	var userID int64
+	if comment.ParentID.Valid {
+		app.Database.DB.Get(&userID, fmt.Sprintf(`
+			SELECT c.user_id FROM %s AS c WHERE c.id = $1
+		`, comment.TableName()),
+			comment.ParentID)
+	} else {
+		app.Database.DB.Get(&userID, fmt.Sprintf(`
+			SELECT p.author_id FROM %s AS p WHERE p.id = $1
+		`, post.TableName()),
+			comment.PostID)
+	}
+	if userID != 0 {
+		notification := model.NewNotification(userID, database.ReplyNotification, comment.PostID,
+			comment.ParentID)
+		notification.ActorID.SetValid(comment.UserID)
+		Notify(app, notification)
+	}
+
+	go SendReplyEmails(app, &comment)
+
+	return PublishEvent(app, model2.PostTopic(comment.PostID), "comment", comment)
+}
+
+// SendReplyEmails email a new reply to users watching its post immediately
+func SendReplyEmails(app *cmn.App, comment *model.PostComment) error {
+	var user model.User
+	var watchers []watcher
+	err := app.Database.DB.Select(&watchers, fmt.Sprintf(`
+		SELECT es.id as subscription_id, u.id as user_id, u.username, u.email FROM (%s) AS es
+		INNER JOIN %s AS u ON es.user_id = u.id
+		WHERE es.level = $2 AND es.frequency = $3 AND es.user_id != $4 AND u.is_active
+	`, effectiveSubscriptions(), user.TableName()),
+		comment.PostID,
+		database.Watching,
+		database.Immediately,
+		comment.UserID)
+	if err != nil || len(watchers) == 0 {
+		return err
+	}
+
+	var author string
+	app.Database.DB.Get(&author, fmt.Sprintf(`
+		SELECT u.username FROM %s AS u WHERE u.id = $1
+	`, user.TableName()),
+		comment.UserID)
+	title := postTitle(app, comment.PostID)
+
+	for _, w := range watchers {
+		err := app.Queue.Email.Publish(cmn.QueueEmailBody{
+			Recipients: []string{w.Email},
+			Subject:    fmt.Sprintf("Forgolang.com | New reply in %s", title),
+			Type:       "reply",
+			Template:   "reply",
+			Params: struct {
+				Username       string
+				Author         string
+				Title          string
+				URL            string
+				UnsubscribeURL string
+			}{
+				Username:       w.Username,
+				Author:         author,
+				Title:          title,
+				URL:            fmt.Sprintf("%s/post/%d#comment-%d", app.Config.UIHost, comment.PostID, comment.ID),
+				UnsubscribeURL: UnsubscribeURL(app, w.SubscriptionID),
+			},
+		}.ToJSON())
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SendSubscriptionDigests email new replies of watched posts and categories
+// as daily and weekly digests, one email for each user
+func SendSubscriptionDigests(app *cmn.App, args interface{}) error {
+	var subscription model.Subscription
+	var user model.User
+	var category model.Category
+	var comment model.PostComment
+	var post model.Post
+	var postCategoryAssignment model.PostCategoryAssignment
+
+	for frequency, interval := range DigestIntervals {
+		var subscriptions []struct {
+			ID            int64       `db:"id"`
+			UserID        int64       `db:"user_id"`
+			PostID        zero.Int    `db:"post_id"`
+			CategoryID    zero.Int    `db:"category_id"`
+			Since         time.Time   `db:"since"`
+			Username      string      `db:"username"`
+			Email         string      `db:"email"`
+			CategoryTitle zero.String `db:"category_title"`
+		}
+		err := app.Database.DB.Select(&subscriptions, fmt.Sprintf(`
+			SELECT
+				s.id, s.user_id, s.post_id, s.category_id, COALESCE(s.last_sent_at, s.inserted_at) as since,
+				u.username, u.email, c.title as category_title
+			FROM %s AS s
+			INNER JOIN %s AS u ON s.user_id = u.id
+			LEFT OUTER JOIN %s AS c ON s.category_id = c.id
+			WHERE s.level = $1 AND s.frequency = $2 AND u.is_active AND
+				COALESCE(s.last_sent_at, s.inserted_at) <= (CURRENT_TIMESTAMP at time zone 'utc') - $3::interval
+			ORDER BY s.user_id, s.id
+		`, subscription.TableName(), user.TableName(), category.TableName()),
+			database.Watching,
+			frequency,
+			fmt.Sprintf("%d seconds", int64(interval.Seconds())))
+		if err != nil {
+			return err
+		}
+
+		digests := make(map[int64][]digestSection)
+		var ids []int64
+		for _, s := range subscriptions {
+			ids = append(ids, s.ID)
+
+			// posts of a watched category are left to their own
+			// subscription when the user has one
+			var threads []struct {
+				PostID  int64 `db:"post_id"`
+				Replies int64 `db:"replies"`
+			}
+			err := app.Database.DB.Select(&threads, fmt.Sprintf(`
+				SELECT c.post_id, count(c.id) as replies FROM %s AS c
+				INNER JOIN %s AS p ON c.post_id = p.id
+				WHERE c.inserted_at > $1 AND c.user_id != $2 AND c.deleted_at IS NULL AND
+					p.status = $3 AND p.deleted_at IS NULL AND (
+						c.post_id = $4 OR (
+							c.post_id IN (SELECT pca.post_id FROM %s AS pca WHERE pca.category_id = $5) AND
+							NOT EXISTS (SELECT 1 FROM %s AS s WHERE s.user_id = $2 AND s.post_id = c.post_id)
+						)
+					)
+				GROUP BY c.post_id
+				ORDER BY c.post_id
+			`, comment.TableName(), post.TableName(), postCategoryAssignment.TableName(),
+				subscription.TableName()),
+				s.Since,
+				s.UserID,
+				database.Published,
+				s.PostID,
+				s.CategoryID)
+			if err != nil {
+				return err
+			}
+			if len(threads) == 0 {
+				continue
+			}
+
+			section := digestSection{
+				Title:          s.CategoryTitle.String,
+				UnsubscribeURL: UnsubscribeURL(app, s.ID),
+			}
+			for _, t := range threads {
+				section.Threads = append(section.Threads, digestThread{
+					Title:   postTitle(app, t.PostID),
+					URL:     fmt.Sprintf("%s/post/%d", app.Config.UIHost, t.PostID),
+					Replies: t.Replies,
+				})
+			}
+			if s.PostID.Valid {
+				section.Title = section.Threads[0].Title
+			}
+			digests[s.UserID] = append(digests[s.UserID], section)
+		}
+
+		for _, s := range subscriptions {
+			sections, ok := digests[s.UserID]
+			if !ok {
+				continue
+			}
+			delete(digests, s.UserID)
+
+			err := app.Queue.Email.Publish(cmn.QueueEmailBody{
+				Recipients: []string{s.Email},
+				Subject:    fmt.Sprintf("Forgolang.com | Your %s digest", frequency),
+				Type:       "digest",
+				Template:   "digest",
+				Params: struct {
+					Username  string
+					Frequency string
+					Sections  []digestSection
+				}{
+					Username:  s.Username,
+					Frequency: string(frequency),
+					Sections:  sections,
+				},
+			}.ToJSON())
+			if err != nil {
+				return err
+			}
+		}
+
+		if len(ids) > 0 {
+			_, err = app.Database.DB.Exec(fmt.Sprintf(`
+				UPDATE %s SET last_sent_at = (CURRENT_TIMESTAMP at time zone 'utc') WHERE id = ANY($1)
+			`, subscription.TableName()),
+				pq.Array(ids))
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// UnsubscribeURL signed one-click unsubscribe link of a subscription
+func UnsubscribeURL(app *cmn.App, subscriptionID int64) string {
+	id := strconv.FormatInt(subscriptionID, 10)
+	return fmt.Sprintf("%s/subscription/%s/unsubscribe?signature=%s", app.Config.UIHost, id,
+		utils.Sign(app.Config.SecretKey, "subscription", id))
+}
+
+// postTitle latest title of a post
+func postTitle(app *cmn.App, postID int64) string {
+	var postDetail model.PostDetail
+	var title string
+	app.Database.DB.Get(&title, fmt.Sprintf(`
+		SELECT pd.title FROM %s AS pd WHERE pd.post_id = $1 ORDER BY pd.id DESC LIMIT 1
+	`, postDetail.TableName()),
+		postID)
+
+	return title
+}
@@ -0,0 +1,229 @@
+// Copyright 2019 StreetByters Community
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with
+// this work for additional information regarding copyright ownership.
+// The ASF licenses this file to You under the Apache License, Version 2.0
+// (the "License"); you may not use this file except in compliance with
+// the License.  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tasks
+
+import (
+	"fmt"
+	"forgolang_forum/cmn"
+	"forgolang_forum/database"
+	"forgolang_forum/database/model"
+	model2 "forgolang_forum/model"
+	"time"
+)
+
+// TrustRequirement activity a user needs for an automatic trust level
+type TrustRequirement struct {
+	Level            database.TrustLevel
+	AccountAge       time.Duration
+	DaysVisited      int64
+	PostsRead        int64
+	LikesReceived    int64
+	MaxFlagsReceived int64
+}
+
+// TrustRequirements automatic trust levels in ascending order, the leader
+// level is given only by moderators
+var TrustRequirements = []TrustRequirement{
+	{Level: database.Basic, AccountAge: 24 * time.Hour, DaysVisited: 2, PostsRead: 10, MaxFlagsReceived: 2},
+	{Level: database.Member, AccountAge: 15 * 24 * time.Hour, DaysVisited: 15, PostsRead: 100,
+		LikesReceived: 1, MaxFlagsReceived: 2},
+	{Level: database.Regular, AccountAge: 50 * 24 * time.Hour, DaysVisited: 50, PostsRead: 500,
+		LikesReceived: 20},
+}
+
+// TrustLimit actions of a trust level, zero links and posts mean no limit
+type TrustLimit struct {
+	MaxLinks    int   `json:"max_links"`
+	PostsPerDay int64 `json:"posts_per_day"`
+	Upload      bool  `json:"upload"`
+	CreateTag   bool  `json:"create_tag"`
+}
+
+// TrustLimits limits of each trust level
+var TrustLimits = map[database.TrustLevel]TrustLimit{
+	database.NewUser: {MaxLinks: 2, PostsPerDay: 3},
+	database.Basic:   {MaxLinks: 10, PostsPerDay: 10, Upload: true},
+	database.Member:  {PostsPerDay: 30, Upload: true},
+	database.Regular: {Upload: true, CreateTag: true},
+	database.Leader:  {Upload: true, CreateTag: true},
+}
+
+// TrustStats activity of a user trust levels are computed from. Removals
+// of content by moderators are counted as flags received.
+type TrustStats struct {
+	UserID        int64               `db:"user_id" json:"user_id"`
+	InsertedAt    time.Time           `db:"inserted_at" json:"inserted_at"`
+	DaysVisited   int64               `db:"days_visited" json:"days_visited"`
+	PostsRead     int64               `db:"posts_read" json:"posts_read"`
+	LikesReceived int64               `db:"likes_received" json:"likes_received"`
+	FlagsReceived int64               `db:"flags_received" json:"flags_received"`
+	Level         database.TrustLevel `db:"level" json:"level"`
+	Locked        bool                `db:"locked" json:"locked"`
+	Limit         TrustLimit          `db:"-" json:"limit"`
+}
+
+// ComputeLevel highest automatic trust level the activity meets
+func (s TrustStats) ComputeLevel(now time.Time) database.TrustLevel {
+	level := database.NewUser
+	for _, r := range TrustRequirements {
+		if now.Sub(s.InsertedAt) < r.AccountAge || s.DaysVisited < r.DaysVisited ||
+			s.PostsRead < r.PostsRead || s.LikesReceived < r.LikesReceived ||
+			s.FlagsReceived > r.MaxFlagsReceived {
+			break
+		}
+		level = r.Level
+	}
+
+	return level
+}
+
+// TrustKey redis key of the cached trust level of a user
+func TrustKey(userID int64) string {
+	return fmt.Sprintf("%s:%d", cmn.GetRedisKey("user", "trust"), userID)
+}
+
+// GetTrustLevel current trust level of a user, users without a computed
+// level are new users
+func GetTrustLevel(app *cmn.App, userID int64) database.TrustLevel {
+	if level, err := app.Cache.Get(TrustKey(userID)).Int64(); err == nil {
+		return database.TrustLevel(level)
+	}
+
+	var userTrustLevel model.UserTrustLevel
+	var level database.TrustLevel
+	app.Database.DB.Get(&level, fmt.Sprintf(`
+		SELECT COALESCE((
+			SELECT utl.level FROM %s AS utl WHERE utl.user_id = $1 ORDER BY utl.id DESC LIMIT 1
+		), 0)
+	`, userTrustLevel.TableName()),
+		userID)
+	app.Cache.Set(TrustKey(userID), int64(level), time.Hour)
+
+	return level
+}
+
+// SetTrustLevel append a trust level of a user
+func SetTrustLevel(app *cmn.App, userTrustLevel *model.UserTrustLevel) error {
+	if err := app.Database.Insert(new(model.UserTrustLevel), userTrustLevel, "id", "inserted_at"); err != nil {
+		return err
+	}
+	app.Cache.Del(TrustKey(userTrustLevel.UserID))
+	clearUserCache(app, userTrustLevel.UserID)
+
+	return nil
+}
+
+// RecordVisit count the day of a request of a user as a visited day, days
+// are written to the database once
+func RecordVisit(app *cmn.App, userID int64) {
+	now := time.Now().UTC()
+	key := fmt.Sprintf("%s:%d:%s", cmn.GetRedisKey("user", "visit"), userID, now.Format("2006-01-02"))
+	if ok, err := app.Cache.SetNX(key, 1, 24*time.Hour).Result(); err != nil || !ok {
+		return
+	}
+
+	var userVisit model.UserVisit
+	app.Database.DB.Exec(fmt.Sprintf(`
+		INSERT INTO %s (user_id, visited_on) VALUES ($1, $2)
+		ON CONFLICT (user_id, visited_on) DO NOTHING
+	`, userVisit.TableName()),
+		userID,
+		now.Format("2006-01-02"))
+}
+
+// RecordRead count a post as read by a user
+func RecordRead(app *cmn.App, userID, postID int64) {
+	var postRead model.PostRead
+	app.Database.DB.Exec(fmt.Sprintf(`
+		INSERT INTO %s (user_id, post_id) VALUES ($1, $2)
+		ON CONFLICT (user_id, post_id) DO NOTHING
+	`, postRead.TableName()),
+		userID,
+		postID)
+}
+
+// trustStatsQuery activity and current trust level of users, callers
+// filter users with a where clause
+func trustStatsQuery() string {
+	var user model.User
+	var userVisit model.UserVisit
+	var postRead model.PostRead
+	var post model.Post
+	var comment model.PostComment
+	var votesUp model.PostVotesUp
+	var commentVotesUp model.PostCommentVotesUp
+	var userTrustLevel model.UserTrustLevel
+
+	return fmt.Sprintf(`
+		SELECT
+			u.id as user_id, u.inserted_at as inserted_at,
+			(SELECT count(uv.id) FROM %s AS uv WHERE uv.user_id = u.id) as days_visited,
+			(SELECT count(pr.id) FROM %s AS pr WHERE pr.user_id = u.id) as posts_read,
+			(SELECT count(v.id) FROM %s AS v INNER JOIN %s AS p ON v.post_id = p.id
+				WHERE p.author_id = u.id AND v.user_id != u.id) +
+			(SELECT count(v.id) FROM %s AS v INNER JOIN %s AS c ON v.comment_id = c.id
+				WHERE c.user_id = u.id AND v.user_id != u.id) as likes_received,
+			(SELECT count(p.id) FROM %s AS p WHERE p.author_id = u.id AND p.deleted_by_id != u.id) +
+			(SELECT count(c.id) FROM %s AS c WHERE c.user_id = u.id AND c.deleted_by_id != u.id) as flags_received,
+			COALESCE(utl.level, 0) as level,
+			COALESCE(utl.locked, false) as locked
+		FROM %s AS u
+		LEFT JOIN LATERAL (
+			SELECT l.* FROM %s AS l WHERE l.user_id = u.id ORDER BY l.id DESC LIMIT 1
+		) AS utl ON true
+	`, userVisit.TableName(), postRead.TableName(), votesUp.TableName(), post.TableName(),
+		commentVotesUp.TableName(), comment.TableName(), post.TableName(), comment.TableName(),
+		user.TableName(), userTrustLevel.TableName())
+}
+
+// GetTrustStats activity and current trust level of a user
+func GetTrustStats(app *cmn.App, userID int64) (*TrustStats, error) {
+	var stats TrustStats
+	err := app.Database.DB.Get(&stats, fmt.Sprintf("%s WHERE u.id = $1", trustStatsQuery()), userID)
+
+	return &stats, err
+}
+
+// RefreshTrustLevels compute trust levels of users from their activity.
+// Users move up and down between automatic levels, levels locked by
+// moderators are kept.
+func RefreshTrustLevels(app *cmn.App, args interface{}) error {
+	var stats []TrustStats
+	if err := app.Database.DB.Select(&stats, trustStatsQuery()); err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	changed := 0
+	for _, s := range stats {
+		level := s.ComputeLevel(now)
+		if s.Locked || s.Level == level {
+			continue
+		}
+
+		if err := SetTrustLevel(app, model.NewUserTrustLevel(s.UserID, level)); err != nil {
+			return err
+		}
+		changed++
+	}
+
+	if app.Mode != model2.Test {
+		app.Logger.LogInfo(fmt.Sprintf("Changed %d trust levels", changed))
+	}
+
+	return nil
+}
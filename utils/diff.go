@@ -0,0 +1,101 @@
+// Copyright 2019 StreetByters Community
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with
+// this work for additional information regarding copyright ownership.
+// The ASF licenses this file to You under the Apache License, Version 2.0
+// (the "License"); you may not use this file except in compliance with
+// the License.  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import "strings"
+
+// DiffOp line diff operation
+type DiffOp string
+
+const (
+	// DiffEqual line exists on both versions
+	DiffEqual DiffOp = "equal"
+	// DiffInsert line added on the new version
+	DiffInsert DiffOp = "insert"
+	// DiffDelete line removed from the old version
+	DiffDelete DiffOp = "delete"
+)
+
+// DiffLine line of a diff between two texts
+type DiffLine struct {
+	Op   DiffOp `json:"op"`
+	Text string `json:"text"`
+}
+
+// Diff line based diff of two texts from the longest common subsequence of
+// their lines. Common leading and trailing lines are matched before the
+// table is built, so small edits on long texts stay cheap.
+func Diff(a, b string) []DiffLine {
+	oldLines := strings.Split(a, "\n")
+	newLines := strings.Split(b, "\n")
+
+	var prefix []DiffLine
+	for len(oldLines) > 0 && len(newLines) > 0 && oldLines[0] == newLines[0] {
+		prefix = append(prefix, DiffLine{Op: DiffEqual, Text: oldLines[0]})
+		oldLines = oldLines[1:]
+		newLines = newLines[1:]
+	}
+
+	var suffix []DiffLine
+	for len(oldLines) > 0 && len(newLines) > 0 &&
+		oldLines[len(oldLines)-1] == newLines[len(newLines)-1] {
+		suffix = append([]DiffLine{{Op: DiffEqual, Text: oldLines[len(oldLines)-1]}}, suffix...)
+		oldLines = oldLines[:len(oldLines)-1]
+		newLines = newLines[:len(newLines)-1]
+	}
+
+	n, m := len(oldLines), len(newLines)
+	lcs := make([][]int32, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int32, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if oldLines[i] == newLines[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	lines := prefix
+	i, j := 0, 0
+	for i < n && j < m {
+		if oldLines[i] == newLines[j] {
+			lines = append(lines, DiffLine{Op: DiffEqual, Text: oldLines[i]})
+			i++
+			j++
+		} else if lcs[i+1][j] >= lcs[i][j+1] {
+			lines = append(lines, DiffLine{Op: DiffDelete, Text: oldLines[i]})
+			i++
+		} else {
+			lines = append(lines, DiffLine{Op: DiffInsert, Text: newLines[j]})
+			j++
+		}
+	}
+	for ; i < n; i++ {
+		lines = append(lines, DiffLine{Op: DiffDelete, Text: oldLines[i]})
+	}
+	for ; j < m; j++ {
+		lines = append(lines, DiffLine{Op: DiffInsert, Text: newLines[j]})
+	}
+
+	return append(lines, suffix...)
+}
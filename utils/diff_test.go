@@ -0,0 +1,27 @@
+package utils
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	assert.Equal(t, []DiffLine{
+		{Op: DiffEqual, Text: "a"},
+		{Op: DiffEqual, Text: "b"},
+	}, Diff("a\nb", "a\nb"))
+
+	assert.Equal(t, []DiffLine{
+		{Op: DiffEqual, Text: "first"},
+		{Op: DiffDelete, Text: "second"},
+		{Op: DiffInsert, Text: "changed"},
+		{Op: DiffInsert, Text: "added"},
+		{Op: DiffEqual, Text: "third"},
+	}, Diff("first\nsecond\nthird", "first\nchanged\nadded\nthird"))
+
+	assert.Equal(t, []DiffLine{
+		{Op: DiffDelete, Text: "x"},
+		{Op: DiffEqual, Text: "y"},
+		{Op: DiffInsert, Text: "z"},
+	}, Diff("x\ny", "y\nz"))
+}
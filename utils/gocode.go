@@ -0,0 +1,191 @@
+// Copyright 2019 StreetByters Community
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with
+// this work for additional information regarding copyright ownership.
+// The ASF licenses this file to You under the Apache License, Version 2.0
+// (the "License"); you may not use this file except in compliance with
+// the License.  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"go/format"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"strings"
+)
+
+// GoCodeWarning go code block syntax error
+type GoCodeWarning struct {
+	Block   int    `json:"block"`
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+	Message string `json:"message"`
+}
+
+// goSnippet wrapping strategies for partial go sources
+type goSnippet int
+
+const (
+	goFile goSnippet = iota
+	goDecls
+	goStmts
+)
+
+var goSnippetHeaders = map[goSnippet]string{
+	goFile:  "",
+	goDecls: "package main\n",
+	goStmts: "package main\nfunc _() {\n",
+}
+
+var goSnippetFooters = map[goSnippet]string{
+	goFile:  "",
+	goDecls: "",
+	goStmts: "\n}\n",
+}
+
+// CheckGoCode parse ```go fenced blocks in the given markdown content and
+// report syntax errors with content line numbers. If format is true, the
+// blocks without errors are rewritten through gofmt.
+func CheckGoCode(content string, format bool) (string, []GoCodeWarning) {
+	var warnings []GoCodeWarning
+
+	lines := strings.Split(content, "\n")
+	result := make([]string, 0, len(lines))
+
+	block := 0
+	for i := 0; i < len(lines); i++ {
+		result = append(result, lines[i])
+		if !isGoFence(lines[i]) {
+			continue
+		}
+
+		end := i + 1
+		for end < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[end]), "```") {
+			end++
+		}
+		if end >= len(lines) {
+			break
+		}
+
+		block++
+		source := strings.Join(lines[i+1:end], "\n")
+		formatted, errs := FormatGoCode(source)
+		for _, e := range errs {
+			e.Block = block
+			e.Line += i + 1
+			warnings = append(warnings, e)
+		}
+
+		if format && len(errs) == 0 {
+			result = append(result, strings.Split(strings.TrimRight(formatted, "\n"), "\n")...)
+		} else {
+			result = append(result, lines[i+1:end]...)
+		}
+		result = append(result, lines[end])
+		i = end
+	}
+
+	return strings.Join(result, "\n"), warnings
+}
+
+// FormatGoCode parse and format a go source snippet. Snippets may be a
+// whole file, top level declarations or plain statements.
+func FormatGoCode(source string) (string, []GoCodeWarning) {
+	var errs scanner.ErrorList
+	for _, s := range []goSnippet{goFile, goDecls, goStmts} {
+		src := goSnippetHeaders[s] + source + goSnippetFooters[s]
+		_, err := parser.ParseFile(token.NewFileSet(), "", src, parser.ParseComments)
+		if err == nil {
+			return formatGoSnippet(s, source), nil
+		}
+
+		list, ok := err.(scanner.ErrorList)
+		if !ok || len(list) == 0 {
+			continue
+		}
+		// Keep the attempt that got furthest before failing
+		if shifted := shiftGoErrors(list, s); errs == nil || shifted[0].Pos.Line > errs[0].Pos.Line {
+			errs = shifted
+		}
+	}
+
+	var warnings []GoCodeWarning
+	sourceLines := strings.Count(source, "\n") + 1
+	for _, e := range errs {
+		line := e.Pos.Line
+		if line < 1 {
+			line = 1
+		} else if line > sourceLines {
+			line = sourceLines
+		}
+		warnings = append(warnings, GoCodeWarning{
+			Line:    line,
+			Column:  e.Pos.Column,
+			Message: e.Msg,
+		})
+	}
+
+	return source, warnings
+}
+
+func formatGoSnippet(s goSnippet, source string) string {
+	src := goSnippetHeaders[s] + source + goSnippetFooters[s]
+	if s == goStmts && strings.Contains(source, "`") {
+		// Raw strings can not be dedented safely
+		return source
+	}
+
+	b, err := format.Source([]byte(src))
+	if err != nil {
+		return source
+	}
+	out := string(b)
+
+	switch s {
+	case goDecls:
+		out = strings.TrimLeft(strings.TrimPrefix(out, "package main\n"), "\n")
+	case goStmts:
+		start := strings.Index(out, "func _() {\n")
+		end := strings.LastIndex(out, "}")
+		if start < 0 || end < start {
+			return source
+		}
+		body := strings.Split(strings.TrimRight(out[start+len("func _() {\n"):end], "\n"), "\n")
+		for i, l := range body {
+			body[i] = strings.TrimPrefix(l, "\t")
+		}
+		out = strings.Join(body, "\n") + "\n"
+	}
+
+	return out
+}
+
+func shiftGoErrors(errs scanner.ErrorList, s goSnippet) scanner.ErrorList {
+	offset := strings.Count(goSnippetHeaders[s], "\n")
+	shifted := make(scanner.ErrorList, 0, len(errs))
+	for _, e := range errs {
+		e2 := *e
+		e2.Pos.Line -= offset
+		shifted = append(shifted, &e2)
+	}
+	return shifted
+}
+
+func isGoFence(line string) bool {
+	l := strings.TrimSpace(line)
+	if !strings.HasPrefix(l, "```") {
+		return false
+	}
+	lang := strings.ToLower(strings.TrimSpace(strings.TrimPrefix(l, "```")))
+	return lang == "go" || lang == "golang"
+}
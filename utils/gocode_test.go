@@ -0,0 +1,44 @@
+package utils
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestFormatGoCodeWithFile(t *testing.T) {
+	source, warnings := FormatGoCode("package main\nfunc main(){\nprintln( \"hi\")\n}")
+	assert.Nil(t, warnings)
+	assert.Equal(t, "package main\n\nfunc main() {\n\tprintln(\"hi\")\n}\n", source)
+}
+
+func TestFormatGoCodeWithDeclarations(t *testing.T) {
+	source, warnings := FormatGoCode("type T struct{A int}")
+	assert.Nil(t, warnings)
+	assert.Equal(t, "type T struct{ A int }\n", source)
+}
+
+func TestFormatGoCodeWithStatements(t *testing.T) {
+	source, warnings := FormatGoCode("x:=1\nif x>0 {\nx++\n}")
+	assert.Nil(t, warnings)
+	assert.Equal(t, "x := 1\nif x > 0 {\n\tx++\n}\n", source)
+}
+
+func TestFormatGoCodeWithSyntaxError(t *testing.T) {
+	source, warnings := FormatGoCode("x := 1\nif x > {\n}")
+	assert.Equal(t, "x := 1\nif x > {\n}", source)
+	assert.NotEmpty(t, warnings)
+	assert.Equal(t, 2, warnings[0].Line)
+}
+
+func TestCheckGoCode(t *testing.T) {
+	content := "Example:\n```go\nx:=1\n```\ntext\n```go\nfunc (\n```\n```\nnot go\n```"
+
+	formatted, warnings := CheckGoCode(content, true)
+	assert.Equal(t, "Example:\n```go\nx := 1\n```\ntext\n```go\nfunc (\n```\n```\nnot go\n```", formatted)
+	assert.Len(t, warnings, 1)
+	assert.Equal(t, 2, warnings[0].Block)
+	assert.Equal(t, 7, warnings[0].Line)
+
+	unformatted, _ := CheckGoCode(content, false)
+	assert.Equal(t, content, unformatted)
+}
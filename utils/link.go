@@ -0,0 +1,35 @@
+// Copyright 2019 StreetByters Community
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with
+// this work for additional information regarding copyright ownership.
+// The ASF licenses this file to You under the Apache License, Version 2.0
+// (the "License"); you may not use this file except in compliance with
+// the License.  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import "regexp"
+
+// linkPattern absolute http and https links, markdown and html links are
+// counted by their urls
+var linkPattern = regexp.MustCompile(`(?i)\bhttps?://[^\s<>"')\]]+`)
+
+// CountLinks number of links in the given text, links in code blocks are
+// not counted
+func CountLinks(text string) int {
+	count := 0
+	forEachText(text, func(s string) string {
+		count += len(linkPattern.FindAllStringIndex(s, -1))
+		return s
+	})
+
+	return count
+}
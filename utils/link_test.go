@@ -0,0 +1,13 @@
+package utils
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestCountLinks(t *testing.T) {
+	assert.Equal(t, 0, CountLinks("no links, just golang.org"))
+	assert.Equal(t, 2, CountLinks("see [docs](https://golang.org/doc) and HTTP://play.golang.org"))
+	assert.Equal(t, 1, CountLinks(`<a href="https://go.dev">go.dev</a>`))
+	assert.Equal(t, 1, CountLinks("https://go.dev\n```\n// https://example.com\n```"))
+}
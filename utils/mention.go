@@ -0,0 +1,89 @@
+// Copyright 2019 StreetByters Community
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with
+// this work for additional information regarding copyright ownership.
+// The ASF licenses this file to You under the Apache License, Version 2.0
+// (the "License"); you may not use this file except in compliance with
+// the License.  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// mentionPattern @username references which are not part of an email
+// address or a path
+var mentionPattern = regexp.MustCompile(`(^|[^\w@/.])@(\w[\w.-]*\w|\w)`)
+
+// codePattern html and markdown code blocks, mentions in code are left as
+// they are
+var codePattern = regexp.MustCompile("(?s)<pre.*?</pre>|<code.*?</code>|```.*?```|`[^`\n]*`")
+
+// ParseMentions unique lower cased usernames mentioned in the given text in
+// order of their first mention
+func ParseMentions(text string) []string {
+	var usernames []string
+	seen := make(map[string]bool)
+	forEachText(text, func(s string) string {
+		for _, match := range mentionPattern.FindAllStringSubmatch(s, -1) {
+			username := strings.ToLower(match[2])
+			if !seen[username] {
+				seen[username] = true
+				usernames = append(usernames, username)
+			}
+		}
+		return s
+	})
+
+	return usernames
+}
+
+// LinkMentions replace mentions of the given usernames with links to their
+// profiles, mentions of other usernames are left as plain text
+func LinkMentions(text string, usernames []string, baseURL string) string {
+	if len(usernames) == 0 {
+		return text
+	}
+
+	linked := make(map[string]bool)
+	for _, username := range usernames {
+		linked[strings.ToLower(username)] = true
+	}
+
+	return forEachText(text, func(s string) string {
+		return mentionPattern.ReplaceAllStringFunc(s, func(m string) string {
+			match := mentionPattern.FindStringSubmatch(m)
+			if !linked[strings.ToLower(match[2])] {
+				return m
+			}
+			return fmt.Sprintf(`%s<a href="%s/user/%s" class="mention">@%s</a>`,
+				match[1], baseURL, match[2], match[2])
+		})
+	})
+}
+
+// forEachText apply the given function to parts of the text outside of code
+// blocks
+func forEachText(text string, fn func(string) string) string {
+	var b strings.Builder
+	last := 0
+	for _, loc := range codePattern.FindAllStringIndex(text, -1) {
+		b.WriteString(fn(text[last:loc[0]]))
+		b.WriteString(text[loc[0]:loc[1]])
+		last = loc[1]
+	}
+	b.WriteString(fn(text[last:]))
+
+	return b.String()
+}
@@ -0,0 +1,23 @@
+package utils
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestParseMentions(t *testing.T) {
+	assert.Equal(t, []string{"gopher", "test-user"},
+		ParseMentions("@Gopher thanks, cc @test-user and @gopher."))
+	assert.Equal(t, []string{"rob"},
+		ParseMentions("mail me at rob@golang.org or see /@docs, ping @rob"))
+	assert.Nil(t, ParseMentions("`@inline` <code>@tag</code>\n```\n@block\n```"))
+	assert.Nil(t, ParseMentions("@ alone"))
+}
+
+func TestLinkMentions(t *testing.T) {
+	assert.Equal(t,
+		`hi <a href="https://forum.dev/user/Gopher" class="mention">@Gopher</a>, @unknown.`,
+		LinkMentions("hi @Gopher, @unknown.", []string{"gopher"}, "https://forum.dev"))
+	assert.Equal(t, "`@gopher`", LinkMentions("`@gopher`", []string{"gopher"}, ""))
+	assert.Equal(t, "@gopher", LinkMentions("@gopher", nil, ""))
+}
@@ -0,0 +1,100 @@
+// Copyright 2019 StreetByters Community
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with
+// this work for additional information regarding copyright ownership.
+// The ASF licenses this file to You under the Apache License, Version 2.0
+// (the "License"); you may not use this file except in compliance with
+// the License.  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+)
+
+// wilsonZ normal quantile for a 95% confidence level
+const wilsonZ = 1.96
+
+// hotEpoch reference time of hot scores
+var hotEpoch = time.Date(2019, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// hotDecay seconds after which a post needs ten times the activity to
+// keep the same hot score
+const hotDecay = 45000
+
+// feedVoteBoost seconds a post is moved up in feeds for each digit of its
+// net votes
+const feedVoteBoost = 3600
+
+// RankingWindows time windows of top listings, zero means all time
+var RankingWindows = map[string]time.Duration{
+	"day":   24 * time.Hour,
+	"week":  7 * 24 * time.Hour,
+	"month": 30 * 24 * time.Hour,
+	"all":   0,
+}
+
+// WilsonLowerBound lower bound of the Wilson score confidence interval for
+// the share of positive votes. Items with few votes rank below items with
+// a similar share and many votes.
+func WilsonLowerBound(up, down int64) float64 {
+	n := float64(up + down)
+	if n == 0 {
+		return 0
+	}
+
+	p := float64(up) / n
+	z2 := wilsonZ * wilsonZ
+
+	return (p + z2/(2*n) - wilsonZ*math.Sqrt((p*(1-p)+z2/(4*n))/n)) / (1 + z2/n)
+}
+
+// HotScore time decayed score from net votes and comment activity. Newer
+// posts get a higher base so older posts sink unless they keep gaining
+// votes and comments.
+func HotScore(score, comments int64, insertedAt time.Time) float64 {
+	activity := float64(score + comments)
+	sign := 0.0
+	if activity > 0 {
+		sign = 1
+	} else if activity < 0 {
+		sign = -1
+	}
+
+	order := math.Log10(math.Max(math.Abs(activity), 1))
+	seconds := insertedAt.Sub(hotEpoch).Seconds()
+
+	return sign*order + seconds/hotDecay
+}
+
+// FeedScore recency score of a post in feeds. Posts are ordered by their
+// publish time and move up an hour for each digit of their positive net
+// votes. Digits are counted instead of taking a logarithm so FeedScoreQuery
+// computes exactly the same score in postgres.
+func FeedScore(score int64, publishedAt time.Time) int64 {
+	var boost int64
+	if score > 0 {
+		boost = int64(len(strconv.FormatInt(score, 10)))
+	}
+
+	return publishedAt.Unix() + boost*feedVoteBoost
+}
+
+// FeedScoreQuery sql expression of FeedScore with the given published at
+// and net votes expressions
+func FeedScoreQuery(publishedAt, score string) string {
+	return fmt.Sprintf("(floor(extract(epoch from %s))::bigint + "+
+		"CASE WHEN %s > 0 THEN length((%s)::text) * %d ELSE 0 END)",
+		publishedAt, score, score, feedVoteBoost)
+}
@@ -0,0 +1,36 @@
+package utils
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestWilsonLowerBound(t *testing.T) {
+	assert.Equal(t, float64(0), WilsonLowerBound(0, 0))
+	assert.InDelta(t, 0.2065, WilsonLowerBound(1, 0), 0.0001)
+	assert.InDelta(t, 0.8882, WilsonLowerBound(95, 5), 0.0001)
+	assert.Greater(t, WilsonLowerBound(95, 5), WilsonLowerBound(3, 0))
+	assert.Greater(t, WilsonLowerBound(10, 1), WilsonLowerBound(10, 5))
+}
+
+func TestHotScore(t *testing.T) {
+	now := time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	assert.InDelta(t, now.Sub(hotEpoch).Seconds()/hotDecay, HotScore(0, 0, now), 0.0001)
+	assert.InDelta(t, HotScore(0, 0, now)+2, HotScore(90, 10, now), 0.0001)
+	assert.Greater(t, HotScore(10, 0, now), HotScore(10, 0, now.Add(-24*time.Hour)))
+	assert.Greater(t, HotScore(10, 5, now), HotScore(10, 0, now))
+	assert.Less(t, HotScore(-10, 0, now), HotScore(0, 0, now))
+}
+
+func TestFeedScore(t *testing.T) {
+	now := time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, now.Unix(), FeedScore(0, now))
+	assert.Equal(t, now.Unix(), FeedScore(-5, now))
+	assert.Equal(t, now.Unix()+feedVoteBoost, FeedScore(9, now))
+	assert.Equal(t, now.Unix()+2*feedVoteBoost, FeedScore(10, now))
+	assert.Greater(t, FeedScore(0, now.Add(time.Hour)), FeedScore(9, now.Add(-time.Minute)))
+	assert.Contains(t, FeedScoreQuery("p.published_at", "p.votes"), "length((p.votes)::text) * 3600")
+}
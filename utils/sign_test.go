@@ -0,0 +1,23 @@
+package utils
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestSign(t *testing.T) {
+	signature := Sign("secret", "subscription", "1")
+
+	assert.Len(t, signature, 64)
+	assert.Equal(t, signature, Sign("secret", "subscription", "1"))
+	assert.NotEqual(t, signature, Sign("secret", "subscription", "2"))
+	assert.NotEqual(t, signature, Sign("other", "subscription", "1"))
+}
+
+func TestVerifySignature(t *testing.T) {
+	signature := Sign("secret", "subscription", "1")
+
+	assert.True(t, VerifySignature("secret", signature, "subscription", "1"))
+	assert.False(t, VerifySignature("secret", signature, "subscription", "2"))
+	assert.False(t, VerifySignature("secret", "", "subscription", "1"))
+}
@@ -0,0 +1,126 @@
+// Copyright 2019 StreetByters Community
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with
+// this work for additional information regarding copyright ownership.
+// The ASF licenses this file to You under the Apache License, Version 2.0
+// (the "License"); you may not use this file except in compliance with
+// the License.  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"math"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// spamWordPattern words of the text, numbers and identifiers are words too
+var spamWordPattern = regexp.MustCompile(`[\p{L}\p{N}][\p{L}\p{N}_'-]*`)
+
+// spamTagPattern html tags, tag and attribute names are not words of the
+// text while link hosts are kept by their own tokens
+var spamTagPattern = regexp.MustCompile(`<[^>]*>`)
+
+// maxSpamTokens tokens a text is scored and trained with
+const maxSpamTokens = 500
+
+// SpamTokens unique lower cased words of the given text in order of their
+// first occurrence. Hosts of links are added as host: tokens since spam
+// waves reuse their domains.
+func SpamTokens(text string) []string {
+	var tokens []string
+	seen := make(map[string]bool)
+	add := func(token string) {
+		if len(tokens) < maxSpamTokens && !seen[token] {
+			seen[token] = true
+			tokens = append(tokens, token)
+		}
+	}
+
+	for _, link := range linkPattern.FindAllString(text, -1) {
+		if u, err := url.Parse(link); err == nil && u.Hostname() != "" {
+			add("host:" + strings.TrimPrefix(strings.ToLower(u.Hostname()), "www."))
+		}
+	}
+	for _, word := range spamWordPattern.FindAllString(spamTagPattern.ReplaceAllString(text, " "), -1) {
+		if n := len(word); n >= 2 && n <= 32 {
+			add(strings.ToLower(word))
+		}
+	}
+
+	return tokens
+}
+
+// SpamModel naive Bayes token counts of documents labeled as spam or ham by
+// moderators. The vocabulary counts distinct tokens of both labels, so a
+// model holding the counts of some tokens only scores them as the full one.
+type SpamModel struct {
+	SpamDocuments int64
+	HamDocuments  int64
+	SpamTotal     int64
+	HamTotal      int64
+	Vocabulary    int64
+	SpamTokens    map[string]int64
+	HamTokens     map[string]int64
+}
+
+// NewSpamModel generate an untrained spam model
+func NewSpamModel() *SpamModel {
+	return &SpamModel{
+		SpamTokens: make(map[string]int64),
+		HamTokens:  make(map[string]int64),
+	}
+}
+
+// Learn add the tokens of a document to the counts of its label, n is -1
+// to forget a document which was learned before
+func (m *SpamModel) Learn(tokens []string, spam bool, n int64) {
+	documents, total, counts := &m.HamDocuments, &m.HamTotal, m.HamTokens
+	if spam {
+		documents, total, counts = &m.SpamDocuments, &m.SpamTotal, m.SpamTokens
+	}
+
+	*documents += n
+	for _, token := range tokens {
+		known := m.SpamTokens[token] > 0 || m.HamTokens[token] > 0
+		counts[token] += n
+		*total += n
+		if counts[token] <= 0 {
+			delete(counts, token)
+		}
+		if m.SpamTokens[token] > 0 || m.HamTokens[token] > 0 {
+			if !known {
+				m.Vocabulary++
+			}
+		} else if known {
+			m.Vocabulary--
+		}
+	}
+}
+
+// Score probability of the tokens being spam with Laplace smoothed token
+// likelihoods. Documents are scored 0.5 until both labels are learned.
+func (m *SpamModel) Score(tokens []string) float64 {
+	if m.SpamDocuments <= 0 || m.HamDocuments <= 0 {
+		return 0.5
+	}
+
+	v := float64(m.Vocabulary + 1)
+
+	logOdds := math.Log(float64(m.SpamDocuments)) - math.Log(float64(m.HamDocuments))
+	for _, token := range tokens {
+		logOdds += math.Log((float64(m.SpamTokens[token])+1)/(float64(m.SpamTotal)+v)) -
+			math.Log((float64(m.HamTokens[token])+1)/(float64(m.HamTotal)+v))
+	}
+
+	return 1 / (1 + math.Exp(-logOdds))
+}
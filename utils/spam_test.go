@@ -0,0 +1,33 @@
+package utils
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestSpamTokens(t *testing.T) {
+	assert.Equal(t, []string{"host:cheap.biz", "buy", "cheap", "pills", "at", "https", "biz"},
+		SpamTokens(`Buy <a href="https://www.cheap.biz">CHEAP pills</a> at https://cheap.biz`))
+	assert.Equal(t, []string{"go", "channels", "don't", "block"}, SpamTokens("Go channels don't block, go!"))
+	assert.Nil(t, SpamTokens("a ! ?"))
+}
+
+func TestSpamModel(t *testing.T) {
+	m := NewSpamModel()
+	assert.Equal(t, 0.5, m.Score(SpamTokens("buy cheap pills")))
+
+	m.Learn(SpamTokens("buy cheap pills now at https://cheap.biz"), true, 1)
+	m.Learn(SpamTokens("cheap watches, buy now https://cheap.biz"), true, 1)
+	m.Learn(SpamTokens("how do I close a channel twice without panic"), false, 1)
+	m.Learn(SpamTokens("goroutine leak when the channel is never closed"), false, 1)
+
+	assert.True(t, m.Score(SpamTokens("buy cheap pills https://cheap.biz")) > 0.9)
+	assert.True(t, m.Score(SpamTokens("why does my goroutine panic on a closed channel")) < 0.1)
+
+	m.Learn(SpamTokens("cheap watches, buy now https://cheap.biz"), true, -1)
+	assert.Equal(t, int64(1), m.SpamDocuments)
+	assert.Equal(t, int64(1), m.SpamTokens["cheap"])
+	assert.Equal(t, int64(22), m.Vocabulary)
+	_, ok := m.SpamTokens["watches"]
+	assert.False(t, ok)
+}